@@ -2,12 +2,16 @@ package config
 
 import (
 	"crypto/rsa"
+	"encoding/hex"
 	"io/ioutil"
 	"log"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/dgrijalva/jwt-go"
+	"github.com/whuangz/go-example/go-api/domain"
 )
 
 var (
@@ -16,6 +20,8 @@ var (
 	PORT               string
 	JWT_PRIVATE_KEY    *rsa.PrivateKey
 	JWT_PUBLIC_KEY     *rsa.PublicKey
+	JWT_KEY_DIR        string
+	JWT_KEY_RELOAD     int64
 	JWT_REFRESH_SECRET string
 	ACCESS_TOKEN_EXP   int64
 	REFRESH_TOKEN_EXP  int64
@@ -24,6 +30,54 @@ var (
 	REDIS_PORT string
 
 	HANDLER_TIMEOUT int64
+
+	MAX_IMAGE_SIZE int64
+	BLOB_STORE     string
+	BLOB_DIR       string
+	BLOB_BASE_URL  string
+	S3_ENDPOINT    string
+	S3_REGION      string
+	S3_BUCKET      string
+	S3_ACCESS_KEY  string
+	S3_SECRET_KEY  string
+	S3_PUBLIC_URL  string
+
+	APP_URL                  string
+	MAIL_FROM                string
+	MAIL_OUTBOX_DIR          string
+	PASSWORD_RESET_TOKEN_EXP int64
+
+	EMAIL_VERIFICATION_TOKEN_EXP int64
+	REQUIRE_VERIFIED_EMAIL       bool
+
+	MFA_ENCRYPTION_KEY []byte
+	MFA_ISSUER         string
+	MFA_CHALLENGE_EXP  int64
+
+	SIGNIN_MAX_FAILURES    int64
+	SIGNIN_MAX_IP_FAILURES int64
+	SIGNIN_FAILURE_WINDOW  int64
+	SIGNIN_LOCKOUT_BASE    int64
+	SIGNIN_LOCKOUT_MAX     int64
+
+	ADMIN_API_KEY string
+
+	RATE_LIMIT_ACCOUNT        int64
+	RATE_LIMIT_ACCOUNT_WINDOW time.Duration
+	RATE_LIMIT_BLOG           int64
+	RATE_LIMIT_BLOG_WINDOW    time.Duration
+
+	OAUTH_ISSUER           string
+	OAUTH_ACCESS_TOKEN_EXP int64
+	OAUTH_CODE_EXP         int64
+
+	SEARCH_INDEX string
+
+	POST_SCHEDULER_INTERVAL int64
+
+	COMMENT_EDIT_WINDOW int64
+
+	REACTION_RECONCILE_INTERVAL int64
 )
 
 func init() {
@@ -39,10 +93,51 @@ func init() {
 	}
 	initJwtKey()
 	initRedis()
+	initBlobStore()
+	initMail()
+	initMFA()
+	initLockout()
+	initRateLimit()
+	initOAuth()
+	initSearch()
+	initPostScheduler()
+	initComments()
+	initReactions()
 
 }
 
 func initJwtKey() {
+	var err error
+
+	// with a key directory, keys can be rotated without a restart.
+	// Otherwise tokens are signed with the single key pair of the pem files
+	JWT_KEY_DIR = getEnv("JWT_KEY_DIR", "")
+	keyReload := getEnv("JWT_KEY_RELOAD", "60")
+	JWT_KEY_RELOAD, err = strconv.ParseInt(keyReload, 0, 64)
+	if err != nil {
+		log.Fatalf("could not parse JWT_KEY_RELOAD as int: %v", err)
+	}
+
+	if JWT_KEY_DIR == "" {
+		initJwtKeyPair()
+	}
+
+	JWT_REFRESH_SECRET = getEnv("REFRESH_SECRET", "")
+
+	AccTokenExp := getEnv("ACCESS_TOKEN_EXP", "")
+	ACCESS_TOKEN_EXP, err = strconv.ParseInt(AccTokenExp, 0, 64)
+	if err != nil {
+		log.Fatalf("could not parse ID_TOKEN_EXP as int: %v", err)
+	}
+
+	refreshTokenExp := getEnv("REFRESH_TOKEN_EXP", "")
+	REFRESH_TOKEN_EXP, err = strconv.ParseInt(refreshTokenExp, 0, 64)
+	if err != nil {
+		log.Fatalf("could not parse REFRESH_TOKEN_EXP as int: %v", err)
+	}
+}
+
+func initJwtKeyPair() {
 	privKeyFile := getEnv("PRIV_KEY_FILE", "")
 	priv, err := ioutil.ReadFile(privKeyFile)
 
@@ -66,25 +161,193 @@ func initJwtKey() {
 	if err != nil {
 		log.Fatalf("could not parse public key: %v\n", err)
 	}
+}
 
-	JWT_REFRESH_SECRET = getEnv("REFRESH_SECRET", "")
+func initRedis() {
+	REDIS_HOST = getEnv("REDIS_HOST", "")
+	REDIS_PORT = getEnv("REDIS_PORT", "")
+}
 
-	AccTokenExp := getEnv("ACCESS_TOKEN_EXP", "")
-	ACCESS_TOKEN_EXP, err = strconv.ParseInt(AccTokenExp, 0, 64)
+func initBlobStore() {
+	var err error
+	maxImageSize := getEnv("MAX_IMAGE_SIZE", "5242880") // 5MB
+	MAX_IMAGE_SIZE, err = strconv.ParseInt(maxImageSize, 0, 64)
 	if err != nil {
-		log.Fatalf("could not parse ID_TOKEN_EXP as int: %v", err)
+		log.Fatalf("could not parse MAX_IMAGE_SIZE as int: %v", err)
 	}
 
-	refreshTokenExp := getEnv("REFRESH_TOKEN_EXP", "")
-	REFRESH_TOKEN_EXP, err = strconv.ParseInt(refreshTokenExp, 0, 64)
+	// local or s3
+	BLOB_STORE = getEnv("BLOB_STORE", "local")
+	BLOB_DIR = getEnv("BLOB_DIR", "./uploads")
+	BLOB_BASE_URL = getEnv("BLOB_BASE_URL", "http://localhost:8080/uploads")
+
+	S3_ENDPOINT = getEnv("S3_ENDPOINT", "")
+	S3_REGION = getEnv("S3_REGION", "us-east-1")
+	S3_BUCKET = getEnv("S3_BUCKET", "")
+	S3_ACCESS_KEY = getEnv("S3_ACCESS_KEY", "")
+	S3_SECRET_KEY = getEnv("S3_SECRET_KEY", "")
+	S3_PUBLIC_URL = getEnv("S3_PUBLIC_URL", "")
+}
+
+func initMail() {
+	// links in emails point to the app
+	APP_URL = getEnv("APP_URL", "http://localhost:8080")
+	MAIL_FROM = getEnv("MAIL_FROM", "no-reply@localhost")
+	MAIL_OUTBOX_DIR = getEnv("MAIL_OUTBOX_DIR", "./outbox")
+
+	var err error
+	resetTokenExp := getEnv("PASSWORD_RESET_TOKEN_EXP", "3600")
+	PASSWORD_RESET_TOKEN_EXP, err = strconv.ParseInt(resetTokenExp, 0, 64)
 	if err != nil {
-		log.Fatalf("could not parse REFRESH_TOKEN_EXP as int: %v", err)
+		log.Fatalf("could not parse PASSWORD_RESET_TOKEN_EXP as int: %v", err)
+	}
+
+	verificationTokenExp := getEnv("EMAIL_VERIFICATION_TOKEN_EXP", "86400")
+	EMAIL_VERIFICATION_TOKEN_EXP, err = strconv.ParseInt(verificationTokenExp, 0, 64)
+	if err != nil {
+		log.Fatalf("could not parse EMAIL_VERIFICATION_TOKEN_EXP as int: %v", err)
+	}
+
+	// when set, accounts have to verify their email before writing content
+	requireVerifiedEmail := getEnv("REQUIRE_VERIFIED_EMAIL", "false")
+	REQUIRE_VERIFIED_EMAIL, err = strconv.ParseBool(requireVerifiedEmail)
+	if err != nil {
+		log.Fatalf("could not parse REQUIRE_VERIFIED_EMAIL as bool: %v", err)
 	}
 }
 
-func initRedis() {
-	REDIS_HOST = getEnv("REDIS_HOST", "")
-	REDIS_PORT = getEnv("REDIS_PORT", "")
+func initMFA() {
+	// 2FA secrets are encrypted at rest with AES-256, the key is hex encoded.
+	// config/.env holds a key for development, deployments set their own
+	key := getEnv("MFA_ENCRYPTION_KEY", "")
+	var err error
+	MFA_ENCRYPTION_KEY, err = hex.DecodeString(key)
+	if err != nil || len(MFA_ENCRYPTION_KEY) != 32 {
+		log.Fatalf("MFA_ENCRYPTION_KEY must be 32 hex encoded bytes, eg: openssl rand -hex 32")
+	}
+
+	MFA_ISSUER = getEnv("MFA_ISSUER", "go-example")
+
+	challengeExp := getEnv("MFA_CHALLENGE_EXP", "300")
+	MFA_CHALLENGE_EXP, err = strconv.ParseInt(challengeExp, 0, 64)
+	if err != nil {
+		log.Fatalf("could not parse MFA_CHALLENGE_EXP as int: %v", err)
+	}
+}
+
+func initLockout() {
+	settings := []struct {
+		name         string
+		defaultValue string
+		value        *int64
+	}{
+		{"SIGNIN_MAX_FAILURES", "5", &SIGNIN_MAX_FAILURES},
+		// an IP may be shared by many users, eg: behind a NAT
+		{"SIGNIN_MAX_IP_FAILURES", "50", &SIGNIN_MAX_IP_FAILURES},
+		{"SIGNIN_FAILURE_WINDOW", "900", &SIGNIN_FAILURE_WINDOW},
+		{"SIGNIN_LOCKOUT_BASE", "60", &SIGNIN_LOCKOUT_BASE},
+		{"SIGNIN_LOCKOUT_MAX", "3600", &SIGNIN_LOCKOUT_MAX},
+	}
+
+	var err error
+	for _, setting := range settings {
+		*setting.value, err = strconv.ParseInt(getEnv(setting.name, setting.defaultValue), 0, 64)
+		if err != nil {
+			log.Fatalf("could not parse %s as int: %v", setting.name, err)
+		}
+	}
+
+	// the admin key grants the admin role, eg: to the first admins. Without
+	// it, the admin routes are only open to accounts whose roles allow them
+	ADMIN_API_KEY = getEnv("ADMIN_API_KEY", "")
+}
+
+func initRateLimit() {
+	// limits are written as requests/window, eg: 30/1m. 0 disables a limit
+	RATE_LIMIT_ACCOUNT, RATE_LIMIT_ACCOUNT_WINDOW = parseRateLimit("RATE_LIMIT_ACCOUNT", "30/1m")
+	RATE_LIMIT_BLOG, RATE_LIMIT_BLOG_WINDOW = parseRateLimit("RATE_LIMIT_BLOG", "120/1m")
+}
+
+func initOAuth() {
+	// the public url of the api, tokens and the discovery document refer to it
+	OAUTH_ISSUER = getEnv("OAUTH_ISSUER", "http://localhost:8080")
+
+	var err error
+	accessTokenExp := getEnv("OAUTH_ACCESS_TOKEN_EXP", "3600")
+	OAUTH_ACCESS_TOKEN_EXP, err = strconv.ParseInt(accessTokenExp, 0, 64)
+	if err != nil {
+		log.Fatalf("could not parse OAUTH_ACCESS_TOKEN_EXP as int: %v", err)
+	}
+
+	codeExp := getEnv("OAUTH_CODE_EXP", "60")
+	OAUTH_CODE_EXP, err = strconv.ParseInt(codeExp, 0, 64)
+	if err != nil {
+		log.Fatalf("could not parse OAUTH_CODE_EXP as int: %v", err)
+	}
+}
+
+func initSearch() {
+	// mysql or memory, the memory index is rebuilt from the posts on start
+	SEARCH_INDEX = getEnv("SEARCH_INDEX", "mysql")
+}
+
+func initPostScheduler() {
+	// seconds between checks for scheduled posts to publish, 0 disables it
+	interval := getEnv("POST_SCHEDULER_INTERVAL", "30")
+	var err error
+	POST_SCHEDULER_INTERVAL, err = strconv.ParseInt(interval, 0, 64)
+	if err != nil {
+		log.Fatalf("could not parse POST_SCHEDULER_INTERVAL as int: %v", err)
+	}
+}
+
+func initComments() {
+	// seconds authors have to edit a comment after posting it
+	editWindow := getEnv("COMMENT_EDIT_WINDOW", "900")
+	var err error
+	COMMENT_EDIT_WINDOW, err = strconv.ParseInt(editWindow, 0, 64)
+	if err != nil {
+		log.Fatalf("could not parse COMMENT_EDIT_WINDOW as int: %v", err)
+	}
+}
+
+func initReactions() {
+	// seconds between reconciliations of the counts of reactions, 0 disables
+	// them. Reactions to posts not reconciled yet are counted once they are
+	interval := getEnv("REACTION_RECONCILE_INTERVAL", "60")
+	var err error
+	REACTION_RECONCILE_INTERVAL, err = strconv.ParseInt(interval, 0, 64)
+	if err != nil {
+		log.Fatalf("could not parse REACTION_RECONCILE_INTERVAL as int: %v", err)
+	}
+}
+
+func parseRateLimit(key string, defaultValue string) (int64, time.Duration) {
+	value := getEnv(key, defaultValue)
+	if value == "0" {
+		return 0, 0
+	}
+
+	parts := strings.Split(value, "/")
+	if len(parts) != 2 {
+		log.Fatalf("could not parse %s as requests/window, eg: 30/1m", key)
+	}
+
+	limit, err := strconv.ParseInt(parts[0], 0, 64)
+	if err != nil {
+		log.Fatalf("could not parse the requests of %s as int: %v", key, err)
+	}
+
+	window, err := time.ParseDuration(parts[1])
+	if err != nil || window < time.Second {
+		log.Fatalf("could not parse the window of %s as a duration of at least 1s: %v", key, err)
+	}
+
+	if !domain.IsRateLimit(limit, window) {
+		log.Fatalf("%s should allow at least 1 request and at most 1 request per ms", key)
+	}
+
+	return limit, window
 }
 
 func getEnv(key string, defaultValue string) string {
@@ -12,35 +12,76 @@ type AccountRepository interface {
 	FindByEmail(ctx context.Context, email string) (*Account, error)
 	FindByID(ctx context.Context, uid uuid.UUID) (*Account, error)
 	Create(ctx context.Context, a *Account) error
+	Update(ctx context.Context, a *Account) error
+	UpdatePassword(ctx context.Context, uid uuid.UUID, password string) error
 }
 
 type AccountService interface {
 	Get(ctx context.Context, uid uuid.UUID) (*Account, error)
 	Signup(ctx context.Context, a *Account) error
 	Signin(ctx context.Context, a *Account) error
+	Update(ctx context.Context, a *Account) error
+	SetProfileImage(ctx context.Context, uid uuid.UUID, data []byte) (*Account, error)
+	ClearProfileImage(ctx context.Context, uid uuid.UUID) (*Account, error)
+	ForgotPassword(ctx context.Context, email string) error
+	ResetPassword(ctx context.Context, token string, password string) (*Account, error)
+	VerifyEmail(ctx context.Context, token string) (*Account, error)
+	ResendVerificationEmail(ctx context.Context, uid uuid.UUID) error
 }
 
 type Account struct {
-	ID        int32        `json:"id"`
-	UID       uuid.UUID    `json:"uid"`
-	Email     string       `json:"email"`
-	Password  string       `json:"-"`
-	Name      string       `json:"name"`
-	ImageUrl  string       `json:"image_url"`
-	Website   string       `json:"website"`
-	UpdatedAt sql.NullTime `json:updated_at`
-	CreatedAt time.Time    `json:created_at`
+	ID              int32        `json:"id"`
+	UID             uuid.UUID    `json:"uid"`
+	Email           string       `json:"email"`
+	Password        string       `json:"-"`
+	Name            string       `json:"name"`
+	ImageUrl        string       `json:"image_url"`
+	Website         string       `json:"website"`
+	EmailVerifiedAt sql.NullTime `json:"email_verified_at"`
+	Roles           []string     `json:"roles"`
+	UpdatedAt       sql.NullTime `json:"updated_at"`
+	CreatedAt       time.Time    `json:"created_at"`
 }
 
 type TokenRepository interface {
-	SetRefreshToken(ctx context.Context, accID string, accessToken string, expiresIn time.Duration) error
-	DeleteRefreshToken(ctx context.Context, accID string, prevAccessToken string) error
+	SetRefreshToken(ctx context.Context, accID string, tokenID string, familyID string, expiresIn time.Duration) error
+	RotateRefreshToken(ctx context.Context, accID string, prevTokenID string) (string, error)
+	DeleteTokenFamily(ctx context.Context, accID string, familyID string) error
+	DeleteUserRefreshTokens(ctx context.Context, accID string) error
+}
+
+// Kinds of one time tokens mailed to an account
+const (
+	PasswordResetToken     = "password_reset"
+	EmailVerificationToken = "email_verification"
+	MFAChallengeToken      = "mfa_challenge"
+)
+
+// OneTimeTokenRepository stores single use tokens by their hash. Consuming
+// a token removes it and returns the id of the account it was issued for
+type OneTimeTokenRepository interface {
+	SetToken(ctx context.Context, kind string, tokenHash string, accID string, expiresIn time.Duration) error
+	ConsumeToken(ctx context.Context, kind string, tokenHash string) (string, error)
+}
+
+// TokenDenylist keeps track of access tokens revoked before their expiry,
+// either one by one through their jti or per account through a watermark
+// invalidating every token issued before it
+type TokenDenylist interface {
+	DenyAccessToken(ctx context.Context, tokenID string, expiresIn time.Duration) error
+	IsAccessTokenDenied(ctx context.Context, tokenID string) (bool, error)
+	SetRevokedBefore(ctx context.Context, accID string, revokedBefore time.Time, expiresIn time.Duration) error
+	GetRevokedBefore(ctx context.Context, accID string) (time.Time, error)
 }
 
 type TokenService interface {
 	NewPairFromUser(ctx context.Context, a *Account, prevAccesstoken string) (*TokenPair, error)
-	ValidateAccessToken(token string) (*Account, error)
+	ValidateAccessToken(ctx context.Context, token string) (*Account, error)
+	RevokeAccessToken(ctx context.Context, token string) error
 	ValidateRefreshToken(tokenString string) (*RefreshToken, error)
+	Signout(ctx context.Context, uid uuid.UUID) error
+	SignoutDevice(ctx context.Context, uid uuid.UUID, refreshToken string) error
+	JWKS() *JWKS
 }
 
 type TokenPair struct {
@@ -49,7 +90,8 @@ type TokenPair struct {
 }
 
 type RefreshToken struct {
-	ID  uuid.UUID `json:"-"`
-	UID uuid.UUID `json:"-"`
-	SS  string    `json:"refresh_token"`
+	ID       uuid.UUID `json:"-"`
+	UID      uuid.UUID `json:"-"`
+	FamilyID uuid.UUID `json:"-"`
+	SS       string    `json:"refresh_token"`
 }
@@ -0,0 +1,56 @@
+package domain
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Scopes of an API key, keys with the read scope
+// alone can't make write requests
+const (
+	APIKeyReadScope  = "read"
+	APIKeyWriteScope = "write"
+)
+
+// APIKey is a long lived credential of an account for scripts and other
+// machine clients. Only a hash of the key is stored, the prefix is kept
+// so the owner can tell its keys apart
+type APIKey struct {
+	UID        uuid.UUID    `json:"id"`
+	AccountUID uuid.UUID    `json:"-"`
+	Name       string       `json:"name"`
+	Prefix     string       `json:"prefix"`
+	KeyHash    string       `json:"-"`
+	Scopes     []string     `json:"scopes"`
+	ExpiresAt  sql.NullTime `json:"expires_at"`
+	LastUsedAt sql.NullTime `json:"last_used_at"`
+	CreatedAt  time.Time    `json:"created_at"`
+}
+
+// HasScope tells whether the key was granted the scope
+func (k *APIKey) HasScope(scope string) bool {
+	for _, s := range k.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+type APIKeyRepository interface {
+	Create(ctx context.Context, k *APIKey) error
+	FindByHash(ctx context.Context, keyHash string) (*APIKey, error)
+	FindByAccount(ctx context.Context, accountUID uuid.UUID) ([]*APIKey, error)
+	Delete(ctx context.Context, accountUID uuid.UUID, uid uuid.UUID) error
+	SetLastUsed(ctx context.Context, uid uuid.UUID, usedAt time.Time) error
+}
+
+type APIKeyService interface {
+	Create(ctx context.Context, accountUID uuid.UUID, name string, scopes []string, expiresIn time.Duration) (*APIKey, string, error)
+	List(ctx context.Context, accountUID uuid.UUID) ([]*APIKey, error)
+	Revoke(ctx context.Context, accountUID uuid.UUID, uid uuid.UUID) error
+	Authenticate(ctx context.Context, key string) (*Account, *APIKey, error)
+}
@@ -3,7 +3,9 @@ package domain
 import (
 	"errors"
 	"fmt"
+	"math"
 	"net/http"
+	"time"
 )
 
 // Type holds a type string and integer code for the error
@@ -14,10 +16,15 @@ const (
 	Authorization        Type = "AUTHORIZATION"          // Authentication Failures -
 	BadRequest           Type = "BAD_REQUEST"            // Validation errors / BadInput
 	Conflict             Type = "CONFLICT"               // Already exists (eg, create account with existent email) - 409
+	Forbidden            Type = "FORBIDDEN"              // Authenticated, but not allowed to perform the action - 403
 	Internal             Type = "INTERNAL"               // Server (500) and fallback errors
 	NotFound             Type = "NOT_FOUND"              // For not finding resource
 	PayloadTooLarge      Type = "PAYLOAD_TOO_LARGE"      // for uploading tons of JSON, or an image over the limit - 413
+	PreconditionFailed   Type = "PRECONDITION_FAILED"    // If-Match doesn't match the current version - 412
+	PreconditionRequired Type = "PRECONDITION_REQUIRED"  // Write without the If-Match it requires - 428
 	ServiceUnavailable   Type = "SERVICE_UNAVAILABLE"    // For long running handlers
+	TokenReused          Type = "TOKEN_REUSED"           // A rotated out refresh token was presented again - 401
+	TooManyRequests      Type = "TOO_MANY_REQUESTS"      // Locked out or rate limited, see RetryAfter - 429
 	UnsupportedMediaType Type = "UNSUPPORTED_MEDIA_TYPE" // for http 415
 )
 
@@ -27,6 +34,8 @@ const (
 type Error struct {
 	Type    Type   `json:"type"`
 	Message string `json:"message"`
+	// RetryAfter is the number of seconds to wait, set for TooManyRequests
+	RetryAfter int64 `json:"retry_after,omitempty"`
 }
 
 // used to help extract validation errors
@@ -55,14 +64,24 @@ func (e *Error) Status() int {
 		return http.StatusBadRequest
 	case Conflict:
 		return http.StatusConflict
+	case Forbidden:
+		return http.StatusForbidden
 	case Internal:
 		return http.StatusInternalServerError
 	case NotFound:
 		return http.StatusNotFound
 	case PayloadTooLarge:
 		return http.StatusRequestEntityTooLarge
+	case PreconditionFailed:
+		return http.StatusPreconditionFailed
+	case PreconditionRequired:
+		return http.StatusPreconditionRequired
 	case ServiceUnavailable:
 		return http.StatusServiceUnavailable
+	case TokenReused:
+		return http.StatusUnauthorized
+	case TooManyRequests:
+		return http.StatusTooManyRequests
 	case UnsupportedMediaType:
 		return http.StatusUnsupportedMediaType
 	default:
@@ -109,6 +128,15 @@ func NewConflict(name string, value string) *Error {
 	}
 }
 
+// NewForbidden to create a 403 when the account
+// is known but isn't allowed to perform the action
+func NewForbidden(reason string) *Error {
+	return &Error{
+		Type:    Forbidden,
+		Message: reason,
+	}
+}
+
 // NewInternal for 500 errors and unknown errors
 func NewInternal() *Error {
 	return &Error{
@@ -133,6 +161,23 @@ func NewPayloadTooLarge(maxBodySize int64, contentLength int64) *Error {
 	}
 }
 
+// NewPreconditionFailed to create an error for 412, when the
+// resource changed since the version the client has
+func NewPreconditionFailed(name string, value string) *Error {
+	return &Error{
+		Type:    PreconditionFailed,
+		Message: fmt.Sprintf("resource: %v with value: %v has changed, fetch it again", name, value),
+	}
+}
+
+// NewPreconditionRequired to create an error for 428
+func NewPreconditionRequired(reason string) *Error {
+	return &Error{
+		Type:    PreconditionRequired,
+		Message: reason,
+	}
+}
+
 // NewServiceUnavailable to create an error for 503
 func NewServiceUnavailable() *Error {
 	return &Error{
@@ -141,6 +186,30 @@ func NewServiceUnavailable() *Error {
 	}
 }
 
+// NewTokenReused to create a 401 when a refresh token
+// that was already rotated out is presented again
+func NewTokenReused() *Error {
+	return &Error{
+		Type:    TokenReused,
+		Message: "Refresh token has already been used, please sign in again",
+	}
+}
+
+// NewTooManyRequests to create an error for 429. The wait
+// is rounded up to whole seconds, as sent in Retry-After
+func NewTooManyRequests(retryAfter time.Duration) *Error {
+	seconds := int64(math.Ceil(retryAfter.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+
+	return &Error{
+		Type:       TooManyRequests,
+		Message:    fmt.Sprintf("Too many requests, retry in %v seconds", seconds),
+		RetryAfter: seconds,
+	}
+}
+
 // NewUnsupportedMediaType to create an error for 415
 func NewUnsupportedMediaType(reason string) *Error {
 	return &Error{
@@ -1,17 +1,26 @@
 package domain
 
 import (
+	"context"
 	"database/sql"
 
 	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
 )
 
+// AuthorRepository provisions the author of an account, the
+// first time the account writes a post
+type AuthorRepository interface {
+	FindOrCreateByAccount(ctx context.Context, a *Account) (*Author, error)
+}
+
 type Author struct {
-	ID        int32        `json:id`
-	Username  string       `json:username validate:"required"`
-	Email     string       `json:email validate:"required"`
-	UpdatedAt sql.NullTime `json:updated_at`
-	CreatedAt string       `json:created_at`
+	ID         int32        `json:id`
+	AccountUID uuid.UUID    `json:"-"`
+	Username   string       `json:username validate:"required"`
+	Email      string       `json:"-"`
+	UpdatedAt  sql.NullTime `json:updated_at`
+	CreatedAt  string       `json:created_at`
 }
 
 func (a *Author) Validate() error {
@@ -0,0 +1,11 @@
+package domain
+
+import "context"
+
+// BlobStore keeps files, like profile images, outside of the database.
+// Keys are slash separated paths, eg: profile/<uid>/512.jpg
+type BlobStore interface {
+	Put(ctx context.Context, key string, contentType string, data []byte) error
+	Delete(ctx context.Context, key string) error
+	URL(key string) string
+}
@@ -0,0 +1,26 @@
+package domain
+
+import "context"
+
+// Category groups posts, categories nest under a parent category
+type Category struct {
+	ID       int32  `json:"id"`
+	ParentID int32  `json:"parent_id,omitempty"`
+	Name     string `json:"name" binding:"required"`
+	Slug     string `json:"slug"`
+	// Children is only set in the tree of categories
+	Children []Category `json:"children,omitempty"`
+}
+
+type CategoryRepository interface {
+	Save(ctx context.Context, category *Category) error
+	FindAll(ctx context.Context) ([]Category, error)
+	FindByID(ctx context.Context, id int32) (Category, error)
+}
+
+type CategoryService interface {
+	// Save creates the category, under its parent when ParentID is set
+	Save(ctx context.Context, category *Category) error
+	// Tree returns the top level categories with their children nested
+	Tree(ctx context.Context) ([]Category, error)
+}
@@ -0,0 +1,77 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Moderation statuses of a comment, only approved comments are public.
+// Comments by accounts that can change the post are approved right away
+const (
+	CommentPending  = "pending"
+	CommentApproved = "approved"
+	CommentRejected = "rejected"
+)
+
+// IsCommentStatus reports whether the status is one of the moderation statuses
+func IsCommentStatus(status string) bool {
+	switch status {
+	case CommentPending, CommentApproved, CommentRejected:
+		return true
+	}
+	return false
+}
+
+// Limits of comments. Comments on the post have depth 0, replies
+// nest at most MaxCommentDepth levels below them
+const (
+	MaxCommentDepth  = 5
+	MaxCommentLength = 5000
+)
+
+// Comment is on the post itself when ParentID is 0. Deleted comments keep
+// their place in the thread without their content
+type Comment struct {
+	ID          int32      `json:"id"`
+	PostID      int32      `json:"post_id"`
+	ParentID    int32      `json:"parent_id,omitempty"`
+	Depth       int        `json:"depth"`
+	AccountUID  uuid.UUID  `json:"account_uid"`
+	AccountName string     `json:"account_name"`
+	Content     string     `json:"content"`
+	Status      string     `json:"status"`
+	Deleted     bool       `json:"deleted"`
+	EditedAt    *time.Time `json:"edited_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+	Replies     []Comment  `json:"replies,omitempty"`
+}
+
+type CommentRepository interface {
+	Save(ctx context.Context, comment *Comment) error
+	FindByID(ctx context.Context, id int32) (Comment, error)
+	// FindByPost returns every comment of the post, oldest first
+	FindByPost(ctx context.Context, postID int32) ([]Comment, error)
+	// Update stores the content and status of the comment
+	Update(ctx context.Context, comment *Comment) error
+	// Delete clears the content of the comment and marks it deleted
+	Delete(ctx context.Context, id int32) error
+}
+
+type CommentService interface {
+	// List returns the threads of the post, replies nested in their parent.
+	// Account is nil for anonymous readers, who only see approved comments.
+	// Authors see their own comments, moderators every comment
+	List(ctx context.Context, account *Account, postID int32) ([]Comment, error)
+	// Create comments on a published post, or replies to an approved comment
+	Create(ctx context.Context, account *Account, postID int32, comment *Comment) error
+	// Update lets the author of a comment change it within the edit
+	// window, comments edited by others than moderators are moderated again
+	Update(ctx context.Context, account *Account, postID int32, id int32, content string) (*Comment, error)
+	// Delete is allowed to the author of the comment and to moderators
+	Delete(ctx context.Context, account *Account, postID int32, id int32) error
+	// Moderate sets the status of a comment, moderators are the accounts
+	// that can change the post: its owner and editors
+	Moderate(ctx context.Context, account *Account, postID int32, id int32, status string) (*Comment, error)
+}
@@ -0,0 +1,16 @@
+package domain
+
+// JWK is the public part of an RSA signing key, as defined by RFC 7517
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+// JWKS lists the keys access tokens can be verified with
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
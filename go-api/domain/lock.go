@@ -0,0 +1,13 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// Locker hands out locks shared by every replica of the api
+type Locker interface {
+	// Acquire takes the lock for ttl unless it is held already. Release
+	// only gives the lock up while the caller still holds it
+	Acquire(ctx context.Context, key string, ttl time.Duration) (release func(), acquired bool, err error)
+}
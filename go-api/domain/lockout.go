@@ -0,0 +1,39 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// SigninAttemptRepository counts failed signins and keeps
+// the temporary locks, both by a key such as an email or an IP
+type SigninAttemptRepository interface {
+	// AddFailure counts a failed attempt and returns the failures
+	// within the window, which restarts with every failure
+	AddFailure(ctx context.Context, key string, window time.Duration) (int64, error)
+	Lock(ctx context.Context, key string, d time.Duration) error
+	// LockedFor returns how long the longest lock of the keys still lasts
+	LockedFor(ctx context.Context, keys ...string) (time.Duration, error)
+	// Reset removes both the failures and the lock of a key
+	Reset(ctx context.Context, key string) error
+}
+
+// LockoutPolicy is when and for how long signins are locked. Once
+// the failures reach the maximum, every further failure doubles
+// the lockout, starting from BaseLockout up to MaxLockout
+type LockoutPolicy struct {
+	MaxFailures   int64
+	MaxIPFailures int64
+	Window        time.Duration
+	BaseLockout   time.Duration
+	MaxLockout    time.Duration
+}
+
+// LockoutService protects the signin against password guessing.
+// Locked signins are rejected with a TooManyRequests error
+type LockoutService interface {
+	Check(ctx context.Context, email string, ip string) error
+	RegisterFailure(ctx context.Context, email string, ip string) error
+	RegisterSuccess(ctx context.Context, email string) error
+	Unlock(ctx context.Context, email string) error
+}
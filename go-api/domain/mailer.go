@@ -0,0 +1,15 @@
+package domain
+
+import "context"
+
+// Message is an email sent to an account
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer delivers messages, like password reset links
+type Mailer interface {
+	Send(ctx context.Context, msg *Message) error
+}
@@ -0,0 +1,50 @@
+package domain
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+)
+
+// MFA is the TOTP second factor of an account. The secret is stored
+// encrypted and the factor is only enabled once a first code is confirmed
+type MFA struct {
+	AccountUID   uuid.UUID
+	Secret       string
+	EnabledAt    sql.NullTime
+	LastUsedStep int64
+}
+
+// MFAEnrollment is what an authenticator app needs to generate codes
+type MFAEnrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"otpauth_uri"`
+	QRCode []byte `json:"qr_png"`
+}
+
+// MFAChallenge is returned by signin instead of a token pair when
+// 2FA is on, the login completes by verifying it with a code
+type MFAChallenge struct {
+	MFARequired    bool   `json:"mfa_required"`
+	ChallengeToken string `json:"challenge_token"`
+	ExpiresIn      int64  `json:"expires_in"`
+}
+
+type MFARepository interface {
+	FindByUID(ctx context.Context, uid uuid.UUID) (*MFA, error)
+	Save(ctx context.Context, m *MFA) error
+	Delete(ctx context.Context, uid uuid.UUID) error
+	UseStep(ctx context.Context, uid uuid.UUID, step int64) (bool, error)
+	SetRecoveryCodes(ctx context.Context, uid uuid.UUID, codeHashes []string) error
+	UseRecoveryCode(ctx context.Context, uid uuid.UUID, codeHash string) (bool, error)
+}
+
+type MFAService interface {
+	Enroll(ctx context.Context, a *Account) (*MFAEnrollment, error)
+	Confirm(ctx context.Context, uid uuid.UUID, code string) ([]string, error)
+	Disable(ctx context.Context, uid uuid.UUID, code string) error
+	IsEnabled(ctx context.Context, uid uuid.UUID) (bool, error)
+	NewChallenge(ctx context.Context, uid uuid.UUID) (*MFAChallenge, error)
+	VerifyChallenge(ctx context.Context, challengeToken string, code string) (uuid.UUID, error)
+}
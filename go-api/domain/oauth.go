@@ -0,0 +1,199 @@
+package domain
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Grant types an OAuth client can be registered for
+const (
+	AuthorizationCodeGrant = "authorization_code"
+	ClientCredentialsGrant = "client_credentials"
+)
+
+// OAuthClient is an app allowed to sign in accounts of this service, or
+// to call it on its own behalf. Public clients, eg: single page apps,
+// have no secret and have to use PKCE
+type OAuthClient struct {
+	ID           int32        `json:"-"`
+	ClientID     string       `json:"client_id"`
+	SecretHash   string       `json:"-"`
+	Name         string       `json:"name"`
+	RedirectURIs []string     `json:"redirect_uris"`
+	Scopes       []string     `json:"scopes"`
+	GrantTypes   []string     `json:"grant_types"`
+	UpdatedAt    sql.NullTime `json:"updated_at"`
+	CreatedAt    time.Time    `json:"created_at"`
+}
+
+// IsPublic tells whether the client can't keep a secret
+func (c *OAuthClient) IsPublic() bool {
+	return c.SecretHash == ""
+}
+
+// OAuthConsent records the scopes an account granted to a client,
+// it isn't asked again for them
+type OAuthConsent struct {
+	AccountUID uuid.UUID
+	ClientID   string
+	Scopes     []string
+}
+
+// AuthorizationCode is what a code, handed to a client through its
+// redirect_uri, is exchanged for at the token endpoint
+type AuthorizationCode struct {
+	ClientID      string    `json:"client_id"`
+	AccountUID    uuid.UUID `json:"account_uid"`
+	RedirectURI   string    `json:"redirect_uri"`
+	Scope         string    `json:"scope"`
+	CodeChallenge string    `json:"code_challenge"`
+	Nonce         string    `json:"nonce"`
+}
+
+type OAuthRepository interface {
+	FindClient(ctx context.Context, clientID string) (*OAuthClient, error)
+	CreateClient(ctx context.Context, c *OAuthClient) error
+	FindConsent(ctx context.Context, uid uuid.UUID, clientID string) (*OAuthConsent, error)
+	SaveConsent(ctx context.Context, consent *OAuthConsent) error
+}
+
+// AuthorizationCodeRepository stores single use codes by their hash
+type AuthorizationCodeRepository interface {
+	SaveCode(ctx context.Context, codeHash string, code *AuthorizationCode, expiresIn time.Duration) error
+	ConsumeCode(ctx context.Context, codeHash string) (*AuthorizationCode, error)
+}
+
+// AuthorizeRequest are the parameters of the authorization endpoint
+type AuthorizeRequest struct {
+	ResponseType        string `form:"response_type" json:"response_type"`
+	ClientID            string `form:"client_id" json:"client_id"`
+	RedirectURI         string `form:"redirect_uri" json:"redirect_uri"`
+	Scope               string `form:"scope" json:"scope"`
+	State               string `form:"state" json:"state"`
+	CodeChallenge       string `form:"code_challenge" json:"code_challenge"`
+	CodeChallengeMethod string `form:"code_challenge_method" json:"code_challenge_method"`
+	Nonce               string `form:"nonce" json:"nonce"`
+}
+
+// AuthorizeResponse either sends the account back to the client, or asks
+// it to consent first. The app the account signed in with follows RedirectTo
+type AuthorizeResponse struct {
+	RedirectTo      string   `json:"redirect_to,omitempty"`
+	ConsentRequired bool     `json:"consent_required,omitempty"`
+	ClientName      string   `json:"client_name,omitempty"`
+	Scopes          []string `json:"scopes,omitempty"`
+}
+
+// TokenRequest are the parameters of the token endpoint
+type TokenRequest struct {
+	GrantType    string `form:"grant_type"`
+	Code         string `form:"code"`
+	RedirectURI  string `form:"redirect_uri"`
+	CodeVerifier string `form:"code_verifier"`
+	Scope        string `form:"scope"`
+	ClientID     string `form:"client_id"`
+	ClientSecret string `form:"client_secret"`
+}
+
+// OAuthTokens is the response of the token endpoint
+type OAuthTokens struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"`
+	Scope       string `json:"scope,omitempty"`
+	IDToken     string `json:"id_token,omitempty"`
+}
+
+// Introspection describes an access token, as defined by RFC 7662
+type Introspection struct {
+	Active    bool   `json:"active"`
+	Scope     string `json:"scope,omitempty"`
+	ClientID  string `json:"client_id,omitempty"`
+	Subject   string `json:"sub,omitempty"`
+	Audience  string `json:"aud,omitempty"`
+	Issuer    string `json:"iss,omitempty"`
+	ExpiresAt int64  `json:"exp,omitempty"`
+	IssuedAt  int64  `json:"iat,omitempty"`
+	TokenID   string `json:"jti,omitempty"`
+	TokenType string `json:"token_type,omitempty"`
+}
+
+// UserInfo are the claims about an account released for the scopes of a token
+type UserInfo struct {
+	Subject       string `json:"sub"`
+	Name          string `json:"name,omitempty"`
+	Picture       string `json:"picture,omitempty"`
+	Website       string `json:"website,omitempty"`
+	Email         string `json:"email,omitempty"`
+	EmailVerified *bool  `json:"email_verified,omitempty"`
+}
+
+// OIDCDiscovery is the OpenID Connect discovery document
+type OIDCDiscovery struct {
+	Issuer                            string   `json:"issuer"`
+	AuthorizationEndpoint             string   `json:"authorization_endpoint"`
+	TokenEndpoint                     string   `json:"token_endpoint"`
+	UserinfoEndpoint                  string   `json:"userinfo_endpoint"`
+	JwksURI                           string   `json:"jwks_uri"`
+	IntrospectionEndpoint             string   `json:"introspection_endpoint"`
+	RevocationEndpoint                string   `json:"revocation_endpoint"`
+	ScopesSupported                   []string `json:"scopes_supported"`
+	ResponseTypesSupported            []string `json:"response_types_supported"`
+	GrantTypesSupported               []string `json:"grant_types_supported"`
+	SubjectTypesSupported             []string `json:"subject_types_supported"`
+	IDTokenSigningAlgValuesSupported  []string `json:"id_token_signing_alg_values_supported"`
+	TokenEndpointAuthMethodsSupported []string `json:"token_endpoint_auth_methods_supported"`
+	CodeChallengeMethodsSupported     []string `json:"code_challenge_methods_supported"`
+	ClaimsSupported                   []string `json:"claims_supported"`
+}
+
+type OAuthService interface {
+	RegisterClient(ctx context.Context, c *OAuthClient, public bool) (string, error)
+	AuthenticateClient(ctx context.Context, clientID string, secret string) (*OAuthClient, error)
+	Authorize(ctx context.Context, uid uuid.UUID, req *AuthorizeRequest) (*AuthorizeResponse, error)
+	Consent(ctx context.Context, uid uuid.UUID, req *AuthorizeRequest, approve bool) (*AuthorizeResponse, error)
+	Exchange(ctx context.Context, client *OAuthClient, req *TokenRequest) (*OAuthTokens, error)
+	Introspect(ctx context.Context, token string) (*Introspection, error)
+	Revoke(ctx context.Context, client *OAuthClient, token string) error
+	UserInfo(ctx context.Context, accessToken string) (*UserInfo, error)
+	Discovery() *OIDCDiscovery
+}
+
+// OAuthError is an error of the OAuth endpoints. Unlike Error,
+// its format and codes are defined by RFC 6749
+type OAuthError struct {
+	Code        string `json:"error"`
+	Description string `json:"error_description,omitempty"`
+}
+
+func (e *OAuthError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Code, e.Description)
+}
+
+// Status of the error at the token, introspection and revocation endpoints
+func (e *OAuthError) Status() int {
+	switch e.Code {
+	case "invalid_client", "invalid_token":
+		return http.StatusUnauthorized
+	case "insufficient_scope":
+		return http.StatusForbidden
+	case "server_error":
+		return http.StatusInternalServerError
+	default:
+		return http.StatusBadRequest
+	}
+}
+
+// NewOAuthError creates an error with one of the codes of RFC 6749,
+// eg: invalid_request, invalid_client, invalid_grant
+func NewOAuthError(code string, description string) *OAuthError {
+	return &OAuthError{
+		Code:        code,
+		Description: description,
+	}
+}
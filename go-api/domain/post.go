@@ -6,32 +6,178 @@ import (
 	"time"
 
 	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
 )
 
 type PostRepository interface {
 	Save(ctx context.Context, post *Post) error
-	FindAll(ctx context.Context) ([]Post, error)
+	// FindAll returns up to limit posts matching the filter, starting
+	// after the cursor in the direction it points to. Posts are always
+	// returned in the order of the sort
+	FindAll(ctx context.Context, filter PostFilter, cursor *PostCursor, limit int) ([]Post, error)
 	FindByID(ctx context.Context, id int32) (Post, error)
-	Update(ctx context.Context, id int32, post *Post) error
-	Delete(ctx context.Context, id int32) error
+	// FindBySlug returns the post whose current or former slug it is, the
+	// slug of the post found differs from the one given when it changed
+	FindBySlug(ctx context.Context, slug string) (Post, error)
+	// FindByIDs returns the posts found, in no particular order
+	FindByIDs(ctx context.Context, ids []int32) ([]Post, error)
+	// Save and Update store the tags of the post along with it, tags no
+	// longer on any post are removed. Update keeps the tags when nil.
+	// Slug is made unique with a suffix, and the slug replaced by
+	// Update is kept for the post. Both add a revision of the post,
+	// made by the account of the author on Save and by editor on Update.
+	// Update and Delete fail with PreconditionFailed unless the post is
+	// still at the version given, post.Version for Update, which is
+	// then bumped
+	Update(ctx context.Context, id int32, post *Post, editor uuid.UUID) error
+	Delete(ctx context.Context, id int32, version int32) error
+	// PublishDue publishes the scheduled posts whose publish_at has
+	// passed, bumping their version, and returns their ids
+	PublishDue(ctx context.Context, now time.Time) ([]int32, error)
+	// FindRevisions returns the revisions of the post newest first,
+	// without their content
+	FindRevisions(ctx context.Context, postID int32) ([]PostRevision, error)
+	FindRevision(ctx context.Context, postID int32, number int32) (PostRevision, error)
 }
 
 type PostService interface {
-	Save(ctx context.Context, post *Post) error
-	FindAll(ctx context.Context) ([]Post, error)
-	FindByID(ctx context.Context, id int32) (Post, error)
-	Update(ctx context.Context, id int32, post *Post) error
-	Delete(ctx context.Context, id int32) error
+	// Save publishes the post under the author of the account
+	Save(ctx context.Context, account *Account, post *Post) error
+	// FindAll and FindByID only return published posts, archived posts
+	// are left out of the listing but can still be found by id
+	FindAll(ctx context.Context, query PostQuery) (*PostPage, error)
+	// FindByID sets MyReaction when account, which is nil for
+	// anonymous readers, is given. Reads set the Reactions of the posts
+	FindByID(ctx context.Context, account *Account, id int32) (Post, error)
+	// FindBySlug finds posts by their former slugs too, see PostRepository
+	FindBySlug(ctx context.Context, slug string) (Post, error)
+	// FindByAccount lists the posts of the account in every status,
+	// query.Status narrows them down
+	FindByAccount(ctx context.Context, account *Account, query PostQuery) (*PostPage, error)
+	Search(ctx context.Context, query SearchQuery) (*SearchPage, error)
+	// Update and Delete are only allowed to the owner of the post or an
+	// editor, and only on the version of the post they were made from:
+	// post.Version for Update
+	Update(ctx context.Context, account *Account, id int32, post *Post) error
+	Delete(ctx context.Context, account *Account, id int32, version int32) error
+	// PublishDue publishes the scheduled posts that are due
+	// and returns how many were published
+	PublishDue(ctx context.Context) (int, error)
+	// Revisions, Diff and Restore are only allowed to the accounts that
+	// can update the post. Restore updates the post with the title and
+	// content of the revision, which adds a revision of its own. Like
+	// Update, it is only made on the version of the post given
+	Revisions(ctx context.Context, account *Account, id int32) ([]PostRevision, error)
+	Diff(ctx context.Context, account *Account, id int32, from int32, to int32) (*PostDiff, error)
+	Restore(ctx context.Context, account *Account, id int32, number int32, version int32) (*Post, error)
+}
+
+// Statuses of a post, only published posts are public
+const (
+	DraftStatus     = "draft"
+	PublishedStatus = "published"
+	// ScheduledStatus posts are published once their publish_at passes
+	ScheduledStatus = "scheduled"
+	ArchivedStatus  = "archived"
+)
+
+// IsPostStatus reports whether the status is one of the post statuses
+func IsPostStatus(status string) bool {
+	switch status {
+	case DraftStatus, PublishedStatus, ScheduledStatus, ArchivedStatus:
+		return true
+	}
+	return false
 }
 
+// Orders posts can be listed in
+const (
+	NewestFirst = "newest"
+	OldestFirst = "oldest"
+)
+
+// MaxSlugLength leaves room in post.slug for the suffix of colliding slugs
+const MaxSlugLength = 80
+
+// Limits of a page of posts
+const (
+	DefaultPostLimit = 20
+	MaxPostLimit     = 100
+)
+
+// PostFilter narrows down the posts listed, zero fields aren't filtered on
+type PostFilter struct {
+	AuthorID int32
+	// From and To bound the creation time of the posts, both inclusive
+	From time.Time
+	To   time.Time
+	// Tag and Category are slugs, posts of the subcategories
+	// of the category are listed too
+	Tag        string
+	Category   string
+	Status     string
+	AccountUID uuid.UUID
+	Sort       string
+}
+
+// PostQuery requests a page of posts. Cursor is the opaque
+// next_cursor or prev_cursor of a page, empty for the first page
+type PostQuery struct {
+	PostFilter
+	Cursor string
+	Limit  int
+}
+
+// PostCursor is the position of a post in the listing, pages are keyed
+// on (created_at, id) so posts added meanwhile don't shift them
+type PostCursor struct {
+	CreatedAt time.Time `json:"t"`
+	ID        int32     `json:"id"`
+	Sort      string    `json:"s"`
+	// Backward cursors point to the posts before the position
+	Backward bool `json:"b,omitempty"`
+}
+
+// PostPage is a page of posts, with the cursors of the pages around it
+type PostPage struct {
+	Data       []Post `json:"data"`
+	NextCursor string `json:"next_cursor"`
+	PrevCursor string `json:"prev_cursor"`
+}
+
+// Post is in no category when CategoryID is 0. PublishedAt is set once the
+// post is published, PublishAt while it is scheduled. Version counts the
+// changes of the post, it is the ETag of the post. Reactions are
+// counted apart from the post and don't change its version
 type Post struct {
-	ID        int32        `json:id valid:"omitempty"`
-	Title     string       `json:title valid:"omitempty"`
-	Content   string       `json:content valid:"omitempty"`
-	UpdatedAt sql.NullTime `json:updated_at`
-	CreatedAt time.Time    `json:created_at`
-	Author    Author       `json:author`
-	AuthorID  int32        `json:author_id`
+	ID          int32        `json:id valid:"omitempty"`
+	Title       string       `json:title valid:"omitempty"`
+	Slug        string       `json:"slug"`
+	Content     string       `json:content valid:"omitempty"`
+	UpdatedAt   sql.NullTime `json:updated_at`
+	CreatedAt   time.Time    `json:created_at`
+	Author      Author       `json:author`
+	AuthorID    int32        `json:author_id`
+	Tags        []Tag        `json:"tags"`
+	CategoryID  int32        `json:"category_id"`
+	Category    *Category    `json:"category,omitempty"`
+	Status      string       `json:"status"`
+	PublishedAt *time.Time   `json:"published_at"`
+	PublishAt   *time.Time   `json:"publish_at"`
+	Version     int32        `json:"version"`
+	// Reactions and MyReaction, the kinds the reader reacted
+	// with, are only set on reads of the service
+	Reactions  ReactionCounts `json:"reactions"`
+	MyReaction []string       `json:"my_reaction"`
+}
+
+// CanBeModifiedBy reports whether the account owns the post and may write
+// posts, or is allowed to edit the posts of any account
+func (p *Post) CanBeModifiedBy(a *Account) bool {
+	if a.HasPermission(EditAnyPostPermission) {
+		return true
+	}
+	return p.Author.AccountUID != uuid.Nil && p.Author.AccountUID == a.UID && a.HasPermission(WritePostsPermission)
 }
 
 func (p *Post) Validate() error {
@@ -0,0 +1,28 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// RateLimit is the state of a limit after taking a request from it
+type RateLimit struct {
+	Allowed   bool
+	Limit     int64
+	Remaining int64
+	// Reset is how long until the whole limit is available again
+	Reset time.Duration
+	// RetryAfter is how long until the next request is allowed, when it wasn't
+	RetryAfter time.Duration
+}
+
+// RateLimiter takes requests from the limit of a key, eg: an IP
+type RateLimiter interface {
+	Allow(ctx context.Context, key string) (*RateLimit, error)
+}
+
+// IsRateLimit tells whether limit requests per window can be enforced,
+// the limiters take a request at most every ms
+func IsRateLimit(limit int64, window time.Duration) bool {
+	return limit > 0 && window/time.Duration(limit) >= time.Millisecond
+}
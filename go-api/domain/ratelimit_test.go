@@ -0,0 +1,16 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsRateLimit(t *testing.T) {
+	assert.True(t, IsRateLimit(30, time.Minute))
+	assert.True(t, IsRateLimit(1000, time.Second))
+	assert.False(t, IsRateLimit(1001, time.Second))
+	assert.False(t, IsRateLimit(0, time.Second))
+	assert.False(t, IsRateLimit(-1, time.Second))
+}
@@ -0,0 +1,91 @@
+package domain
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// Kinds of reactions to posts, an account reacts at most once with each
+const (
+	LikeReaction      = "like"
+	LoveReaction      = "love"
+	LaughReaction     = "laugh"
+	WowReaction       = "wow"
+	SadReaction       = "sad"
+	CelebrateReaction = "celebrate"
+)
+
+// ReactionEmoji maps each kind of reaction to the emoji shown for it
+var ReactionEmoji = map[string]string{
+	LikeReaction:      "👍",
+	LoveReaction:      "❤️",
+	LaughReaction:     "😂",
+	WowReaction:       "😮",
+	SadReaction:       "😢",
+	CelebrateReaction: "🎉",
+}
+
+// IsReaction reports whether the kind is one of the kinds of reactions
+func IsReaction(kind string) bool {
+	_, ok := ReactionEmoji[kind]
+	return ok
+}
+
+// ReactionCounts are the reactions to a post by kind,
+// kinds nobody reacted with are left out
+type ReactionCounts map[string]int64
+
+// ReactionRepository stores the reactions of the accounts, along with
+// the counts of the posts as of their last reconciliation
+type ReactionRepository interface {
+	// Add and Remove report whether the reaction changed, adding a
+	// reaction twice or removing a missing one does nothing
+	Add(ctx context.Context, postID int32, accountUID uuid.UUID, kind string) (bool, error)
+	Remove(ctx context.Context, postID int32, accountUID uuid.UUID, kind string) (bool, error)
+	// FindByAccount returns the kinds the account reacted with to the post
+	FindByAccount(ctx context.Context, postID int32, accountUID uuid.UUID) ([]string, error)
+	// Count counts the reactions to the post one by one
+	Count(ctx context.Context, postID int32) (ReactionCounts, error)
+	// SaveCounts and FindCounts store and read the reconciled counts,
+	// posts without reactions are left out of FindCounts
+	SaveCounts(ctx context.Context, postID int32, counts ReactionCounts) error
+	FindCounts(ctx context.Context, postIDs []int32) (map[int32]ReactionCounts, error)
+}
+
+// ReactionCounter holds the counts of reactions where they are cheap to
+// read and change. It only holds the counts of posts once they are Set,
+// reactions to other posts are counted when the post is reconciled
+type ReactionCounter interface {
+	// Incr adds delta to the count of the kind, when the counts of the
+	// post are held, and marks the post to be reconciled
+	Incr(ctx context.Context, postID int32, kind string, delta int64) error
+	// Get returns the counts held, posts not held are left out
+	Get(ctx context.Context, postIDs []int32) (map[int32]ReactionCounts, error)
+	Set(ctx context.Context, postID int32, counts ReactionCounts) error
+	// TakeDirty takes up to n of the posts marked by Incr,
+	// MarkDirty marks posts that still need reconciling
+	TakeDirty(ctx context.Context, n int) ([]int32, error)
+	MarkDirty(ctx context.Context, postIDs ...int32) error
+	// Remove drops the counts held of a deleted post
+	Remove(ctx context.Context, postID int32) error
+}
+
+type ReactionService interface {
+	// React adds the reaction of the account to a published post,
+	// Unreact removes it. Both return the reactions of the account
+	React(ctx context.Context, account *Account, postID int32, kind string) ([]string, error)
+	Unreact(ctx context.Context, account *Account, postID int32, kind string) ([]string, error)
+	// Mine returns the kinds the account reacted with to the post
+	Mine(ctx context.Context, account *Account, postID int32) ([]string, error)
+	// Count sets the Reactions of the posts from the counter, falling
+	// back to the reconciled counts of the posts it doesn't hold
+	Count(ctx context.Context, posts []Post) error
+	// Reconcile counts the reactions to the posts reacted to since the
+	// last reconciliation, stores their counts and sets them in the
+	// counter. It returns how many posts were reconciled
+	Reconcile(ctx context.Context) (int, error)
+	// Forget drops the counts held of a deleted post, its reactions
+	// and reconciled counts go along with the post
+	Forget(ctx context.Context, postID int32) error
+}
@@ -0,0 +1,41 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PostRevision is the title and content a post was saved with, revisions
+// are numbered from 1 per post and never change. AccountUID is the
+// account that made the revision, nil for posts older than accounts
+type PostRevision struct {
+	PostID     int32     `json:"post_id"`
+	Number     int32     `json:"number"`
+	Title      string    `json:"title"`
+	Content    string    `json:"content,omitempty"`
+	AccountUID uuid.UUID `json:"account_uid"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// Operations of a line of a PostDiff
+const (
+	DiffEqual  = "equal"
+	DiffInsert = "insert"
+	DiffDelete = "delete"
+)
+
+// DiffLine is a line kept, inserted or deleted between two revisions
+type DiffLine struct {
+	Op   string `json:"op"`
+	Text string `json:"text"`
+}
+
+// PostDiff is the line by line change of the title
+// and content of a post between two revisions
+type PostDiff struct {
+	From    int32      `json:"from"`
+	To      int32      `json:"to"`
+	Title   []DiffLine `json:"title"`
+	Content []DiffLine `json:"content"`
+}
@@ -0,0 +1,80 @@
+package domain
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// Built-in roles of an account
+const (
+	AdminRole  = "admin"
+	EditorRole = "editor"
+	AuthorRole = "author"
+	ReaderRole = "reader"
+)
+
+// DefaultRole is held by accounts without any role assigned,
+// so every account that signed up can keep writing posts
+const DefaultRole = AuthorRole
+
+// Permissions checked with middleware.Require and by the services
+const (
+	WritePostsPermission       = "posts:write"    // create posts, change and delete owned ones
+	EditAnyPostPermission      = "posts:edit_any" // change and delete posts of other accounts
+	WriteCommentsPermission    = "comments:write"
+	WriteReactionsPermission   = "reactions:write"
+	ManageCategoriesPermission = "categories:manage"
+	ManageRolesPermission      = "roles:manage"
+	ManageOAuthPermission      = "oauth_clients:manage"
+	ManageLockoutsPermission   = "lockouts:manage"
+)
+
+// RolePermissions lists the permissions granted by each role
+var RolePermissions = map[string][]string{
+	ReaderRole: {WriteCommentsPermission, WriteReactionsPermission},
+	AuthorRole: {WritePostsPermission, WriteCommentsPermission, WriteReactionsPermission},
+	EditorRole: {
+		WritePostsPermission, EditAnyPostPermission, WriteCommentsPermission, WriteReactionsPermission,
+		ManageCategoriesPermission,
+	},
+	AdminRole: {
+		WritePostsPermission, EditAnyPostPermission, WriteCommentsPermission, WriteReactionsPermission,
+		ManageCategoriesPermission, ManageRolesPermission, ManageOAuthPermission, ManageLockoutsPermission,
+	},
+}
+
+// IsRole reports whether the role is one of the built-in roles
+func IsRole(role string) bool {
+	_, ok := RolePermissions[role]
+	return ok
+}
+
+// HasPermission reports whether any role of the account grants the permission
+func (a *Account) HasPermission(permission string) bool {
+	roles := a.Roles
+	if len(roles) == 0 {
+		roles = []string{DefaultRole}
+	}
+
+	for _, role := range roles {
+		for _, p := range RolePermissions[role] {
+			if p == permission {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// RoleRepository stores the roles assigned to accounts. The roles are
+// read along with the account by the AccountRepository
+type RoleRepository interface {
+	AddRole(ctx context.Context, accountUID uuid.UUID, role string) error
+	RemoveRole(ctx context.Context, accountUID uuid.UUID, role string) error
+}
+
+type RoleService interface {
+	Grant(ctx context.Context, accountUID uuid.UUID, role string) (*Account, error)
+	Revoke(ctx context.Context, accountUID uuid.UUID, role string) (*Account, error)
+}
@@ -0,0 +1,46 @@
+package domain
+
+import "context"
+
+// SearchHit is a post matching a search, with snippets of its title
+// and content where the terms searched for are highlighted
+type SearchHit struct {
+	PostID       int32
+	Score        float64
+	TitleSnippet string
+	Snippet      string
+}
+
+// SearchIndex finds posts by relevance to a query. Implementations
+// whose index is maintained by the database treat Index and Remove
+// as no-ops
+type SearchIndex interface {
+	Index(ctx context.Context, post *Post) error
+	Remove(ctx context.Context, id int32) error
+	// Search returns the hits from offset, most relevant first, along
+	// with the total number of posts matching
+	Search(ctx context.Context, query string, offset int, limit int) ([]SearchHit, int, error)
+}
+
+// SearchQuery requests a page of search results, Cursor
+// is the next_cursor of a page, empty for the first page
+type SearchQuery struct {
+	Query  string
+	Cursor string
+	Limit  int
+}
+
+type SearchResult struct {
+	Post         Post    `json:"post"`
+	Score        float64 `json:"score"`
+	TitleSnippet string  `json:"title_snippet"`
+	Snippet      string  `json:"snippet"`
+}
+
+// SearchPage is a page of search results. Results are ranked, so pages
+// are only walked forward
+type SearchPage struct {
+	Data       []SearchResult `json:"data"`
+	Total      int            `json:"total"`
+	NextCursor string         `json:"next_cursor"`
+}
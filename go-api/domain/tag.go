@@ -0,0 +1,43 @@
+package domain
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// MaxPostTags is the most tags a post can have
+const MaxPostTags = 10
+
+// Tag labels posts. Tags are identified by their slug, so names
+// only differing by case or punctuation are the same tag
+type Tag struct {
+	ID   int32  `json:"id"`
+	Name string `json:"name"`
+	Slug string `json:"slug"`
+	// Count is the number of posts tagged, only set when listing tags
+	Count int `json:"count,omitempty"`
+}
+
+// UnmarshalJSON accepts a plain name too, so the tags
+// of a post can be sent as ["Go", "Web Development"]
+func (t *Tag) UnmarshalJSON(b []byte) error {
+	var name string
+	if err := json.Unmarshal(b, &name); err == nil {
+		*t = Tag{Name: name}
+		return nil
+	}
+
+	type tag Tag
+	return json.Unmarshal(b, (*tag)(t))
+}
+
+// TagRepository lists the tags in use. Tags are written along
+// with their posts by the PostRepository
+type TagRepository interface {
+	// FindAll returns the tags along with how many posts they are on
+	FindAll(ctx context.Context) ([]Tag, error)
+}
+
+type TagService interface {
+	FindAll(ctx context.Context) ([]Tag, error)
+}
@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"io/ioutil"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -8,26 +9,53 @@ import (
 	"github.com/whuangz/go-example/go-api/middleware"
 )
 
+// imageContentTypes are the sniffed content types accepted for profile images
+var imageContentTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+}
+
+// multipartHeadroom is allowed on top of the max image size for the
+// boundaries and headers of the multipart body
+const multipartHeadroom = 1 << 20
+
 type accountHandler struct {
-	service      domain.AccountService
-	tokenService domain.TokenService
+	service        domain.AccountService
+	tokenService   domain.TokenService
+	mfaService     domain.MFAService
+	lockoutService domain.LockoutService
+	maxImageBytes  int64
 }
 
-func NewAccountHandler(router *gin.Engine, service domain.AccountService, tokenService domain.TokenService) {
+func NewAccountHandler(router gin.IRouter, service domain.AccountService, tokenService domain.TokenService, mfaService domain.MFAService, lockoutService domain.LockoutService, maxImageBytes int64) {
 	h := &accountHandler{service: service,
-		tokenService: tokenService}
+		tokenService:   tokenService,
+		mfaService:     mfaService,
+		lockoutService: lockoutService,
+		maxImageBytes:  maxImageBytes}
 
 	accountGroup := router.Group("/api/account")
+	// an unverified account must still be able to manage itself,
+	// eg: to fix a mistyped email, so a verified email isn't required
 	if gin.Mode() != gin.TestMode {
 		//accountGroup.Use(middleware.Timeout(time.Duration(config.HANDLER_TIMEOUT), domain.NewServiceUnavailable()))
-		accountGroup.GET("/me", middleware.AuthUser(tokenService), h.Me)
+		accountGroup.GET("/me", middleware.AuthUser(tokenService, nil, false), h.Me)
 		accountGroup.POST("/signup", h.Signup)
 		accountGroup.POST("/signin", h.Signin)
-		accountGroup.POST("/signout", h.Signout)
+		accountGroup.POST("/signout", middleware.AuthUser(tokenService, nil, false), h.Signout)
 		accountGroup.POST("/tokens", h.Tokens)
-		accountGroup.POST("/image", h.Image)
-		accountGroup.DELETE("/image", h.DeleteImage)
-		accountGroup.PUT("/details", h.Details)
+		accountGroup.POST("/image", middleware.AuthUser(tokenService, nil, false), h.Image)
+		accountGroup.DELETE("/image", middleware.AuthUser(tokenService, nil, false), h.DeleteImage)
+		accountGroup.PUT("/details", middleware.AuthUser(tokenService, nil, false), h.Details)
+		accountGroup.POST("/password/forgot", h.ForgotPassword)
+		accountGroup.POST("/password/reset", h.ResetPassword)
+		accountGroup.GET("/verify", h.VerifyEmail)
+		accountGroup.POST("/verify/resend", middleware.AuthUser(tokenService, nil, false), h.ResendVerificationEmail)
+		accountGroup.POST("/2fa/enroll", middleware.AuthUser(tokenService, nil, false), h.EnrollMFA)
+		accountGroup.POST("/2fa/confirm", middleware.AuthUser(tokenService, nil, false), h.ConfirmMFA)
+		accountGroup.POST("/2fa/disable", middleware.AuthUser(tokenService, nil, false), h.DisableMFA)
+		accountGroup.POST("/2fa/verify", h.VerifyMFA)
 	} else {
 		accountGroup.GET("/me", h.Me)
 		accountGroup.POST("/signup", h.Signup)
@@ -37,6 +65,14 @@ func NewAccountHandler(router *gin.Engine, service domain.AccountService, tokenS
 		accountGroup.POST("/image", h.Image)
 		accountGroup.DELETE("/image", h.DeleteImage)
 		accountGroup.PUT("/details", h.Details)
+		accountGroup.POST("/password/forgot", h.ForgotPassword)
+		accountGroup.POST("/password/reset", h.ResetPassword)
+		accountGroup.GET("/verify", h.VerifyEmail)
+		accountGroup.POST("/verify/resend", h.ResendVerificationEmail)
+		accountGroup.POST("/2fa/enroll", h.EnrollMFA)
+		accountGroup.POST("/2fa/confirm", h.ConfirmMFA)
+		accountGroup.POST("/2fa/disable", h.DisableMFA)
+		accountGroup.POST("/2fa/verify", h.VerifyMFA)
 	}
 
 }
@@ -110,7 +146,8 @@ func (h *accountHandler) Signup(c *gin.Context) {
 	})
 }
 
-// Signin handler
+// Signin handler. Failed attempts are counted by email and by IP,
+// too many of them lock the signin for a while
 func (h *accountHandler) Signin(c *gin.Context) {
 	var req signInReq
 	if ok := bindData(c, &req); !ok {
@@ -123,15 +160,65 @@ func (h *accountHandler) Signin(c *gin.Context) {
 	}
 
 	ctx := c.Request.Context()
+	ip := c.ClientIP()
+
+	if err := h.lockoutService.Check(ctx, req.Email, ip); err != nil {
+		setRetryAfter(c, err)
+		c.JSON(domain.Status(err), gin.H{
+			"error": err,
+		})
+		return
+	}
+
 	err := h.service.Signin(ctx, a)
 
 	if err != nil {
+		// only wrong credentials count as a failure, not server errors
+		if domain.Status(err) == http.StatusUnauthorized {
+			if lockErr := h.lockoutService.RegisterFailure(ctx, req.Email, ip); lockErr != nil {
+				err = lockErr
+			}
+		}
+
+		setRetryAfter(c, err)
 		c.JSON(domain.Status(err), gin.H{
 			"error": err,
 		})
 		return
 	}
 
+	if err := h.lockoutService.RegisterSuccess(ctx, req.Email); err != nil {
+		c.JSON(domain.Status(err), gin.H{
+			"error": err,
+		})
+		return
+	}
+
+	mfaEnabled, err := h.mfaService.IsEnabled(ctx, a.UID)
+	if err != nil {
+		c.JSON(domain.Status(err), gin.H{
+			"error": err,
+		})
+		return
+	}
+
+	// the password alone isn't enough, the login
+	// completes through /2fa/verify with a code
+	if mfaEnabled {
+		challenge, err := h.mfaService.NewChallenge(ctx, a.UID)
+		if err != nil {
+			c.JSON(domain.Status(err), gin.H{
+				"error": err,
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"data": challenge,
+		})
+		return
+	}
+
 	tokens, err := h.tokenService.NewPairFromUser(ctx, a, "")
 	if err != nil {
 		c.JSON(domain.Status(err), gin.H{
@@ -146,37 +233,378 @@ func (h *accountHandler) Signin(c *gin.Context) {
 
 }
 
-// Signout handler
+// Signout handler revokes the refresh tokens of the account,
+// either on every device or only for the presented refresh token
 func (h *accountHandler) Signout(c *gin.Context) {
+	account, exists := c.Get("account")
+
+	if !exists {
+		err := domain.NewAuthorization("unauthroized")
+		c.JSON(err.Status(), gin.H{
+			"error": err,
+		})
+
+		return
+	}
+
+	// the body is optional, without one we sign out of all devices
+	var req signoutReq
+	if c.Request.ContentLength > 0 {
+		if ok := bindData(c, &req); !ok {
+			return
+		}
+	}
+
+	if req.ThisDeviceOnly && req.RefreshToken == "" {
+		err := domain.NewBadRequest("refresh_token is required to sign out this device only")
+		c.JSON(err.Status(), gin.H{
+			"error": err,
+		})
+		return
+	}
+
+	uid := account.(*domain.Account).UID
+
+	ctx := c.Request.Context()
+
+	var err error
+	if req.ThisDeviceOnly {
+		err = h.tokenService.SignoutDevice(ctx, uid, req.RefreshToken)
+
+		// signing out of every device revokes all access tokens,
+		// here only the one used for this request is revoked
+		if accessToken, exists := c.Get("access_token"); exists && err == nil {
+			err = h.tokenService.RevokeAccessToken(ctx, accessToken.(string))
+		}
+	} else {
+		err = h.tokenService.Signout(ctx, uid)
+	}
+
+	if err != nil {
+		c.JSON(domain.Status(err), gin.H{
+			"error": err,
+		})
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
-		"hello": "it's signout",
+		"data": "user signed out successfully!",
 	})
 }
 
-// Tokens handler
+// Tokens handler exchanges a valid refresh token
+// for a new token pair, invalidating the old one
 func (h *accountHandler) Tokens(c *gin.Context) {
+	var req tokensReq
+	if ok := bindData(c, &req); !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	refreshToken, err := h.tokenService.ValidateRefreshToken(req.RefreshToken)
+	if err != nil {
+		c.JSON(domain.Status(err), gin.H{
+			"error": err,
+		})
+		return
+	}
+
+	a, err := h.service.Get(ctx, refreshToken.UID)
+	if err != nil {
+		c.JSON(domain.Status(err), gin.H{
+			"error": err,
+		})
+		return
+	}
+
+	// passing the previous token id deletes it from the whitelist,
+	// which fails if it has already been used or revoked
+	tokens, err := h.tokenService.NewPairFromUser(ctx, a, refreshToken.ID.String())
+	if err != nil {
+		c.JSON(domain.Status(err), gin.H{
+			"error": err,
+		})
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
-		"hello": "it's tokens",
+		"data": tokens,
 	})
 }
 
-// Image handler
+// Image handler uploads the profile image of the account from
+// the imageFile field of a multipart/form-data request
 func (h *accountHandler) Image(c *gin.Context) {
+	account, exists := c.Get("account")
+
+	if !exists {
+		err := domain.NewAuthorization("unauthroized")
+		c.JSON(err.Status(), gin.H{
+			"error": err,
+		})
+
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.maxImageBytes+multipartHeadroom)
+
+	imageFileHeader, err := c.FormFile("imageFile")
+	if err != nil {
+		var e *domain.Error
+		// MaxBytesReader doesn't have a typed error
+		if err.Error() == "http: request body too large" {
+			e = domain.NewPayloadTooLarge(h.maxImageBytes, c.Request.ContentLength)
+		} else {
+			e = domain.NewBadRequest("Unable to parse multipart/form-data with an imageFile")
+		}
+
+		c.JSON(e.Status(), gin.H{
+			"error": e,
+		})
+		return
+	}
+
+	if imageFileHeader.Size > h.maxImageBytes {
+		e := domain.NewPayloadTooLarge(h.maxImageBytes, imageFileHeader.Size)
+		c.JSON(e.Status(), gin.H{
+			"error": e,
+		})
+		return
+	}
+
+	imageFile, err := imageFileHeader.Open()
+	if err != nil {
+		e := domain.NewBadRequest("Unable to open imageFile")
+		c.JSON(e.Status(), gin.H{
+			"error": e,
+		})
+		return
+	}
+	defer imageFile.Close()
+
+	data, err := ioutil.ReadAll(imageFile)
+	if err != nil {
+		e := domain.NewBadRequest("Unable to read imageFile")
+		c.JSON(e.Status(), gin.H{
+			"error": e,
+		})
+		return
+	}
+
+	// the declared content type can't be trusted, sniff it from the content
+	if contentType := http.DetectContentType(data); !imageContentTypes[contentType] {
+		e := domain.NewUnsupportedMediaType("imageFile must be a jpeg, png or gif image")
+		c.JSON(e.Status(), gin.H{
+			"error": e,
+		})
+		return
+	}
+
+	uid := account.(*domain.Account).UID
+
+	ctx := c.Request.Context()
+	a, err := h.service.SetProfileImage(ctx, uid, data)
+
+	if err != nil {
+		c.JSON(domain.Status(err), gin.H{
+			"error": err,
+		})
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
-		"hello": "it's image",
+		"data": a,
 	})
 }
 
-// DeleteImage handler
+// DeleteImage handler removes the profile image of the account
 func (h *accountHandler) DeleteImage(c *gin.Context) {
+	account, exists := c.Get("account")
+
+	if !exists {
+		err := domain.NewAuthorization("unauthroized")
+		c.JSON(err.Status(), gin.H{
+			"error": err,
+		})
+
+		return
+	}
+
+	uid := account.(*domain.Account).UID
+
+	ctx := c.Request.Context()
+	a, err := h.service.ClearProfileImage(ctx, uid)
+
+	if err != nil {
+		c.JSON(domain.Status(err), gin.H{
+			"error": err,
+		})
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
-		"hello": "it's deleteImage",
+		"data": a,
 	})
 }
 
-// Details handler
+// Details handler updates the profile of the account. Since the
+// account is embedded in the access token, a new token pair is returned
 func (h *accountHandler) Details(c *gin.Context) {
+	account, exists := c.Get("account")
+
+	if !exists {
+		err := domain.NewAuthorization("unauthroized")
+		c.JSON(err.Status(), gin.H{
+			"error": err,
+		})
+
+		return
+	}
+
+	var req detailsReq
+	if ok := bindData(c, &req); !ok {
+		return
+	}
+
+	a := &domain.Account{
+		UID:     account.(*domain.Account).UID,
+		Name:    req.Name,
+		Email:   req.Email,
+		Website: req.Website,
+	}
+
+	ctx := c.Request.Context()
+	err := h.service.Update(ctx, a)
+
+	if err != nil {
+		c.JSON(domain.Status(err), gin.H{
+			"error": err,
+		})
+		return
+	}
+
+	tokens, err := h.tokenService.NewPairFromUser(ctx, a, "")
+	if err != nil {
+		c.JSON(domain.Status(err), gin.H{
+			"error": err,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": gin.H{
+			"account": a,
+			"tokens":  tokens,
+		},
+	})
+}
+
+// ForgotPassword handler mails a password reset link. The response
+// is the same whether or not an account uses the email
+func (h *accountHandler) ForgotPassword(c *gin.Context) {
+	var req forgotPasswordReq
+	if ok := bindData(c, &req); !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	err := h.service.ForgotPassword(ctx, req.Email)
+
+	if err != nil {
+		c.JSON(domain.Status(err), gin.H{
+			"error": err,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": "if an account uses this email, a password reset link has been sent",
+	})
+}
+
+// ResetPassword handler sets a new password with a reset token
+// and signs the account out of every device
+func (h *accountHandler) ResetPassword(c *gin.Context) {
+	var req resetPasswordReq
+	if ok := bindData(c, &req); !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	a, err := h.service.ResetPassword(ctx, req.Token, req.Password)
+
+	if err != nil {
+		c.JSON(domain.Status(err), gin.H{
+			"error": err,
+		})
+		return
+	}
+
+	// whoever knew the old password may still hold tokens
+	if err := h.tokenService.Signout(ctx, a.UID); err != nil {
+		c.JSON(domain.Status(err), gin.H{
+			"error": err,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": "password has been reset, please sign in again",
+	})
+}
+
+// VerifyEmail handler verifies the email of the account
+// with the token of the link mailed to it
+func (h *accountHandler) VerifyEmail(c *gin.Context) {
+	token := c.Query("token")
+	if token == "" {
+		err := domain.NewBadRequest("token is required")
+		c.JSON(err.Status(), gin.H{
+			"error": err,
+		})
+		return
+	}
+
+	ctx := c.Request.Context()
+	a, err := h.service.VerifyEmail(ctx, token)
+
+	if err != nil {
+		c.JSON(domain.Status(err), gin.H{
+			"error": err,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": a,
+	})
+}
+
+// ResendVerificationEmail handler mails a new verification link
+func (h *accountHandler) ResendVerificationEmail(c *gin.Context) {
+	account, exists := c.Get("account")
+
+	if !exists {
+		err := domain.NewAuthorization("unauthroized")
+		c.JSON(err.Status(), gin.H{
+			"error": err,
+		})
+
+		return
+	}
+
+	uid := account.(*domain.Account).UID
+
+	ctx := c.Request.Context()
+	if err := h.service.ResendVerificationEmail(ctx, uid); err != nil {
+		c.JSON(domain.Status(err), gin.H{
+			"error": err,
+		})
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
-		"hello": "it's details",
+		"data": "verification email sent",
 	})
 }
@@ -0,0 +1,129 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/whuangz/go-example/go-api/domain"
+	"github.com/whuangz/go-example/go-api/middleware"
+)
+
+type adminHandler struct {
+	lockoutService domain.LockoutService
+	oauthService   domain.OAuthService
+	roleService    domain.RoleService
+}
+
+// NewAdminHandler serves the admin routes to the accounts whose roles
+// grant the permission of the route, and to requests with the admin key
+func NewAdminHandler(router gin.IRouter, lockoutService domain.LockoutService, oauthService domain.OAuthService, roleService domain.RoleService, tokenService domain.TokenService, apiKeyService domain.APIKeyService, adminKey string, requireVerifiedEmail bool) {
+	h := &adminHandler{lockoutService: lockoutService, oauthService: oauthService, roleService: roleService}
+
+	adminGroup := router.Group("/api/admin")
+	if gin.Mode() != gin.TestMode {
+		adminGroup.Use(middleware.AdminKeyOrAuthUser(adminKey, middleware.AuthUser(tokenService, apiKeyService, requireVerifiedEmail)))
+
+		adminGroup.DELETE("/lockouts/:email", middleware.Require(domain.ManageLockoutsPermission), h.Unlock)
+		adminGroup.POST("/oauth/clients", middleware.Require(domain.ManageOAuthPermission), h.RegisterClient)
+		adminGroup.PUT("/accounts/:uid/roles/:role", middleware.Require(domain.ManageRolesPermission), h.GrantRole)
+		adminGroup.DELETE("/accounts/:uid/roles/:role", middleware.Require(domain.ManageRolesPermission), h.RevokeRole)
+	} else {
+		adminGroup.DELETE("/lockouts/:email", h.Unlock)
+		adminGroup.POST("/oauth/clients", h.RegisterClient)
+		adminGroup.PUT("/accounts/:uid/roles/:role", h.GrantRole)
+		adminGroup.DELETE("/accounts/:uid/roles/:role", h.RevokeRole)
+	}
+}
+
+// Unlock handler lifts the signin lock of an account
+func (h *adminHandler) Unlock(c *gin.Context) {
+	email := c.Param("email")
+
+	ctx := c.Request.Context()
+	if err := h.lockoutService.Unlock(ctx, email); err != nil {
+		c.JSON(domain.Status(err), gin.H{
+			"error": err,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": "signin of " + email + " has been unlocked",
+	})
+}
+
+type registerClientReq struct {
+	Name         string   `json:"name" binding:"required"`
+	RedirectURIs []string `json:"redirect_uris"`
+	Scopes       []string `json:"scopes" binding:"required"`
+	GrantTypes   []string `json:"grant_types" binding:"required"`
+	// public clients, eg: single page or mobile apps, can't keep a secret
+	Public bool `json:"public"`
+}
+
+// RegisterClient handler adds an OAuth client. Its secret
+// is only returned here, it is stored hashed
+func (h *adminHandler) RegisterClient(c *gin.Context) {
+	var req registerClientReq
+	if ok := bindData(c, &req); !ok {
+		return
+	}
+
+	client := &domain.OAuthClient{
+		Name:         req.Name,
+		RedirectURIs: req.RedirectURIs,
+		Scopes:       req.Scopes,
+		GrantTypes:   req.GrantTypes,
+	}
+
+	ctx := c.Request.Context()
+	secret, err := h.oauthService.RegisterClient(ctx, client, req.Public)
+	if err != nil {
+		c.JSON(domain.Status(err), gin.H{
+			"error": err,
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"data": gin.H{
+			"client":        client,
+			"client_secret": secret,
+		},
+	})
+}
+
+// GrantRole handler assigns a role to an account
+func (h *adminHandler) GrantRole(c *gin.Context) {
+	h.changeRole(c, h.roleService.Grant)
+}
+
+// RevokeRole handler removes a role from an account
+func (h *adminHandler) RevokeRole(c *gin.Context) {
+	h.changeRole(c, h.roleService.Revoke)
+}
+
+func (h *adminHandler) changeRole(c *gin.Context, change func(ctx context.Context, uid uuid.UUID, role string) (*domain.Account, error)) {
+	uid, err := uuid.Parse(c.Param("uid"))
+	if err != nil {
+		e := domain.NewNotFound("uid", c.Param("uid"))
+		c.JSON(e.Status(), gin.H{
+			"error": e,
+		})
+		return
+	}
+
+	account, err := change(c.Request.Context(), uid, c.Param("role"))
+	if err != nil {
+		c.JSON(domain.Status(err), gin.H{
+			"error": err,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": account,
+	})
+}
@@ -0,0 +1,129 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/whuangz/go-example/go-api/domain"
+	"github.com/whuangz/go-example/go-api/middleware"
+)
+
+type apiKeyHandler struct {
+	service domain.APIKeyService
+}
+
+// NewAPIKeyHandler serves the API keys of the signed in account. They are
+// managed with an access token only, a leaked key can't mint new keys
+func NewAPIKeyHandler(router gin.IRouter, service domain.APIKeyService, tokenService domain.TokenService) {
+	h := &apiKeyHandler{service: service}
+
+	keysGroup := router.Group("/api/account/keys")
+	if gin.Mode() != gin.TestMode {
+		keysGroup.Use(middleware.AuthUser(tokenService, nil, false))
+	}
+	keysGroup.GET("", h.List)
+	keysGroup.POST("", h.Create)
+	keysGroup.DELETE("/:key_id", h.Revoke)
+}
+
+// List handler returns the keys of the account, without the keys themselves
+func (h *apiKeyHandler) List(c *gin.Context) {
+	account, exists := c.Get("account")
+	if !exists {
+		err := domain.NewAuthorization("unauthorized")
+		c.JSON(err.Status(), gin.H{
+			"error": err,
+		})
+		return
+	}
+
+	ctx := c.Request.Context()
+	keys, err := h.service.List(ctx, account.(*domain.Account).UID)
+	if err != nil {
+		c.JSON(domain.Status(err), gin.H{
+			"error": err,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": keys,
+	})
+}
+
+type createAPIKeyReq struct {
+	Name   string   `json:"name" binding:"required,max=255"`
+	Scopes []string `json:"scopes" binding:"required"`
+	// the key doesn't expire when left out
+	ExpiresInDays int `json:"expires_in_days" binding:"gte=0"`
+}
+
+// Create handler generates a key, it is only ever shown in this response
+func (h *apiKeyHandler) Create(c *gin.Context) {
+	account, exists := c.Get("account")
+	if !exists {
+		err := domain.NewAuthorization("unauthorized")
+		c.JSON(err.Status(), gin.H{
+			"error": err,
+		})
+		return
+	}
+
+	var req createAPIKeyReq
+	if ok := bindData(c, &req); !ok {
+		return
+	}
+
+	expiresIn := time.Duration(req.ExpiresInDays) * 24 * time.Hour
+
+	ctx := c.Request.Context()
+	apiKey, key, err := h.service.Create(ctx, account.(*domain.Account).UID, req.Name, req.Scopes, expiresIn)
+	if err != nil {
+		c.JSON(domain.Status(err), gin.H{
+			"error": err,
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"data": gin.H{
+			"api_key": apiKey,
+			"key":     key,
+		},
+	})
+}
+
+// Revoke handler deletes a key of the account, requests made with it fail right away
+func (h *apiKeyHandler) Revoke(c *gin.Context) {
+	account, exists := c.Get("account")
+	if !exists {
+		err := domain.NewAuthorization("unauthorized")
+		c.JSON(err.Status(), gin.H{
+			"error": err,
+		})
+		return
+	}
+
+	uid, err := uuid.Parse(c.Param("key_id"))
+	if err != nil {
+		e := domain.NewNotFound("api key", c.Param("key_id"))
+		c.JSON(e.Status(), gin.H{
+			"error": e,
+		})
+		return
+	}
+
+	ctx := c.Request.Context()
+	if err := h.service.Revoke(ctx, account.(*domain.Account).UID, uid); err != nil {
+		c.JSON(domain.Status(err), gin.H{
+			"error": err,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": "API key has been revoked",
+	})
+}
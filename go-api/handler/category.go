@@ -0,0 +1,67 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/whuangz/go-example/go-api/domain"
+	"github.com/whuangz/go-example/go-api/middleware"
+)
+
+type categoryHandler struct {
+	service domain.CategoryService
+}
+
+// NewCategoryHandler serves the tree of categories, accounts
+// whose roles allow it can add categories
+func NewCategoryHandler(router gin.IRouter, service domain.CategoryService, tokenService domain.TokenService, apiKeyService domain.APIKeyService, requireVerifiedEmail bool) {
+	h := &categoryHandler{service: service}
+
+	categoryGroup := router.Group("/api/categories")
+	if gin.Mode() != gin.TestMode {
+		auth := middleware.AuthUser(tokenService, apiKeyService, requireVerifiedEmail)
+		categoryGroup.GET("", h.Tree)
+		categoryGroup.POST("", auth, middleware.Require(domain.ManageCategoriesPermission), h.Create)
+	} else {
+		categoryGroup.GET("", h.Tree)
+		categoryGroup.POST("", h.Create)
+	}
+}
+
+// Tree handler returns the top level categories, nesting their children
+func (h *categoryHandler) Tree(c *gin.Context) {
+	categories, err := h.service.Tree(c.Request.Context())
+	if err != nil {
+		c.JSON(domain.Status(err), gin.H{
+			"error": err,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": categories,
+	})
+}
+
+type createCategoryReq struct {
+	Name     string `json:"name" binding:"required,max=50"`
+	ParentID int32  `json:"parent_id" binding:"gte=0"`
+}
+
+// Create handler adds a category, under parent_id when given
+func (h *categoryHandler) Create(c *gin.Context) {
+	var req createCategoryReq
+	if ok := bindData(c, &req); !ok {
+		return
+	}
+
+	category := &domain.Category{Name: req.Name, ParentID: req.ParentID}
+	if err := h.service.Save(c.Request.Context(), category); err != nil {
+		c.JSON(domain.Status(err), gin.H{
+			"error": err,
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, category)
+}
@@ -0,0 +1,187 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/whuangz/go-example/go-api/domain"
+	"github.com/whuangz/go-example/go-api/middleware"
+)
+
+type commentHandler struct {
+	service domain.CommentService
+}
+
+// NewCommentHandler serves the comments of the posts. Anyone can read
+// the approved comments, accounts whose roles allow it can comment
+func NewCommentHandler(router gin.IRouter, posts PostChildren, service domain.CommentService, tokenService domain.TokenService, apiKeyService domain.APIKeyService, requireVerifiedEmail bool) {
+	h := &commentHandler{service: service}
+
+	commentGroup := router.Group("/api/post/:post_id/comments")
+	if gin.Mode() != gin.TestMode {
+		auth := middleware.AuthUser(tokenService, apiKeyService, requireVerifiedEmail)
+		canComment := middleware.Require(domain.WriteCommentsPermission)
+
+		posts.GET("comments", middleware.OptionalAuthUser(tokenService, apiKeyService, requireVerifiedEmail), h.List)
+		commentGroup.POST("", auth, canComment, h.Create)
+		commentGroup.PATCH("/:comment_id", auth, canComment, h.Update)
+		commentGroup.DELETE("/:comment_id", auth, h.Delete)
+		commentGroup.PUT("/:comment_id/status", auth, h.Moderate)
+	} else {
+		posts.GET("comments", h.List)
+		commentGroup.POST("", h.Create)
+		commentGroup.PATCH("/:comment_id", h.Update)
+		commentGroup.DELETE("/:comment_id", h.Delete)
+		commentGroup.PUT("/:comment_id/status", h.Moderate)
+	}
+}
+
+// List handler returns the threads of comments on the post, signed in
+// accounts see their own comments awaiting moderation too
+func (h *commentHandler) List(c *gin.Context) {
+	postID, ok := getPathInt(c, "post_id")
+	if !ok {
+		return
+	}
+
+	comments, err := h.service.List(c.Request.Context(), optionalAccount(c), int32(postID))
+	if err != nil {
+		c.JSON(domain.Status(err), gin.H{
+			"error": err,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": comments,
+	})
+}
+
+type createCommentReq struct {
+	Content  string `json:"content" binding:"required"`
+	ParentID int32  `json:"parent_id" binding:"gte=0"`
+}
+
+// Create handler comments on the post, or replies to parent_id
+func (h *commentHandler) Create(c *gin.Context) {
+	postID, ok := getPathInt(c, "post_id")
+	if !ok {
+		return
+	}
+	account, ok := contextAccount(c)
+	if !ok {
+		return
+	}
+
+	var req createCommentReq
+	if ok := bindData(c, &req); !ok {
+		return
+	}
+
+	comment := &domain.Comment{Content: req.Content, ParentID: req.ParentID}
+	if err := h.service.Create(c.Request.Context(), account, int32(postID), comment); err != nil {
+		c.JSON(domain.Status(err), gin.H{
+			"error": err,
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, comment)
+}
+
+type updateCommentReq struct {
+	Content string `json:"content" binding:"required"`
+}
+
+// Update handler changes the content of a comment of the account
+func (h *commentHandler) Update(c *gin.Context) {
+	postID, ok := getPathInt(c, "post_id")
+	if !ok {
+		return
+	}
+	commentID, ok := getPathInt(c, "comment_id")
+	if !ok {
+		return
+	}
+	account, ok := contextAccount(c)
+	if !ok {
+		return
+	}
+
+	var req updateCommentReq
+	if ok := bindData(c, &req); !ok {
+		return
+	}
+
+	comment, err := h.service.Update(c.Request.Context(), account, int32(postID), int32(commentID), req.Content)
+	if err != nil {
+		c.JSON(domain.Status(err), gin.H{
+			"error": err,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, comment)
+}
+
+// Delete handler removes the content of a comment, its replies stay
+func (h *commentHandler) Delete(c *gin.Context) {
+	postID, ok := getPathInt(c, "post_id")
+	if !ok {
+		return
+	}
+	commentID, ok := getPathInt(c, "comment_id")
+	if !ok {
+		return
+	}
+	account, ok := contextAccount(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.Delete(c.Request.Context(), account, int32(postID), int32(commentID)); err != nil {
+		c.JSON(domain.Status(err), gin.H{
+			"error": err,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": "Comment has been deleted",
+	})
+}
+
+type moderateCommentReq struct {
+	Status string `json:"status" binding:"required"`
+}
+
+// Moderate handler approves or rejects a comment, or puts it back to pending
+func (h *commentHandler) Moderate(c *gin.Context) {
+	postID, ok := getPathInt(c, "post_id")
+	if !ok {
+		return
+	}
+	commentID, ok := getPathInt(c, "comment_id")
+	if !ok {
+		return
+	}
+	account, ok := contextAccount(c)
+	if !ok {
+		return
+	}
+
+	var req moderateCommentReq
+	if ok := bindData(c, &req); !ok {
+		return
+	}
+
+	comment, err := h.service.Moderate(c.Request.Context(), account, int32(postID), int32(commentID), req.Status)
+	if err != nil {
+		c.JSON(domain.Status(err), gin.H{
+			"error": err,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, comment)
+}
@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/whuangz/go-example/go-api/domain"
+)
+
+// versionETag is the strong ETag of a resource at a version
+func versionETag(version int32) string {
+	return `"` + strconv.Itoa(int(version)) + `"`
+}
+
+// notModified sets the ETag of the response and answers 304 when
+// If-None-Match already has it. Weak ETags match too, as for any GET
+func notModified(c *gin.Context, etag string) bool {
+	c.Header("ETag", etag)
+
+	header := c.GetHeader("If-None-Match")
+	if header == "" {
+		return false
+	}
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == "*" || tag == etag {
+			c.Status(http.StatusNotModified)
+			return true
+		}
+	}
+	return false
+}
+
+// ifMatchVersion returns the version in the If-Match of a write, which
+// is required. An If-Match that isn't the strong ETag of a version can
+// never match, so it fails like a version that changed
+func ifMatchVersion(c *gin.Context, name string, value string) (int32, bool) {
+	header := strings.TrimSpace(c.GetHeader("If-Match"))
+	if header == "" {
+		e := domain.NewPreconditionRequired("If-Match is required, send the ETag of the " + name)
+		c.JSON(e.Status(), gin.H{
+			"message": e.Error(),
+		})
+		c.Abort()
+		return 0, false
+	}
+
+	version, err := strconv.ParseInt(strings.TrimSuffix(strings.TrimPrefix(header, `"`), `"`), 10, 32)
+	if err != nil || versionETag(int32(version)) != header {
+		e := domain.NewPreconditionFailed(name, value)
+		c.JSON(e.Status(), gin.H{
+			"message": e.Error(),
+		})
+		c.Abort()
+		return 0, false
+	}
+	return int32(version), true
+}
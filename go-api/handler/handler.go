@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"strconv"
@@ -24,6 +25,15 @@ func getPathInt(c *gin.Context, name string) (int, bool) {
 	return r, true
 }
 
+// setRetryAfter tells the client when to try again
+// after a request was rejected with TooManyRequests
+func setRetryAfter(c *gin.Context, err error) {
+	var e *domain.Error
+	if errors.As(err, &e) && e.Type == domain.TooManyRequests {
+		c.Header("Retry-After", strconv.FormatInt(e.RetryAfter, 10))
+	}
+}
+
 func bindData(c *gin.Context, req interface{}) bool {
 	if c.ContentType() != "application/json" {
 		msg := fmt.Sprintf("%s only accepts Content-Type application/json", c.FullPath())
@@ -0,0 +1,147 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/whuangz/go-example/go-api/domain"
+)
+
+// EnrollMFA handler starts the 2FA enrollment, returning the
+// secret, its otpauth URI and a QR code of it as a png
+func (h *accountHandler) EnrollMFA(c *gin.Context) {
+	account, exists := c.Get("account")
+
+	if !exists {
+		err := domain.NewAuthorization("unauthroized")
+		c.JSON(err.Status(), gin.H{
+			"error": err,
+		})
+
+		return
+	}
+
+	ctx := c.Request.Context()
+	enrollment, err := h.mfaService.Enroll(ctx, account.(*domain.Account))
+
+	if err != nil {
+		c.JSON(domain.Status(err), gin.H{
+			"error": err,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": enrollment,
+	})
+}
+
+// ConfirmMFA handler enables 2FA with a first code and
+// returns the recovery codes, which are only shown once
+func (h *accountHandler) ConfirmMFA(c *gin.Context) {
+	account, exists := c.Get("account")
+
+	if !exists {
+		err := domain.NewAuthorization("unauthroized")
+		c.JSON(err.Status(), gin.H{
+			"error": err,
+		})
+
+		return
+	}
+
+	var req mfaCodeReq
+	if ok := bindData(c, &req); !ok {
+		return
+	}
+
+	uid := account.(*domain.Account).UID
+
+	ctx := c.Request.Context()
+	codes, err := h.mfaService.Confirm(ctx, uid, req.Code)
+
+	if err != nil {
+		c.JSON(domain.Status(err), gin.H{
+			"error": err,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": gin.H{
+			"recovery_codes": codes,
+		},
+	})
+}
+
+// DisableMFA handler turns 2FA off
+func (h *accountHandler) DisableMFA(c *gin.Context) {
+	account, exists := c.Get("account")
+
+	if !exists {
+		err := domain.NewAuthorization("unauthroized")
+		c.JSON(err.Status(), gin.H{
+			"error": err,
+		})
+
+		return
+	}
+
+	var req mfaCodeReq
+	if ok := bindData(c, &req); !ok {
+		return
+	}
+
+	uid := account.(*domain.Account).UID
+
+	ctx := c.Request.Context()
+	if err := h.mfaService.Disable(ctx, uid, req.Code); err != nil {
+		c.JSON(domain.Status(err), gin.H{
+			"error": err,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": "2FA has been disabled",
+	})
+}
+
+// VerifyMFA handler completes a signin of an account with 2FA,
+// exchanging the challenge and a code for a token pair
+func (h *accountHandler) VerifyMFA(c *gin.Context) {
+	var req mfaVerifyReq
+	if ok := bindData(c, &req); !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	uid, err := h.mfaService.VerifyChallenge(ctx, req.ChallengeToken, req.Code)
+
+	if err != nil {
+		c.JSON(domain.Status(err), gin.H{
+			"error": err,
+		})
+		return
+	}
+
+	a, err := h.service.Get(ctx, uid)
+	if err != nil {
+		c.JSON(domain.Status(err), gin.H{
+			"error": err,
+		})
+		return
+	}
+
+	tokens, err := h.tokenService.NewPairFromUser(ctx, a, "")
+	if err != nil {
+		c.JSON(domain.Status(err), gin.H{
+			"error": err,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": tokens,
+	})
+}
@@ -0,0 +1,297 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/whuangz/go-example/go-api/domain"
+	"github.com/whuangz/go-example/go-api/middleware"
+)
+
+type oauthHandler struct {
+	service domain.OAuthService
+}
+
+// NewOAuthHandler serves the OAuth 2 and OpenID Connect endpoints. The
+// api has no pages, the app accounts sign in with calls the authorization
+// endpoint with their access token and follows the returned redirect
+func NewOAuthHandler(router gin.IRouter, service domain.OAuthService, tokenService domain.TokenService) {
+	h := &oauthHandler{service: service}
+
+	oauthGroup := router.Group("/oauth")
+	if gin.Mode() != gin.TestMode {
+		oauthGroup.GET("/authorize", middleware.AuthUser(tokenService, nil, false), h.Authorize)
+		oauthGroup.POST("/authorize", middleware.AuthUser(tokenService, nil, false), h.Consent)
+	} else {
+		oauthGroup.GET("/authorize", h.Authorize)
+		oauthGroup.POST("/authorize", h.Consent)
+	}
+	oauthGroup.POST("/token", h.Token)
+	oauthGroup.POST("/introspect", h.Introspect)
+	oauthGroup.POST("/revoke", h.Revoke)
+
+	router.GET("/userinfo", h.UserInfo)
+	router.POST("/userinfo", h.UserInfo)
+	router.GET("/.well-known/openid-configuration", h.Discovery)
+}
+
+// Authorize handler returns where to send the account back to the
+// client, unless it has to consent to the requested scopes first
+func (h *oauthHandler) Authorize(c *gin.Context) {
+	account, exists := c.Get("account")
+	if !exists {
+		err := domain.NewAuthorization("unauthorized")
+		c.JSON(err.Status(), gin.H{
+			"error": err,
+		})
+		return
+	}
+
+	var req domain.AuthorizeRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		e := domain.NewBadRequest("Invalid authorization request")
+		c.JSON(e.Status(), gin.H{
+			"error": e,
+		})
+		return
+	}
+
+	ctx := c.Request.Context()
+	res, err := h.service.Authorize(ctx, account.(*domain.Account).UID, &req)
+	if err != nil {
+		c.JSON(domain.Status(err), gin.H{
+			"error": err,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": res,
+	})
+}
+
+type consentReq struct {
+	domain.AuthorizeRequest
+	Approve bool `json:"approve"`
+}
+
+// Consent handler records whether the account approves the authorization
+// request, which is sent again along with the decision
+func (h *oauthHandler) Consent(c *gin.Context) {
+	account, exists := c.Get("account")
+	if !exists {
+		err := domain.NewAuthorization("unauthorized")
+		c.JSON(err.Status(), gin.H{
+			"error": err,
+		})
+		return
+	}
+
+	var req consentReq
+	if ok := bindData(c, &req); !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	res, err := h.service.Consent(ctx, account.(*domain.Account).UID, &req.AuthorizeRequest, req.Approve)
+	if err != nil {
+		c.JSON(domain.Status(err), gin.H{
+			"error": err,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": res,
+	})
+}
+
+// Token handler exchanges an authorization code, or the credentials
+// of a client, for tokens. Like the other OAuth endpoints, its requests
+// are form encoded and its responses follow RFC 6749 instead of data
+func (h *oauthHandler) Token(c *gin.Context) {
+	var req domain.TokenRequest
+	if ok := bindForm(c, &req); !ok {
+		return
+	}
+
+	client, ok := h.authenticateClient(c, req.ClientID, req.ClientSecret)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	tokens, err := h.service.Exchange(ctx, client, &req)
+	if err != nil {
+		oauthError(c, err)
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.Header("Pragma", "no-cache")
+	c.JSON(http.StatusOK, tokens)
+}
+
+type tokenReq struct {
+	Token        string `form:"token"`
+	ClientID     string `form:"client_id"`
+	ClientSecret string `form:"client_secret"`
+}
+
+// Introspect handler tells a confidential client, eg: a resource
+// server, whether an access token is active and what it was granted
+func (h *oauthHandler) Introspect(c *gin.Context) {
+	var req tokenReq
+	if ok := bindForm(c, &req); !ok {
+		return
+	}
+
+	client, ok := h.authenticateClient(c, req.ClientID, req.ClientSecret)
+	if !ok {
+		return
+	}
+
+	if client.IsPublic() {
+		oauthError(c, domain.NewOAuthError("invalid_client", "public clients can't introspect tokens"))
+		return
+	}
+
+	if req.Token == "" {
+		oauthError(c, domain.NewOAuthError("invalid_request", "token is required"))
+		return
+	}
+
+	ctx := c.Request.Context()
+	introspection, err := h.service.Introspect(ctx, req.Token)
+	if err != nil {
+		oauthError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, introspection)
+}
+
+// Revoke handler revokes an access token issued to the client. As
+// required by RFC 7009, it succeeds for tokens which aren't valid anymore
+func (h *oauthHandler) Revoke(c *gin.Context) {
+	var req tokenReq
+	if ok := bindForm(c, &req); !ok {
+		return
+	}
+
+	client, ok := h.authenticateClient(c, req.ClientID, req.ClientSecret)
+	if !ok {
+		return
+	}
+
+	if req.Token == "" {
+		oauthError(c, domain.NewOAuthError("invalid_request", "token is required"))
+		return
+	}
+
+	ctx := c.Request.Context()
+	if err := h.service.Revoke(ctx, client, req.Token); err != nil {
+		oauthError(c, err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// UserInfo handler returns the claims about the account
+// of an access token issued with the openid scope
+func (h *oauthHandler) UserInfo(c *gin.Context) {
+	accTokenHeader := strings.Split(c.GetHeader("Authorization"), "Bearer ")
+	if len(accTokenHeader) < 2 || accTokenHeader[1] == "" {
+		c.Header("WWW-Authenticate", `Bearer realm="userinfo"`)
+		c.Status(http.StatusUnauthorized)
+		return
+	}
+
+	ctx := c.Request.Context()
+	info, err := h.service.UserInfo(ctx, accTokenHeader[1])
+	if err != nil {
+		var e *domain.OAuthError
+		if errors.As(err, &e) {
+			c.Header("WWW-Authenticate", fmt.Sprintf(`Bearer error="%s", error_description="%s"`, e.Code, e.Description))
+		}
+		oauthError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, info)
+}
+
+// Discovery handler publishes the OpenID Connect discovery document
+func (h *oauthHandler) Discovery(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=3600")
+	c.JSON(http.StatusOK, h.service.Discovery())
+}
+
+// authenticateClient takes the credentials of the client from the
+// Authorization: Basic header, or from the form for clients which can't
+// send it. Public clients only send their client_id in the form
+func (h *oauthHandler) authenticateClient(c *gin.Context, formClientID string, formSecret string) (*domain.OAuthClient, bool) {
+	clientID, secret := formClientID, formSecret
+
+	basicID, basicSecret, basic := c.Request.BasicAuth()
+	if basic {
+		if (formClientID != "" && formClientID != basicID) || formSecret != "" {
+			oauthError(c, domain.NewOAuthError("invalid_request", "client credentials must be sent with a single method"))
+			return nil, false
+		}
+
+		// the credentials are form encoded before being put in the header
+		var idErr, secretErr error
+		clientID, idErr = url.QueryUnescape(basicID)
+		secret, secretErr = url.QueryUnescape(basicSecret)
+		if idErr != nil || secretErr != nil {
+			c.Header("WWW-Authenticate", `Basic realm="oauth"`)
+			oauthError(c, domain.NewOAuthError("invalid_client", "malformed client credentials"))
+			return nil, false
+		}
+	}
+
+	ctx := c.Request.Context()
+	client, err := h.service.AuthenticateClient(ctx, clientID, secret)
+	if err != nil {
+		if basic {
+			c.Header("WWW-Authenticate", `Basic realm="oauth"`)
+		}
+		oauthError(c, err)
+		return nil, false
+	}
+
+	return client, true
+}
+
+// bindForm binds a form encoded request, as sent to the OAuth endpoints
+func bindForm(c *gin.Context, req interface{}) bool {
+	if c.ContentType() != "application/x-www-form-urlencoded" {
+		oauthError(c, domain.NewOAuthError("invalid_request", fmt.Sprintf("%s only accepts Content-Type application/x-www-form-urlencoded", c.FullPath())))
+		return false
+	}
+
+	if err := c.ShouldBind(req); err != nil {
+		oauthError(c, domain.NewOAuthError("invalid_request", "the request could not be parsed"))
+		return false
+	}
+
+	return true
+}
+
+// oauthError responds with an RFC 6749 error, errors of the
+// application are reported as a server_error
+func oauthError(c *gin.Context, err error) {
+	var e *domain.OAuthError
+	if !errors.As(err, &e) {
+		e = domain.NewOAuthError("server_error", "the request could not be processed")
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.JSON(e.Status(), e)
+}
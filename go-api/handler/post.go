@@ -1,42 +1,161 @@
 package handler
 
 import (
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"time"
+
 	"github.com/gin-gonic/gin"
 	"github.com/whuangz/go-example/go-api/domain"
+	"github.com/whuangz/go-example/go-api/middleware"
 )
 
 type postHandler struct {
-	service domain.PostService
+	service  domain.PostService
+	children PostChildren
+}
+
+// PostChildren are the GET routes below /api/post/:post_id/, which gin
+// can't route next to /api/post/by-slug/:slug. See getPostChild
+type PostChildren map[string]gin.HandlersChain
+
+// GET routes /api/post/:post_id/{child} to the handlers
+func (p PostChildren) GET(child string, handlers ...gin.HandlerFunc) {
+	p[child] = handlers
 }
 
-func NewPostHandler(router *gin.Engine, service domain.PostService) {
-	handler := &postHandler{service}
+// NewPostHandler serves the posts, writes are made with an access token
+// or the API key of an account whose roles allow writing posts. The GET
+// routes of other handlers below a post are added to the PostChildren
+func NewPostHandler(router gin.IRouter, service domain.PostService, tokenService domain.TokenService, apiKeyService domain.APIKeyService, requireVerifiedEmail bool) PostChildren {
+	handler := &postHandler{service: service, children: PostChildren{}}
 
 	postGroup := router.Group("/api/post")
-	{
+	if gin.Mode() != gin.TestMode {
+		auth := middleware.AuthUser(tokenService, apiKeyService, requireVerifiedEmail)
+		canWrite := middleware.Require(domain.WritePostsPermission)
+
+		postGroup.GET("", handler.getPosts)
+		postGroup.POST("", auth, canWrite, handler.createPost)
+		postGroup.GET("/:post_id", middleware.OptionalAuthUser(tokenService, apiKeyService, requireVerifiedEmail), handler.getPostByID)
+		postGroup.GET("/:post_id/:child", handler.getPostChild)
+		postGroup.PATCH("/:post_id", auth, canWrite, handler.updatePost)
+		postGroup.DELETE("/:post_id", auth, canWrite, handler.deletePost)
+		postGroup.POST("/:post_id/revisions/:revision/restore", auth, canWrite, handler.restoreRevision)
+		router.GET("/api/account/me/posts", auth, handler.getMyPosts)
+
+		handler.children.GET("revisions", auth, canWrite, handler.getRevisions)
+		handler.children.GET("diff", auth, canWrite, handler.getDiff)
+	} else {
 		postGroup.GET("", handler.getPosts)
 		postGroup.POST("", handler.createPost)
 		postGroup.GET("/:post_id", handler.getPostByID)
+		postGroup.GET("/:post_id/:child", handler.getPostChild)
 		postGroup.PATCH("/:post_id", handler.updatePost)
 		postGroup.DELETE("/:post_id", handler.deletePost)
+		postGroup.POST("/:post_id/revisions/:revision/restore", handler.restoreRevision)
+		router.GET("/api/account/me/posts", handler.getMyPosts)
+
+		handler.children.GET("revisions", handler.getRevisions)
+		handler.children.GET("diff", handler.getDiff)
+	}
+	return handler.children
+}
+
+type getPostsReq struct {
+	Cursor   string    `form:"cursor"`
+	Limit    int       `form:"limit"`
+	AuthorID int32     `form:"author_id"`
+	From     time.Time `form:"from"`
+	To       time.Time `form:"to"`
+	Tag      string    `form:"tag"`
+	Category string    `form:"category"`
+	Status   string    `form:"status"`
+	Sort     string    `form:"sort"`
+}
+
+func (r *getPostsReq) query() domain.PostQuery {
+	return domain.PostQuery{
+		PostFilter: domain.PostFilter{
+			AuthorID: r.AuthorID,
+			From:     r.From,
+			To:       r.To,
+			Tag:      r.Tag,
+			Category: r.Category,
+			Status:   r.Status,
+			Sort:     r.Sort,
+		},
+		Cursor: r.Cursor,
+		Limit:  r.Limit,
 	}
 }
 
+// getPosts returns a page of posts, the next_cursor or prev_cursor
+// of the response are passed as cursor to get the pages around it
 func (p *postHandler) getPosts(c *gin.Context) {
-	posts, err := p.service.FindAll(c)
+	var req getPostsReq
+	if err := c.ShouldBindQuery(&req); err != nil {
+		e := domain.NewBadRequest(err.Error())
+		c.JSON(e.Status(), gin.H{
+			"message": e.Error(),
+		})
+		c.Abort()
+		return
+	}
+
+	page, err := p.service.FindAll(c, req.query())
+
+	if err != nil {
+		c.JSON(domain.Status(err), gin.H{
+			"message": err.Error(),
+		})
+		c.Abort()
+		return
+	}
+
+	c.JSON(200, page)
+}
+
+// getMyPosts pages through the posts of the signed in account, drafts
+// and scheduled posts included. They are filtered like getPosts, and
+// on their status with ?status=
+func (p *postHandler) getMyPosts(c *gin.Context) {
+	account, ok := contextAccount(c)
+	if !ok {
+		return
+	}
+
+	var req getPostsReq
+	if err := c.ShouldBindQuery(&req); err != nil {
+		e := domain.NewBadRequest(err.Error())
+		c.JSON(e.Status(), gin.H{
+			"message": e.Error(),
+		})
+		c.Abort()
+		return
+	}
+
+	page, err := p.service.FindByAccount(c, account, req.query())
 
 	if err != nil {
-		c.JSON(404, gin.H{
+		c.JSON(domain.Status(err), gin.H{
 			"message": err.Error(),
 		})
 		c.Abort()
 		return
 	}
 
-	c.JSON(200, posts)
+	c.JSON(200, page)
 }
 
 func (p *postHandler) createPost(c *gin.Context) {
+	account, ok := contextAccount(c)
+	if !ok {
+		return
+	}
+
 	var req domain.Post
 	err := c.ShouldBindJSON(&req)
 	if err != nil {
@@ -47,7 +166,7 @@ func (p *postHandler) createPost(c *gin.Context) {
 		return
 	}
 
-	err = p.service.Save(c, &req)
+	err = p.service.Save(c, account, &req)
 
 	if err != nil {
 		c.JSON(domain.Status(err), gin.H{
@@ -57,13 +176,22 @@ func (p *postHandler) createPost(c *gin.Context) {
 		return
 	}
 
+	c.Header("ETag", versionETag(req.Version))
 	c.JSON(200, &req)
 }
 
+// getPostByID returns the post along with my_reaction, the reactions of the
+// account, when the request is signed in
 func (p *postHandler) getPostByID(c *gin.Context) {
+	// gin can't route /api/post/search next to /api/post/:post_id
+	if c.Param("post_id") == "search" {
+		p.searchPosts(c)
+		return
+	}
+
 	if postId, ok := getPathInt(c, "post_id"); ok {
 
-		post, err := p.service.FindByID(c, int32(postId))
+		post, err := p.service.FindByID(c, optionalAccount(c), int32(postId))
 
 		if err != nil {
 			c.JSON(domain.Status(err), gin.H{
@@ -73,18 +201,121 @@ func (p *postHandler) getPostByID(c *gin.Context) {
 			return
 		}
 
+		// my_reaction differs between accounts. The ETag stays the version of
+		// the post for If-Match, a 304 may leave the client stale reactions
+		c.Header("Vary", "Authorization")
+		if notModified(c, versionETag(post.Version)) {
+			return
+		}
 		c.JSON(200, post)
 	}
 }
 
+// getPostChild serves /api/post/by-slug/:slug and the routes of children,
+// which gin can't tell apart. The middlewares of the chain run in turn,
+// their c.Next has nothing left to call past this handler
+func (p *postHandler) getPostChild(c *gin.Context) {
+	if c.Param("post_id") == "by-slug" {
+		p.getPostBySlug(c)
+		return
+	}
+
+	chain, ok := p.children[c.Param("child")]
+	if !ok {
+		e := domain.NewNotFound("path", c.Request.URL.Path)
+		c.JSON(e.Status(), gin.H{
+			"message": e.Error(),
+		})
+		c.Abort()
+		return
+	}
+	for _, h := range chain {
+		h(c)
+		if c.IsAborted() {
+			return
+		}
+	}
+}
+
+// getPostBySlug redirects former slugs to the current one
+func (p *postHandler) getPostBySlug(c *gin.Context) {
+	slug := c.Param("child")
+	post, err := p.service.FindBySlug(c, slug)
+
+	if err != nil {
+		c.JSON(domain.Status(err), gin.H{
+			"message": err.Error(),
+		})
+		c.Abort()
+		return
+	}
+
+	if post.Slug != slug {
+		c.Redirect(http.StatusMovedPermanently, path.Join(path.Dir(c.Request.URL.Path), url.PathEscape(post.Slug)))
+		return
+	}
+
+	if notModified(c, versionETag(post.Version)) {
+		return
+	}
+	c.JSON(200, post)
+}
+
+type searchPostsReq struct {
+	Query  string `form:"q" binding:"required"`
+	Cursor string `form:"cursor"`
+	Limit  int    `form:"limit"`
+}
+
+// searchPosts returns the posts most relevant to q, with snippets where
+// the terms are wrapped in <mark> tags. The snippets are HTML escaped
+func (p *postHandler) searchPosts(c *gin.Context) {
+	var req searchPostsReq
+	if err := c.ShouldBindQuery(&req); err != nil {
+		e := domain.NewBadRequest(err.Error())
+		c.JSON(e.Status(), gin.H{
+			"message": e.Error(),
+		})
+		c.Abort()
+		return
+	}
+
+	page, err := p.service.Search(c, domain.SearchQuery{
+		Query:  req.Query,
+		Cursor: req.Cursor,
+		Limit:  req.Limit,
+	})
+
+	if err != nil {
+		c.JSON(domain.Status(err), gin.H{
+			"message": err.Error(),
+		})
+		c.Abort()
+		return
+	}
+
+	c.JSON(200, page)
+}
+
 func (p *postHandler) updatePost(c *gin.Context) {
 	if postId, ok := getPathInt(c, "post_id"); ok {
 
+		account, ok := contextAccount(c)
+		if !ok {
+			return
+		}
+
+		version, ok := ifMatchVersion(c, "post", strconv.Itoa(postId))
+		if !ok {
+			return
+		}
+
 		var req domain.Post
 		if ok := bindData(c, &req); !ok {
 			return
 		}
-		err := p.service.Update(c, int32(postId), &req)
+		req.Version = version
+		err := p.service.Update(c, account, int32(postId), &req)
 
 		if err != nil {
 			c.JSON(domain.Status(err), gin.H{
@@ -94,13 +325,123 @@ func (p *postHandler) updatePost(c *gin.Context) {
 			return
 		}
 
+		c.Header("ETag", versionETag(req.Version))
 		c.JSON(200, &req)
 	}
 }
 
+// getRevisions lists the revisions of the post newest first,
+// without their content
+func (p *postHandler) getRevisions(c *gin.Context) {
+	if postId, ok := getPathInt(c, "post_id"); ok {
+		account, ok := contextAccount(c)
+		if !ok {
+			return
+		}
+
+		revisions, err := p.service.Revisions(c, account, int32(postId))
+
+		if err != nil {
+			c.JSON(domain.Status(err), gin.H{
+				"message": err.Error(),
+			})
+			c.Abort()
+			return
+		}
+
+		c.JSON(200, gin.H{
+			"data": revisions,
+		})
+	}
+}
+
+type getDiffReq struct {
+	From int32 `form:"from" binding:"required"`
+	To   int32 `form:"to" binding:"required"`
+}
+
+// getDiff serves /api/post/:post_id/diff?from=&to=, the lines
+// of the revision from turned into the ones of the revision to
+func (p *postHandler) getDiff(c *gin.Context) {
+	if postId, ok := getPathInt(c, "post_id"); ok {
+		account, ok := contextAccount(c)
+		if !ok {
+			return
+		}
+
+		var req getDiffReq
+		if err := c.ShouldBindQuery(&req); err != nil {
+			e := domain.NewBadRequest(err.Error())
+			c.JSON(e.Status(), gin.H{
+				"message": e.Error(),
+			})
+			c.Abort()
+			return
+		}
+
+		diff, err := p.service.Diff(c, account, int32(postId), req.From, req.To)
+
+		if err != nil {
+			c.JSON(domain.Status(err), gin.H{
+				"message": err.Error(),
+			})
+			c.Abort()
+			return
+		}
+
+		c.JSON(200, diff)
+	}
+}
+
+// restoreRevision updates the post with the title and content of the
+// revision, the previous ones stay in the history. Like updatePost, it
+// requires the ETag of the post in If-Match
+func (p *postHandler) restoreRevision(c *gin.Context) {
+	postId, ok := getPathInt(c, "post_id")
+	if !ok {
+		return
+	}
+	number, ok := getPathInt(c, "revision")
+	if !ok {
+		return
+	}
+	account, ok := contextAccount(c)
+	if !ok {
+		return
+	}
+
+	version, ok := ifMatchVersion(c, "post", strconv.Itoa(postId))
+	if !ok {
+		return
+	}
+
+	post, err := p.service.Restore(c, account, int32(postId), int32(number), version)
+
+	if err != nil {
+		c.JSON(domain.Status(err), gin.H{
+			"message": err.Error(),
+		})
+		c.Abort()
+		return
+	}
+
+	c.Header("ETag", versionETag(post.Version))
+	c.JSON(200, post)
+}
+
 func (p *postHandler) deletePost(c *gin.Context) {
 	if postId, ok := getPathInt(c, "post_id"); ok {
-		err := p.service.Delete(c, int32(postId))
+		account, ok := contextAccount(c)
+		if !ok {
+			return
+		}
+
+		version, ok := ifMatchVersion(c, "post", strconv.Itoa(postId))
+		if !ok {
+			return
+		}
+
+		err := p.service.Delete(c, account, int32(postId), version)
 
 		if err != nil {
 			c.JSON(domain.Status(err), gin.H{
@@ -113,3 +454,26 @@ func (p *postHandler) deletePost(c *gin.Context) {
 		c.JSON(200, "success deleted")
 	}
 }
+
+// contextAccount returns the account set by AuthUser
+func contextAccount(c *gin.Context) (*domain.Account, bool) {
+	account, exists := c.Get("account")
+	if !exists {
+		err := domain.NewAuthorization("unauthorized")
+		c.JSON(err.Status(), gin.H{
+			"message": err.Error(),
+		})
+		c.Abort()
+		return nil, false
+	}
+	return account.(*domain.Account), true
+}
+
+// optionalAccount returns the account set by OptionalAuthUser,
+// nil for anonymous requests
+func optionalAccount(c *gin.Context) *domain.Account {
+	if account, exists := c.Get("account"); exists {
+		return account.(*domain.Account)
+	}
+	return nil
+}
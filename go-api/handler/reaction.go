@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/whuangz/go-example/go-api/domain"
+	"github.com/whuangz/go-example/go-api/middleware"
+)
+
+type reactionHandler struct {
+	service domain.ReactionService
+}
+
+// NewReactionHandler lets the accounts whose roles allow it react to
+// posts. The counts of reactions come along with the posts
+func NewReactionHandler(router gin.IRouter, service domain.ReactionService, tokenService domain.TokenService, apiKeyService domain.APIKeyService, requireVerifiedEmail bool) {
+	h := &reactionHandler{service: service}
+
+	router.GET("/api/reactions", h.Kinds)
+	reactionGroup := router.Group("/api/post/:post_id/reactions")
+	if gin.Mode() != gin.TestMode {
+		auth := middleware.AuthUser(tokenService, apiKeyService, requireVerifiedEmail)
+		canReact := middleware.Require(domain.WriteReactionsPermission)
+
+		reactionGroup.PUT("/:kind", auth, canReact, h.React)
+		reactionGroup.DELETE("/:kind", auth, h.Unreact)
+	} else {
+		reactionGroup.PUT("/:kind", h.React)
+		reactionGroup.DELETE("/:kind", h.Unreact)
+	}
+}
+
+// Kinds handler returns the kinds of reactions along with their emoji
+func (h *reactionHandler) Kinds(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"data": domain.ReactionEmoji,
+	})
+}
+
+// React handler adds a reaction of the account to the post, reacting
+// again with the same kind leaves it as it is
+func (h *reactionHandler) React(c *gin.Context) {
+	postID, ok := getPathInt(c, "post_id")
+	if !ok {
+		return
+	}
+	account, ok := contextAccount(c)
+	if !ok {
+		return
+	}
+
+	mine, err := h.service.React(c.Request.Context(), account, int32(postID), c.Param("kind"))
+	if err != nil {
+		c.JSON(domain.Status(err), gin.H{
+			"error": err,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"my_reaction": mine,
+	})
+}
+
+// Unreact handler removes a reaction of the account from the post
+func (h *reactionHandler) Unreact(c *gin.Context) {
+	postID, ok := getPathInt(c, "post_id")
+	if !ok {
+		return
+	}
+	account, ok := contextAccount(c)
+	if !ok {
+		return
+	}
+
+	mine, err := h.service.Unreact(c.Request.Context(), account, int32(postID), c.Param("kind"))
+	if err != nil {
+		c.JSON(domain.Status(err), gin.H{
+			"error": err,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"my_reaction": mine,
+	})
+}
@@ -15,3 +15,43 @@ type signInReq struct {
 	Email    string `json:"email" binding:"required,email"`
 	Password string `json:"password" binding:"required,gte=6,lte=30"`
 }
+
+// Sign out
+type signoutReq struct {
+	ThisDeviceOnly bool   `json:"this_device_only"`
+	RefreshToken   string `json:"refresh_token"`
+}
+
+// Tokens
+type tokensReq struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// Details
+type detailsReq struct {
+	Name    string `json:"name" binding:"omitempty,max=20"`
+	Email   string `json:"email" binding:"required,email"`
+	Website string `json:"website" binding:"omitempty,url"`
+}
+
+// Forgot password
+type forgotPasswordReq struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// Reset password
+type resetPasswordReq struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required,gte=6,lte=30"`
+}
+
+// 2FA code, either a TOTP or a recovery code
+type mfaCodeReq struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// 2FA signin challenge
+type mfaVerifyReq struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+	Code           string `json:"code" binding:"required"`
+}
@@ -0,0 +1,34 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/whuangz/go-example/go-api/domain"
+)
+
+type tagHandler struct {
+	service domain.TagService
+}
+
+// NewTagHandler serves the tags in use, tags are set on the posts
+func NewTagHandler(router gin.IRouter, service domain.TagService) {
+	h := &tagHandler{service: service}
+
+	router.GET("/api/tags", h.List)
+}
+
+// List handler returns the tags with how many posts they are on
+func (h *tagHandler) List(c *gin.Context) {
+	tags, err := h.service.FindAll(c.Request.Context())
+	if err != nil {
+		c.JSON(domain.Status(err), gin.H{
+			"error": err,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": tags,
+	})
+}
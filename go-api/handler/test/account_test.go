@@ -4,9 +4,13 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"image"
+	"image/png"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -17,6 +21,8 @@ import (
 	mocks "github.com/whuangz/go-example/go-api/mocks/account"
 )
 
+const maxImageSize = 1024 * 1024
+
 func TestGetMe(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	t.Run("Success", func(t *testing.T) {
@@ -42,7 +48,7 @@ func TestGetMe(t *testing.T) {
 			)
 		})
 
-		handler.NewAccountHandler(router, mockAccService, nil)
+		handler.NewAccountHandler(router, mockAccService, nil, nil, nil, maxImageSize)
 		request, err := http.NewRequest(http.MethodGet, "/api/account/me", nil)
 		assert.NoError(t, err)
 
@@ -66,7 +72,7 @@ func TestGetMe(t *testing.T) {
 		rec := httptest.NewRecorder()
 
 		router := gin.Default()
-		handler.NewAccountHandler(router, mockAccService, nil)
+		handler.NewAccountHandler(router, mockAccService, nil, nil, nil, maxImageSize)
 		request, err := http.NewRequest(http.MethodGet, "/api/account/me", nil)
 		assert.NoError(t, err)
 
@@ -94,7 +100,7 @@ func TestGetMe(t *testing.T) {
 			)
 		})
 
-		handler.NewAccountHandler(router, mockAccService, nil)
+		handler.NewAccountHandler(router, mockAccService, nil, nil, nil, maxImageSize)
 
 		request, err := http.NewRequest(http.MethodGet, "/api/account/me", nil)
 		assert.NoError(t, err)
@@ -123,7 +129,7 @@ func TestSignUp(t *testing.T) {
 		rec := httptest.NewRecorder()
 
 		router := gin.Default()
-		handler.NewAccountHandler(router, mockAccService, nil)
+		handler.NewAccountHandler(router, mockAccService, nil, nil, nil, maxImageSize)
 
 		// create a request body with empty email and password
 		reqBody, err := json.Marshal(gin.H{
@@ -150,7 +156,7 @@ func TestSignUp(t *testing.T) {
 		rec := httptest.NewRecorder()
 
 		router := gin.Default()
-		handler.NewAccountHandler(router, mockAccService, nil)
+		handler.NewAccountHandler(router, mockAccService, nil, nil, nil, maxImageSize)
 
 		// create a request body with empty email and password
 		reqBody, err := json.Marshal(gin.H{
@@ -185,7 +191,7 @@ func TestSignUp(t *testing.T) {
 
 		// don't need a middleware as we don't yet have authorized user
 		router := gin.Default()
-		handler.NewAccountHandler(router, mockAccService, nil)
+		handler.NewAccountHandler(router, mockAccService, nil, nil, nil, maxImageSize)
 
 		// create a request body with empty email and password
 		reqBody, err := json.Marshal(gin.H{
@@ -226,7 +232,7 @@ func TestSignUp(t *testing.T) {
 		rec := httptest.NewRecorder()
 
 		router := gin.Default()
-		handler.NewAccountHandler(router, mockAccService, mockTokenService)
+		handler.NewAccountHandler(router, mockAccService, mockTokenService, nil, nil, maxImageSize)
 
 		reqBody, err := json.Marshal(gin.H{
 			"email":    a.Email,
@@ -271,7 +277,7 @@ func TestSignUp(t *testing.T) {
 		rec := httptest.NewRecorder()
 
 		router := gin.Default()
-		handler.NewAccountHandler(router, mockAccService, mockTokenService)
+		handler.NewAccountHandler(router, mockAccService, mockTokenService, nil, nil, maxImageSize)
 
 		reqBody, err := json.Marshal(gin.H{
 			"email":    a.Email,
@@ -305,9 +311,15 @@ func TestSignin(t *testing.T) {
 
 	mockAccService := new(mocks.MockAccountService)
 	mockTokenService := new(mocks.MockTokenService)
+	mockMFAService := new(mocks.MockMFAService)
+	mockMFAService.On("IsEnabled", mock.Anything, uuid.Nil).Return(false, nil)
+	mockLockoutService := new(mocks.MockLockoutService)
+	mockLockoutService.On("Check", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mockLockoutService.On("RegisterFailure", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mockLockoutService.On("RegisterSuccess", mock.Anything, mock.Anything).Return(nil)
 
 	router := gin.Default()
-	handler.NewAccountHandler(router, mockAccService, mockTokenService)
+	handler.NewAccountHandler(router, mockAccService, mockTokenService, mockMFAService, mockLockoutService, maxImageSize)
 
 	t.Run("Bad request data", func(t *testing.T) {
 		// a response recorder for getting written http response
@@ -465,3 +477,955 @@ func TestSignin(t *testing.T) {
 		mockTokenService.AssertCalled(t, "NewPairFromUser", mockTSArgs...)
 	})
 }
+
+func TestSigninLockout(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	email := "whuangz@gmail.com"
+
+	newRequest := func(password string) *http.Request {
+		reqBody, _ := json.Marshal(gin.H{
+			"email":    email,
+			"password": password,
+		})
+		request, _ := http.NewRequest(http.MethodPost, "/api/account/signin", bytes.NewBuffer(reqBody))
+		request.Header.Set("Content-Type", "application/json")
+		request.RemoteAddr = "10.0.0.1:1234"
+		return request
+	}
+
+	t.Run("Locked signin", func(t *testing.T) {
+		mockAccService := new(mocks.MockAccountService)
+		mockLockoutService := new(mocks.MockLockoutService)
+
+		mockError := domain.NewTooManyRequests(90 * time.Second)
+		mockLockoutService.On("Check", mock.Anything, email, "10.0.0.1").Return(mockError)
+
+		router := gin.Default()
+		handler.NewAccountHandler(router, mockAccService, nil, nil, mockLockoutService, maxImageSize)
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, newRequest("admin123"))
+
+		respBody, _ := json.Marshal(gin.H{
+			"error": mockError,
+		})
+
+		assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+		assert.Equal(t, "90", rr.Header().Get("Retry-After"))
+		assert.Equal(t, respBody, rr.Body.Bytes())
+		// the password isn't even checked while locked
+		mockAccService.AssertNotCalled(t, "Signin", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Failure locking the signin", func(t *testing.T) {
+		mockAccService := new(mocks.MockAccountService)
+		mockLockoutService := new(mocks.MockLockoutService)
+
+		mockError := domain.NewTooManyRequests(time.Minute)
+		mockLockoutService.On("Check", mock.Anything, email, "10.0.0.1").Return(nil)
+		mockAccService.On("Signin", mock.Anything, mock.AnythingOfType("*domain.Account")).
+			Return(domain.NewAuthorization("Invalid email and password combination"))
+		mockLockoutService.On("RegisterFailure", mock.Anything, email, "10.0.0.1").Return(mockError)
+
+		router := gin.Default()
+		handler.NewAccountHandler(router, mockAccService, nil, nil, mockLockoutService, maxImageSize)
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, newRequest("wrongpassword"))
+
+		assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+		assert.Equal(t, "60", rr.Header().Get("Retry-After"))
+		mockLockoutService.AssertExpectations(t)
+	})
+
+	t.Run("Server errors aren't failures", func(t *testing.T) {
+		mockAccService := new(mocks.MockAccountService)
+		mockLockoutService := new(mocks.MockLockoutService)
+
+		mockLockoutService.On("Check", mock.Anything, email, "10.0.0.1").Return(nil)
+		mockAccService.On("Signin", mock.Anything, mock.AnythingOfType("*domain.Account")).Return(domain.NewInternal())
+
+		router := gin.Default()
+		handler.NewAccountHandler(router, mockAccService, nil, nil, mockLockoutService, maxImageSize)
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, newRequest("admin123"))
+
+		assert.Equal(t, http.StatusInternalServerError, rr.Code)
+		assert.Empty(t, rr.Header().Get("Retry-After"))
+		mockLockoutService.AssertNotCalled(t, "RegisterFailure", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Success resets the failures", func(t *testing.T) {
+		mockAccService := new(mocks.MockAccountService)
+		mockTokenService := new(mocks.MockTokenService)
+		mockMFAService := new(mocks.MockMFAService)
+		mockLockoutService := new(mocks.MockLockoutService)
+
+		tokens := &domain.TokenPair{AccessToken: "acctoken", RefreshToken: "refreshToken"}
+		mockLockoutService.On("Check", mock.Anything, email, "10.0.0.1").Return(nil)
+		mockAccService.On("Signin", mock.Anything, mock.AnythingOfType("*domain.Account")).Return(nil)
+		mockLockoutService.On("RegisterSuccess", mock.Anything, email).Return(nil)
+		mockMFAService.On("IsEnabled", mock.Anything, uuid.Nil).Return(false, nil)
+		mockTokenService.On("NewPairFromUser", mock.Anything, mock.AnythingOfType("*domain.Account"), "").Return(tokens, nil)
+
+		router := gin.Default()
+		handler.NewAccountHandler(router, mockAccService, mockTokenService, mockMFAService, mockLockoutService, maxImageSize)
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, newRequest("admin123"))
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		mockLockoutService.AssertExpectations(t)
+	})
+}
+
+func TestTokens(t *testing.T) {
+	// Setup
+	gin.SetMode(gin.TestMode)
+
+	mockAccService := new(mocks.MockAccountService)
+	mockTokenService := new(mocks.MockTokenService)
+
+	router := gin.Default()
+	handler.NewAccountHandler(router, mockAccService, mockTokenService, nil, nil, maxImageSize)
+
+	t.Run("Invalid request", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+
+		// create a request body with invalid fields
+		reqBody, err := json.Marshal(gin.H{
+			"notRefreshToken": "this key is not valid for this handler!",
+		})
+		assert.NoError(t, err)
+
+		request, err := http.NewRequest(http.MethodPost, "/api/account/tokens", bytes.NewBuffer(reqBody))
+		assert.NoError(t, err)
+
+		request.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(rr, request)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		mockTokenService.AssertNotCalled(t, "ValidateRefreshToken")
+		mockAccService.AssertNotCalled(t, "Get")
+		mockTokenService.AssertNotCalled(t, "NewPairFromUser")
+	})
+
+	t.Run("Invalid token", func(t *testing.T) {
+		invalidTokenString := "invalid"
+		mockErrorMessage := "authProbs"
+		mockError := domain.NewAuthorization(mockErrorMessage)
+
+		mockTokenService.
+			On("ValidateRefreshToken", invalidTokenString).
+			Return(nil, mockError)
+
+		rr := httptest.NewRecorder()
+
+		reqBody, err := json.Marshal(gin.H{
+			"refresh_token": invalidTokenString,
+		})
+		assert.NoError(t, err)
+
+		request, err := http.NewRequest(http.MethodPost, "/api/account/tokens", bytes.NewBuffer(reqBody))
+		assert.NoError(t, err)
+
+		request.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(rr, request)
+
+		respBody, _ := json.Marshal(gin.H{
+			"error": mockError,
+		})
+
+		assert.Equal(t, mockError.Status(), rr.Code)
+		assert.Equal(t, respBody, rr.Body.Bytes())
+		mockTokenService.AssertCalled(t, "ValidateRefreshToken", invalidTokenString)
+		mockAccService.AssertNotCalled(t, "Get")
+		mockTokenService.AssertNotCalled(t, "NewPairFromUser")
+	})
+
+	t.Run("Failure to create new token pair", func(t *testing.T) {
+		validTokenString := "valid"
+		mockTokenID, _ := uuid.NewRandom()
+		mockUID, _ := uuid.NewRandom()
+
+		mockRefreshTokenResp := &domain.RefreshToken{
+			SS:  validTokenString,
+			ID:  mockTokenID,
+			UID: mockUID,
+		}
+
+		mockTokenService.
+			On("ValidateRefreshToken", validTokenString).
+			Return(mockRefreshTokenResp, nil)
+
+		mockAccResp := &domain.Account{
+			UID: mockUID,
+		}
+
+		mockAccService.
+			On("Get", mock.Anything, mockRefreshTokenResp.UID).
+			Return(mockAccResp, nil)
+
+		// a previously used or revoked refresh token fails here
+		mockError := domain.NewAuthorization("Invalid refresh token")
+		mockTokenService.
+			On("NewPairFromUser", mock.Anything, mockAccResp, mockRefreshTokenResp.ID.String()).
+			Return(nil, mockError)
+
+		rr := httptest.NewRecorder()
+
+		reqBody, err := json.Marshal(gin.H{
+			"refresh_token": validTokenString,
+		})
+		assert.NoError(t, err)
+
+		request, err := http.NewRequest(http.MethodPost, "/api/account/tokens", bytes.NewBuffer(reqBody))
+		assert.NoError(t, err)
+
+		request.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(rr, request)
+
+		respBody, _ := json.Marshal(gin.H{
+			"error": mockError,
+		})
+
+		assert.Equal(t, mockError.Status(), rr.Code)
+		assert.Equal(t, respBody, rr.Body.Bytes())
+		mockTokenService.AssertCalled(t, "ValidateRefreshToken", validTokenString)
+		mockAccService.AssertCalled(t, "Get", mock.Anything, mockRefreshTokenResp.UID)
+		mockTokenService.AssertCalled(t, "NewPairFromUser", mock.Anything, mockAccResp, mockRefreshTokenResp.ID.String())
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		validTokenString := "anothervalid"
+		mockTokenID, _ := uuid.NewRandom()
+		mockUID, _ := uuid.NewRandom()
+
+		mockRefreshTokenResp := &domain.RefreshToken{
+			SS:  validTokenString,
+			ID:  mockTokenID,
+			UID: mockUID,
+		}
+
+		mockTokenService.
+			On("ValidateRefreshToken", validTokenString).
+			Return(mockRefreshTokenResp, nil)
+
+		mockAccResp := &domain.Account{
+			UID: mockUID,
+		}
+
+		mockAccService.
+			On("Get", mock.Anything, mockRefreshTokenResp.UID).
+			Return(mockAccResp, nil)
+
+		mockNewTokenPair := &domain.TokenPair{
+			AccessToken:  "aNewAccessToken",
+			RefreshToken: "aNewRefreshToken",
+		}
+
+		mockTokenService.
+			On("NewPairFromUser", mock.Anything, mockAccResp, mockRefreshTokenResp.ID.String()).
+			Return(mockNewTokenPair, nil)
+
+		rr := httptest.NewRecorder()
+
+		reqBody, err := json.Marshal(gin.H{
+			"refresh_token": validTokenString,
+		})
+		assert.NoError(t, err)
+
+		request, err := http.NewRequest(http.MethodPost, "/api/account/tokens", bytes.NewBuffer(reqBody))
+		assert.NoError(t, err)
+
+		request.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(rr, request)
+
+		respBody, _ := json.Marshal(gin.H{
+			"data": mockNewTokenPair,
+		})
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, respBody, rr.Body.Bytes())
+		mockTokenService.AssertCalled(t, "ValidateRefreshToken", validTokenString)
+		mockAccService.AssertCalled(t, "Get", mock.Anything, mockRefreshTokenResp.UID)
+		mockTokenService.AssertCalled(t, "NewPairFromUser", mock.Anything, mockAccResp, mockRefreshTokenResp.ID.String())
+	})
+}
+
+func TestSignout(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("Signout all devices", func(t *testing.T) {
+		uid, _ := uuid.NewRandom()
+
+		mockTokenService := new(mocks.MockTokenService)
+		mockTokenService.On("Signout", mock.Anything, uid).Return(nil)
+
+		rr := httptest.NewRecorder()
+
+		router := gin.Default()
+		router.Use(func(c *gin.Context) {
+			c.Set("account", &domain.Account{
+				UID: uid,
+			})
+		})
+
+		handler.NewAccountHandler(router, nil, mockTokenService, nil, nil, maxImageSize)
+
+		request, _ := http.NewRequest(http.MethodPost, "/api/account/signout", nil)
+		router.ServeHTTP(rr, request)
+
+		respBody, _ := json.Marshal(gin.H{
+			"data": "user signed out successfully!",
+		})
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, respBody, rr.Body.Bytes())
+		mockTokenService.AssertExpectations(t)
+		mockTokenService.AssertNotCalled(t, "SignoutDevice")
+	})
+
+	t.Run("Signout this device only", func(t *testing.T) {
+		uid, _ := uuid.NewRandom()
+		refreshToken := "aRefreshToken"
+
+		mockTokenService := new(mocks.MockTokenService)
+		mockTokenService.On("SignoutDevice", mock.Anything, uid, refreshToken).Return(nil)
+
+		rr := httptest.NewRecorder()
+
+		router := gin.Default()
+		router.Use(func(c *gin.Context) {
+			c.Set("account", &domain.Account{
+				UID: uid,
+			})
+		})
+
+		handler.NewAccountHandler(router, nil, mockTokenService, nil, nil, maxImageSize)
+
+		reqBody, err := json.Marshal(gin.H{
+			"this_device_only": true,
+			"refresh_token":    refreshToken,
+		})
+		assert.NoError(t, err)
+
+		request, _ := http.NewRequest(http.MethodPost, "/api/account/signout", bytes.NewBuffer(reqBody))
+		request.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(rr, request)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		mockTokenService.AssertExpectations(t)
+		mockTokenService.AssertNotCalled(t, "Signout")
+	})
+
+	t.Run("Signout this device revokes the access token", func(t *testing.T) {
+		uid, _ := uuid.NewRandom()
+		refreshToken := "aRefreshToken"
+		accessToken := "anAccessToken"
+
+		mockTokenService := new(mocks.MockTokenService)
+		mockTokenService.On("SignoutDevice", mock.Anything, uid, refreshToken).Return(nil)
+		mockTokenService.On("RevokeAccessToken", mock.Anything, accessToken).Return(nil)
+
+		rr := httptest.NewRecorder()
+
+		router := gin.Default()
+		router.Use(func(c *gin.Context) {
+			c.Set("account", &domain.Account{
+				UID: uid,
+			})
+			c.Set("access_token", accessToken)
+		})
+
+		handler.NewAccountHandler(router, nil, mockTokenService, nil, nil, maxImageSize)
+
+		reqBody, err := json.Marshal(gin.H{
+			"this_device_only": true,
+			"refresh_token":    refreshToken,
+		})
+		assert.NoError(t, err)
+
+		request, _ := http.NewRequest(http.MethodPost, "/api/account/signout", bytes.NewBuffer(reqBody))
+		request.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(rr, request)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		mockTokenService.AssertExpectations(t)
+	})
+
+	t.Run("This device only without refresh token", func(t *testing.T) {
+		uid, _ := uuid.NewRandom()
+
+		mockTokenService := new(mocks.MockTokenService)
+
+		rr := httptest.NewRecorder()
+
+		router := gin.Default()
+		router.Use(func(c *gin.Context) {
+			c.Set("account", &domain.Account{
+				UID: uid,
+			})
+		})
+
+		handler.NewAccountHandler(router, nil, mockTokenService, nil, nil, maxImageSize)
+
+		reqBody, err := json.Marshal(gin.H{
+			"this_device_only": true,
+		})
+		assert.NoError(t, err)
+
+		request, _ := http.NewRequest(http.MethodPost, "/api/account/signout", bytes.NewBuffer(reqBody))
+		request.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(rr, request)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		mockTokenService.AssertNotCalled(t, "Signout")
+		mockTokenService.AssertNotCalled(t, "SignoutDevice")
+	})
+
+	t.Run("Signout error", func(t *testing.T) {
+		uid, _ := uuid.NewRandom()
+
+		mockError := domain.NewInternal()
+		mockTokenService := new(mocks.MockTokenService)
+		mockTokenService.On("Signout", mock.Anything, uid).Return(mockError)
+
+		rr := httptest.NewRecorder()
+
+		router := gin.Default()
+		router.Use(func(c *gin.Context) {
+			c.Set("account", &domain.Account{
+				UID: uid,
+			})
+		})
+
+		handler.NewAccountHandler(router, nil, mockTokenService, nil, nil, maxImageSize)
+
+		request, _ := http.NewRequest(http.MethodPost, "/api/account/signout", nil)
+		router.ServeHTTP(rr, request)
+
+		respBody, _ := json.Marshal(gin.H{
+			"error": mockError,
+		})
+
+		assert.Equal(t, mockError.Status(), rr.Code)
+		assert.Equal(t, respBody, rr.Body.Bytes())
+		mockTokenService.AssertExpectations(t)
+	})
+}
+
+func TestDetails(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	uid, _ := uuid.NewRandom()
+	ctxAccount := &domain.Account{
+		UID: uid,
+	}
+
+	setupRouter := func(accService *mocks.MockAccountService, tokenService *mocks.MockTokenService) *gin.Engine {
+		router := gin.Default()
+		router.Use(func(c *gin.Context) {
+			c.Set("account", ctxAccount)
+		})
+
+		handler.NewAccountHandler(router, accService, tokenService, nil, nil, maxImageSize)
+		return router
+	}
+
+	t.Run("Data binding error", func(t *testing.T) {
+		mockAccService := new(mocks.MockAccountService)
+		mockTokenService := new(mocks.MockTokenService)
+		router := setupRouter(mockAccService, mockTokenService)
+
+		rr := httptest.NewRecorder()
+
+		reqBody, _ := json.Marshal(gin.H{
+			"name":    "a name which is way too long for the column",
+			"email":   "notanemail",
+			"website": "notawebsite",
+		})
+
+		request, _ := http.NewRequest(http.MethodPut, "/api/account/details", bytes.NewBuffer(reqBody))
+		request.Header.Set("Content-Type", "application/json")
+
+		router.ServeHTTP(rr, request)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		mockAccService.AssertNotCalled(t, "Update")
+		mockTokenService.AssertNotCalled(t, "NewPairFromUser")
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		mockAccService := new(mocks.MockAccountService)
+		mockTokenService := new(mocks.MockTokenService)
+		router := setupRouter(mockAccService, mockTokenService)
+
+		rr := httptest.NewRecorder()
+
+		newName := "William"
+		newEmail := "whuangz@gmail.com"
+		newWebsite := "https://whuangz.dev"
+
+		reqBody, _ := json.Marshal(gin.H{
+			"name":    newName,
+			"email":   newEmail,
+			"website": newWebsite,
+		})
+
+		request, _ := http.NewRequest(http.MethodPut, "/api/account/details", bytes.NewBuffer(reqBody))
+		request.Header.Set("Content-Type", "application/json")
+
+		accToUpdate := &domain.Account{
+			UID:     uid,
+			Name:    newName,
+			Email:   newEmail,
+			Website: newWebsite,
+		}
+
+		mockTokenPair := &domain.TokenPair{
+			AccessToken:  "aNewAccessToken",
+			RefreshToken: "aNewRefreshToken",
+		}
+
+		mockAccService.On("Update", mock.Anything, accToUpdate).Return(nil)
+		mockTokenService.On("NewPairFromUser", mock.Anything, accToUpdate, "").Return(mockTokenPair, nil)
+
+		router.ServeHTTP(rr, request)
+
+		respBody, _ := json.Marshal(gin.H{
+			"data": gin.H{
+				"account": accToUpdate,
+				"tokens":  mockTokenPair,
+			},
+		})
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, respBody, rr.Body.Bytes())
+		mockAccService.AssertExpectations(t)
+		mockTokenService.AssertExpectations(t)
+	})
+
+	t.Run("Email conflict", func(t *testing.T) {
+		mockAccService := new(mocks.MockAccountService)
+		mockTokenService := new(mocks.MockTokenService)
+		router := setupRouter(mockAccService, mockTokenService)
+
+		rr := httptest.NewRecorder()
+
+		newEmail := "taken@gmail.com"
+
+		reqBody, _ := json.Marshal(gin.H{
+			"email": newEmail,
+		})
+
+		request, _ := http.NewRequest(http.MethodPut, "/api/account/details", bytes.NewBuffer(reqBody))
+		request.Header.Set("Content-Type", "application/json")
+
+		accToUpdate := &domain.Account{
+			UID:   uid,
+			Email: newEmail,
+		}
+
+		mockError := domain.NewConflict("email", newEmail)
+		mockAccService.On("Update", mock.Anything, accToUpdate).Return(mockError)
+
+		router.ServeHTTP(rr, request)
+
+		respBody, _ := json.Marshal(gin.H{
+			"error": mockError,
+		})
+
+		assert.Equal(t, mockError.Status(), rr.Code)
+		assert.Equal(t, respBody, rr.Body.Bytes())
+		mockAccService.AssertExpectations(t)
+		mockTokenService.AssertNotCalled(t, "NewPairFromUser")
+	})
+}
+
+func TestImage(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	uid, _ := uuid.NewRandom()
+	ctxAccount := &domain.Account{
+		UID: uid,
+	}
+
+	setupRouter := func(accService *mocks.MockAccountService) *gin.Engine {
+		router := gin.Default()
+		router.Use(func(c *gin.Context) {
+			c.Set("account", ctxAccount)
+		})
+
+		handler.NewAccountHandler(router, accService, nil, nil, nil, maxImageSize)
+		return router
+	}
+
+	multipartRequest := func(field string, content []byte) *http.Request {
+		body := &bytes.Buffer{}
+		writer := multipart.NewWriter(body)
+		part, _ := writer.CreateFormFile(field, "image.png")
+		part.Write(content)
+		writer.Close()
+
+		request, _ := http.NewRequest(http.MethodPost, "/api/account/image", body)
+		request.Header.Set("Content-Type", writer.FormDataContentType())
+		return request
+	}
+
+	pngImage := &bytes.Buffer{}
+	png.Encode(pngImage, image.NewRGBA(image.Rect(0, 0, 8, 8)))
+
+	t.Run("Success", func(t *testing.T) {
+		mockAccService := new(mocks.MockAccountService)
+		router := setupRouter(mockAccService)
+
+		updatedAcc := &domain.Account{
+			UID:      uid,
+			ImageUrl: "http://localhost:8080/uploads/profile/" + uid.String() + "/512.jpg?v=1",
+		}
+		mockAccService.On("SetProfileImage", mock.Anything, uid, pngImage.Bytes()).Return(updatedAcc, nil)
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, multipartRequest("imageFile", pngImage.Bytes()))
+
+		respBody, _ := json.Marshal(gin.H{
+			"data": updatedAcc,
+		})
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, respBody, rr.Body.Bytes())
+		mockAccService.AssertExpectations(t)
+	})
+
+	t.Run("Missing imageFile", func(t *testing.T) {
+		mockAccService := new(mocks.MockAccountService)
+		router := setupRouter(mockAccService)
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, multipartRequest("notImageFile", pngImage.Bytes()))
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		mockAccService.AssertNotCalled(t, "SetProfileImage")
+	})
+
+	t.Run("Not an image", func(t *testing.T) {
+		mockAccService := new(mocks.MockAccountService)
+		router := setupRouter(mockAccService)
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, multipartRequest("imageFile", []byte("<html><body>not an image</body></html>")))
+
+		assert.Equal(t, http.StatusUnsupportedMediaType, rr.Code)
+		mockAccService.AssertNotCalled(t, "SetProfileImage")
+	})
+
+	t.Run("Image of the max size", func(t *testing.T) {
+		mockAccService := new(mocks.MockAccountService)
+		router := setupRouter(mockAccService)
+
+		// the multipart body is larger than the image it holds
+		content := make([]byte, maxImageSize)
+		copy(content, pngImage.Bytes())
+		mockAccService.On("SetProfileImage", mock.Anything, uid, content).Return(ctxAccount, nil)
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, multipartRequest("imageFile", content))
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		mockAccService.AssertExpectations(t)
+	})
+
+	t.Run("Image too large", func(t *testing.T) {
+		mockAccService := new(mocks.MockAccountService)
+		router := setupRouter(mockAccService)
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, multipartRequest("imageFile", make([]byte, maxImageSize+1)))
+
+		assert.Equal(t, http.StatusRequestEntityTooLarge, rr.Code)
+		mockAccService.AssertNotCalled(t, "SetProfileImage")
+	})
+
+	t.Run("Body over the headroom", func(t *testing.T) {
+		mockAccService := new(mocks.MockAccountService)
+		router := setupRouter(mockAccService)
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, multipartRequest("imageFile", make([]byte, maxImageSize+2<<20)))
+
+		assert.Equal(t, http.StatusRequestEntityTooLarge, rr.Code)
+		mockAccService.AssertNotCalled(t, "SetProfileImage")
+	})
+
+	t.Run("Error from service", func(t *testing.T) {
+		mockAccService := new(mocks.MockAccountService)
+		router := setupRouter(mockAccService)
+
+		mockError := domain.NewBadRequest("Unable to decode image")
+		mockAccService.On("SetProfileImage", mock.Anything, uid, pngImage.Bytes()).Return(nil, mockError)
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, multipartRequest("imageFile", pngImage.Bytes()))
+
+		respBody, _ := json.Marshal(gin.H{
+			"error": mockError,
+		})
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Equal(t, respBody, rr.Body.Bytes())
+		mockAccService.AssertExpectations(t)
+	})
+}
+
+func TestDeleteImage(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	uid, _ := uuid.NewRandom()
+	ctxAccount := &domain.Account{
+		UID: uid,
+	}
+
+	setupRouter := func(accService *mocks.MockAccountService) *gin.Engine {
+		router := gin.Default()
+		router.Use(func(c *gin.Context) {
+			c.Set("account", ctxAccount)
+		})
+
+		handler.NewAccountHandler(router, accService, nil, nil, nil, maxImageSize)
+		return router
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		mockAccService := new(mocks.MockAccountService)
+		router := setupRouter(mockAccService)
+
+		clearedAcc := &domain.Account{
+			UID: uid,
+		}
+		mockAccService.On("ClearProfileImage", mock.Anything, uid).Return(clearedAcc, nil)
+
+		rr := httptest.NewRecorder()
+		request, _ := http.NewRequest(http.MethodDelete, "/api/account/image", nil)
+		router.ServeHTTP(rr, request)
+
+		respBody, _ := json.Marshal(gin.H{
+			"data": clearedAcc,
+		})
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, respBody, rr.Body.Bytes())
+		mockAccService.AssertExpectations(t)
+	})
+
+	t.Run("Error from service", func(t *testing.T) {
+		mockAccService := new(mocks.MockAccountService)
+		router := setupRouter(mockAccService)
+
+		mockError := domain.NewInternal()
+		mockAccService.On("ClearProfileImage", mock.Anything, uid).Return(nil, mockError)
+
+		rr := httptest.NewRecorder()
+		request, _ := http.NewRequest(http.MethodDelete, "/api/account/image", nil)
+		router.ServeHTTP(rr, request)
+
+		assert.Equal(t, http.StatusInternalServerError, rr.Code)
+		mockAccService.AssertExpectations(t)
+	})
+}
+
+func TestForgotPassword(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("Success", func(t *testing.T) {
+		mockAccService := new(mocks.MockAccountService)
+		mockAccService.On("ForgotPassword", mock.Anything, "whuangz@gmail.com").Return(nil)
+
+		rr := httptest.NewRecorder()
+		router := gin.Default()
+		handler.NewAccountHandler(router, mockAccService, nil, nil, nil, maxImageSize)
+
+		reqBody, _ := json.Marshal(gin.H{
+			"email": "whuangz@gmail.com",
+		})
+		request, _ := http.NewRequest(http.MethodPost, "/api/account/password/forgot", bytes.NewBuffer(reqBody))
+		request.Header.Set("Content-Type", "application/json")
+
+		router.ServeHTTP(rr, request)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		mockAccService.AssertExpectations(t)
+	})
+
+	t.Run("Invalid email", func(t *testing.T) {
+		mockAccService := new(mocks.MockAccountService)
+
+		rr := httptest.NewRecorder()
+		router := gin.Default()
+		handler.NewAccountHandler(router, mockAccService, nil, nil, nil, maxImageSize)
+
+		reqBody, _ := json.Marshal(gin.H{
+			"email": "notanemail",
+		})
+		request, _ := http.NewRequest(http.MethodPost, "/api/account/password/forgot", bytes.NewBuffer(reqBody))
+		request.Header.Set("Content-Type", "application/json")
+
+		router.ServeHTTP(rr, request)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		mockAccService.AssertNotCalled(t, "ForgotPassword")
+	})
+}
+
+func TestResetPassword(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	uid, _ := uuid.NewRandom()
+
+	newRequest := func(body gin.H) *http.Request {
+		reqBody, _ := json.Marshal(body)
+		request, _ := http.NewRequest(http.MethodPost, "/api/account/password/reset", bytes.NewBuffer(reqBody))
+		request.Header.Set("Content-Type", "application/json")
+		return request
+	}
+
+	t.Run("Success revokes all tokens", func(t *testing.T) {
+		mockAccService := new(mocks.MockAccountService)
+		mockTokenService := new(mocks.MockTokenService)
+		mockAccService.On("ResetPassword", mock.Anything, "aResetToken", "aNewPassword").Return(&domain.Account{UID: uid}, nil)
+		mockTokenService.On("Signout", mock.Anything, uid).Return(nil)
+
+		rr := httptest.NewRecorder()
+		router := gin.Default()
+		handler.NewAccountHandler(router, mockAccService, mockTokenService, nil, nil, maxImageSize)
+
+		router.ServeHTTP(rr, newRequest(gin.H{
+			"token":    "aResetToken",
+			"password": "aNewPassword",
+		}))
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		mockAccService.AssertExpectations(t)
+		mockTokenService.AssertExpectations(t)
+	})
+
+	t.Run("Invalid token", func(t *testing.T) {
+		mockAccService := new(mocks.MockAccountService)
+		mockTokenService := new(mocks.MockTokenService)
+		mockError := domain.NewBadRequest("Invalid or expired password reset token")
+		mockAccService.On("ResetPassword", mock.Anything, "aUsedToken", "aNewPassword").Return(nil, mockError)
+
+		rr := httptest.NewRecorder()
+		router := gin.Default()
+		handler.NewAccountHandler(router, mockAccService, mockTokenService, nil, nil, maxImageSize)
+
+		router.ServeHTTP(rr, newRequest(gin.H{
+			"token":    "aUsedToken",
+			"password": "aNewPassword",
+		}))
+
+		respBody, _ := json.Marshal(gin.H{
+			"error": mockError,
+		})
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Equal(t, respBody, rr.Body.Bytes())
+		mockTokenService.AssertNotCalled(t, "Signout", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Password too short", func(t *testing.T) {
+		mockAccService := new(mocks.MockAccountService)
+
+		rr := httptest.NewRecorder()
+		router := gin.Default()
+		handler.NewAccountHandler(router, mockAccService, nil, nil, nil, maxImageSize)
+
+		router.ServeHTTP(rr, newRequest(gin.H{
+			"token":    "aResetToken",
+			"password": "short",
+		}))
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		mockAccService.AssertNotCalled(t, "ResetPassword")
+	})
+}
+
+func TestVerifyEmail(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	uid, _ := uuid.NewRandom()
+
+	t.Run("Success", func(t *testing.T) {
+		mockAccService := new(mocks.MockAccountService)
+		verifiedAcc := &domain.Account{UID: uid}
+		mockAccService.On("VerifyEmail", mock.Anything, "aVerificationToken").Return(verifiedAcc, nil)
+
+		rr := httptest.NewRecorder()
+		router := gin.Default()
+		handler.NewAccountHandler(router, mockAccService, nil, nil, nil, maxImageSize)
+
+		request, _ := http.NewRequest(http.MethodGet, "/api/account/verify?token=aVerificationToken", nil)
+		router.ServeHTTP(rr, request)
+
+		respBody, _ := json.Marshal(gin.H{
+			"data": verifiedAcc,
+		})
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, respBody, rr.Body.Bytes())
+		mockAccService.AssertExpectations(t)
+	})
+
+	t.Run("Missing token", func(t *testing.T) {
+		mockAccService := new(mocks.MockAccountService)
+
+		rr := httptest.NewRecorder()
+		router := gin.Default()
+		handler.NewAccountHandler(router, mockAccService, nil, nil, nil, maxImageSize)
+
+		request, _ := http.NewRequest(http.MethodGet, "/api/account/verify", nil)
+		router.ServeHTTP(rr, request)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		mockAccService.AssertNotCalled(t, "VerifyEmail")
+	})
+
+	t.Run("Invalid token", func(t *testing.T) {
+		mockAccService := new(mocks.MockAccountService)
+		mockAccService.On("VerifyEmail", mock.Anything, "aUsedToken").Return(nil, domain.NewBadRequest("Invalid or expired verification token"))
+
+		rr := httptest.NewRecorder()
+		router := gin.Default()
+		handler.NewAccountHandler(router, mockAccService, nil, nil, nil, maxImageSize)
+
+		request, _ := http.NewRequest(http.MethodGet, "/api/account/verify?token=aUsedToken", nil)
+		router.ServeHTTP(rr, request)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		mockAccService.AssertExpectations(t)
+	})
+}
+
+func TestResendVerificationEmail(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	uid, _ := uuid.NewRandom()
+
+	mockAccService := new(mocks.MockAccountService)
+	mockAccService.On("ResendVerificationEmail", mock.Anything, uid).Return(nil)
+
+	rr := httptest.NewRecorder()
+	router := gin.Default()
+	router.Use(func(c *gin.Context) {
+		c.Set("account", &domain.Account{UID: uid})
+	})
+	handler.NewAccountHandler(router, mockAccService, nil, nil, nil, maxImageSize)
+
+	request, _ := http.NewRequest(http.MethodPost, "/api/account/verify/resend", nil)
+	router.ServeHTTP(rr, request)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	mockAccService.AssertExpectations(t)
+}
@@ -0,0 +1,120 @@
+package handle_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/whuangz/go-example/go-api/domain"
+	"github.com/whuangz/go-example/go-api/handler"
+	mocks "github.com/whuangz/go-example/go-api/mocks/account"
+)
+
+func TestUnlock(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockLockoutService := new(mocks.MockLockoutService)
+	mockLockoutService.On("Unlock", mock.Anything, "whuangz@gmail.com").Return(nil)
+
+	router := gin.Default()
+	handler.NewAdminHandler(router, mockLockoutService, new(mocks.MockOAuthService), nil, nil, nil, "anAdminKey", false)
+
+	rr := httptest.NewRecorder()
+	request, _ := http.NewRequest(http.MethodDelete, "/api/admin/lockouts/whuangz@gmail.com", nil)
+	router.ServeHTTP(rr, request)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	mockLockoutService.AssertExpectations(t)
+}
+
+func TestRegisterOAuthClient(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	client := &domain.OAuthClient{
+		Name:         "A web app",
+		RedirectURIs: []string{"https://app.example.com/callback"},
+		Scopes:       []string{"openid", "email"},
+		GrantTypes:   []string{domain.AuthorizationCodeGrant},
+	}
+
+	mockOAuthService := new(mocks.MockOAuthService)
+	mockOAuthService.On("RegisterClient", mock.Anything, client, false).
+		Run(func(args mock.Arguments) {
+			args.Get(1).(*domain.OAuthClient).ClientID = "aClientID"
+		}).Return("aClientSecret", nil)
+
+	router := gin.Default()
+	handler.NewAdminHandler(router, nil, mockOAuthService, nil, nil, nil, "anAdminKey", false)
+
+	reqBody, _ := json.Marshal(gin.H{
+		"name":          "A web app",
+		"redirect_uris": []string{"https://app.example.com/callback"},
+		"scopes":        []string{"openid", "email"},
+		"grant_types":   []string{domain.AuthorizationCodeGrant},
+	})
+
+	rr := httptest.NewRecorder()
+	request, _ := http.NewRequest(http.MethodPost, "/api/admin/oauth/clients", bytes.NewBuffer(reqBody))
+	request.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(rr, request)
+
+	client.ClientID = "aClientID"
+	respBody, _ := json.Marshal(gin.H{
+		"data": gin.H{
+			"client":        client,
+			"client_secret": "aClientSecret",
+		},
+	})
+
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	assert.Equal(t, respBody, rr.Body.Bytes())
+	mockOAuthService.AssertExpectations(t)
+}
+
+func TestChangeRole(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	uid := uuid.New()
+
+	t.Run("Grant", func(t *testing.T) {
+		account := &domain.Account{UID: uid, Email: "whuangz@gmail.com", Roles: []string{domain.EditorRole}}
+
+		mockRoleService := new(mocks.MockRoleService)
+		mockRoleService.On("Grant", mock.Anything, uid, domain.EditorRole).Return(account, nil)
+
+		router := gin.Default()
+		handler.NewAdminHandler(router, nil, nil, mockRoleService, nil, nil, "anAdminKey", false)
+
+		rr := httptest.NewRecorder()
+		request, _ := http.NewRequest(http.MethodPut, "/api/admin/accounts/"+uid.String()+"/roles/editor", nil)
+		router.ServeHTTP(rr, request)
+
+		respBody, _ := json.Marshal(gin.H{
+			"data": account,
+		})
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, respBody, rr.Body.Bytes())
+		mockRoleService.AssertExpectations(t)
+	})
+
+	t.Run("Revoke unknown role", func(t *testing.T) {
+		mockRoleService := new(mocks.MockRoleService)
+		mockRoleService.On("Revoke", mock.Anything, uid, "owner").Return(nil, domain.NewBadRequest("unknown role: owner"))
+
+		router := gin.Default()
+		handler.NewAdminHandler(router, nil, nil, mockRoleService, nil, nil, "anAdminKey", false)
+
+		rr := httptest.NewRecorder()
+		request, _ := http.NewRequest(http.MethodDelete, "/api/admin/accounts/"+uid.String()+"/roles/owner", nil)
+		router.ServeHTTP(rr, request)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+}
@@ -0,0 +1,121 @@
+package handle_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/whuangz/go-example/go-api/domain"
+	"github.com/whuangz/go-example/go-api/handler"
+	mocks "github.com/whuangz/go-example/go-api/mocks/account"
+)
+
+func TestAPIKeys(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	ctxAccount := &domain.Account{
+		UID:   uuid.New(),
+		Email: "whuangz@gmail.com",
+	}
+
+	setupRouter := func(apiKeyService *mocks.MockAPIKeyService) *gin.Engine {
+		router := gin.Default()
+		router.Use(func(c *gin.Context) {
+			c.Set("account", ctxAccount)
+		})
+		handler.NewAPIKeyHandler(router, apiKeyService, nil)
+		return router
+	}
+
+	t.Run("List", func(t *testing.T) {
+		mockAPIKeyService := new(mocks.MockAPIKeyService)
+		keys := []*domain.APIKey{{UID: uuid.New(), Name: "ci", Prefix: "gxk_abcdefgh", Scopes: []string{"read"}}}
+		mockAPIKeyService.On("List", mock.Anything, ctxAccount.UID).Return(keys, nil)
+
+		rr := httptest.NewRecorder()
+		request, _ := http.NewRequest(http.MethodGet, "/api/account/keys", nil)
+		setupRouter(mockAPIKeyService).ServeHTTP(rr, request)
+
+		respBody, _ := json.Marshal(gin.H{
+			"data": keys,
+		})
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, respBody, rr.Body.Bytes())
+	})
+
+	t.Run("Create", func(t *testing.T) {
+		mockAPIKeyService := new(mocks.MockAPIKeyService)
+		k := &domain.APIKey{UID: uuid.New(), Name: "ci", Prefix: "gxk_abcdefgh", Scopes: []string{"read", "write"}}
+		mockAPIKeyService.On("Create", mock.Anything, ctxAccount.UID, "ci", []string{"read", "write"}, 30*24*time.Hour).
+			Return(k, "gxk_abcdefghTheRest", nil)
+
+		rr := httptest.NewRecorder()
+		reqBody, _ := json.Marshal(gin.H{
+			"name":            "ci",
+			"scopes":          []string{"read", "write"},
+			"expires_in_days": 30,
+		})
+		request, _ := http.NewRequest(http.MethodPost, "/api/account/keys", bytes.NewBuffer(reqBody))
+		request.Header.Set("Content-Type", "application/json")
+		setupRouter(mockAPIKeyService).ServeHTTP(rr, request)
+
+		respBody, _ := json.Marshal(gin.H{
+			"data": gin.H{
+				"api_key": k,
+				"key":     "gxk_abcdefghTheRest",
+			},
+		})
+
+		assert.Equal(t, http.StatusCreated, rr.Code)
+		assert.Equal(t, respBody, rr.Body.Bytes())
+		mockAPIKeyService.AssertExpectations(t)
+	})
+
+	t.Run("Create without a name", func(t *testing.T) {
+		mockAPIKeyService := new(mocks.MockAPIKeyService)
+
+		rr := httptest.NewRecorder()
+		reqBody, _ := json.Marshal(gin.H{
+			"scopes": []string{"read"},
+		})
+		request, _ := http.NewRequest(http.MethodPost, "/api/account/keys", bytes.NewBuffer(reqBody))
+		request.Header.Set("Content-Type", "application/json")
+		setupRouter(mockAPIKeyService).ServeHTTP(rr, request)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		mockAPIKeyService.AssertNotCalled(t, "Create", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Revoke", func(t *testing.T) {
+		mockAPIKeyService := new(mocks.MockAPIKeyService)
+		keyUID := uuid.New()
+		mockAPIKeyService.On("Revoke", mock.Anything, ctxAccount.UID, keyUID).Return(nil)
+
+		rr := httptest.NewRecorder()
+		request, _ := http.NewRequest(http.MethodDelete, "/api/account/keys/"+keyUID.String(), nil)
+		setupRouter(mockAPIKeyService).ServeHTTP(rr, request)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		mockAPIKeyService.AssertExpectations(t)
+	})
+
+	t.Run("Revoke unknown key", func(t *testing.T) {
+		mockAPIKeyService := new(mocks.MockAPIKeyService)
+		keyUID := uuid.New()
+		mockAPIKeyService.On("Revoke", mock.Anything, ctxAccount.UID, keyUID).Return(domain.NewNotFound("api key", keyUID.String()))
+
+		rr := httptest.NewRecorder()
+		request, _ := http.NewRequest(http.MethodDelete, "/api/account/keys/"+keyUID.String(), nil)
+		setupRouter(mockAPIKeyService).ServeHTTP(rr, request)
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+}
@@ -0,0 +1,102 @@
+package handle_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/whuangz/go-example/go-api/domain"
+	"github.com/whuangz/go-example/go-api/handler"
+	mocks "github.com/whuangz/go-example/go-api/mocks/post"
+)
+
+func TestTags(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockTagService := new(mocks.MockTagService)
+	tags := []domain.Tag{{ID: 2, Name: "Go", Slug: "go", Count: 12}, {ID: 1, Name: "Web", Slug: "web", Count: 3}}
+	mockTagService.On("FindAll", mock.Anything).Return(tags, nil)
+
+	router := gin.New()
+	handler.NewTagHandler(router, mockTagService)
+
+	rr := httptest.NewRecorder()
+	request, _ := http.NewRequest(http.MethodGet, "/api/tags", nil)
+	router.ServeHTTP(rr, request)
+
+	respBody, _ := json.Marshal(gin.H{
+		"data": tags,
+	})
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, respBody, rr.Body.Bytes())
+}
+
+func TestCategories(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	setupRouter := func(categoryService *mocks.MockCategoryService) *gin.Engine {
+		router := gin.New()
+		handler.NewCategoryHandler(router, categoryService, nil, nil, false)
+		return router
+	}
+
+	t.Run("Tree", func(t *testing.T) {
+		mockCategoryService := new(mocks.MockCategoryService)
+		tree := []domain.Category{{ID: 1, Name: "Programming", Slug: "programming", Children: []domain.Category{
+			{ID: 2, ParentID: 1, Name: "Go", Slug: "go"},
+		}}}
+		mockCategoryService.On("Tree", mock.Anything).Return(tree, nil)
+
+		rr := httptest.NewRecorder()
+		request, _ := http.NewRequest(http.MethodGet, "/api/categories", nil)
+		setupRouter(mockCategoryService).ServeHTTP(rr, request)
+
+		respBody, _ := json.Marshal(gin.H{
+			"data": tree,
+		})
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, respBody, rr.Body.Bytes())
+	})
+
+	t.Run("Create", func(t *testing.T) {
+		mockCategoryService := new(mocks.MockCategoryService)
+		mockCategoryService.On("Save", mock.Anything, &domain.Category{Name: "Go", ParentID: 1}).
+			Run(func(args mock.Arguments) {
+				c := args.Get(1).(*domain.Category)
+				c.ID = 2
+				c.Slug = "go"
+			}).Return(nil)
+
+		body, _ := json.Marshal(gin.H{"name": "Go", "parent_id": 1})
+		rr := httptest.NewRecorder()
+		request, _ := http.NewRequest(http.MethodPost, "/api/categories", bytes.NewBuffer(body))
+		request.Header.Set("Content-Type", "application/json")
+		setupRouter(mockCategoryService).ServeHTTP(rr, request)
+
+		respBody, _ := json.Marshal(domain.Category{ID: 2, ParentID: 1, Name: "Go", Slug: "go"})
+
+		assert.Equal(t, http.StatusCreated, rr.Code)
+		assert.Equal(t, respBody, rr.Body.Bytes())
+		mockCategoryService.AssertExpectations(t)
+	})
+
+	t.Run("Create without a name", func(t *testing.T) {
+		mockCategoryService := new(mocks.MockCategoryService)
+
+		body, _ := json.Marshal(gin.H{"parent_id": 1})
+		rr := httptest.NewRecorder()
+		request, _ := http.NewRequest(http.MethodPost, "/api/categories", bytes.NewBuffer(body))
+		request.Header.Set("Content-Type", "application/json")
+		setupRouter(mockCategoryService).ServeHTTP(rr, request)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		mockCategoryService.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+	})
+}
@@ -0,0 +1,157 @@
+package handle_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/whuangz/go-example/go-api/domain"
+	"github.com/whuangz/go-example/go-api/handler"
+	mocks "github.com/whuangz/go-example/go-api/mocks/post"
+)
+
+func TestComments(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	setupRouter := func(commentService *mocks.MockCommentService, middlewares ...gin.HandlerFunc) *gin.Engine {
+		router := gin.New()
+		router.Use(middlewares...)
+		posts := handler.NewPostHandler(router, new(mocks.MockPostService), nil, nil, false)
+		handler.NewCommentHandler(router, posts, commentService, nil, nil, false)
+		return router
+	}
+
+	t.Run("List anonymously", func(t *testing.T) {
+		mockCommentService := new(mocks.MockCommentService)
+		comments := []domain.Comment{{ID: 1, PostID: 1, Content: "First", Status: domain.CommentApproved, Replies: []domain.Comment{
+			{ID: 2, PostID: 1, ParentID: 1, Depth: 1, Content: "Reply", Status: domain.CommentApproved},
+		}}}
+		mockCommentService.On("List", mock.Anything, (*domain.Account)(nil), int32(1)).Return(comments, nil)
+
+		rr := httptest.NewRecorder()
+		request, _ := http.NewRequest(http.MethodGet, "/api/post/1/comments", nil)
+		setupRouter(mockCommentService).ServeHTTP(rr, request)
+
+		respBody, _ := json.Marshal(gin.H{
+			"data": comments,
+		})
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, respBody, rr.Body.Bytes())
+	})
+
+	t.Run("List signed in", func(t *testing.T) {
+		mockCommentService := new(mocks.MockCommentService)
+		mockCommentService.On("List", mock.Anything, postAccount, int32(1)).Return([]domain.Comment{}, nil)
+
+		rr := httptest.NewRecorder()
+		request, _ := http.NewRequest(http.MethodGet, "/api/post/1/comments", nil)
+		setupRouter(mockCommentService, withPostAccount).ServeHTTP(rr, request)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		mockCommentService.AssertExpectations(t)
+	})
+
+	t.Run("Create", func(t *testing.T) {
+		mockCommentService := new(mocks.MockCommentService)
+		mockCommentService.On("Create", mock.Anything, postAccount, int32(1), &domain.Comment{Content: "Nice", ParentID: 3}).
+			Return(nil)
+
+		rr := httptest.NewRecorder()
+		reqBody, _ := json.Marshal(gin.H{
+			"content":   "Nice",
+			"parent_id": 3,
+		})
+		request, _ := http.NewRequest(http.MethodPost, "/api/post/1/comments", bytes.NewBuffer(reqBody))
+		request.Header.Set("Content-Type", "application/json")
+		setupRouter(mockCommentService, withPostAccount).ServeHTTP(rr, request)
+
+		assert.Equal(t, http.StatusCreated, rr.Code)
+		mockCommentService.AssertExpectations(t)
+	})
+
+	t.Run("Create without content", func(t *testing.T) {
+		mockCommentService := new(mocks.MockCommentService)
+
+		rr := httptest.NewRecorder()
+		request, _ := http.NewRequest(http.MethodPost, "/api/post/1/comments", bytes.NewBufferString(`{}`))
+		request.Header.Set("Content-Type", "application/json")
+		setupRouter(mockCommentService, withPostAccount).ServeHTTP(rr, request)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		mockCommentService.AssertNotCalled(t, "Create", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		mockCommentService := new(mocks.MockCommentService)
+		comment := &domain.Comment{ID: 2, PostID: 1, Content: "Edited", Status: domain.CommentPending}
+		mockCommentService.On("Update", mock.Anything, postAccount, int32(1), int32(2), "Edited").Return(comment, nil)
+
+		rr := httptest.NewRecorder()
+		request, _ := http.NewRequest(http.MethodPatch, "/api/post/1/comments/2", bytes.NewBufferString(`{"content": "Edited"}`))
+		request.Header.Set("Content-Type", "application/json")
+		setupRouter(mockCommentService, withPostAccount).ServeHTTP(rr, request)
+
+		respBody, _ := json.Marshal(comment)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, respBody, rr.Body.Bytes())
+	})
+
+	t.Run("Update after the edit window", func(t *testing.T) {
+		mockCommentService := new(mocks.MockCommentService)
+		mockCommentService.On("Update", mock.Anything, postAccount, int32(1), int32(2), "Edited").
+			Return(nil, domain.NewForbidden("Comments can only be edited within 15m0s of posting them"))
+
+		rr := httptest.NewRecorder()
+		request, _ := http.NewRequest(http.MethodPatch, "/api/post/1/comments/2", bytes.NewBufferString(`{"content": "Edited"}`))
+		request.Header.Set("Content-Type", "application/json")
+		setupRouter(mockCommentService, withPostAccount).ServeHTTP(rr, request)
+
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		mockCommentService := new(mocks.MockCommentService)
+		mockCommentService.On("Delete", mock.Anything, postAccount, int32(1), int32(2)).Return(nil)
+
+		rr := httptest.NewRecorder()
+		request, _ := http.NewRequest(http.MethodDelete, "/api/post/1/comments/2", nil)
+		setupRouter(mockCommentService, withPostAccount).ServeHTTP(rr, request)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		mockCommentService.AssertExpectations(t)
+	})
+
+	t.Run("Moderate", func(t *testing.T) {
+		mockCommentService := new(mocks.MockCommentService)
+		comment := &domain.Comment{ID: 2, PostID: 1, Content: "Hello", Status: domain.CommentApproved}
+		mockCommentService.On("Moderate", mock.Anything, postAccount, int32(1), int32(2), domain.CommentApproved).Return(comment, nil)
+
+		rr := httptest.NewRecorder()
+		request, _ := http.NewRequest(http.MethodPut, "/api/post/1/comments/2/status", bytes.NewBufferString(`{"status": "approved"}`))
+		request.Header.Set("Content-Type", "application/json")
+		setupRouter(mockCommentService, withPostAccount).ServeHTTP(rr, request)
+
+		respBody, _ := json.Marshal(comment)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, respBody, rr.Body.Bytes())
+	})
+
+	t.Run("Unauthorized", func(t *testing.T) {
+		mockCommentService := new(mocks.MockCommentService)
+
+		rr := httptest.NewRecorder()
+		request, _ := http.NewRequest(http.MethodDelete, "/api/post/1/comments/2", nil)
+		setupRouter(mockCommentService).ServeHTTP(rr, request)
+
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+		mockCommentService.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
@@ -0,0 +1,253 @@
+package handle_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/whuangz/go-example/go-api/domain"
+	"github.com/whuangz/go-example/go-api/handler"
+	mocks "github.com/whuangz/go-example/go-api/mocks/account"
+)
+
+func TestSigninMFARequired(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	uid, _ := uuid.NewRandom()
+
+	mockAccService := new(mocks.MockAccountService)
+	mockTokenService := new(mocks.MockTokenService)
+	mockMFAService := new(mocks.MockMFAService)
+
+	mockAccService.On("Signin", mock.Anything, mock.AnythingOfType("*domain.Account")).
+		Run(func(args mock.Arguments) {
+			args.Get(1).(*domain.Account).UID = uid
+		}).Return(nil)
+
+	challenge := &domain.MFAChallenge{
+		MFARequired:    true,
+		ChallengeToken: "aChallengeToken",
+		ExpiresIn:      300,
+	}
+	mockMFAService.On("IsEnabled", mock.Anything, uid).Return(true, nil)
+	mockMFAService.On("NewChallenge", mock.Anything, uid).Return(challenge, nil)
+
+	mockLockoutService := new(mocks.MockLockoutService)
+	mockLockoutService.On("Check", mock.Anything, "whuangz@gmail.com", mock.Anything).Return(nil)
+	mockLockoutService.On("RegisterSuccess", mock.Anything, "whuangz@gmail.com").Return(nil)
+
+	router := gin.Default()
+	handler.NewAccountHandler(router, mockAccService, mockTokenService, mockMFAService, mockLockoutService, maxImageSize)
+
+	rr := httptest.NewRecorder()
+	reqBody, _ := json.Marshal(gin.H{
+		"email":    "whuangz@gmail.com",
+		"password": "admin123",
+	})
+	request, _ := http.NewRequest(http.MethodPost, "/api/account/signin", bytes.NewBuffer(reqBody))
+	request.Header.Set("Content-Type", "application/json")
+
+	router.ServeHTTP(rr, request)
+
+	respBody, _ := json.Marshal(gin.H{
+		"data": challenge,
+	})
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, respBody, rr.Body.Bytes())
+	mockMFAService.AssertExpectations(t)
+	// no tokens until the challenge is verified
+	mockTokenService.AssertNotCalled(t, "NewPairFromUser", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestEnrollMFA(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	ctxAccount := &domain.Account{
+		UID:   uuid.New(),
+		Email: "whuangz@gmail.com",
+	}
+
+	mockMFAService := new(mocks.MockMFAService)
+	enrollment := &domain.MFAEnrollment{
+		Secret: "JBSWY3DPEHPK3PXP",
+		URI:    "otpauth://totp/go-example:whuangz@gmail.com?secret=JBSWY3DPEHPK3PXP",
+		QRCode: []byte("png"),
+	}
+	mockMFAService.On("Enroll", mock.Anything, ctxAccount).Return(enrollment, nil)
+
+	router := gin.Default()
+	router.Use(func(c *gin.Context) {
+		c.Set("account", ctxAccount)
+	})
+	handler.NewAccountHandler(router, nil, nil, mockMFAService, nil, maxImageSize)
+
+	rr := httptest.NewRecorder()
+	request, _ := http.NewRequest(http.MethodPost, "/api/account/2fa/enroll", nil)
+	router.ServeHTTP(rr, request)
+
+	respBody, _ := json.Marshal(gin.H{
+		"data": enrollment,
+	})
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, respBody, rr.Body.Bytes())
+	mockMFAService.AssertExpectations(t)
+}
+
+func TestConfirmMFA(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	uid, _ := uuid.NewRandom()
+
+	setupRouter := func(mfaService *mocks.MockMFAService) *gin.Engine {
+		router := gin.Default()
+		router.Use(func(c *gin.Context) {
+			c.Set("account", &domain.Account{UID: uid})
+		})
+		handler.NewAccountHandler(router, nil, nil, mfaService, nil, maxImageSize)
+		return router
+	}
+
+	newRequest := func(body gin.H) *http.Request {
+		reqBody, _ := json.Marshal(body)
+		request, _ := http.NewRequest(http.MethodPost, "/api/account/2fa/confirm", bytes.NewBuffer(reqBody))
+		request.Header.Set("Content-Type", "application/json")
+		return request
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		mockMFAService := new(mocks.MockMFAService)
+		codes := []string{"AAAA-BBBB-CCCC-DDDD"}
+		mockMFAService.On("Confirm", mock.Anything, uid, "123456").Return(codes, nil)
+
+		rr := httptest.NewRecorder()
+		setupRouter(mockMFAService).ServeHTTP(rr, newRequest(gin.H{"code": "123456"}))
+
+		respBody, _ := json.Marshal(gin.H{
+			"data": gin.H{
+				"recovery_codes": codes,
+			},
+		})
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, respBody, rr.Body.Bytes())
+		mockMFAService.AssertExpectations(t)
+	})
+
+	t.Run("Invalid code", func(t *testing.T) {
+		mockMFAService := new(mocks.MockMFAService)
+		mockMFAService.On("Confirm", mock.Anything, uid, "000000").Return(nil, domain.NewAuthorization("Invalid 2FA code"))
+
+		rr := httptest.NewRecorder()
+		setupRouter(mockMFAService).ServeHTTP(rr, newRequest(gin.H{"code": "000000"}))
+
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+		mockMFAService.AssertExpectations(t)
+	})
+
+	t.Run("Missing code", func(t *testing.T) {
+		mockMFAService := new(mocks.MockMFAService)
+
+		rr := httptest.NewRecorder()
+		setupRouter(mockMFAService).ServeHTTP(rr, newRequest(gin.H{}))
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		mockMFAService.AssertNotCalled(t, "Confirm")
+	})
+}
+
+func TestDisableMFA(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	uid, _ := uuid.NewRandom()
+
+	mockMFAService := new(mocks.MockMFAService)
+	mockMFAService.On("Disable", mock.Anything, uid, "AAAA-BBBB-CCCC-DDDD").Return(nil)
+
+	router := gin.Default()
+	router.Use(func(c *gin.Context) {
+		c.Set("account", &domain.Account{UID: uid})
+	})
+	handler.NewAccountHandler(router, nil, nil, mockMFAService, nil, maxImageSize)
+
+	rr := httptest.NewRecorder()
+	reqBody, _ := json.Marshal(gin.H{"code": "AAAA-BBBB-CCCC-DDDD"})
+	request, _ := http.NewRequest(http.MethodPost, "/api/account/2fa/disable", bytes.NewBuffer(reqBody))
+	request.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(rr, request)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	mockMFAService.AssertExpectations(t)
+}
+
+func TestVerifyMFA(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	uid, _ := uuid.NewRandom()
+
+	newRequest := func(body gin.H) *http.Request {
+		reqBody, _ := json.Marshal(body)
+		request, _ := http.NewRequest(http.MethodPost, "/api/account/2fa/verify", bytes.NewBuffer(reqBody))
+		request.Header.Set("Content-Type", "application/json")
+		return request
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		mockAccService := new(mocks.MockAccountService)
+		mockTokenService := new(mocks.MockTokenService)
+		mockMFAService := new(mocks.MockMFAService)
+
+		a := &domain.Account{UID: uid, Email: "whuangz@gmail.com"}
+		tokens := &domain.TokenPair{AccessToken: "anAccessToken", RefreshToken: "aRefreshToken"}
+
+		mockMFAService.On("VerifyChallenge", mock.Anything, "aChallengeToken", "123456").Return(uid, nil)
+		mockAccService.On("Get", mock.Anything, uid).Return(a, nil)
+		mockTokenService.On("NewPairFromUser", mock.Anything, a, "").Return(tokens, nil)
+
+		router := gin.Default()
+		handler.NewAccountHandler(router, mockAccService, mockTokenService, mockMFAService, nil, maxImageSize)
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, newRequest(gin.H{
+			"challenge_token": "aChallengeToken",
+			"code":            "123456",
+		}))
+
+		respBody, _ := json.Marshal(gin.H{
+			"data": tokens,
+		})
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, respBody, rr.Body.Bytes())
+		mockMFAService.AssertExpectations(t)
+		mockAccService.AssertExpectations(t)
+		mockTokenService.AssertExpectations(t)
+	})
+
+	t.Run("Invalid challenge", func(t *testing.T) {
+		mockTokenService := new(mocks.MockTokenService)
+		mockMFAService := new(mocks.MockMFAService)
+
+		mockMFAService.On("VerifyChallenge", mock.Anything, "aUsedChallenge", "123456").
+			Return(uuid.Nil, domain.NewAuthorization("Invalid or expired 2FA challenge"))
+
+		router := gin.Default()
+		handler.NewAccountHandler(router, nil, mockTokenService, mockMFAService, nil, maxImageSize)
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, newRequest(gin.H{
+			"challenge_token": "aUsedChallenge",
+			"code":            "123456",
+		}))
+
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+		mockTokenService.AssertNotCalled(t, "NewPairFromUser", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
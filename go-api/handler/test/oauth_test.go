@@ -0,0 +1,401 @@
+package handle_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/whuangz/go-example/go-api/domain"
+	"github.com/whuangz/go-example/go-api/handler"
+	mocks "github.com/whuangz/go-example/go-api/mocks/account"
+)
+
+func newFormRequest(path string, form url.Values) *http.Request {
+	request, _ := http.NewRequest(http.MethodPost, path, strings.NewReader(form.Encode()))
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return request
+}
+
+func TestOAuthAuthorize(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	uid, _ := uuid.NewRandom()
+
+	setupRouter := func(oauthService *mocks.MockOAuthService) *gin.Engine {
+		router := gin.Default()
+		router.Use(func(c *gin.Context) {
+			c.Set("account", &domain.Account{UID: uid})
+		})
+		handler.NewOAuthHandler(router, oauthService, nil)
+		return router
+	}
+
+	authorizeReq := &domain.AuthorizeRequest{
+		ResponseType:        "code",
+		ClientID:            "aWebClient",
+		RedirectURI:         "https://app.example.com/callback",
+		Scope:               "openid email",
+		State:               "aState",
+		CodeChallenge:       "aChallenge",
+		CodeChallengeMethod: "S256",
+	}
+
+	t.Run("Authorize", func(t *testing.T) {
+		mockOAuthService := new(mocks.MockOAuthService)
+		res := &domain.AuthorizeResponse{ConsentRequired: true, ClientName: "A web app", Scopes: []string{"openid", "email"}}
+		mockOAuthService.On("Authorize", mock.Anything, uid, authorizeReq).Return(res, nil)
+
+		query := url.Values{
+			"response_type":         {"code"},
+			"client_id":             {"aWebClient"},
+			"redirect_uri":          {"https://app.example.com/callback"},
+			"scope":                 {"openid email"},
+			"state":                 {"aState"},
+			"code_challenge":        {"aChallenge"},
+			"code_challenge_method": {"S256"},
+		}
+
+		rr := httptest.NewRecorder()
+		request, _ := http.NewRequest(http.MethodGet, "/oauth/authorize?"+query.Encode(), nil)
+		setupRouter(mockOAuthService).ServeHTTP(rr, request)
+
+		respBody, _ := json.Marshal(gin.H{
+			"data": res,
+		})
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, respBody, rr.Body.Bytes())
+		mockOAuthService.AssertExpectations(t)
+	})
+
+	t.Run("Unknown client", func(t *testing.T) {
+		mockOAuthService := new(mocks.MockOAuthService)
+		mockOAuthService.On("Authorize", mock.Anything, uid, mock.AnythingOfType("*domain.AuthorizeRequest")).
+			Return(nil, domain.NewBadRequest("unknown client_id"))
+
+		rr := httptest.NewRecorder()
+		request, _ := http.NewRequest(http.MethodGet, "/oauth/authorize?client_id=unknown", nil)
+		setupRouter(mockOAuthService).ServeHTTP(rr, request)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("Consent", func(t *testing.T) {
+		mockOAuthService := new(mocks.MockOAuthService)
+		res := &domain.AuthorizeResponse{RedirectTo: "https://app.example.com/callback?code=aCode&state=aState"}
+		mockOAuthService.On("Consent", mock.Anything, uid, authorizeReq, true).Return(res, nil)
+
+		reqBody, _ := json.Marshal(gin.H{
+			"response_type":         "code",
+			"client_id":             "aWebClient",
+			"redirect_uri":          "https://app.example.com/callback",
+			"scope":                 "openid email",
+			"state":                 "aState",
+			"code_challenge":        "aChallenge",
+			"code_challenge_method": "S256",
+			"approve":               true,
+		})
+
+		rr := httptest.NewRecorder()
+		request, _ := http.NewRequest(http.MethodPost, "/oauth/authorize", bytes.NewBuffer(reqBody))
+		request.Header.Set("Content-Type", "application/json")
+		setupRouter(mockOAuthService).ServeHTTP(rr, request)
+
+		respBody, _ := json.Marshal(gin.H{
+			"data": res,
+		})
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, respBody, rr.Body.Bytes())
+		mockOAuthService.AssertExpectations(t)
+	})
+}
+
+func TestOAuthToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	client := &domain.OAuthClient{ClientID: "aWebClient", SecretHash: "aHash"}
+	tokenReq := &domain.TokenRequest{
+		GrantType:    domain.AuthorizationCodeGrant,
+		Code:         "aCode",
+		RedirectURI:  "https://app.example.com/callback",
+		CodeVerifier: "aVerifier",
+	}
+	form := url.Values{
+		"grant_type":    {domain.AuthorizationCodeGrant},
+		"code":          {"aCode"},
+		"redirect_uri":  {"https://app.example.com/callback"},
+		"code_verifier": {"aVerifier"},
+	}
+	tokens := &domain.OAuthTokens{AccessToken: "anAccessToken", TokenType: "Bearer", ExpiresIn: 3600, Scope: "openid", IDToken: "anIDToken"}
+
+	t.Run("Basic authentication", func(t *testing.T) {
+		mockOAuthService := new(mocks.MockOAuthService)
+		mockOAuthService.On("AuthenticateClient", mock.Anything, "aWebClient", "aClientSecret").Return(client, nil)
+		mockOAuthService.On("Exchange", mock.Anything, client, tokenReq).Return(tokens, nil)
+
+		router := gin.Default()
+		handler.NewOAuthHandler(router, mockOAuthService, nil)
+
+		rr := httptest.NewRecorder()
+		request := newFormRequest("/oauth/token", form)
+		request.SetBasicAuth("aWebClient", "aClientSecret")
+		router.ServeHTTP(rr, request)
+
+		// not wrapped in data, as defined by RFC 6749
+		respBody, _ := json.Marshal(tokens)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, respBody, rr.Body.Bytes())
+		assert.Equal(t, "no-store", rr.Header().Get("Cache-Control"))
+		mockOAuthService.AssertExpectations(t)
+	})
+
+	t.Run("Credentials in the form", func(t *testing.T) {
+		formReq := *tokenReq
+		formReq.ClientID = "aWebClient"
+		formReq.ClientSecret = "aClientSecret"
+
+		mockOAuthService := new(mocks.MockOAuthService)
+		mockOAuthService.On("AuthenticateClient", mock.Anything, "aWebClient", "aClientSecret").Return(client, nil)
+		mockOAuthService.On("Exchange", mock.Anything, client, &formReq).Return(tokens, nil)
+
+		router := gin.Default()
+		handler.NewOAuthHandler(router, mockOAuthService, nil)
+
+		withCredentials := url.Values{"client_id": {"aWebClient"}, "client_secret": {"aClientSecret"}}
+		for key, values := range form {
+			withCredentials[key] = values
+		}
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, newFormRequest("/oauth/token", withCredentials))
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		mockOAuthService.AssertExpectations(t)
+	})
+
+	t.Run("Invalid client", func(t *testing.T) {
+		mockOAuthService := new(mocks.MockOAuthService)
+		mockOAuthService.On("AuthenticateClient", mock.Anything, "aWebClient", "wrong").
+			Return(nil, domain.NewOAuthError("invalid_client", "invalid client credentials"))
+
+		router := gin.Default()
+		handler.NewOAuthHandler(router, mockOAuthService, nil)
+
+		rr := httptest.NewRecorder()
+		request := newFormRequest("/oauth/token", form)
+		request.SetBasicAuth("aWebClient", "wrong")
+		router.ServeHTTP(rr, request)
+
+		respBody, _ := json.Marshal(gin.H{
+			"error":             "invalid_client",
+			"error_description": "invalid client credentials",
+		})
+
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+		assert.Equal(t, respBody, rr.Body.Bytes())
+		assert.Contains(t, rr.Header().Get("WWW-Authenticate"), "Basic")
+		mockOAuthService.AssertNotCalled(t, "Exchange", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Invalid grant", func(t *testing.T) {
+		mockOAuthService := new(mocks.MockOAuthService)
+		mockOAuthService.On("AuthenticateClient", mock.Anything, "aWebClient", "aClientSecret").Return(client, nil)
+		mockOAuthService.On("Exchange", mock.Anything, client, tokenReq).
+			Return(nil, domain.NewOAuthError("invalid_grant", "the code is invalid, expired or already used"))
+
+		router := gin.Default()
+		handler.NewOAuthHandler(router, mockOAuthService, nil)
+
+		rr := httptest.NewRecorder()
+		request := newFormRequest("/oauth/token", form)
+		request.SetBasicAuth("aWebClient", "aClientSecret")
+		router.ServeHTTP(rr, request)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Contains(t, rr.Body.String(), `"error":"invalid_grant"`)
+	})
+
+	t.Run("Internal error", func(t *testing.T) {
+		mockOAuthService := new(mocks.MockOAuthService)
+		mockOAuthService.On("AuthenticateClient", mock.Anything, "aWebClient", "aClientSecret").Return(client, nil)
+		mockOAuthService.On("Exchange", mock.Anything, client, tokenReq).Return(nil, domain.NewInternal())
+
+		router := gin.Default()
+		handler.NewOAuthHandler(router, mockOAuthService, nil)
+
+		rr := httptest.NewRecorder()
+		request := newFormRequest("/oauth/token", form)
+		request.SetBasicAuth("aWebClient", "aClientSecret")
+		router.ServeHTTP(rr, request)
+
+		assert.Equal(t, http.StatusInternalServerError, rr.Code)
+		assert.Contains(t, rr.Body.String(), `"error":"server_error"`)
+	})
+
+	t.Run("JSON body", func(t *testing.T) {
+		mockOAuthService := new(mocks.MockOAuthService)
+
+		router := gin.Default()
+		handler.NewOAuthHandler(router, mockOAuthService, nil)
+
+		rr := httptest.NewRecorder()
+		request, _ := http.NewRequest(http.MethodPost, "/oauth/token", strings.NewReader(`{"grant_type":"client_credentials"}`))
+		request.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(rr, request)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Contains(t, rr.Body.String(), `"error":"invalid_request"`)
+		mockOAuthService.AssertNotCalled(t, "AuthenticateClient", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestOAuthIntrospect(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("Active token", func(t *testing.T) {
+		resourceServer := &domain.OAuthClient{ClientID: "aResourceServer", SecretHash: "aHash"}
+		introspection := &domain.Introspection{Active: true, Scope: "openid", ClientID: "aWebClient", Subject: "aSubject"}
+
+		mockOAuthService := new(mocks.MockOAuthService)
+		mockOAuthService.On("AuthenticateClient", mock.Anything, "aResourceServer", "aSecret").Return(resourceServer, nil)
+		mockOAuthService.On("Introspect", mock.Anything, "anAccessToken").Return(introspection, nil)
+
+		router := gin.Default()
+		handler.NewOAuthHandler(router, mockOAuthService, nil)
+
+		rr := httptest.NewRecorder()
+		request := newFormRequest("/oauth/introspect", url.Values{"token": {"anAccessToken"}})
+		request.SetBasicAuth("aResourceServer", "aSecret")
+		router.ServeHTTP(rr, request)
+
+		respBody, _ := json.Marshal(introspection)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, respBody, rr.Body.Bytes())
+		mockOAuthService.AssertExpectations(t)
+	})
+
+	t.Run("Public client", func(t *testing.T) {
+		mockOAuthService := new(mocks.MockOAuthService)
+		mockOAuthService.On("AuthenticateClient", mock.Anything, "aPublicClient", "").Return(&domain.OAuthClient{ClientID: "aPublicClient"}, nil)
+
+		router := gin.Default()
+		handler.NewOAuthHandler(router, mockOAuthService, nil)
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, newFormRequest("/oauth/introspect", url.Values{"token": {"anAccessToken"}, "client_id": {"aPublicClient"}}))
+
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+		mockOAuthService.AssertNotCalled(t, "Introspect", mock.Anything, mock.Anything)
+	})
+}
+
+func TestOAuthRevoke(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	client := &domain.OAuthClient{ClientID: "aPublicClient"}
+
+	mockOAuthService := new(mocks.MockOAuthService)
+	mockOAuthService.On("AuthenticateClient", mock.Anything, "aPublicClient", "").Return(client, nil)
+	mockOAuthService.On("Revoke", mock.Anything, client, "anAccessToken").Return(nil)
+
+	router := gin.Default()
+	handler.NewOAuthHandler(router, mockOAuthService, nil)
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, newFormRequest("/oauth/revoke", url.Values{"token": {"anAccessToken"}, "client_id": {"aPublicClient"}}))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	mockOAuthService.AssertExpectations(t)
+}
+
+func TestUserInfo(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("Success", func(t *testing.T) {
+		info := &domain.UserInfo{Subject: "aSubject", Name: "William"}
+
+		mockOAuthService := new(mocks.MockOAuthService)
+		mockOAuthService.On("UserInfo", mock.Anything, "anAccessToken").Return(info, nil)
+
+		router := gin.Default()
+		handler.NewOAuthHandler(router, mockOAuthService, nil)
+
+		rr := httptest.NewRecorder()
+		request, _ := http.NewRequest(http.MethodGet, "/userinfo", nil)
+		request.Header.Set("Authorization", "Bearer anAccessToken")
+		router.ServeHTTP(rr, request)
+
+		respBody, _ := json.Marshal(info)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, respBody, rr.Body.Bytes())
+	})
+
+	t.Run("No token", func(t *testing.T) {
+		mockOAuthService := new(mocks.MockOAuthService)
+
+		router := gin.Default()
+		handler.NewOAuthHandler(router, mockOAuthService, nil)
+
+		rr := httptest.NewRecorder()
+		request, _ := http.NewRequest(http.MethodGet, "/userinfo", nil)
+		router.ServeHTTP(rr, request)
+
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+		assert.Contains(t, rr.Header().Get("WWW-Authenticate"), "Bearer")
+		mockOAuthService.AssertNotCalled(t, "UserInfo", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Invalid token", func(t *testing.T) {
+		mockOAuthService := new(mocks.MockOAuthService)
+		mockOAuthService.On("UserInfo", mock.Anything, "anAccessToken").
+			Return(nil, domain.NewOAuthError("invalid_token", "the access token is invalid, expired or revoked"))
+
+		router := gin.Default()
+		handler.NewOAuthHandler(router, mockOAuthService, nil)
+
+		rr := httptest.NewRecorder()
+		request, _ := http.NewRequest(http.MethodGet, "/userinfo", nil)
+		request.Header.Set("Authorization", "Bearer anAccessToken")
+		router.ServeHTTP(rr, request)
+
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+		assert.Contains(t, rr.Header().Get("WWW-Authenticate"), `error="invalid_token"`)
+	})
+}
+
+func TestOpenIDConfiguration(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	discovery := &domain.OIDCDiscovery{
+		Issuer:  "https://api.example.com",
+		JwksURI: "https://api.example.com/.well-known/jwks.json",
+	}
+
+	mockOAuthService := new(mocks.MockOAuthService)
+	mockOAuthService.On("Discovery").Return(discovery)
+
+	router := gin.Default()
+	handler.NewOAuthHandler(router, mockOAuthService, nil)
+
+	rr := httptest.NewRecorder()
+	request, _ := http.NewRequest(http.MethodGet, "/.well-known/openid-configuration", nil)
+	router.ServeHTTP(rr, request)
+
+	respBody, _ := json.Marshal(discovery)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, respBody, rr.Body.Bytes())
+	mockOAuthService.AssertExpectations(t)
+}
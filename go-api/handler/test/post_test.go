@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/whuangz/go-example/go-api/domain"
@@ -16,6 +17,13 @@ import (
 	mocks "github.com/whuangz/go-example/go-api/mocks/post"
 )
 
+// the account AuthUser would have set, post routes skip it in test mode
+var postAccount = &domain.Account{UID: uuid.New(), Email: "whuangz@gmail.com"}
+
+func withPostAccount(c *gin.Context) {
+	c.Set("account", postAccount)
+}
+
 func TestGetPosts(t *testing.T) {
 
 	gin.SetMode(gin.TestMode)
@@ -38,18 +46,31 @@ func TestGetPosts(t *testing.T) {
 		mockListPostResp := make([]domain.Post, 0)
 		mockListPostResp = append(mockListPostResp, mockPost)
 
-		mockService.On("FindAll", mock.AnythingOfType("*gin.Context")).Return(mockListPostResp, nil)
+		page := &domain.PostPage{Data: mockListPostResp, NextCursor: "aNextCursor"}
+		query := domain.PostQuery{
+			PostFilter: domain.PostFilter{
+				AuthorID: 1,
+				From:     time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC),
+				Sort:     domain.OldestFirst,
+			},
+			Cursor: "aCursor",
+			Limit:  10,
+		}
+		mockService.On("FindAll", mock.AnythingOfType("*gin.Context"), query).Return(page, nil)
 
 		rec := httptest.NewRecorder()
 		router := gin.New()
-		handler.NewPostHandler(router, mockService)
+		handler.NewPostHandler(router, mockService, nil, nil, false)
 
-		req, err := http.NewRequest(http.MethodGet, "/api/post", nil)
+		req, err := http.NewRequest(http.MethodGet, "/api/post?cursor=aCursor&limit=10&author_id=1&from=2021-06-01T00:00:00Z&sort=oldest", nil)
 		assert.NoError(t, err)
 
 		router.ServeHTTP(rec, req)
 
+		respBody, _ := json.Marshal(page)
+
 		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, respBody, rec.Body.Bytes())
 		mockService.AssertExpectations(t)
 	})
 
@@ -58,11 +79,11 @@ func TestGetPosts(t *testing.T) {
 
 		mockService := new(mocks.MockPostService)
 
-		mockService.On("FindAll", mock.Anything).Return(nil, respErr)
+		mockService.On("FindAll", mock.Anything, mock.Anything).Return(nil, respErr)
 
 		rec := httptest.NewRecorder()
 		router := gin.New()
-		handler.NewPostHandler(router, mockService)
+		handler.NewPostHandler(router, mockService, nil, nil, false)
 
 		req, err := http.NewRequest(http.MethodGet, "/api/post", nil)
 		assert.NoError(t, err)
@@ -91,13 +112,15 @@ func TestSavePost(t *testing.T) {
 
 		mockArgs := mock.Arguments{
 			mock.AnythingOfType("*gin.Context"),
+			postAccount,
 			mock.AnythingOfType("*domain.Post"),
 		}
 		mockService.On("Save", mockArgs...).Return(nil)
 
 		rec := httptest.NewRecorder()
 		router := gin.New()
-		handler.NewPostHandler(router, mockService)
+		router.Use(withPostAccount)
+		handler.NewPostHandler(router, mockService, nil, nil, false)
 
 		req, err := http.NewRequest(http.MethodPost, "/api/post", strings.NewReader(string(j)))
 		assert.NoError(t, err)
@@ -108,6 +131,32 @@ func TestSavePost(t *testing.T) {
 		mockService.AssertExpectations(t)
 	})
 
+	t.Run("Tags by name", func(t *testing.T) {
+		mockService := new(mocks.MockPostService)
+
+		expected := &domain.Post{
+			Title:      "Test Mock 1",
+			Content:    "Test Mock Desc",
+			Tags:       []domain.Tag{{Name: "Go"}, {Name: "Web Development"}},
+			CategoryID: 3,
+		}
+		mockService.On("Save", mock.AnythingOfType("*gin.Context"), postAccount, expected).Return(nil)
+
+		rec := httptest.NewRecorder()
+		router := gin.New()
+		router.Use(withPostAccount)
+		handler.NewPostHandler(router, mockService, nil, nil, false)
+
+		body := `{"Title": "Test Mock 1", "Content": "Test Mock Desc", "tags": ["Go", "Web Development"], "category_id": 3}`
+		req, err := http.NewRequest(http.MethodPost, "/api/post", strings.NewReader(body))
+		assert.NoError(t, err)
+
+		router.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		mockService.AssertExpectations(t)
+	})
+
 	t.Run("Fail", func(t *testing.T) {
 		respErr := domain.NewBadRequest("missing param")
 
@@ -120,13 +169,15 @@ func TestSavePost(t *testing.T) {
 
 		mockArgs := mock.Arguments{
 			mock.AnythingOfType("*gin.Context"),
+			postAccount,
 			mock.AnythingOfType("*domain.Post"),
 		}
 		mockService.On("Save", mockArgs...).Return(respErr)
 
 		rec := httptest.NewRecorder()
 		router := gin.New()
-		handler.NewPostHandler(router, mockService)
+		router.Use(withPostAccount)
+		handler.NewPostHandler(router, mockService, nil, nil, false)
 
 		req, err := http.NewRequest(http.MethodPost, "/api/post", strings.NewReader(string(j)))
 		assert.NoError(t, err)
@@ -157,18 +208,24 @@ func TestFindPostByID(t *testing.T) {
 			},
 		}
 
-		mockService.On("FindByID", mock.AnythingOfType("*gin.Context"), mock.AnythingOfType("int32")).Return(mockPostResp, nil)
+		mockService.On("FindByID", mock.AnythingOfType("*gin.Context"), mock.Anything, mock.AnythingOfType("int32")).Return(mockPostResp, nil)
 
 		rec := httptest.NewRecorder()
 		router := gin.New()
-		handler.NewPostHandler(router, mockService)
+		handler.NewPostHandler(router, mockService, nil, nil, false)
 
 		req, err := http.NewRequest(http.MethodGet, "/api/post/1", nil)
 		assert.NoError(t, err)
 
 		router.ServeHTTP(rec, req)
 
+		var resp map[string]interface{}
+		json.Unmarshal(rec.Body.Bytes(), &resp)
+
 		assert.Equal(t, http.StatusOK, rec.Code)
+		// the email of the author stays private
+		assert.NotContains(t, resp["Author"], "Email")
+		assert.NotContains(t, rec.Body.String(), "whuangz@gmail.com")
 		mockService.AssertExpectations(t)
 
 	})
@@ -178,11 +235,11 @@ func TestFindPostByID(t *testing.T) {
 
 		mockService := new(mocks.MockPostService)
 
-		mockService.On("FindByID", mock.Anything, mock.AnythingOfType("int32")).Return(nil, respErr)
+		mockService.On("FindByID", mock.Anything, mock.Anything, mock.AnythingOfType("int32")).Return(nil, respErr)
 
 		rec := httptest.NewRecorder()
 		router := gin.New()
-		handler.NewPostHandler(router, mockService)
+		handler.NewPostHandler(router, mockService, nil, nil, false)
 
 		req, err := http.NewRequest(http.MethodGet, "/api/post/0", nil)
 
@@ -211,15 +268,18 @@ func TestUpdatePost(t *testing.T) {
 		assert.NoError(t, err)
 
 		mockService.On("Update", mock.AnythingOfType("*gin.Context"),
+			postAccount,
 			mock.AnythingOfType("int32"),
 			mock.AnythingOfType("*domain.Post")).Return(nil)
 
 		rec := httptest.NewRecorder()
 		router := gin.New()
-		handler.NewPostHandler(router, mockService)
+		router.Use(withPostAccount)
+		handler.NewPostHandler(router, mockService, nil, nil, false)
 
 		req, err := http.NewRequest(http.MethodPatch, "/api/post/1", strings.NewReader(string(j)))
 		req.Header.Add("Content-Type", "application/json")
+		req.Header.Add("If-Match", `"1"`)
 
 		assert.NoError(t, err)
 
@@ -233,12 +293,14 @@ func TestUpdatePost(t *testing.T) {
 		respErr := domain.NewNotFound("id", "not found")
 
 		mockService.On("Update", mock.AnythingOfType("*gin.Context"),
+			postAccount,
 			mock.AnythingOfType("int32"),
 			mock.AnythingOfType("*domain.Post")).Return(respErr)
 
 		rec := httptest.NewRecorder()
 		router := gin.New()
-		handler.NewPostHandler(router, mockService)
+		router.Use(withPostAccount)
+		handler.NewPostHandler(router, mockService, nil, nil, false)
 
 		req, err := http.NewRequest(http.MethodPatch, "/api/post/", nil)
 		req.Header.Add("Content-Type", "application/json")
@@ -259,14 +321,16 @@ func TestDeletePost(t *testing.T) {
 	mockService := new(mocks.MockPostService)
 
 	t.Run("Success", func(t *testing.T) {
-		mockService.On("Delete", mock.AnythingOfType("*gin.Context"), mock.AnythingOfType("int32")).Return(nil)
+		mockService.On("Delete", mock.AnythingOfType("*gin.Context"), postAccount, mock.AnythingOfType("int32"), int32(1)).Return(nil)
 
 		rec := httptest.NewRecorder()
 		router := gin.New()
-		handler.NewPostHandler(router, mockService)
+		router.Use(withPostAccount)
+		handler.NewPostHandler(router, mockService, nil, nil, false)
 
 		req, err := http.NewRequest(http.MethodDelete, "/api/post/1", nil)
 		assert.NoError(t, err)
+		req.Header.Add("If-Match", `"1"`)
 		router.ServeHTTP(rec, req)
 
 		assert.Equal(t, http.StatusOK, rec.Code)
@@ -278,11 +342,14 @@ func TestDeletePost(t *testing.T) {
 		respErr := domain.NewNotFound("id", "not found")
 
 		mockService.On("Delete", mock.AnythingOfType("*gin.Context"),
+			postAccount,
+			mock.AnythingOfType("int32"),
 			mock.AnythingOfType("int32")).Return(respErr)
 
 		rec := httptest.NewRecorder()
 		router := gin.New()
-		handler.NewPostHandler(router, mockService)
+		router.Use(withPostAccount)
+		handler.NewPostHandler(router, mockService, nil, nil, false)
 
 		req, err := http.NewRequest(http.MethodDelete, "/api/post/", nil)
 
@@ -295,3 +362,399 @@ func TestDeletePost(t *testing.T) {
 		mockService.AssertExpectations(t)
 	})
 }
+
+func TestDeletePostForbidden(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	respErr := domain.NewForbidden("Only the author of the post or an editor can change it")
+
+	mockService := new(mocks.MockPostService)
+	mockService.On("Delete", mock.AnythingOfType("*gin.Context"), postAccount, int32(1), int32(1)).Return(respErr)
+
+	rec := httptest.NewRecorder()
+	router := gin.New()
+	router.Use(withPostAccount)
+	handler.NewPostHandler(router, mockService, nil, nil, false)
+
+	req, err := http.NewRequest(http.MethodDelete, "/api/post/1", nil)
+	assert.NoError(t, err)
+	req.Header.Add("If-Match", `"1"`)
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestSearchPosts(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("Success", func(t *testing.T) {
+		mockService := new(mocks.MockPostService)
+
+		page := &domain.SearchPage{
+			Data: []domain.SearchResult{{
+				Post:         domain.Post{ID: 1, Title: "Getting started with Go"},
+				Score:        1.5,
+				TitleSnippet: "Getting started with <mark>Go</mark>",
+			}},
+			Total:      3,
+			NextCursor: "aNextCursor",
+		}
+		query := domain.SearchQuery{Query: "go", Cursor: "aCursor", Limit: 1}
+		mockService.On("Search", mock.AnythingOfType("*gin.Context"), query).Return(page, nil)
+
+		rec := httptest.NewRecorder()
+		router := gin.New()
+		handler.NewPostHandler(router, mockService, nil, nil, false)
+
+		req, err := http.NewRequest(http.MethodGet, "/api/post/search?q=go&cursor=aCursor&limit=1", nil)
+		assert.NoError(t, err)
+
+		router.ServeHTTP(rec, req)
+
+		respBody, _ := json.Marshal(page)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, respBody, rec.Body.Bytes())
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("Missing query", func(t *testing.T) {
+		mockService := new(mocks.MockPostService)
+
+		rec := httptest.NewRecorder()
+		router := gin.New()
+		handler.NewPostHandler(router, mockService, nil, nil, false)
+
+		req, err := http.NewRequest(http.MethodGet, "/api/post/search", nil)
+		assert.NoError(t, err)
+
+		router.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		mockService.AssertNotCalled(t, "Search", mock.Anything, mock.Anything)
+		mockService.AssertNotCalled(t, "FindByID", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestGetMyPosts(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("Success", func(t *testing.T) {
+		mockService := new(mocks.MockPostService)
+
+		page := &domain.PostPage{Data: []domain.Post{{ID: 1, Title: "Draft", Status: domain.DraftStatus}}}
+		query := domain.PostQuery{PostFilter: domain.PostFilter{Status: domain.DraftStatus}}
+		mockService.On("FindByAccount", mock.AnythingOfType("*gin.Context"), postAccount, query).Return(page, nil)
+
+		rec := httptest.NewRecorder()
+		router := gin.New()
+		router.Use(withPostAccount)
+		handler.NewPostHandler(router, mockService, nil, nil, false)
+
+		req, err := http.NewRequest(http.MethodGet, "/api/account/me/posts?status=draft", nil)
+		assert.NoError(t, err)
+
+		router.ServeHTTP(rec, req)
+
+		respBody, _ := json.Marshal(page)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, respBody, rec.Body.Bytes())
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("Unauthorized", func(t *testing.T) {
+		mockService := new(mocks.MockPostService)
+
+		rec := httptest.NewRecorder()
+		router := gin.New()
+		handler.NewPostHandler(router, mockService, nil, nil, false)
+
+		req, err := http.NewRequest(http.MethodGet, "/api/account/me/posts", nil)
+		assert.NoError(t, err)
+
+		router.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+		mockService.AssertNotCalled(t, "FindByAccount", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestGetPostBySlug(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	post := domain.Post{ID: 1, Title: "Hello Gophers", Slug: "hello-gophers", Status: domain.PublishedStatus}
+
+	setupRouter := func() (*gin.Engine, *mocks.MockPostService) {
+		mockService := new(mocks.MockPostService)
+		mockService.On("FindBySlug", mock.AnythingOfType("*gin.Context"), "hello-gophers").Return(post, nil)
+		// a former slug of the post
+		mockService.On("FindBySlug", mock.AnythingOfType("*gin.Context"), "hello-world").Return(post, nil)
+
+		router := gin.New()
+		handler.NewPostHandler(router, mockService, nil, nil, false)
+		return router, mockService
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		router, _ := setupRouter()
+
+		rec := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/api/post/by-slug/hello-gophers", nil)
+		router.ServeHTTP(rec, req)
+
+		respBody, _ := json.Marshal(post)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, respBody, rec.Body.Bytes())
+	})
+
+	t.Run("Former slug", func(t *testing.T) {
+		router, _ := setupRouter()
+
+		rec := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/api/post/by-slug/hello-world", nil)
+		router.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusMovedPermanently, rec.Code)
+		assert.Equal(t, "/api/post/by-slug/hello-gophers", rec.Header().Get("Location"))
+	})
+
+	t.Run("Unknown path", func(t *testing.T) {
+		router, mockService := setupRouter()
+
+		rec := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/api/post/1/hello-gophers", nil)
+		router.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+		mockService.AssertNotCalled(t, "FindBySlug", mock.Anything, mock.Anything)
+	})
+}
+
+func TestRevisions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	setupRouter := func(mockService *mocks.MockPostService) *gin.Engine {
+		router := gin.New()
+		router.Use(withPostAccount)
+		handler.NewPostHandler(router, mockService, nil, nil, false)
+		return router
+	}
+
+	t.Run("List", func(t *testing.T) {
+		mockService := new(mocks.MockPostService)
+		revisions := []domain.PostRevision{
+			{PostID: 1, Number: 2, Title: "Hello Gophers", AccountUID: postAccount.UID},
+			{PostID: 1, Number: 1, Title: "Hello World", AccountUID: postAccount.UID},
+		}
+		mockService.On("Revisions", mock.AnythingOfType("*gin.Context"), postAccount, int32(1)).Return(revisions, nil)
+
+		rec := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/api/post/1/revisions", nil)
+		setupRouter(mockService).ServeHTTP(rec, req)
+
+		respBody, _ := json.Marshal(gin.H{
+			"data": revisions,
+		})
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, respBody, rec.Body.Bytes())
+	})
+
+	t.Run("Diff", func(t *testing.T) {
+		mockService := new(mocks.MockPostService)
+		diff := &domain.PostDiff{
+			From:    1,
+			To:      2,
+			Title:   []domain.DiffLine{{Op: domain.DiffDelete, Text: "Hello World"}, {Op: domain.DiffInsert, Text: "Hello Gophers"}},
+			Content: []domain.DiffLine{{Op: domain.DiffEqual, Text: "Content"}},
+		}
+		mockService.On("Diff", mock.AnythingOfType("*gin.Context"), postAccount, int32(1), int32(1), int32(2)).Return(diff, nil)
+
+		rec := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/api/post/1/diff?from=1&to=2", nil)
+		setupRouter(mockService).ServeHTTP(rec, req)
+
+		respBody, _ := json.Marshal(diff)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, respBody, rec.Body.Bytes())
+	})
+
+	t.Run("Diff without revisions", func(t *testing.T) {
+		mockService := new(mocks.MockPostService)
+
+		rec := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/api/post/1/diff?from=1", nil)
+		setupRouter(mockService).ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		mockService.AssertNotCalled(t, "Diff", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Restore", func(t *testing.T) {
+		mockService := new(mocks.MockPostService)
+		post := &domain.Post{ID: 1, Title: "Hello World", Slug: "hello-world", Content: "Content", Status: domain.PublishedStatus}
+		mockService.On("Restore", mock.AnythingOfType("*gin.Context"), postAccount, int32(1), int32(1), int32(2)).Return(post, nil)
+
+		rec := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/api/post/1/revisions/1/restore", nil)
+		req.Header.Set("If-Match", `"2"`)
+		setupRouter(mockService).ServeHTTP(rec, req)
+
+		respBody, _ := json.Marshal(post)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, respBody, rec.Body.Bytes())
+	})
+
+	t.Run("Restore an unknown revision", func(t *testing.T) {
+		mockService := new(mocks.MockPostService)
+		mockService.On("Restore", mock.AnythingOfType("*gin.Context"), postAccount, int32(1), int32(9), int32(1)).
+			Return(nil, domain.NewNotFound("revision", "9"))
+
+		rec := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/api/post/1/revisions/9/restore", nil)
+		req.Header.Set("If-Match", `"1"`)
+		setupRouter(mockService).ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+
+	t.Run("Restore without If-Match", func(t *testing.T) {
+		mockService := new(mocks.MockPostService)
+
+		rec := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/api/post/1/revisions/1/restore", nil)
+		setupRouter(mockService).ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusPreconditionRequired, rec.Code)
+		mockService.AssertNotCalled(t, "Restore", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Restore of a former version", func(t *testing.T) {
+		mockService := new(mocks.MockPostService)
+		mockService.On("Restore", mock.AnythingOfType("*gin.Context"), postAccount, int32(1), int32(1), int32(1)).
+			Return(nil, domain.NewPreconditionFailed("post", "1"))
+
+		rec := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/api/post/1/revisions/1/restore", nil)
+		req.Header.Set("If-Match", `"1"`)
+		setupRouter(mockService).ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusPreconditionFailed, rec.Code)
+	})
+}
+
+func TestPostETag(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	post := domain.Post{ID: 1, Title: "Hello Gophers", Slug: "hello-gophers", Status: domain.PublishedStatus, Version: 4}
+
+	setupRouter := func(mockService *mocks.MockPostService) *gin.Engine {
+		router := gin.New()
+		router.Use(withPostAccount)
+		handler.NewPostHandler(router, mockService, nil, nil, false)
+		return router
+	}
+
+	t.Run("Read", func(t *testing.T) {
+		mockService := new(mocks.MockPostService)
+		mockService.On("FindByID", mock.AnythingOfType("*gin.Context"), mock.Anything, int32(1)).Return(post, nil)
+
+		rec := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/api/post/1", nil)
+		setupRouter(mockService).ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, `"4"`, rec.Header().Get("ETag"))
+	})
+
+	t.Run("Not modified", func(t *testing.T) {
+		mockService := new(mocks.MockPostService)
+		mockService.On("FindByID", mock.AnythingOfType("*gin.Context"), mock.Anything, int32(1)).Return(post, nil)
+		mockService.On("FindBySlug", mock.AnythingOfType("*gin.Context"), "hello-gophers").Return(post, nil)
+
+		for _, path := range []string{"/api/post/1", "/api/post/by-slug/hello-gophers"} {
+			rec := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodGet, path, nil)
+			req.Header.Add("If-None-Match", `"3", W/"4"`)
+			setupRouter(mockService).ServeHTTP(rec, req)
+
+			assert.Equal(t, http.StatusNotModified, rec.Code, path)
+			assert.Empty(t, rec.Body.Bytes(), path)
+		}
+	})
+
+	t.Run("Modified", func(t *testing.T) {
+		mockService := new(mocks.MockPostService)
+		mockService.On("FindByID", mock.AnythingOfType("*gin.Context"), mock.Anything, int32(1)).Return(post, nil)
+
+		rec := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/api/post/1", nil)
+		req.Header.Add("If-None-Match", `"3"`)
+		setupRouter(mockService).ServeHTTP(rec, req)
+
+		respBody, _ := json.Marshal(post)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, respBody, rec.Body.Bytes())
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		mockService := new(mocks.MockPostService)
+		mockService.On("Update", mock.AnythingOfType("*gin.Context"), postAccount, int32(1), mock.AnythingOfType("*domain.Post")).
+			Run(func(args mock.Arguments) {
+				args.Get(3).(*domain.Post).Version++
+			}).
+			Return(nil)
+
+		rec := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPatch, "/api/post/1", strings.NewReader(`{"title": "Hello", "version": 9}`))
+		req.Header.Add("Content-Type", "application/json")
+		req.Header.Add("If-Match", `"4"`)
+		setupRouter(mockService).ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, `"5"`, rec.Header().Get("ETag"))
+	})
+
+	t.Run("Write without If-Match", func(t *testing.T) {
+		mockService := new(mocks.MockPostService)
+
+		rec := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodDelete, "/api/post/1", nil)
+		setupRouter(mockService).ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusPreconditionRequired, rec.Code)
+		mockService.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Write with a weak ETag", func(t *testing.T) {
+		mockService := new(mocks.MockPostService)
+
+		rec := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodDelete, "/api/post/1", nil)
+		req.Header.Add("If-Match", `W/"4"`)
+		setupRouter(mockService).ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusPreconditionFailed, rec.Code)
+		mockService.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Write of a former version", func(t *testing.T) {
+		mockService := new(mocks.MockPostService)
+		mockService.On("Delete", mock.AnythingOfType("*gin.Context"), postAccount, int32(1), int32(3)).
+			Return(domain.NewPreconditionFailed("post", "1"))
+
+		rec := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodDelete, "/api/post/1", nil)
+		req.Header.Add("If-Match", `"3"`)
+		setupRouter(mockService).ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusPreconditionFailed, rec.Code)
+		mockService.AssertExpectations(t)
+	})
+}
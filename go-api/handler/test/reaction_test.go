@@ -0,0 +1,118 @@
+package handle_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/whuangz/go-example/go-api/domain"
+	"github.com/whuangz/go-example/go-api/handler"
+	mocks "github.com/whuangz/go-example/go-api/mocks/post"
+)
+
+func TestReactions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	setupRouter := func(postService *mocks.MockPostService, reactionService *mocks.MockReactionService, middlewares ...gin.HandlerFunc) *gin.Engine {
+		router := gin.New()
+		router.Use(middlewares...)
+		posts := handler.NewPostHandler(router, postService, nil, nil, false)
+		handler.NewCommentHandler(router, posts, new(mocks.MockCommentService), nil, nil, false)
+		handler.NewReactionHandler(router, reactionService, nil, nil, false)
+		return router
+	}
+
+	t.Run("Kinds", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		request, _ := http.NewRequest(http.MethodGet, "/api/reactions", nil)
+		setupRouter(new(mocks.MockPostService), new(mocks.MockReactionService)).ServeHTTP(rr, request)
+
+		respBody, _ := json.Marshal(gin.H{
+			"data": domain.ReactionEmoji,
+		})
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, respBody, rr.Body.Bytes())
+	})
+
+	t.Run("React", func(t *testing.T) {
+		mockReactionService := new(mocks.MockReactionService)
+		mine := []string{domain.LikeReaction, domain.LoveReaction}
+		mockReactionService.On("React", mock.Anything, postAccount, int32(1), domain.LoveReaction).Return(mine, nil)
+
+		rr := httptest.NewRecorder()
+		request, _ := http.NewRequest(http.MethodPut, "/api/post/1/reactions/love", nil)
+		setupRouter(new(mocks.MockPostService), mockReactionService, withPostAccount).ServeHTTP(rr, request)
+
+		respBody, _ := json.Marshal(gin.H{
+			"my_reaction": mine,
+		})
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, respBody, rr.Body.Bytes())
+	})
+
+	t.Run("React with an unknown kind", func(t *testing.T) {
+		mockReactionService := new(mocks.MockReactionService)
+		mockReactionService.On("React", mock.Anything, postAccount, int32(1), "angry").
+			Return(nil, domain.NewBadRequest("unknown reaction: angry"))
+
+		rr := httptest.NewRecorder()
+		request, _ := http.NewRequest(http.MethodPut, "/api/post/1/reactions/angry", nil)
+		setupRouter(new(mocks.MockPostService), mockReactionService, withPostAccount).ServeHTTP(rr, request)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("React anonymously", func(t *testing.T) {
+		mockReactionService := new(mocks.MockReactionService)
+
+		rr := httptest.NewRecorder()
+		request, _ := http.NewRequest(http.MethodPut, "/api/post/1/reactions/like", nil)
+		setupRouter(new(mocks.MockPostService), mockReactionService).ServeHTTP(rr, request)
+
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+		mockReactionService.AssertNotCalled(t, "React", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Unreact", func(t *testing.T) {
+		mockReactionService := new(mocks.MockReactionService)
+		mockReactionService.On("Unreact", mock.Anything, postAccount, int32(1), domain.LikeReaction).Return([]string{}, nil)
+
+		rr := httptest.NewRecorder()
+		request, _ := http.NewRequest(http.MethodDelete, "/api/post/1/reactions/like", nil)
+		setupRouter(new(mocks.MockPostService), mockReactionService, withPostAccount).ServeHTTP(rr, request)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `{"my_reaction": []}`, rr.Body.String())
+	})
+
+	t.Run("Post with my_reaction", func(t *testing.T) {
+		mockPostService := new(mocks.MockPostService)
+		post := domain.Post{
+			ID:         1,
+			Title:      "Reacted to",
+			Status:     domain.PublishedStatus,
+			Version:    1,
+			Reactions:  domain.ReactionCounts{domain.LikeReaction: 3},
+			MyReaction: []string{domain.LikeReaction},
+		}
+		mockPostService.On("FindByID", mock.Anything, postAccount, int32(1)).Return(post, nil)
+
+		rr := httptest.NewRecorder()
+		request, _ := http.NewRequest(http.MethodGet, "/api/post/1", nil)
+		setupRouter(mockPostService, new(mocks.MockReactionService), withPostAccount).ServeHTTP(rr, request)
+
+		var resp map[string]interface{}
+		json.Unmarshal(rr.Body.Bytes(), &resp)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, map[string]interface{}{"like": float64(3)}, resp["reactions"])
+		assert.Equal(t, []interface{}{"like"}, resp["my_reaction"])
+		assert.Equal(t, "Authorization", rr.Header().Get("Vary"))
+	})
+}
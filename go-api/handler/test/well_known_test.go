@@ -0,0 +1,41 @@
+package handle_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/whuangz/go-example/go-api/domain"
+	"github.com/whuangz/go-example/go-api/handler"
+	mocks "github.com/whuangz/go-example/go-api/mocks/account"
+)
+
+func TestJWKS(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	jwks := &domain.JWKS{
+		Keys: []domain.JWK{
+			{Kty: "RSA", Use: "sig", Alg: "RS256", Kid: "aKid", N: "aModulus", E: "AQAB"},
+		},
+	}
+
+	mockTokenService := new(mocks.MockTokenService)
+	mockTokenService.On("JWKS").Return(jwks)
+
+	router := gin.Default()
+	handler.NewWellKnownHandler(router, mockTokenService)
+
+	rr := httptest.NewRecorder()
+	request, _ := http.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil)
+	router.ServeHTTP(rr, request)
+
+	respBody, _ := json.Marshal(jwks)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, respBody, rr.Body.Bytes())
+	assert.Contains(t, rr.Header().Get("Cache-Control"), "max-age")
+	mockTokenService.AssertExpectations(t)
+}
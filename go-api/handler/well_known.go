@@ -0,0 +1,27 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/whuangz/go-example/go-api/domain"
+)
+
+type wellKnownHandler struct {
+	tokenService domain.TokenService
+}
+
+func NewWellKnownHandler(router gin.IRouter, tokenService domain.TokenService) {
+	h := &wellKnownHandler{tokenService: tokenService}
+
+	router.GET("/.well-known/jwks.json", h.JWKS)
+}
+
+// JWKS handler publishes the public keys access tokens are signed
+// with, so other services can verify them. Unlike the other responses
+// it isn't wrapped in data, the format is defined by RFC 7517
+func (h *wellKnownHandler) JWKS(c *gin.Context) {
+	// rotated keys are picked up by clients within minutes
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, h.tokenService.JWKS())
+}
@@ -1,26 +1,18 @@
 package db
 
 import (
+	"context"
 	"database/sql"
-	"fmt"
 
 	_ "github.com/go-sql-driver/mysql"
 
 	"github.com/jmoiron/sqlx"
 	"github.com/sirupsen/logrus"
-	config "github.com/whuangz/go-example/go-api/config"
 )
 
-func init() {
-	if config.IS_DEBUG_MODE {
-		fmt.Println("Service RUN on DEBUG mode")
-	}
-}
-
+// Configure connects to the data source. The package doesn't read the
+// config, so repositories running transactions can be tested without it
 func Configure(dataSource string) *sqlx.DB {
-	if dataSource == "" {
-		dataSource = config.URI
-	}
 	client := connect(dataSource)
 	return client
 }
@@ -46,6 +38,9 @@ type Transaction interface {
 	Prepare(query string) (*sql.Stmt, error)
 	Query(query string, args ...interface{}) (*sql.Rows, error)
 	QueryRow(query string, args ...interface{}) *sql.Row
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
 }
 
 // A Txfn is a function that will be called with an initialized `Transaction` object
@@ -72,8 +67,6 @@ func WithTransaction(db *sqlx.DB, fn TxFn) (err error) {
 			// all good, commit
 			err = tx.Commit()
 		}
-
-		db.Close()
 	}()
 
 	err = fn(tx)
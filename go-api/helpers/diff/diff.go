@@ -0,0 +1,131 @@
+package diff
+
+import "strings"
+
+// Operations of a line of a diff
+const (
+	Equal  = "equal"
+	Insert = "insert"
+	Delete = "delete"
+)
+
+// Line is a line kept, inserted or deleted going from a text to another
+type Line struct {
+	Op   string `json:"op"`
+	Text string `json:"text"`
+}
+
+// Lines returns the shortest edit of the lines of a into the lines of b,
+// found with the algorithm of Myers. Deleted lines come before the lines
+// inserted in their place
+func Lines(a string, b string) []Line {
+	x, y := splitLines(a), splitLines(b)
+
+	// the lines both texts start and end with are left out of the search
+	prefix := 0
+	for prefix < len(x) && prefix < len(y) && x[prefix] == y[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(x)-prefix && suffix < len(y)-prefix && x[len(x)-1-suffix] == y[len(y)-1-suffix] {
+		suffix++
+	}
+
+	lines := make([]Line, 0, len(x)+len(y))
+	for _, text := range x[:prefix] {
+		lines = append(lines, Line{Op: Equal, Text: text})
+	}
+	lines = append(lines, edit(x[prefix:len(x)-suffix], y[prefix:len(y)-suffix])...)
+	for _, text := range x[len(x)-suffix:] {
+		lines = append(lines, Line{Op: Equal, Text: text})
+	}
+	return lines
+}
+
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+}
+
+// edit walks the furthest reaching paths of each number of edits d,
+// keeping them to trace the shortest edit back once b is reached
+func edit(x []string, y []string) []Line {
+	n, m := len(x), len(y)
+	max := n + m
+	if max == 0 {
+		return nil
+	}
+
+	offset := max
+	v := make([]int, 2*max+2)
+	var trace [][]int
+
+	for d := 0; d <= max; d++ {
+		trace = append(trace, append([]int(nil), v...))
+
+		for k := -d; k <= d; k += 2 {
+			var i int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				i = v[offset+k+1] // down, inserting y[j]
+			} else {
+				i = v[offset+k-1] + 1 // right, deleting x[i]
+			}
+			j := i - k
+			for i < n && j < m && x[i] == y[j] {
+				i++
+				j++
+			}
+			v[offset+k] = i
+
+			if i >= n && j >= m {
+				return backtrack(x, y, trace, offset, d)
+			}
+		}
+	}
+	return nil
+}
+
+func backtrack(x []string, y []string, trace [][]int, offset int, d int) []Line {
+	var reversed []Line
+	i, j := len(x), len(y)
+
+	for ; d > 0; d-- {
+		v := trace[d]
+		k := i - j
+
+		var prevK int
+		if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevI := v[offset+prevK]
+		prevJ := prevI - prevK
+
+		for i > prevI && j > prevJ {
+			i--
+			j--
+			reversed = append(reversed, Line{Op: Equal, Text: x[i]})
+		}
+		if i == prevI {
+			j--
+			reversed = append(reversed, Line{Op: Insert, Text: y[j]})
+		} else {
+			i--
+			reversed = append(reversed, Line{Op: Delete, Text: x[i]})
+		}
+	}
+	for i > 0 && j > 0 {
+		i--
+		j--
+		reversed = append(reversed, Line{Op: Equal, Text: x[i]})
+	}
+
+	lines := make([]Line, len(reversed))
+	for l, line := range reversed {
+		lines[len(reversed)-1-l] = line
+	}
+	return lines
+}
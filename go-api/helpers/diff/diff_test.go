@@ -0,0 +1,57 @@
+package diff
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLines(t *testing.T) {
+	t.Run("Changed line", func(t *testing.T) {
+		lines := Lines("one\ntwo\nthree", "one\n2\nthree")
+
+		assert.Equal(t, []Line{
+			{Op: Equal, Text: "one"},
+			{Op: Delete, Text: "two"},
+			{Op: Insert, Text: "2"},
+			{Op: Equal, Text: "three"},
+		}, lines)
+	})
+
+	t.Run("Empty texts", func(t *testing.T) {
+		assert.Empty(t, Lines("", ""))
+		assert.Equal(t, []Line{{Op: Insert, Text: "new"}}, Lines("", "new"))
+		assert.Equal(t, []Line{{Op: Delete, Text: "old"}}, Lines("old", ""))
+	})
+
+	t.Run("Shortest edit", func(t *testing.T) {
+		// the classic example of the paper, seven lines kept out of eleven
+		a := strings.Join(strings.Split("ABCABBA", ""), "\n")
+		b := strings.Join(strings.Split("CBABAC", ""), "\n")
+
+		lines := Lines(a, b)
+
+		var edits int
+		var from, to []string
+		for _, line := range lines {
+			if line.Op != Equal {
+				edits++
+			}
+			if line.Op != Insert {
+				from = append(from, line.Text)
+			}
+			if line.Op != Delete {
+				to = append(to, line.Text)
+			}
+		}
+		assert.Equal(t, 5, edits)
+		assert.Equal(t, a, strings.Join(from, "\n"))
+		assert.Equal(t, b, strings.Join(to, "\n"))
+	})
+
+	t.Run("Windows line endings", func(t *testing.T) {
+		lines := Lines("one\r\ntwo", "one\ntwo")
+		assert.Equal(t, []Line{{Op: Equal, Text: "one"}, {Op: Equal, Text: "two"}}, lines)
+	})
+}
@@ -0,0 +1,245 @@
+package jwt
+
+import (
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/whuangz/go-example/go-api/domain"
+)
+
+// Key is an RSA key identified by its kid. Keys only kept to verify
+// tokens signed before a rotation don't need the private part
+type Key struct {
+	ID      string
+	Private *rsa.PrivateKey
+	Public  *rsa.PublicKey
+}
+
+// NewKey creates a signing key, its kid is the thumbprint of the public key
+func NewKey(private *rsa.PrivateKey) *Key {
+	return &Key{ID: Thumbprint(&private.PublicKey), Private: private, Public: &private.PublicKey}
+}
+
+// NewPublicKey creates a key which can only verify tokens
+func NewPublicKey(public *rsa.PublicKey) *Key {
+	return &Key{ID: Thumbprint(public), Public: public}
+}
+
+// Thumbprint is the RFC 7638 JWK thumbprint of an RSA public key,
+// it stays the same for a key however it is stored
+func Thumbprint(public *rsa.PublicKey) string {
+	// the members are required in lexicographic order without whitespace
+	jwk := fmt.Sprintf(`{"e":"%s","kty":"RSA","n":"%s"}`, encodeExponent(public.E), encodeBigInt(public.N))
+	sum := sha256.Sum256([]byte(jwk))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// Keyring holds the key access tokens are signed with and every key they
+// are verified with. Its keys can be replaced while it is being used
+type Keyring struct {
+	mu      sync.RWMutex
+	signing *Key
+	keys    map[string]*Key
+}
+
+// NewKeyring creates a keyring signing with the given key. The
+// verification keys are the ones of tokens signed before a rotation
+func NewKeyring(signing *Key, verification ...*Key) *Keyring {
+	k := &Keyring{}
+	k.Set(signing, verification...)
+	return k
+}
+
+// Set replaces the keys of the keyring
+func (k *Keyring) Set(signing *Key, verification ...*Key) {
+	keys := map[string]*Key{signing.ID: signing}
+	for _, key := range verification {
+		// a public key of the signing key doesn't replace it
+		if _, exists := keys[key.ID]; !exists {
+			keys[key.ID] = key
+		}
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	k.signing = signing
+	k.keys = keys
+}
+
+// SigningKey returns the key new tokens are signed with
+func (k *Keyring) SigningKey() *Key {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	return k.signing
+}
+
+// PublicKey returns the key to verify a token by its kid
+func (k *Keyring) PublicKey(kid string) (*rsa.PublicKey, bool) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	key, ok := k.keys[kid]
+	if !ok {
+		return nil, false
+	}
+	return key.Public, true
+}
+
+// PublicKeys returns every verification key, the signing key first
+func (k *Keyring) PublicKeys() []*Key {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	keys := []*Key{k.signing}
+	for id, key := range k.keys {
+		if id != k.signing.ID {
+			keys = append(keys, key)
+		}
+	}
+
+	sort.SliceStable(keys[1:], func(i, j int) bool {
+		return keys[i+1].ID < keys[j+1].ID
+	})
+	return keys
+}
+
+// JWKS returns the public part of every verification key
+func (k *Keyring) JWKS() *domain.JWKS {
+	jwks := &domain.JWKS{Keys: []domain.JWK{}}
+
+	for _, key := range k.PublicKeys() {
+		jwks.Keys = append(jwks.Keys, domain.JWK{
+			Kty: "RSA",
+			Use: "sig",
+			Alg: jwt.SigningMethodRS256.Alg(),
+			Kid: key.ID,
+			N:   encodeBigInt(key.Public.N),
+			E:   encodeExponent(key.Public.E),
+		})
+	}
+
+	return jwks
+}
+
+// LoadDir replaces the keys with the PEM files of a directory. Every
+// private and public key verifies tokens, the private key of the file
+// sorting last by name signs them, eg: keys named by their creation date.
+// After a rotation, the previous key has to stay in the directory for as
+// long as the tokens it signed are valid
+func (k *Keyring) LoadDir(dir string) error {
+	files, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return err
+	}
+	sort.Strings(files)
+
+	var signing *Key
+	var keys []*Key
+
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return err
+		}
+
+		if private, err := jwt.ParseRSAPrivateKeyFromPEM(data); err == nil {
+			if signing != nil {
+				keys = append(keys, signing)
+			}
+			signing = NewKey(private)
+			continue
+		}
+
+		public, err := jwt.ParseRSAPublicKeyFromPEM(data)
+		if err != nil {
+			return fmt.Errorf("%s is neither an RSA private nor public key: %v", file, err)
+		}
+		keys = append(keys, NewPublicKey(public))
+	}
+
+	if signing == nil {
+		return fmt.Errorf("no RSA private key found in %s", dir)
+	}
+
+	k.Set(signing, keys...)
+	return nil
+}
+
+// WatchDir reloads the keys when the PEM files of the directory change,
+// which lets keys be rotated without a restart. When the files can't
+// be loaded, the current keys are kept
+func (k *Keyring) WatchDir(dir string, interval time.Duration) (stop func()) {
+	done := make(chan struct{})
+	ticker := time.NewTicker(interval)
+
+	go func() {
+		defer ticker.Stop()
+
+		state, _ := dirState(dir)
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				current, err := dirState(dir)
+				if err != nil {
+					log.Printf("Could not check signing keys in %s: %v\n", dir, err)
+					continue
+				}
+				if current == state {
+					continue
+				}
+
+				if err := k.LoadDir(dir); err != nil {
+					log.Printf("Could not reload signing keys from %s, keeping the current ones: %v\n", dir, err)
+					continue
+				}
+				state = current
+				log.Printf("Reloaded signing keys from %s, signing with kid: %s\n", dir, k.SigningKey().ID)
+			}
+		}
+	}()
+
+	return func() { close(done) }
+}
+
+// dirState sums up the name, size and modification time of the PEM files
+func dirState(dir string) (string, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return "", err
+	}
+	sort.Strings(files)
+
+	var state strings.Builder
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(&state, "%s:%d:%d;", file, info.Size(), info.ModTime().UnixNano())
+	}
+	return state.String(), nil
+}
+
+func encodeBigInt(n *big.Int) string {
+	return base64.RawURLEncoding.EncodeToString(n.Bytes())
+}
+
+func encodeExponent(e int) string {
+	return encodeBigInt(big.NewInt(int64(e)))
+}
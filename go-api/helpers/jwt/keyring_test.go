@@ -0,0 +1,120 @@
+package jwt
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeKey(t *testing.T, dir string, name string, key *rsa.PrivateKey, publicOnly bool) {
+	var block *pem.Block
+	if publicOnly {
+		der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+		assert.NoError(t, err)
+		block = &pem.Block{Type: "PUBLIC KEY", Bytes: der}
+	} else {
+		block = &pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}
+	}
+
+	err := ioutil.WriteFile(filepath.Join(dir, name), pem.EncodeToMemory(block), 0600)
+	assert.NoError(t, err)
+}
+
+func TestJWKS(t *testing.T) {
+	private, _ := rsa.GenerateKey(rand.Reader, 2048)
+	keys := NewKeyring(NewKey(private))
+
+	jwks := keys.JWKS()
+	assert.Len(t, jwks.Keys, 1)
+
+	jwk := jwks.Keys[0]
+	assert.Equal(t, "RSA", jwk.Kty)
+	assert.Equal(t, "RS256", jwk.Alg)
+	assert.Equal(t, "sig", jwk.Use)
+	assert.Equal(t, Thumbprint(&private.PublicKey), jwk.Kid)
+	assert.Equal(t, "AQAB", jwk.E)
+
+	n, err := base64.RawURLEncoding.DecodeString(jwk.N)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, private.PublicKey.N.Cmp(new(big.Int).SetBytes(n)))
+}
+
+func TestLoadDir(t *testing.T) {
+	dir, err := ioutil.TempDir("", "keys")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	first, _ := rsa.GenerateKey(rand.Reader, 2048)
+	second, _ := rsa.GenerateKey(rand.Reader, 2048)
+	retired, _ := rsa.GenerateKey(rand.Reader, 2048)
+
+	writeKey(t, dir, "20210101.pem", retired, true)
+	writeKey(t, dir, "20210301.pem", first, false)
+	writeKey(t, dir, "20210501.pem", second, false)
+
+	keys := &Keyring{}
+	err = keys.LoadDir(dir)
+	assert.NoError(t, err)
+
+	// the last private key signs, the others only verify
+	assert.Equal(t, Thumbprint(&second.PublicKey), keys.SigningKey().ID)
+	for _, key := range []*rsa.PrivateKey{first, second, retired} {
+		_, ok := keys.PublicKey(Thumbprint(&key.PublicKey))
+		assert.True(t, ok)
+	}
+	assert.Len(t, keys.JWKS().Keys, 3)
+
+	t.Run("No private key", func(t *testing.T) {
+		dir, _ := ioutil.TempDir("", "keys")
+		defer os.RemoveAll(dir)
+
+		writeKey(t, dir, "20210101.pem", retired, true)
+
+		err := (&Keyring{}).LoadDir(dir)
+		assert.Error(t, err)
+	})
+}
+
+func TestWatchDir(t *testing.T) {
+	dir, err := ioutil.TempDir("", "keys")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	first, _ := rsa.GenerateKey(rand.Reader, 2048)
+	second, _ := rsa.GenerateKey(rand.Reader, 2048)
+
+	writeKey(t, dir, "20210301.pem", first, false)
+
+	keys := &Keyring{}
+	err = keys.LoadDir(dir)
+	assert.NoError(t, err)
+
+	stop := keys.WatchDir(dir, 10*time.Millisecond)
+	defer stop()
+
+	// an invalid file keeps the current keys
+	err = ioutil.WriteFile(filepath.Join(dir, "broken.pem"), []byte("not a key"), 0600)
+	assert.NoError(t, err)
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, Thumbprint(&first.PublicKey), keys.SigningKey().ID)
+
+	os.Remove(filepath.Join(dir, "broken.pem"))
+	writeKey(t, dir, "20210501.pem", second, false)
+
+	assert.Eventually(t, func() bool {
+		return keys.SigningKey().ID == Thumbprint(&second.PublicKey)
+	}, time.Second, 10*time.Millisecond)
+
+	_, ok := keys.PublicKey(Thumbprint(&first.PublicKey))
+	assert.True(t, ok)
+}
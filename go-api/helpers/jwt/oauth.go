@@ -0,0 +1,126 @@
+package jwt
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/google/uuid"
+	"github.com/whuangz/go-example/go-api/domain"
+)
+
+// OAuthAccessTokenType is the typ header of access tokens issued to OAuth
+// clients, as defined by RFC 9068. It keeps them from being mistaken for
+// ID tokens, which are signed with the same keys
+const OAuthAccessTokenType = "at+jwt"
+
+// OAuthAccessTokenClaims are the claims of an access token issued to an
+// OAuth client. The subject is the account uid, or the client id when the
+// client acts on its own behalf
+type OAuthAccessTokenClaims struct {
+	Scope    string `json:"scope,omitempty"`
+	ClientID string `json:"client_id"`
+	jwt.StandardClaims
+}
+
+// IDTokenClaims are the claims of an OpenID Connect ID token
+type IDTokenClaims struct {
+	Nonce           string `json:"nonce,omitempty"`
+	AccessTokenHash string `json:"at_hash,omitempty"`
+	Name            string `json:"name,omitempty"`
+	Picture         string `json:"picture,omitempty"`
+	Website         string `json:"website,omitempty"`
+	Email           string `json:"email,omitempty"`
+	EmailVerified   *bool  `json:"email_verified,omitempty"`
+	jwt.StandardClaims
+}
+
+// GenerateOAuthAccessToken signs an access token issued by the OAuth
+// provider, the audience is the api itself
+func GenerateOAuthAccessToken(issuer string, subject string, clientID string, scope string, key *Key, exp int64) (string, error) {
+	unixTime := time.Now().Unix()
+
+	tokenID, err := uuid.NewRandom()
+	if err != nil {
+		log.Println("Failed to generate OAuth access token ID")
+		return "", err
+	}
+
+	claims := OAuthAccessTokenClaims{
+		Scope:    scope,
+		ClientID: clientID,
+		StandardClaims: jwt.StandardClaims{
+			Issuer:    issuer,
+			Subject:   subject,
+			Audience:  issuer,
+			IssuedAt:  unixTime,
+			ExpiresAt: unixTime + exp,
+			Id:        tokenID.String(),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = key.ID
+	token.Header["typ"] = OAuthAccessTokenType
+	return token.SignedString(key.Private)
+}
+
+// GenerateIDToken signs an ID token for the client with the claims
+// about the account, along with the access token issued with it
+func GenerateIDToken(issuer string, clientID string, info *domain.UserInfo, nonce string, accessToken string, key *Key, exp int64) (string, error) {
+	unixTime := time.Now().Unix()
+
+	claims := IDTokenClaims{
+		Nonce:           nonce,
+		AccessTokenHash: AccessTokenHash(accessToken),
+		Name:            info.Name,
+		Picture:         info.Picture,
+		Website:         info.Website,
+		Email:           info.Email,
+		EmailVerified:   info.EmailVerified,
+		StandardClaims: jwt.StandardClaims{
+			Issuer:    issuer,
+			Subject:   info.Subject,
+			Audience:  clientID,
+			IssuedAt:  unixTime,
+			ExpiresAt: unixTime + exp,
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = key.ID
+	return token.SignedString(key.Private)
+}
+
+// AccessTokenHash is the at_hash claim of an ID token issued along an
+// access token, the left half of the SHA-256 of the access token
+func AccessTokenHash(accessToken string) string {
+	sum := sha256.Sum256([]byte(accessToken))
+	return base64.RawURLEncoding.EncodeToString(sum[:len(sum)/2])
+}
+
+// ValidateOAuthAccessToken verifies an access token issued to an OAuth
+// client with the key of its kid
+func ValidateOAuthAccessToken(tokenString string, keys *Keyring) (*OAuthAccessTokenClaims, error) {
+	claims := &OAuthAccessTokenClaims{}
+
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		if typ, _ := token.Header["typ"].(string); typ != OAuthAccessTokenType {
+			return nil, fmt.Errorf("unexpected token type: %v", token.Header["typ"])
+		}
+		return verificationKey(token, keys)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !token.Valid {
+		return nil, fmt.Errorf("OAuth access token is invalid")
+	}
+
+	return claims, nil
+}
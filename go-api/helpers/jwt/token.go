@@ -14,6 +14,7 @@ import (
 type RefreshToken struct {
 	SS        string
 	ID        string
+	FamilyID  string
 	ExpiresIn time.Duration
 }
 
@@ -22,20 +23,31 @@ type AccessTokenTokenCustomClaims struct {
 	jwt.StandardClaims
 }
 
-func GenerateAccessToken(a *domain.Account, key *rsa.PrivateKey, exp int64) (string, error) {
+// GenerateAccessToken signs the token with the key, its
+// kid header tells which key to verify the token with
+func GenerateAccessToken(a *domain.Account, key *Key, exp int64) (string, error) {
 	unixTime := time.Now().Unix()
 	tokenExp := unixTime + exp // 60 minutes from current time
 
+	// the jti lets a single access token be revoked before it expires
+	tokenID, err := uuid.NewRandom()
+	if err != nil {
+		log.Println("Failed to generate access token ID")
+		return "", err
+	}
+
 	claims := AccessTokenTokenCustomClaims{
 		Account: a,
 		StandardClaims: jwt.StandardClaims{
 			IssuedAt:  unixTime,
 			ExpiresAt: tokenExp,
+			Id:        tokenID.String(),
 		},
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
-	ss, err := token.SignedString(key)
+	token.Header["kid"] = key.ID
+	ss, err := token.SignedString(key.Private)
 
 	if err != nil {
 		log.Println("Failed to sign id token string")
@@ -46,11 +58,15 @@ func GenerateAccessToken(a *domain.Account, key *rsa.PrivateKey, exp int64) (str
 }
 
 type RefreshTokenCustomClaims struct {
-	UID uuid.UUID `json:"uid"`
+	UID      uuid.UUID `json:"uid"`
+	FamilyID uuid.UUID `json:"fam"`
 	jwt.StandardClaims
 }
 
-func GenerateRefreshToken(uid uuid.UUID, key string, exp int64) (*RefreshToken, error) {
+// GenerateRefreshToken mints a refresh token belonging to the given
+// token family. Every rotation of a refresh token stays in the family
+// of the sign in it originated from
+func GenerateRefreshToken(uid uuid.UUID, familyID uuid.UUID, key string, exp int64) (*RefreshToken, error) {
 	currentTime := time.Now()
 	tokenExp := currentTime.Add(time.Duration(exp) * time.Second)
 	tokenID, err := uuid.NewRandom() // v4 uuid in the google uuid lib
@@ -61,7 +77,8 @@ func GenerateRefreshToken(uid uuid.UUID, key string, exp int64) (*RefreshToken,
 	}
 
 	claims := RefreshTokenCustomClaims{
-		UID: uid,
+		UID:      uid,
+		FamilyID: familyID,
 		StandardClaims: jwt.StandardClaims{
 			IssuedAt:  currentTime.Unix(),
 			ExpiresAt: tokenExp.Unix(),
@@ -80,15 +97,17 @@ func GenerateRefreshToken(uid uuid.UUID, key string, exp int64) (*RefreshToken,
 	return &RefreshToken{
 		SS:        ss,
 		ID:        tokenID.String(),
+		FamilyID:  familyID.String(),
 		ExpiresIn: tokenExp.Sub(currentTime),
 	}, nil
 }
 
-func ValidateAccessToken(tokenString string, key *rsa.PublicKey) (*AccessTokenTokenCustomClaims, error) {
+// ValidateAccessToken verifies the token with the key of its kid
+func ValidateAccessToken(tokenString string, keys *Keyring) (*AccessTokenTokenCustomClaims, error) {
 	claims := &AccessTokenTokenCustomClaims{}
 
 	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-		return key, nil
+		return verificationKey(token, keys)
 	})
 
 	// For now we'll just return the error and handle logging in service level
@@ -109,6 +128,24 @@ func ValidateAccessToken(tokenString string, key *rsa.PublicKey) (*AccessTokenTo
 	return claims, nil
 }
 
+func verificationKey(token *jwt.Token, keys *Keyring) (*rsa.PublicKey, error) {
+	if token.Method != jwt.SigningMethodRS256 {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+
+	// tokens signed before keys had a kid were signed with the signing key
+	kid, ok := token.Header["kid"].(string)
+	if !ok {
+		return keys.SigningKey().Public, nil
+	}
+
+	key, ok := keys.PublicKey(kid)
+	if !ok {
+		return nil, fmt.Errorf("unknown kid: %s", kid)
+	}
+	return key, nil
+}
+
 // validateRefreshToken uses the secret key to validate a refresh token
 func ValidateRefreshToken(tokenString string, key string) (*RefreshTokenCustomClaims, error) {
 	claims := &RefreshTokenCustomClaims{}
@@ -0,0 +1,49 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/whuangz/go-example/go-api/domain"
+)
+
+type outboxMailer struct {
+	dir  string
+	from string
+}
+
+// NewOutboxMailer creates a mailer which writes every message as an
+// .eml file to a local outbox directory instead of delivering it
+func NewOutboxMailer(dir string, from string) domain.Mailer {
+	return &outboxMailer{dir: dir, from: from}
+}
+
+func (m *outboxMailer) Send(ctx context.Context, msg *domain.Message) error {
+	if err := os.MkdirAll(m.dir, 0755); err != nil {
+		return fmt.Errorf("could not create outbox directory: %w", err)
+	}
+
+	now := time.Now()
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", m.from)
+	fmt.Fprintf(&buf, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&buf, "Subject: %s\r\n", msg.Subject)
+	fmt.Fprintf(&buf, "Date: %s\r\n", now.Format(time.RFC1123Z))
+	fmt.Fprintf(&buf, "Content-Type: text/plain; charset=utf-8\r\n")
+	fmt.Fprintf(&buf, "\r\n%s\r\n", msg.Body)
+
+	// prefixed with the time so the outbox lists in sending order
+	name := fmt.Sprintf("%s-%s.eml", now.UTC().Format("20060102T150405.000000000Z"), uuid.New())
+	if err := ioutil.WriteFile(filepath.Join(m.dir, name), buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("could not write message to outbox: %w", err)
+	}
+
+	return nil
+}
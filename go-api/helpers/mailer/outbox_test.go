@@ -0,0 +1,38 @@
+package mailer
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/whuangz/go-example/go-api/domain"
+)
+
+func TestOutboxMailer(t *testing.T) {
+	dir := t.TempDir()
+	m := NewOutboxMailer(filepath.Join(dir, "outbox"), "no-reply@example.com")
+
+	msg := &domain.Message{
+		To:      "whuangz@gmail.com",
+		Subject: "Reset your password",
+		Body:    "http://localhost:8080/reset-password?token=abc",
+	}
+
+	assert.NoError(t, m.Send(context.Background(), msg))
+	assert.NoError(t, m.Send(context.Background(), msg))
+
+	files, err := ioutil.ReadDir(filepath.Join(dir, "outbox"))
+	assert.NoError(t, err)
+	assert.Len(t, files, 2)
+
+	content, err := ioutil.ReadFile(filepath.Join(dir, "outbox", files[0].Name()))
+	assert.NoError(t, err)
+	assert.True(t, strings.HasSuffix(files[0].Name(), ".eml"))
+	assert.Contains(t, string(content), "From: no-reply@example.com\r\n")
+	assert.Contains(t, string(content), "To: whuangz@gmail.com\r\n")
+	assert.Contains(t, string(content), "Subject: Reset your password\r\n")
+	assert.Contains(t, string(content), "\r\n\r\nhttp://localhost:8080/reset-password?token=abc\r\n")
+}
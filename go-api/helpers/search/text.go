@@ -0,0 +1,117 @@
+package search
+
+import (
+	"html"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Token is a term of a text along with its byte offsets
+type Token struct {
+	Term  string
+	Start int
+	End   int
+}
+
+// Tokenize splits the text on anything but letters and digits
+// and lowercases the terms
+func Tokenize(text string) []Token {
+	var tokens []Token
+	start := -1
+
+	for i, r := range text {
+		isTermRune := unicode.IsLetter(r) || unicode.IsDigit(r)
+		if isTermRune && start < 0 {
+			start = i
+		} else if !isTermRune && start >= 0 {
+			tokens = append(tokens, Token{Term: strings.ToLower(text[start:i]), Start: start, End: i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		tokens = append(tokens, Token{Term: strings.ToLower(text[start:]), Start: start, End: len(text)})
+	}
+
+	return tokens
+}
+
+// Terms returns the distinct terms of the text in order
+func Terms(text string) []string {
+	seen := map[string]bool{}
+	var terms []string
+
+	for _, t := range Tokenize(text) {
+		if !seen[t.Term] {
+			seen[t.Term] = true
+			terms = append(terms, t.Term)
+		}
+	}
+	return terms
+}
+
+// Highlight returns an excerpt of about maxLength bytes of the text around
+// the first term found, with every term found wrapped in <mark> tags. The
+// rest of the text is HTML escaped so the snippet can be rendered as is
+func Highlight(text string, terms []string, maxLength int) string {
+	wanted := map[string]bool{}
+	for _, term := range terms {
+		wanted[term] = true
+	}
+
+	tokens := Tokenize(text)
+	first := -1
+	for i, t := range tokens {
+		if wanted[t.Term] {
+			first = i
+			break
+		}
+	}
+
+	// start a little before the first match, on a term boundary
+	start := 0
+	if first >= 0 && len(text) > maxLength && tokens[first].Start > maxLength/4 {
+		start = tokens[first].Start
+		for i := first - 1; i >= 0 && tokens[first].Start-tokens[i].Start <= maxLength/4; i-- {
+			start = tokens[i].Start
+		}
+	}
+
+	end := len(text)
+	if end-start > maxLength {
+		end = start + maxLength
+		// don't cut a term or a rune in half
+		for _, t := range tokens {
+			if t.Start < end && t.End > end {
+				end = t.Start
+				break
+			}
+		}
+		for end > start && !utf8.RuneStart(text[end]) {
+			end--
+		}
+	}
+
+	var b strings.Builder
+	pos := start
+	for _, t := range tokens {
+		if t.Start < start || t.End > end || !wanted[t.Term] {
+			continue
+		}
+		b.WriteString(html.EscapeString(text[pos:t.Start]))
+		b.WriteString("<mark>")
+		b.WriteString(html.EscapeString(text[t.Start:t.End]))
+		b.WriteString("</mark>")
+		pos = t.End
+	}
+	b.WriteString(html.EscapeString(text[pos:end]))
+
+	snippet := strings.TrimSpace(b.String())
+	if start > 0 {
+		snippet = "…" + snippet
+	}
+	if end < len(text) {
+		snippet += "…"
+	}
+	return snippet
+}
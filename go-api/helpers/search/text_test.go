@@ -0,0 +1,54 @@
+package search
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenize(t *testing.T) {
+	tokens := Tokenize("Go's net/http, Über 2021!")
+
+	var terms []string
+	for _, token := range tokens {
+		terms = append(terms, token.Term)
+	}
+
+	assert.Equal(t, []string{"go", "s", "net", "http", "über", "2021"}, terms)
+	assert.Equal(t, Token{Term: "über", Start: 15, End: 20}, tokens[4])
+}
+
+func TestTerms(t *testing.T) {
+	assert.Equal(t, []string{"go", "is", "fun"}, Terms("Go is fun, go!"))
+}
+
+func TestHighlight(t *testing.T) {
+	t.Run("Marks every term", func(t *testing.T) {
+		snippet := Highlight("Writing Go services with Gin and go-redis", []string{"go", "gin"}, 200)
+
+		assert.Equal(t, "Writing <mark>Go</mark> services with <mark>Gin</mark> and <mark>go</mark>-redis", snippet)
+	})
+
+	t.Run("Escapes the text", func(t *testing.T) {
+		snippet := Highlight("<script>alert('go')</script>", []string{"go"}, 200)
+
+		assert.Equal(t, "&lt;script&gt;alert(&#39;<mark>go</mark>&#39;)&lt;/script&gt;", snippet)
+	})
+
+	t.Run("Excerpt around the first match", func(t *testing.T) {
+		text := strings.Repeat("lorem ipsum ", 50) + "the gopher appears " + strings.Repeat("dolor sit ", 50)
+		snippet := Highlight(text, []string{"gopher"}, 80)
+
+		assert.True(t, strings.HasPrefix(snippet, "…"))
+		assert.True(t, strings.HasSuffix(snippet, "…"))
+		assert.Contains(t, snippet, "<mark>gopher</mark>")
+		assert.LessOrEqual(t, len(snippet), 80+len("<mark></mark>")+2*len("…"))
+	})
+
+	t.Run("No match", func(t *testing.T) {
+		snippet := Highlight("nothing to see here", []string{"gopher"}, 10)
+
+		assert.Equal(t, "nothing to…", snippet)
+	})
+}
@@ -0,0 +1,95 @@
+package slug
+
+import (
+	"strings"
+	"unicode"
+)
+
+// transliterations spells letters in ascii, letters missing
+// from it are kept as they are
+var transliterations = map[rune]string{}
+
+func init() {
+	for ascii, letters := range map[string]string{
+		// latin
+		"a": "àáâãäåāăą", "ae": "æ", "c": "çćĉċč", "d": "ďđð", "e": "èéêëēĕėęě",
+		"g": "ĝğġģ", "h": "ĥħ", "i": "ìíîïĩīĭįı", "ij": "ĳ", "j": "ĵ", "k": "ķ",
+		"l": "ĺļľŀł", "n": "ñńņňŉ", "o": "òóôõöøōŏő", "oe": "œ", "r": "ŕŗř",
+		"s": "śŝşšș", "ss": "ß", "t": "ţťŧț", "th": "þ", "u": "ùúûüũūŭůűų",
+		"w": "ŵ", "y": "ýÿŷ", "z": "źżž",
+		// cyrillic, the signs are dropped
+		"b": "б", "v": "в", "yo": "ё", "zh": "ж", "kh": "х", "ts": "ц",
+		"ch": "чχ", "sh": "ш", "shch": "щ", "yu": "ю", "ya": "я", "yi": "ї", "ye": "є",
+		"": "ъь",
+		// greek
+		"ps": "ψ", "x": "ξ", "f": "φ",
+	} {
+		for _, r := range letters {
+			transliterations[r] = ascii
+		}
+	}
+
+	// letters of cyrillic and greek spelled with a single ascii letter
+	for _, pair := range []string{
+		"аa", "гg", "дd", "еe", "зz", "иi", "йy", "кk", "лl", "мm", "нn", "оo", "пp",
+		"рr", "сs", "тt", "уu", "фf", "ыy", "эe", "іi", "ґg",
+		"αa", "άa", "βv", "γg", "δd", "εe", "έe", "ζz", "ηi", "ήi", "ιi", "ίi", "κk",
+		"λl", "μm", "νn", "οo", "όo", "πp", "ρr", "σs", "ςs", "τt", "υy", "ύy",
+		"ωo", "ώo",
+	} {
+		runes := []rune(pair)
+		transliterations[runes[0]] = string(runes[1])
+	}
+	transliterations['θ'] = "th"
+}
+
+// Make lowercases and transliterates the text, then joins its runs of
+// letters and digits with dashes. Names differing only by case,
+// accents or punctuation share a slug
+func Make(text string) string {
+	var b strings.Builder
+	dash := false
+
+	for _, r := range strings.ToLower(text) {
+		ascii, transliterated := transliterations[r]
+		if !transliterated && !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			dash = true
+			continue
+		}
+
+		if dash && b.Len() > 0 {
+			b.WriteByte('-')
+		}
+		dash = false
+
+		if transliterated {
+			b.WriteString(ascii)
+		} else {
+			b.WriteRune(r)
+		}
+	}
+
+	return b.String()
+}
+
+// Truncate cuts the slug to at most maxLength bytes, between two words
+// when it can
+func Truncate(slug string, maxLength int) string {
+	if len(slug) <= maxLength {
+		return slug
+	}
+
+	cut := strings.LastIndexByte(slug[:maxLength+1], '-')
+	if cut <= 0 {
+		// a single long word, cut on a rune
+		cut = maxLength
+		for cut > 0 && !isRuneStart(slug[cut]) {
+			cut--
+		}
+	}
+	return strings.TrimRight(slug[:cut], "-")
+}
+
+func isRuneStart(b byte) bool {
+	return b&0xC0 != 0x80
+}
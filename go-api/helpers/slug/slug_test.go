@@ -0,0 +1,35 @@
+package slug
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMake(t *testing.T) {
+	slugs := map[string]string{
+		"Go":                "go",
+		"  Web Development": "web-development",
+		"C++ / Rust!":       "c-rust",
+		"already-a-slug":    "already-a-slug",
+		"Ünïcode Tägs":      "unicode-tags",
+		"Straße & Œuvre":    "strasse-oeuvre",
+		"Привет, мир":       "privet-mir",
+		"Объём":             "obyom",
+		"Καλημέρα κόσμε":    "kalimera-kosme",
+		"日本語のブログ":           "日本語のブログ",
+		"--":                "",
+	}
+
+	for text, want := range slugs {
+		assert.Equal(t, want, Make(text), text)
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	assert.Equal(t, "hello-world", Truncate("hello-world", 20))
+	assert.Equal(t, "hello-big", Truncate("hello-big-world", 12))
+	assert.Equal(t, "hello-big", Truncate("hello-big-world", 9))
+	assert.Equal(t, "abcde", Truncate("abcdefghij", 5))
+	assert.Equal(t, "日", Truncate("日本", 4))
+}
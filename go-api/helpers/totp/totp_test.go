@@ -0,0 +1,67 @@
+package totp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerate(t *testing.T) {
+	// SHA1 test vectors of RFC 6238, appendix B
+	key := []byte("12345678901234567890")
+	vectors := map[int64]string{
+		59:          "94287082",
+		1111111109:  "07081804",
+		1111111111:  "14050471",
+		1234567890:  "89005924",
+		2000000000:  "69279037",
+		20000000000: "65353130",
+	}
+
+	for unix, expected := range vectors {
+		assert.Equal(t, expected, generate(key, uint64(Step(time.Unix(unix, 0))), 8))
+	}
+}
+
+func TestValidate(t *testing.T) {
+	secret, err := GenerateSecret()
+	assert.NoError(t, err)
+
+	now := time.Unix(1620000000, 0)
+
+	t.Run("Current code", func(t *testing.T) {
+		code, err := Code(secret, now)
+		assert.NoError(t, err)
+		assert.Len(t, code, Digits)
+
+		step, ok := Validate(secret, code, now, 1)
+		assert.True(t, ok)
+		assert.Equal(t, Step(now), step)
+	})
+
+	t.Run("Within skew", func(t *testing.T) {
+		code, _ := Code(secret, now.Add(-Period*time.Second))
+
+		step, ok := Validate(secret, code, now, 1)
+		assert.True(t, ok)
+		assert.Equal(t, Step(now)-1, step)
+	})
+
+	t.Run("Outside of skew", func(t *testing.T) {
+		code, _ := Code(secret, now.Add(-2*Period*time.Second))
+
+		_, ok := Validate(secret, code, now, 1)
+		assert.False(t, ok)
+	})
+
+	t.Run("Malformed code", func(t *testing.T) {
+		_, ok := Validate(secret, "12345", now, 1)
+		assert.False(t, ok)
+	})
+}
+
+func TestURI(t *testing.T) {
+	uri := URI("go-example", "whuangz@gmail.com", "JBSWY3DPEHPK3PXP")
+	assert.Equal(t, "otpauth://totp/go-example:whuangz@gmail.com?algorithm=SHA1&digits=6&issuer=go-example&period=30&secret=JBSWY3DPEHPK3PXP", uri)
+}
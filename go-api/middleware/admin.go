@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"crypto/subtle"
+
+	"github.com/gin-gonic/gin"
+	"github.com/whuangz/go-example/go-api/domain"
+)
+
+// AdminKey only lets through requests presenting
+// the admin key in the X-Admin-Key header
+func AdminKey(key string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !validAdminKey(c, key) {
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// AdminKeyOrAuthUser authenticates requests presenting the admin key in
+// the X-Admin-Key header as an account holding the admin role, which is
+// how the first admins are granted their role. Other requests are
+// authenticated by auth, admin routes check the permissions of either
+// with Require
+func AdminKeyOrAuthUser(key string, auth gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetHeader("X-Admin-Key") == "" {
+			auth(c)
+			return
+		}
+
+		if !validAdminKey(c, key) {
+			return
+		}
+
+		c.Set("account", &domain.Account{Name: "admin key", Roles: []string{domain.AdminRole}})
+		c.Next()
+	}
+}
+
+// validAdminKey aborts the request unless it presents the admin key,
+// no key is valid when none is configured
+func validAdminKey(c *gin.Context, key string) bool {
+	presented := c.GetHeader("X-Admin-Key")
+
+	if key == "" || subtle.ConstantTimeCompare([]byte(presented), []byte(key)) != 1 {
+		err := domain.NewAuthorization("Provided admin key is invalid")
+		c.JSON(err.Status(), gin.H{
+			"error": err,
+		})
+		c.Abort()
+		return false
+	}
+	return true
+}
@@ -0,0 +1,95 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/whuangz/go-example/go-api/domain"
+)
+
+func TestAdminKey(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cases := []struct {
+		name      string
+		key       string
+		presented string
+		status    int
+	}{
+		{"Valid key", "anAdminKey", "anAdminKey", http.StatusOK},
+		{"Invalid key", "anAdminKey", "notTheKey", http.StatusUnauthorized},
+		{"Missing key", "anAdminKey", "", http.StatusUnauthorized},
+		{"No key configured", "", "", http.StatusUnauthorized},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			_, r := gin.CreateTestContext(rr)
+
+			r.DELETE("/api/admin/lockouts/:email", AdminKey(tc.key), func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			request, _ := http.NewRequest(http.MethodDelete, "/api/admin/lockouts/whuangz@gmail.com", http.NoBody)
+			if tc.presented != "" {
+				request.Header.Set("X-Admin-Key", tc.presented)
+			}
+			r.ServeHTTP(rr, request)
+
+			assert.Equal(t, tc.status, rr.Code)
+		})
+	}
+}
+
+func TestAdminKeyOrAuthUser(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// stands in for AuthUser, signing in an account holding the reader role
+	auth := func(c *gin.Context) {
+		if c.GetHeader("Authorization") == "" {
+			c.AbortWithStatus(http.StatusUnauthorized)
+			return
+		}
+		c.Set("account", &domain.Account{Roles: []string{domain.ReaderRole}})
+		c.Next()
+	}
+
+	cases := []struct {
+		name          string
+		presented     string
+		authorization string
+		status        int
+	}{
+		{"Admin key", "anAdminKey", "", http.StatusOK},
+		{"Invalid admin key", "notTheKey", "Bearer token", http.StatusUnauthorized},
+		{"Account without the permission", "", "Bearer token", http.StatusForbidden},
+		{"Not authenticated", "", "", http.StatusUnauthorized},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			_, r := gin.CreateTestContext(rr)
+
+			r.PUT("/api/admin/accounts/:uid/roles/:role", AdminKeyOrAuthUser("anAdminKey", auth),
+				Require(domain.ManageRolesPermission), func(c *gin.Context) {
+					c.Status(http.StatusOK)
+				})
+
+			request, _ := http.NewRequest(http.MethodPut, "/api/admin/accounts/1/roles/admin", http.NoBody)
+			if tc.presented != "" {
+				request.Header.Set("X-Admin-Key", tc.presented)
+			}
+			if tc.authorization != "" {
+				request.Header.Set("Authorization", tc.authorization)
+			}
+			r.ServeHTTP(rr, request)
+
+			assert.Equal(t, tc.status, rr.Code)
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"net/http"
 	"strings"
 
 	"github.com/gin-gonic/gin"
@@ -12,7 +13,11 @@ type authHeader struct {
 	AccessToken string `header:"Authorization"`
 }
 
-func AuthUser(s domain.TokenService) gin.HandlerFunc {
+// AuthUser authenticates the account from the access token of the request.
+// When apiKeys is given, an Authorization: ApiKey {key} header is accepted
+// too. With requireVerifiedEmail, accounts which haven't verified their
+// email can only make read requests
+func AuthUser(s domain.TokenService, apiKeys domain.APIKeyService, requireVerifiedEmail bool) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		h := authHeader{}
 
@@ -22,10 +27,10 @@ func AuthUser(s domain.TokenService) gin.HandlerFunc {
 
 				for _, err := range errs {
 					invalidArgs = append(invalidArgs, domain.InvalidArgument{
-						err.Field(),
-						err.Value().(string),
-						err.Tag(),
-						err.Param(),
+						Field: err.Field(),
+						Value: err.Value().(string),
+						Tag:   err.Tag(),
+						Param: err.Param(),
 					})
 				}
 
@@ -48,10 +53,19 @@ func AuthUser(s domain.TokenService) gin.HandlerFunc {
 			return
 		}
 
+		if apiKeys != nil && strings.HasPrefix(h.AccessToken, "ApiKey ") {
+			authAPIKey(c, apiKeys, strings.TrimPrefix(h.AccessToken, "ApiKey "), requireVerifiedEmail)
+			return
+		}
+
 		accTokenHeader := strings.Split(h.AccessToken, "Bearer ")
 
 		if len(accTokenHeader) < 2 {
-			err := domain.NewAuthorization("Authorization header should with format `Bearer {token}`")
+			msg := "Authorization header should with format `Bearer {token}`"
+			if apiKeys != nil {
+				msg = "Authorization header should with format `Bearer {token}` or `ApiKey {key}`"
+			}
+			err := domain.NewAuthorization(msg)
 
 			c.JSON(err.Status(), gin.H{
 				"error": err,
@@ -60,18 +74,102 @@ func AuthUser(s domain.TokenService) gin.HandlerFunc {
 			return
 		}
 
-		acc, err := s.ValidateAccessToken(accTokenHeader[1])
+		acc, err := s.ValidateAccessToken(c.Request.Context(), accTokenHeader[1])
 		if err != nil {
-			err := domain.NewAuthorization("Provided token is invalid")
-			c.JSON(err.Status(), gin.H{
-				"error": err,
-			})
-			c.Abort()
+			abortAuth(c, err, "Provided token is invalid")
+			return
+		}
+
+		if !allowedVerifiedEmail(c, acc, requireVerifiedEmail) {
 			return
 		}
 
 		c.Set("account", acc)
+		// kept so handlers can revoke the token the request was made with
+		c.Set("access_token", accTokenHeader[1])
 
 		c.Next()
 	}
 }
+
+// abortAuth answers an authorization failure with the reason given, other
+// errors, like the store being unreachable, go through with their status
+func abortAuth(c *gin.Context, err error, reason string) {
+	if domain.Status(err) != http.StatusUnauthorized {
+		c.JSON(domain.Status(err), gin.H{
+			"error": err,
+		})
+		c.Abort()
+		return
+	}
+
+	authErr := domain.NewAuthorization(reason)
+	c.JSON(authErr.Status(), gin.H{
+		"error": authErr,
+	})
+	c.Abort()
+}
+
+// OptionalAuthUser authenticates the account like AuthUser when the request
+// has an Authorization header, requests without one go through anonymously
+func OptionalAuthUser(s domain.TokenService, apiKeys domain.APIKeyService, requireVerifiedEmail bool) gin.HandlerFunc {
+	auth := AuthUser(s, apiKeys, requireVerifiedEmail)
+	return func(c *gin.Context) {
+		if c.GetHeader("Authorization") == "" {
+			c.Next()
+			return
+		}
+		auth(c)
+	}
+}
+
+// authAPIKey authenticates the account of an API key, which must have
+// been granted the scope of the request. Keys with the write scope can read
+func authAPIKey(c *gin.Context, apiKeys domain.APIKeyService, key string, requireVerifiedEmail bool) {
+	acc, apiKey, err := apiKeys.Authenticate(c.Request.Context(), key)
+	if err != nil {
+		abortAuth(c, err, "Provided API key is invalid")
+		return
+	}
+
+	allowed := apiKey.HasScope(domain.APIKeyWriteScope)
+	if isReadMethod(c.Request.Method) {
+		allowed = allowed || apiKey.HasScope(domain.APIKeyReadScope)
+	}
+
+	if !allowed {
+		err := domain.NewForbidden("API key hasn't been granted the scope of this action")
+		c.JSON(err.Status(), gin.H{
+			"error": err,
+		})
+		c.Abort()
+		return
+	}
+
+	if !allowedVerifiedEmail(c, acc, requireVerifiedEmail) {
+		return
+	}
+
+	c.Set("account", acc)
+	c.Set("api_key", apiKey)
+
+	c.Next()
+}
+
+// allowedVerifiedEmail rejects write requests of
+// unverified accounts when a verified email is required
+func allowedVerifiedEmail(c *gin.Context, acc *domain.Account, requireVerifiedEmail bool) bool {
+	if requireVerifiedEmail && !acc.EmailVerifiedAt.Valid && !isReadMethod(c.Request.Method) {
+		err := domain.NewForbidden("Email has to be verified to perform this action")
+		c.JSON(err.Status(), gin.H{
+			"error": err,
+		})
+		c.Abort()
+		return false
+	}
+	return true
+}
+
+func isReadMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
@@ -0,0 +1,104 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/whuangz/go-example/go-api/domain"
+)
+
+// KeyFunc identifies who a request is limited as,
+// an empty key lets the next KeyFunc decide
+type KeyFunc func(c *gin.Context) string
+
+// KeyByIP limits by the client IP
+func KeyByIP(c *gin.Context) string {
+	return "ip:" + c.ClientIP()
+}
+
+// KeyByAccount limits by the account of the access token. The account is
+// taken from the context when AuthUser ran first, otherwise the token is
+// validated here. Requests without a valid token aren't keyed
+func KeyByAccount(s domain.TokenService) KeyFunc {
+	return func(c *gin.Context) string {
+		if account, exists := c.Get("account"); exists {
+			return "account:" + account.(*domain.Account).UID.String()
+		}
+
+		accTokenHeader := strings.Split(c.GetHeader("Authorization"), "Bearer ")
+		if len(accTokenHeader) < 2 {
+			return ""
+		}
+
+		acc, err := s.ValidateAccessToken(c.Request.Context(), accTokenHeader[1])
+		if err != nil {
+			return ""
+		}
+		return "account:" + acc.UID.String()
+	}
+}
+
+// KeyByAPIKey limits by the API key of the Authorization: ApiKey {key}
+// header. Only a hash of the key is kept in the limiter
+func KeyByAPIKey(c *gin.Context) string {
+	apiKeyHeader := strings.Split(c.GetHeader("Authorization"), "ApiKey ")
+	if len(apiKeyHeader) < 2 || apiKeyHeader[1] == "" {
+		return ""
+	}
+
+	sum := sha256.Sum256([]byte(apiKeyHeader[1]))
+	return "apikey:" + hex.EncodeToString(sum[:])
+}
+
+// RateLimit takes every request from the limiter under the first key found
+// by keys, falling back to the IP. The limits of each route group are kept
+// apart by name. The state of the limit is sent in the RateLimit-Limit,
+// RateLimit-Remaining and RateLimit-Reset headers, and requests over the
+// limit are rejected with a 429
+func RateLimit(limiter domain.RateLimiter, name string, keys ...KeyFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := ""
+		for _, keyFunc := range keys {
+			if key = keyFunc(c); key != "" {
+				break
+			}
+		}
+		if key == "" {
+			key = KeyByIP(c)
+		}
+
+		rl, err := limiter.Allow(c.Request.Context(), name+":"+key)
+		if err != nil {
+			// failing open, an unavailable limiter shouldn't take the api down
+			log.Printf("Could not rate limit %s for key: %s: %v\n", name, key, err)
+			c.Next()
+			return
+		}
+
+		c.Header("RateLimit-Limit", strconv.FormatInt(rl.Limit, 10))
+		c.Header("RateLimit-Remaining", strconv.FormatInt(rl.Remaining, 10))
+		c.Header("RateLimit-Reset", strconv.FormatInt(ceilSeconds(rl.Reset), 10))
+
+		if !rl.Allowed {
+			err := domain.NewTooManyRequests(rl.RetryAfter)
+			c.Header("Retry-After", strconv.FormatInt(err.RetryAfter, 10))
+			c.JSON(err.Status(), gin.H{
+				"error": err,
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+func ceilSeconds(d time.Duration) int64 {
+	return int64(math.Ceil(d.Seconds()))
+}
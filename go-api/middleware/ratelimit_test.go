@@ -0,0 +1,163 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/whuangz/go-example/go-api/domain"
+	mocks "github.com/whuangz/go-example/go-api/mocks/account"
+	"github.com/whuangz/go-example/go-api/repository"
+)
+
+func TestRateLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	newRouter := func(limiter domain.RateLimiter, keys ...KeyFunc) *gin.Engine {
+		_, r := gin.CreateTestContext(httptest.NewRecorder())
+		r.GET("/api/post", RateLimit(limiter, "blog", keys...), func(c *gin.Context) {
+			c.Status(http.StatusOK)
+		})
+		return r
+	}
+
+	newRequest := func(ip string) *http.Request {
+		request, _ := http.NewRequest(http.MethodGet, "/api/post", http.NoBody)
+		request.RemoteAddr = ip + ":1234"
+		return request
+	}
+
+	t.Run("Sliding window", func(t *testing.T) {
+		r := newRouter(repository.NewMemorySlidingWindowLimiter(2, time.Minute))
+
+		for i := 1; i >= 0; i-- {
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, newRequest("10.0.0.1"))
+
+			assert.Equal(t, http.StatusOK, rr.Code)
+			assert.Equal(t, "2", rr.Header().Get("RateLimit-Limit"))
+			assert.Equal(t, strconv.Itoa(i), rr.Header().Get("RateLimit-Remaining"))
+			assert.Equal(t, "60", rr.Header().Get("RateLimit-Reset"))
+		}
+
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, newRequest("10.0.0.1"))
+
+		var body struct {
+			Error domain.Error `json:"error"`
+		}
+		err := json.Unmarshal(rr.Body.Bytes(), &body)
+		assert.NoError(t, err)
+
+		assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+		assert.Equal(t, "0", rr.Header().Get("RateLimit-Remaining"))
+		assert.Equal(t, "60", rr.Header().Get("Retry-After"))
+		assert.Equal(t, domain.TooManyRequests, body.Error.Type)
+		assert.Equal(t, int64(60), body.Error.RetryAfter)
+
+		// other clients have their own limit
+		rr = httptest.NewRecorder()
+		r.ServeHTTP(rr, newRequest("10.0.0.2"))
+		assert.Equal(t, http.StatusOK, rr.Code)
+	})
+
+	t.Run("Token bucket", func(t *testing.T) {
+		r := newRouter(repository.NewMemoryTokenBucketLimiter(3, 3*time.Minute))
+
+		for i := 0; i < 3; i++ {
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, newRequest("10.0.0.1"))
+			assert.Equal(t, http.StatusOK, rr.Code)
+		}
+
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, newRequest("10.0.0.1"))
+
+		assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+		// a token is added every minute, the bucket is full after 3
+		assert.Equal(t, "60", rr.Header().Get("Retry-After"))
+		assert.Equal(t, "180", rr.Header().Get("RateLimit-Reset"))
+	})
+
+	t.Run("Keyed by account", func(t *testing.T) {
+		uid, _ := uuid.NewRandom()
+		mockTokenService := new(mocks.MockTokenService)
+		mockTokenService.On("ValidateAccessToken", mock.Anything, "validTokenString").Return(&domain.Account{UID: uid}, nil)
+		mockTokenService.On("ValidateAccessToken", mock.Anything, "invalidTokenString").Return(nil, domain.NewAuthorization("invalid"))
+
+		r := newRouter(repository.NewMemorySlidingWindowLimiter(1, time.Minute), KeyByAccount(mockTokenService))
+
+		// the same account from different IPs
+		for i, ip := range []string{"10.0.0.1", "10.0.0.2"} {
+			request := newRequest(ip)
+			request.Header.Set("Authorization", "Bearer validTokenString")
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, request)
+
+			if i == 0 {
+				assert.Equal(t, http.StatusOK, rr.Code)
+			} else {
+				assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+			}
+		}
+
+		// an invalid token falls back to the IP
+		request := newRequest("10.0.0.3")
+		request.Header.Set("Authorization", "Bearer invalidTokenString")
+
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, request)
+		assert.Equal(t, http.StatusOK, rr.Code)
+	})
+
+	t.Run("Keyed by API key", func(t *testing.T) {
+		r := newRouter(repository.NewMemorySlidingWindowLimiter(1, time.Minute), KeyByAPIKey)
+
+		for _, key := range []string{"firstKey", "secondKey"} {
+			request := newRequest("10.0.0.1")
+			request.Header.Set("Authorization", "ApiKey "+key)
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, request)
+			assert.Equal(t, http.StatusOK, rr.Code)
+		}
+	})
+
+	t.Run("Fails open", func(t *testing.T) {
+		r := newRouter(failingLimiter{})
+
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, newRequest("10.0.0.1"))
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Empty(t, rr.Header().Get("RateLimit-Limit"))
+	})
+
+	t.Run("Falls back to the in-process limiter", func(t *testing.T) {
+		r := newRouter(repository.NewFallbackRateLimiter(failingLimiter{}, repository.NewMemorySlidingWindowLimiter(1, time.Minute)))
+
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, newRequest("10.0.0.1"))
+		assert.Equal(t, http.StatusOK, rr.Code)
+
+		rr = httptest.NewRecorder()
+		r.ServeHTTP(rr, newRequest("10.0.0.1"))
+		assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+	})
+}
+
+type failingLimiter struct{}
+
+func (failingLimiter) Allow(ctx context.Context, key string) (*domain.RateLimit, error) {
+	return nil, errors.New("redis is unreachable")
+}
@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/whuangz/go-example/go-api/domain"
+)
+
+// Require only lets through accounts holding every one of the
+// permissions through their roles. It has to run after AuthUser
+func Require(permissions ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		account, exists := c.Get("account")
+		if !exists {
+			err := domain.NewAuthorization("unauthorized")
+			c.JSON(err.Status(), gin.H{
+				"error": err,
+			})
+			c.Abort()
+			return
+		}
+
+		for _, permission := range permissions {
+			if !account.(*domain.Account).HasPermission(permission) {
+				err := domain.NewForbidden("Account doesn't have the permission to perform this action")
+				c.JSON(err.Status(), gin.H{
+					"error": err,
+				})
+				c.Abort()
+				return
+			}
+		}
+
+		c.Next()
+	}
+}
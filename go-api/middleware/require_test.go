@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/whuangz/go-example/go-api/domain"
+)
+
+func TestRequire(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cases := []struct {
+		name        string
+		account     *domain.Account
+		permissions []string
+		status      int
+	}{
+		{"Granted by role", &domain.Account{Roles: []string{domain.EditorRole}}, []string{domain.EditAnyPostPermission}, http.StatusOK},
+		{"Granted by any role", &domain.Account{Roles: []string{domain.ReaderRole, domain.AdminRole}}, []string{domain.ManageRolesPermission}, http.StatusOK},
+		{"Default role", &domain.Account{}, []string{domain.WritePostsPermission}, http.StatusOK},
+		{"Missing permission", &domain.Account{Roles: []string{domain.ReaderRole}}, []string{domain.WritePostsPermission}, http.StatusForbidden},
+		{"Missing one of the permissions", &domain.Account{Roles: []string{domain.AuthorRole}}, []string{domain.WritePostsPermission, domain.EditAnyPostPermission}, http.StatusForbidden},
+		{"Not authenticated", nil, []string{domain.WritePostsPermission}, http.StatusUnauthorized},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			_, r := gin.CreateTestContext(rr)
+
+			if tc.account != nil {
+				tc.account.UID = uuid.New()
+				r.Use(func(c *gin.Context) {
+					c.Set("account", tc.account)
+				})
+			}
+			r.DELETE("/api/post/:post_id", Require(tc.permissions...), func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			request, _ := http.NewRequest(http.MethodDelete, "/api/post/1", http.NoBody)
+			r.ServeHTTP(rr, request)
+
+			assert.Equal(t, tc.status, rr.Code)
+		})
+	}
+}
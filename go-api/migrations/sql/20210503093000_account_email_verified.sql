@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE `account` ADD COLUMN `email_verified_at` datetime DEFAULT NULL AFTER `website`;
+
+-- +goose Down
+ALTER TABLE `account` DROP COLUMN `email_verified_at`;
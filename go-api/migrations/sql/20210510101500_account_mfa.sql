@@ -0,0 +1,24 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS `account_mfa` (
+  `account_uid` varchar(40) NOT NULL,
+  `secret` varchar(255) NOT NULL,
+  `enabled_at` datetime DEFAULT NULL,
+  `last_used_step` BIGINT NOT NULL DEFAULT 0,
+  `updated_at` datetime DEFAULT NULL,
+  `created_at` datetime DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`account_uid`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8 COLLATE=utf8_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS `account_recovery_code` (
+  `id` INT NOT NULL AUTO_INCREMENT,
+  `account_uid` varchar(40) NOT NULL,
+  `code_hash` char(64) NOT NULL,
+  `used_at` datetime DEFAULT NULL,
+  `created_at` datetime DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  UNIQUE(`account_uid`, `code_hash`)
+) ENGINE=InnoDB AUTO_INCREMENT=1 DEFAULT CHARSET=utf8 COLLATE=utf8_unicode_ci;
+
+-- +goose Down
+DROP TABLE IF EXISTS `account_recovery_code`;
+DROP TABLE IF EXISTS `account_mfa`;
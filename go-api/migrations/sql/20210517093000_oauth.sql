@@ -0,0 +1,27 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS `oauth_client` (
+  `id` INT NOT NULL AUTO_INCREMENT,
+  `client_id` varchar(64) NOT NULL,
+  `secret_hash` char(64) DEFAULT NULL,
+  `name` varchar(255) NOT NULL,
+  `redirect_uris` TEXT NOT NULL,
+  `scopes` varchar(255) NOT NULL,
+  `grant_types` varchar(255) NOT NULL,
+  `updated_at` datetime DEFAULT NULL,
+  `created_at` datetime DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  UNIQUE(`client_id`)
+) ENGINE=InnoDB AUTO_INCREMENT=1 DEFAULT CHARSET=utf8 COLLATE=utf8_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS `oauth_consent` (
+  `account_uid` varchar(40) NOT NULL,
+  `client_id` varchar(64) NOT NULL,
+  `scopes` varchar(255) NOT NULL,
+  `updated_at` datetime DEFAULT NULL,
+  `created_at` datetime DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`account_uid`, `client_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8 COLLATE=utf8_unicode_ci;
+
+-- +goose Down
+DROP TABLE IF EXISTS `oauth_consent`;
+DROP TABLE IF EXISTS `oauth_client`;
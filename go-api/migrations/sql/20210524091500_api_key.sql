@@ -0,0 +1,20 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS `api_key` (
+  `id` INT NOT NULL AUTO_INCREMENT,
+  `uid` varchar(40) NOT NULL,
+  `account_uid` varchar(40) NOT NULL,
+  `name` varchar(255) NOT NULL,
+  `prefix` varchar(16) NOT NULL,
+  `key_hash` char(64) NOT NULL,
+  `scopes` varchar(255) NOT NULL,
+  `expires_at` datetime DEFAULT NULL,
+  `last_used_at` datetime DEFAULT NULL,
+  `created_at` datetime DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  UNIQUE(`uid`),
+  UNIQUE(`key_hash`),
+  INDEX(`account_uid`)
+) ENGINE=InnoDB AUTO_INCREMENT=1 DEFAULT CHARSET=utf8 COLLATE=utf8_unicode_ci;
+
+-- +goose Down
+DROP TABLE IF EXISTS `api_key`;
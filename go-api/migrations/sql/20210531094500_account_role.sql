@@ -0,0 +1,14 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS `account_role` (
+  `account_uid` varchar(40) NOT NULL,
+  `role` varchar(20) NOT NULL,
+  `created_at` datetime DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`account_uid`, `role`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8 COLLATE=utf8_unicode_ci;
+
+-- the account an author belongs to, owning its posts
+ALTER TABLE `author` ADD COLUMN `account_uid` varchar(40) DEFAULT NULL AFTER `id`, ADD UNIQUE(`account_uid`);
+
+-- +goose Down
+ALTER TABLE `author` DROP INDEX `account_uid`, DROP COLUMN `account_uid`;
+DROP TABLE IF EXISTS `account_role`;
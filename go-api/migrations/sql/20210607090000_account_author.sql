@@ -0,0 +1,11 @@
+-- +goose Up
+-- every account gets an author to publish its posts under,
+-- accounts signing up later get theirs on their first post
+INSERT INTO `author` (`account_uid`, `username`, `email`, `created_at`)
+SELECT `account`.`uid`, LEFT(COALESCE(NULLIF(`account`.`name`, ''), SUBSTRING_INDEX(`account`.`email`, '@', 1)), 20), `account`.`email`, NOW()
+FROM `account` LEFT JOIN `author` ON `author`.`account_uid` = `account`.`uid`
+WHERE `author`.`id` IS NULL;
+
+-- +goose Down
+-- authors of accounts without posts can be provisioned again
+DELETE FROM `author` WHERE `account_uid` IS NOT NULL AND `id` NOT IN (SELECT DISTINCT `author_id` FROM `post`);
@@ -0,0 +1,6 @@
+-- +goose Up
+-- posts are paged on (created_at, id)
+ALTER TABLE `post` ADD INDEX `created_at_id` (`created_at`, `id`);
+
+-- +goose Down
+ALTER TABLE `post` DROP INDEX `created_at_id`;
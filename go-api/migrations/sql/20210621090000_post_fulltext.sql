@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE `post` ADD FULLTEXT INDEX `post_search` (`title`, `content`);
+
+-- +goose Down
+ALTER TABLE `post` DROP INDEX `post_search`;
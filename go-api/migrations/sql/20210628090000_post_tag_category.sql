@@ -0,0 +1,39 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS `tag` (
+  `id` INT NOT NULL AUTO_INCREMENT,
+  `name` varchar(50) COLLATE utf8_unicode_ci NOT NULL,
+  `slug` varchar(50) COLLATE utf8_unicode_ci NOT NULL,
+  `created_at` datetime DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  UNIQUE (`slug`)
+) ENGINE=InnoDB AUTO_INCREMENT=1 DEFAULT CHARSET=utf8 COLLATE=utf8_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS `post_tag` (
+  `post_id` INT NOT NULL,
+  `tag_id` INT NOT NULL,
+  PRIMARY KEY (`post_id`, `tag_id`),
+  KEY (`tag_id`),
+  CONSTRAINT FOREIGN KEY (`post_id`) REFERENCES post(`id`) ON DELETE CASCADE,
+  CONSTRAINT FOREIGN KEY (`tag_id`) REFERENCES tag(`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8 COLLATE=utf8_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS `category` (
+  `id` INT NOT NULL AUTO_INCREMENT,
+  `parent_id` INT DEFAULT NULL,
+  `name` varchar(50) COLLATE utf8_unicode_ci NOT NULL,
+  `slug` varchar(50) COLLATE utf8_unicode_ci NOT NULL,
+  `created_at` datetime DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  UNIQUE (`slug`),
+  CONSTRAINT FOREIGN KEY (`parent_id`) REFERENCES category(`id`) ON DELETE CASCADE
+) ENGINE=InnoDB AUTO_INCREMENT=1 DEFAULT CHARSET=utf8 COLLATE=utf8_unicode_ci;
+
+-- posts of a deleted category are left without one
+ALTER TABLE `post` ADD COLUMN `category_id` INT DEFAULT NULL AFTER `author_id`,
+  ADD CONSTRAINT `post_category` FOREIGN KEY (`category_id`) REFERENCES category(`id`) ON DELETE SET NULL;
+
+-- +goose Down
+ALTER TABLE `post` DROP FOREIGN KEY `post_category`, DROP COLUMN `category_id`;
+DROP TABLE IF EXISTS `category`;
+DROP TABLE IF EXISTS `post_tag`;
+DROP TABLE IF EXISTS `tag`;
@@ -0,0 +1,13 @@
+-- +goose Up
+-- posts saved so far were public as soon as they were saved
+ALTER TABLE `post` ADD COLUMN `status` varchar(20) NOT NULL DEFAULT 'published' AFTER `category_id`,
+  ADD COLUMN `published_at` datetime DEFAULT NULL AFTER `status`,
+  ADD COLUMN `publish_at` datetime DEFAULT NULL AFTER `published_at`,
+  ADD INDEX `status_created_at_id` (`status`, `created_at`, `id`),
+  ADD INDEX `status_publish_at` (`status`, `publish_at`);
+
+UPDATE `post` SET `published_at` = `created_at`;
+
+-- +goose Down
+ALTER TABLE `post` DROP INDEX `status_publish_at`, DROP INDEX `status_created_at_id`,
+  DROP COLUMN `publish_at`, DROP COLUMN `published_at`, DROP COLUMN `status`;
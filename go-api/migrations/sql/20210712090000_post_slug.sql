@@ -0,0 +1,22 @@
+-- +goose Up
+-- only NULL while the post is being saved, before its slug is picked
+ALTER TABLE `post` ADD COLUMN `slug` varchar(100) COLLATE utf8_unicode_ci DEFAULT NULL AFTER `title`, ADD UNIQUE (`slug`);
+
+-- every slug a post ever had, old ones redirect to the post
+CREATE TABLE IF NOT EXISTS `post_slug` (
+  `slug` varchar(100) COLLATE utf8_unicode_ci NOT NULL,
+  `post_id` INT NOT NULL,
+  `created_at` datetime DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`slug`),
+  KEY (`post_id`),
+  CONSTRAINT FOREIGN KEY (`post_id`) REFERENCES post(`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8 COLLATE=utf8_unicode_ci;
+
+-- existing posts get the words of their title, suffixed with their id to be unique
+UPDATE `post` SET `slug` = CONCAT_WS('-',
+  NULLIF(TRIM(BOTH '-' FROM REGEXP_REPLACE(LOWER(`title`), '[^a-z0-9]+', '-')), ''), `id`);
+INSERT INTO `post_slug` (`slug`, `post_id`) SELECT `slug`, `id` FROM `post`;
+
+-- +goose Down
+DROP TABLE IF EXISTS `post_slug`;
+ALTER TABLE `post` DROP INDEX `slug`, DROP COLUMN `slug`;
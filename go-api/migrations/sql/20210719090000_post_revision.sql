@@ -0,0 +1,21 @@
+-- +goose Up
+-- the title and content of every save of a post, numbered from 1 per post.
+-- account_uid is who made the revision, NULL for posts older than accounts
+CREATE TABLE IF NOT EXISTS `post_revision` (
+  `post_id` INT NOT NULL,
+  `number` INT NOT NULL,
+  `title` varchar(30) COLLATE utf8_unicode_ci NOT NULL,
+  `content` longtext COLLATE utf8_unicode_ci NOT NULL,
+  `account_uid` varchar(40) DEFAULT NULL,
+  `created_at` datetime DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`post_id`, `number`),
+  CONSTRAINT FOREIGN KEY (`post_id`) REFERENCES post(`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8 COLLATE=utf8_unicode_ci;
+
+-- existing posts start their history as they are now
+INSERT INTO `post_revision` (`post_id`, `number`, `title`, `content`, `account_uid`, `created_at`)
+SELECT `post`.`id`, 1, `post`.`title`, `post`.`content`, `author`.`account_uid`, COALESCE(`post`.`updated_at`, `post`.`created_at`)
+FROM `post` JOIN `author` ON `author`.`id` = `post`.`author_id`;
+
+-- +goose Down
+DROP TABLE IF EXISTS `post_revision`;
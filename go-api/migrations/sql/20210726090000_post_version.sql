@@ -0,0 +1,6 @@
+-- +goose Up
+-- bumped on every change of the post, clients send it back in If-Match
+ALTER TABLE `post` ADD COLUMN `version` INT NOT NULL DEFAULT 1 AFTER `publish_at`;
+
+-- +goose Down
+ALTER TABLE `post` DROP COLUMN `version`;
@@ -0,0 +1,22 @@
+-- +goose Up
+-- comments on posts, replies point to their parent. Deleted comments
+-- keep their row, without content, so their replies stay in the thread
+CREATE TABLE IF NOT EXISTS `comment` (
+  `id` INT NOT NULL AUTO_INCREMENT,
+  `post_id` INT NOT NULL,
+  `parent_id` INT DEFAULT NULL,
+  `depth` INT NOT NULL DEFAULT 0,
+  `account_uid` varchar(40) NOT NULL,
+  `content` text COLLATE utf8_unicode_ci NOT NULL,
+  `status` varchar(20) NOT NULL DEFAULT 'pending',
+  `edited_at` datetime DEFAULT NULL,
+  `deleted_at` datetime DEFAULT NULL,
+  `created_at` datetime DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  KEY (`post_id`, `created_at`),
+  CONSTRAINT FOREIGN KEY (`post_id`) REFERENCES post(`id`) ON DELETE CASCADE,
+  CONSTRAINT FOREIGN KEY (`parent_id`) REFERENCES comment(`id`) ON DELETE CASCADE
+) ENGINE=InnoDB AUTO_INCREMENT=1 DEFAULT CHARSET=utf8 COLLATE=utf8_unicode_ci;
+
+-- +goose Down
+DROP TABLE IF EXISTS `comment`;
@@ -0,0 +1,25 @@
+-- +goose Up
+-- reactions of the accounts to posts, one of each kind per account
+CREATE TABLE IF NOT EXISTS `post_reaction` (
+  `post_id` INT NOT NULL,
+  `account_uid` varchar(40) NOT NULL,
+  `kind` varchar(20) NOT NULL,
+  `created_at` datetime DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`post_id`, `account_uid`, `kind`),
+  CONSTRAINT FOREIGN KEY (`post_id`) REFERENCES post(`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8 COLLATE=utf8_unicode_ci;
+
+-- counts of the reactions as of the last reconciliation, listings read
+-- them from redis and fall back to these instead of counting the rows
+CREATE TABLE IF NOT EXISTS `post_reaction_count` (
+  `post_id` INT NOT NULL,
+  `kind` varchar(20) NOT NULL,
+  `count` INT NOT NULL DEFAULT 0,
+  `reconciled_at` datetime DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`post_id`, `kind`),
+  CONSTRAINT FOREIGN KEY (`post_id`) REFERENCES post(`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8 COLLATE=utf8_unicode_ci;
+
+-- +goose Down
+DROP TABLE IF EXISTS `post_reaction_count`;
+DROP TABLE IF EXISTS `post_reaction`;
@@ -73,3 +73,29 @@ func (m *MockAccountRepo) Create(ctx context.Context, a *domain.Account) error {
 	}
 	return r0
 }
+
+func (m *MockAccountRepo) Update(ctx context.Context, a *domain.Account) error {
+	ret := m.Called(ctx, a)
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.Account) error); ok {
+		r0 = rf(ctx, a)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(error)
+		}
+	}
+	return r0
+}
+
+func (m *MockAccountRepo) UpdatePassword(ctx context.Context, uid uuid.UUID, password string) error {
+	ret := m.Called(ctx, uid, password)
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, string) error); ok {
+		r0 = rf(ctx, uid, password)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(error)
+		}
+	}
+	return r0
+}
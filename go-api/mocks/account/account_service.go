@@ -69,3 +69,144 @@ func (m *MockAccountService) Signin(ctx context.Context, a *domain.Account) erro
 	}
 	return r0
 }
+
+func (m *MockAccountService) Update(ctx context.Context, a *domain.Account) error {
+
+	ret := m.Called(ctx, a)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.Account) error); ok {
+		r0 = rf(ctx, a)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(error)
+		}
+	}
+	return r0
+}
+
+func (m *MockAccountService) SetProfileImage(ctx context.Context, uid uuid.UUID, data []byte) (*domain.Account, error) {
+
+	ret := m.Called(ctx, uid, data)
+
+	var r0 *domain.Account
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, []byte) *domain.Account); ok {
+		r0 = rf(ctx, uid, data)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.Account)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID, []byte) error); ok {
+		r1 = rf(ctx, uid, data)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(error)
+		}
+	}
+	return r0, r1
+}
+
+func (m *MockAccountService) ClearProfileImage(ctx context.Context, uid uuid.UUID) (*domain.Account, error) {
+
+	ret := m.Called(ctx, uid)
+
+	var r0 *domain.Account
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) *domain.Account); ok {
+		r0 = rf(ctx, uid)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.Account)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID) error); ok {
+		r1 = rf(ctx, uid)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(error)
+		}
+	}
+	return r0, r1
+}
+
+func (m *MockAccountService) ForgotPassword(ctx context.Context, email string) error {
+
+	ret := m.Called(ctx, email)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, email)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(error)
+		}
+	}
+	return r0
+}
+
+func (m *MockAccountService) ResetPassword(ctx context.Context, token string, password string) (*domain.Account, error) {
+
+	ret := m.Called(ctx, token, password)
+
+	var r0 *domain.Account
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *domain.Account); ok {
+		r0 = rf(ctx, token, password)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.Account)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, token, password)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(error)
+		}
+	}
+	return r0, r1
+}
+
+func (m *MockAccountService) VerifyEmail(ctx context.Context, token string) (*domain.Account, error) {
+
+	ret := m.Called(ctx, token)
+
+	var r0 *domain.Account
+	if rf, ok := ret.Get(0).(func(context.Context, string) *domain.Account); ok {
+		r0 = rf(ctx, token)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.Account)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, token)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(error)
+		}
+	}
+	return r0, r1
+}
+
+func (m *MockAccountService) ResendVerificationEmail(ctx context.Context, uid uuid.UUID) error {
+
+	ret := m.Called(ctx, uid)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) error); ok {
+		r0 = rf(ctx, uid)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(error)
+		}
+	}
+	return r0
+}
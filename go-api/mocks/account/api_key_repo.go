@@ -0,0 +1,107 @@
+package mocks
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+	"github.com/whuangz/go-example/go-api/domain"
+)
+
+type MockAPIKeyRepo struct {
+	mock.Mock
+}
+
+func (m *MockAPIKeyRepo) Create(ctx context.Context, k *domain.APIKey) error {
+	ret := m.Called(ctx, k)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.APIKey) error); ok {
+		r0 = rf(ctx, k)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(error)
+		}
+	}
+
+	return r0
+}
+
+func (m *MockAPIKeyRepo) FindByHash(ctx context.Context, keyHash string) (*domain.APIKey, error) {
+	ret := m.Called(ctx, keyHash)
+
+	var r0 *domain.APIKey
+	if rf, ok := ret.Get(0).(func(context.Context, string) *domain.APIKey); ok {
+		r0 = rf(ctx, keyHash)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.APIKey)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, keyHash)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(error)
+		}
+	}
+
+	return r0, r1
+}
+
+func (m *MockAPIKeyRepo) FindByAccount(ctx context.Context, accountUID uuid.UUID) ([]*domain.APIKey, error) {
+	ret := m.Called(ctx, accountUID)
+
+	var r0 []*domain.APIKey
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) []*domain.APIKey); ok {
+		r0 = rf(ctx, accountUID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*domain.APIKey)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID) error); ok {
+		r1 = rf(ctx, accountUID)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(error)
+		}
+	}
+
+	return r0, r1
+}
+
+func (m *MockAPIKeyRepo) Delete(ctx context.Context, accountUID uuid.UUID, uid uuid.UUID) error {
+	ret := m.Called(ctx, accountUID, uid)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, uuid.UUID) error); ok {
+		r0 = rf(ctx, accountUID, uid)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(error)
+		}
+	}
+
+	return r0
+}
+
+func (m *MockAPIKeyRepo) SetLastUsed(ctx context.Context, uid uuid.UUID, usedAt time.Time) error {
+	ret := m.Called(ctx, uid, usedAt)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, time.Time) error); ok {
+		r0 = rf(ctx, uid, usedAt)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(error)
+		}
+	}
+
+	return r0
+}
@@ -0,0 +1,117 @@
+package mocks
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+	"github.com/whuangz/go-example/go-api/domain"
+)
+
+type MockAPIKeyService struct {
+	mock.Mock
+}
+
+func (m *MockAPIKeyService) Create(ctx context.Context, accountUID uuid.UUID, name string, scopes []string, expiresIn time.Duration) (*domain.APIKey, string, error) {
+	ret := m.Called(ctx, accountUID, name, scopes, expiresIn)
+
+	var r0 *domain.APIKey
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, string, []string, time.Duration) *domain.APIKey); ok {
+		r0 = rf(ctx, accountUID, name, scopes, expiresIn)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.APIKey)
+		}
+	}
+
+	var r1 string
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID, string, []string, time.Duration) string); ok {
+		r1 = rf(ctx, accountUID, name, scopes, expiresIn)
+	} else {
+		r1 = ret.Get(1).(string)
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, uuid.UUID, string, []string, time.Duration) error); ok {
+		r2 = rf(ctx, accountUID, name, scopes, expiresIn)
+	} else {
+		if ret.Get(2) != nil {
+			r2 = ret.Get(2).(error)
+		}
+	}
+
+	return r0, r1, r2
+}
+
+func (m *MockAPIKeyService) List(ctx context.Context, accountUID uuid.UUID) ([]*domain.APIKey, error) {
+	ret := m.Called(ctx, accountUID)
+
+	var r0 []*domain.APIKey
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) []*domain.APIKey); ok {
+		r0 = rf(ctx, accountUID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*domain.APIKey)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID) error); ok {
+		r1 = rf(ctx, accountUID)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(error)
+		}
+	}
+
+	return r0, r1
+}
+
+func (m *MockAPIKeyService) Revoke(ctx context.Context, accountUID uuid.UUID, uid uuid.UUID) error {
+	ret := m.Called(ctx, accountUID, uid)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, uuid.UUID) error); ok {
+		r0 = rf(ctx, accountUID, uid)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(error)
+		}
+	}
+
+	return r0
+}
+
+func (m *MockAPIKeyService) Authenticate(ctx context.Context, key string) (*domain.Account, *domain.APIKey, error) {
+	ret := m.Called(ctx, key)
+
+	var r0 *domain.Account
+	if rf, ok := ret.Get(0).(func(context.Context, string) *domain.Account); ok {
+		r0 = rf(ctx, key)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.Account)
+		}
+	}
+
+	var r1 *domain.APIKey
+	if rf, ok := ret.Get(1).(func(context.Context, string) *domain.APIKey); ok {
+		r1 = rf(ctx, key)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*domain.APIKey)
+		}
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, string) error); ok {
+		r2 = rf(ctx, key)
+	} else {
+		if ret.Get(2) != nil {
+			r2 = ret.Get(2).(error)
+		}
+	}
+
+	return r0, r1, r2
+}
@@ -0,0 +1,52 @@
+package mocks
+
+import (
+	"context"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/whuangz/go-example/go-api/domain"
+)
+
+type MockAuthorizationCodeRepo struct {
+	mock.Mock
+}
+
+func (m *MockAuthorizationCodeRepo) SaveCode(ctx context.Context, codeHash string, code *domain.AuthorizationCode, expiresIn time.Duration) error {
+	ret := m.Called(ctx, codeHash, code, expiresIn)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, *domain.AuthorizationCode, time.Duration) error); ok {
+		r0 = rf(ctx, codeHash, code, expiresIn)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(error)
+		}
+	}
+
+	return r0
+}
+
+func (m *MockAuthorizationCodeRepo) ConsumeCode(ctx context.Context, codeHash string) (*domain.AuthorizationCode, error) {
+	ret := m.Called(ctx, codeHash)
+
+	var r0 *domain.AuthorizationCode
+	if rf, ok := ret.Get(0).(func(context.Context, string) *domain.AuthorizationCode); ok {
+		r0 = rf(ctx, codeHash)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.AuthorizationCode)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, codeHash)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(error)
+		}
+	}
+
+	return r0, r1
+}
@@ -0,0 +1,63 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type MockLockoutService struct {
+	mock.Mock
+}
+
+func (m *MockLockoutService) Check(ctx context.Context, email string, ip string) error {
+	ret := m.Called(ctx, email, ip)
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, email, ip)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(error)
+		}
+	}
+	return r0
+}
+
+func (m *MockLockoutService) RegisterFailure(ctx context.Context, email string, ip string) error {
+	ret := m.Called(ctx, email, ip)
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, email, ip)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(error)
+		}
+	}
+	return r0
+}
+
+func (m *MockLockoutService) RegisterSuccess(ctx context.Context, email string) error {
+	ret := m.Called(ctx, email)
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, email)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(error)
+		}
+	}
+	return r0
+}
+
+func (m *MockLockoutService) Unlock(ctx context.Context, email string) error {
+	ret := m.Called(ctx, email)
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, email)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(error)
+		}
+	}
+	return r0
+}
@@ -0,0 +1,117 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+	"github.com/whuangz/go-example/go-api/domain"
+)
+
+type MockMFARepo struct {
+	mock.Mock
+}
+
+func (m *MockMFARepo) FindByUID(ctx context.Context, uid uuid.UUID) (*domain.MFA, error) {
+	ret := m.Called(ctx, uid)
+
+	var r0 *domain.MFA
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) *domain.MFA); ok {
+		r0 = rf(ctx, uid)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.MFA)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID) error); ok {
+		r1 = rf(ctx, uid)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(error)
+		}
+	}
+	return r0, r1
+}
+
+func (m *MockMFARepo) Save(ctx context.Context, mfa *domain.MFA) error {
+	ret := m.Called(ctx, mfa)
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.MFA) error); ok {
+		r0 = rf(ctx, mfa)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(error)
+		}
+	}
+	return r0
+}
+
+func (m *MockMFARepo) Delete(ctx context.Context, uid uuid.UUID) error {
+	ret := m.Called(ctx, uid)
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) error); ok {
+		r0 = rf(ctx, uid)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(error)
+		}
+	}
+	return r0
+}
+
+func (m *MockMFARepo) UseStep(ctx context.Context, uid uuid.UUID, step int64) (bool, error) {
+	ret := m.Called(ctx, uid, step)
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, int64) bool); ok {
+		r0 = rf(ctx, uid, step)
+	} else {
+		r0 = ret.Bool(0)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID, int64) error); ok {
+		r1 = rf(ctx, uid, step)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(error)
+		}
+	}
+	return r0, r1
+}
+
+func (m *MockMFARepo) SetRecoveryCodes(ctx context.Context, uid uuid.UUID, codeHashes []string) error {
+	ret := m.Called(ctx, uid, codeHashes)
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, []string) error); ok {
+		r0 = rf(ctx, uid, codeHashes)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(error)
+		}
+	}
+	return r0
+}
+
+func (m *MockMFARepo) UseRecoveryCode(ctx context.Context, uid uuid.UUID, codeHash string) (bool, error) {
+	ret := m.Called(ctx, uid, codeHash)
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, string) bool); ok {
+		r0 = rf(ctx, uid, codeHash)
+	} else {
+		r0 = ret.Bool(0)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID, string) error); ok {
+		r1 = rf(ctx, uid, codeHash)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(error)
+		}
+	}
+	return r0, r1
+}
@@ -0,0 +1,91 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+	"github.com/whuangz/go-example/go-api/domain"
+)
+
+type MockOAuthRepo struct {
+	mock.Mock
+}
+
+func (m *MockOAuthRepo) FindClient(ctx context.Context, clientID string) (*domain.OAuthClient, error) {
+	ret := m.Called(ctx, clientID)
+
+	var r0 *domain.OAuthClient
+	if rf, ok := ret.Get(0).(func(context.Context, string) *domain.OAuthClient); ok {
+		r0 = rf(ctx, clientID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.OAuthClient)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, clientID)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(error)
+		}
+	}
+
+	return r0, r1
+}
+
+func (m *MockOAuthRepo) CreateClient(ctx context.Context, c *domain.OAuthClient) error {
+	ret := m.Called(ctx, c)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.OAuthClient) error); ok {
+		r0 = rf(ctx, c)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(error)
+		}
+	}
+
+	return r0
+}
+
+func (m *MockOAuthRepo) FindConsent(ctx context.Context, uid uuid.UUID, clientID string) (*domain.OAuthConsent, error) {
+	ret := m.Called(ctx, uid, clientID)
+
+	var r0 *domain.OAuthConsent
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, string) *domain.OAuthConsent); ok {
+		r0 = rf(ctx, uid, clientID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.OAuthConsent)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID, string) error); ok {
+		r1 = rf(ctx, uid, clientID)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(error)
+		}
+	}
+
+	return r0, r1
+}
+
+func (m *MockOAuthRepo) SaveConsent(ctx context.Context, consent *domain.OAuthConsent) error {
+	ret := m.Called(ctx, consent)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.OAuthConsent) error); ok {
+		r0 = rf(ctx, consent)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(error)
+		}
+	}
+
+	return r0
+}
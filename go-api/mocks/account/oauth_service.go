@@ -0,0 +1,204 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+	"github.com/whuangz/go-example/go-api/domain"
+)
+
+type MockOAuthService struct {
+	mock.Mock
+}
+
+func (m *MockOAuthService) RegisterClient(ctx context.Context, c *domain.OAuthClient, public bool) (string, error) {
+	ret := m.Called(ctx, c, public)
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.OAuthClient, bool) string); ok {
+		r0 = rf(ctx, c, public)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *domain.OAuthClient, bool) error); ok {
+		r1 = rf(ctx, c, public)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(error)
+		}
+	}
+
+	return r0, r1
+}
+
+func (m *MockOAuthService) AuthenticateClient(ctx context.Context, clientID string, secret string) (*domain.OAuthClient, error) {
+	ret := m.Called(ctx, clientID, secret)
+
+	var r0 *domain.OAuthClient
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *domain.OAuthClient); ok {
+		r0 = rf(ctx, clientID, secret)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.OAuthClient)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, clientID, secret)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(error)
+		}
+	}
+
+	return r0, r1
+}
+
+func (m *MockOAuthService) Authorize(ctx context.Context, uid uuid.UUID, req *domain.AuthorizeRequest) (*domain.AuthorizeResponse, error) {
+	ret := m.Called(ctx, uid, req)
+
+	var r0 *domain.AuthorizeResponse
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, *domain.AuthorizeRequest) *domain.AuthorizeResponse); ok {
+		r0 = rf(ctx, uid, req)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.AuthorizeResponse)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID, *domain.AuthorizeRequest) error); ok {
+		r1 = rf(ctx, uid, req)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(error)
+		}
+	}
+
+	return r0, r1
+}
+
+func (m *MockOAuthService) Consent(ctx context.Context, uid uuid.UUID, req *domain.AuthorizeRequest, approve bool) (*domain.AuthorizeResponse, error) {
+	ret := m.Called(ctx, uid, req, approve)
+
+	var r0 *domain.AuthorizeResponse
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, *domain.AuthorizeRequest, bool) *domain.AuthorizeResponse); ok {
+		r0 = rf(ctx, uid, req, approve)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.AuthorizeResponse)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID, *domain.AuthorizeRequest, bool) error); ok {
+		r1 = rf(ctx, uid, req, approve)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(error)
+		}
+	}
+
+	return r0, r1
+}
+
+func (m *MockOAuthService) Exchange(ctx context.Context, client *domain.OAuthClient, req *domain.TokenRequest) (*domain.OAuthTokens, error) {
+	ret := m.Called(ctx, client, req)
+
+	var r0 *domain.OAuthTokens
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.OAuthClient, *domain.TokenRequest) *domain.OAuthTokens); ok {
+		r0 = rf(ctx, client, req)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.OAuthTokens)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *domain.OAuthClient, *domain.TokenRequest) error); ok {
+		r1 = rf(ctx, client, req)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(error)
+		}
+	}
+
+	return r0, r1
+}
+
+func (m *MockOAuthService) Introspect(ctx context.Context, token string) (*domain.Introspection, error) {
+	ret := m.Called(ctx, token)
+
+	var r0 *domain.Introspection
+	if rf, ok := ret.Get(0).(func(context.Context, string) *domain.Introspection); ok {
+		r0 = rf(ctx, token)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.Introspection)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, token)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(error)
+		}
+	}
+
+	return r0, r1
+}
+
+func (m *MockOAuthService) Revoke(ctx context.Context, client *domain.OAuthClient, token string) error {
+	ret := m.Called(ctx, client, token)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.OAuthClient, string) error); ok {
+		r0 = rf(ctx, client, token)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(error)
+		}
+	}
+
+	return r0
+}
+
+func (m *MockOAuthService) UserInfo(ctx context.Context, accessToken string) (*domain.UserInfo, error) {
+	ret := m.Called(ctx, accessToken)
+
+	var r0 *domain.UserInfo
+	if rf, ok := ret.Get(0).(func(context.Context, string) *domain.UserInfo); ok {
+		r0 = rf(ctx, accessToken)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.UserInfo)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, accessToken)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(error)
+		}
+	}
+
+	return r0, r1
+}
+func (m *MockOAuthService) Discovery() *domain.OIDCDiscovery {
+	ret := m.Called()
+
+	var r0 *domain.OIDCDiscovery
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(*domain.OIDCDiscovery)
+	}
+
+	return r0
+}
@@ -0,0 +1,46 @@
+package mocks
+
+import (
+	"context"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type MockOneTimeTokenRepo struct {
+	mock.Mock
+}
+
+func (m *MockOneTimeTokenRepo) SetToken(ctx context.Context, kind string, tokenHash string, accID string, expiresIn time.Duration) error {
+	ret := m.Called(ctx, kind, tokenHash, accID, expiresIn)
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string, time.Duration) error); ok {
+		r0 = rf(ctx, kind, tokenHash, accID, expiresIn)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(error)
+		}
+	}
+	return r0
+}
+
+func (m *MockOneTimeTokenRepo) ConsumeToken(ctx context.Context, kind string, tokenHash string) (string, error) {
+	ret := m.Called(ctx, kind, tokenHash)
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) string); ok {
+		r0 = rf(ctx, kind, tokenHash)
+	} else {
+		r0 = ret.String(0)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, kind, tokenHash)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(error)
+		}
+	}
+	return r0, r1
+}
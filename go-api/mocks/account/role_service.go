@@ -0,0 +1,61 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+	"github.com/whuangz/go-example/go-api/domain"
+)
+
+type MockRoleService struct {
+	mock.Mock
+}
+
+func (m *MockRoleService) Grant(ctx context.Context, accountUID uuid.UUID, role string) (*domain.Account, error) {
+	ret := m.Called(ctx, accountUID, role)
+
+	var r0 *domain.Account
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, string) *domain.Account); ok {
+		r0 = rf(ctx, accountUID, role)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.Account)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID, string) error); ok {
+		r1 = rf(ctx, accountUID, role)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(error)
+		}
+	}
+
+	return r0, r1
+}
+
+func (m *MockRoleService) Revoke(ctx context.Context, accountUID uuid.UUID, role string) (*domain.Account, error) {
+	ret := m.Called(ctx, accountUID, role)
+
+	var r0 *domain.Account
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, string) *domain.Account); ok {
+		r0 = rf(ctx, accountUID, role)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.Account)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID, string) error); ok {
+		r1 = rf(ctx, accountUID, role)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(error)
+		}
+	}
+
+	return r0, r1
+}
@@ -0,0 +1,81 @@
+package mocks
+
+import (
+	"context"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type MockSigninAttemptRepo struct {
+	mock.Mock
+}
+
+func (m *MockSigninAttemptRepo) AddFailure(ctx context.Context, key string, window time.Duration) (int64, error) {
+	ret := m.Called(ctx, key, window)
+
+	var r0 int64
+	if rf, ok := ret.Get(0).(func(context.Context, string, time.Duration) int64); ok {
+		r0 = rf(ctx, key, window)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, time.Duration) error); ok {
+		r1 = rf(ctx, key, window)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(error)
+		}
+	}
+	return r0, r1
+}
+
+func (m *MockSigninAttemptRepo) Lock(ctx context.Context, key string, d time.Duration) error {
+	ret := m.Called(ctx, key, d)
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, time.Duration) error); ok {
+		r0 = rf(ctx, key, d)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(error)
+		}
+	}
+	return r0
+}
+
+// LockedFor passes the keys as a single []string argument
+func (m *MockSigninAttemptRepo) LockedFor(ctx context.Context, keys ...string) (time.Duration, error) {
+	ret := m.Called(ctx, keys)
+
+	var r0 time.Duration
+	if rf, ok := ret.Get(0).(func(context.Context, ...string) time.Duration); ok {
+		r0 = rf(ctx, keys...)
+	} else {
+		r0 = ret.Get(0).(time.Duration)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, ...string) error); ok {
+		r1 = rf(ctx, keys...)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(error)
+		}
+	}
+	return r0, r1
+}
+
+func (m *MockSigninAttemptRepo) Reset(ctx context.Context, key string) error {
+	ret := m.Called(ctx, key)
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, key)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(error)
+		}
+	}
+	return r0
+}
@@ -11,11 +11,11 @@ type MockTokenRepo struct {
 	mock.Mock
 }
 
-func (m *MockTokenRepo) SetRefreshToken(ctx context.Context, accID string, accessToken string, expiresIn time.Duration) error {
-	ret := m.Called(ctx, accID, accessToken, expiresIn)
+func (m *MockTokenRepo) SetRefreshToken(ctx context.Context, accID string, tokenID string, familyID string, expiresIn time.Duration) error {
+	ret := m.Called(ctx, accID, tokenID, familyID, expiresIn)
 	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, string, string, time.Duration) error); ok {
-		r0 = rf(ctx, accID, accessToken, expiresIn)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string, time.Duration) error); ok {
+		r0 = rf(ctx, accID, tokenID, familyID, expiresIn)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(error)
@@ -24,11 +24,45 @@ func (m *MockTokenRepo) SetRefreshToken(ctx context.Context, accID string, acces
 	return r0
 }
 
-func (m *MockTokenRepo) DeleteRefreshToken(ctx context.Context, accID string, prevAccessToken string) error {
-	ret := m.Called(ctx, accID, prevAccessToken)
+func (m *MockTokenRepo) RotateRefreshToken(ctx context.Context, accID string, prevTokenID string) (string, error) {
+	ret := m.Called(ctx, accID, prevTokenID)
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) string); ok {
+		r0 = rf(ctx, accID, prevTokenID)
+	} else {
+		r0 = ret.String(0)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, accID, prevTokenID)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(error)
+		}
+	}
+	return r0, r1
+}
+
+func (m *MockTokenRepo) DeleteTokenFamily(ctx context.Context, accID string, familyID string) error {
+	ret := m.Called(ctx, accID, familyID)
 	var r0 error
 	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
-		r0 = rf(ctx, accID, prevAccessToken)
+		r0 = rf(ctx, accID, familyID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(error)
+		}
+	}
+	return r0
+}
+
+func (m *MockTokenRepo) DeleteUserRefreshTokens(ctx context.Context, accID string) error {
+	ret := m.Called(ctx, accID)
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, accID)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(error)
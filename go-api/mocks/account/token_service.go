@@ -3,6 +3,7 @@ package mocks
 import (
 	"context"
 
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/mock"
 	"github.com/whuangz/go-example/go-api/domain"
 )
@@ -36,12 +37,12 @@ func (m *MockTokenService) NewPairFromUser(ctx context.Context, a *domain.Accoun
 	return r0, r1
 }
 
-func (m *MockTokenService) ValidateAccessToken(token string) (*domain.Account, error) {
-	ret := m.Called(token)
+func (m *MockTokenService) ValidateAccessToken(ctx context.Context, token string) (*domain.Account, error) {
+	ret := m.Called(ctx, token)
 
 	var r0 *domain.Account
-	if rf, ok := ret.Get(0).(func(string) *domain.Account); ok {
-		r0 = rf(token)
+	if rf, ok := ret.Get(0).(func(context.Context, string) *domain.Account); ok {
+		r0 = rf(ctx, token)
 	} else {
 		if ret.Get(0) != nil {
 			// we can just return this if we know we won't be passing function to "Return"
@@ -50,8 +51,8 @@ func (m *MockTokenService) ValidateAccessToken(token string) (*domain.Account, e
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(string) error); ok {
-		r1 = rf(token)
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, token)
 	} else {
 		if ret.Get(1) != nil {
 			r1 = ret.Get(1).(error)
@@ -84,3 +85,59 @@ func (m *MockTokenService) ValidateRefreshToken(tokenString string) (*domain.Ref
 
 	return r0, r1
 }
+
+func (m *MockTokenService) Signout(ctx context.Context, uid uuid.UUID) error {
+	ret := m.Called(ctx, uid)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) error); ok {
+		r0 = rf(ctx, uid)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(error)
+		}
+	}
+	return r0
+}
+
+func (m *MockTokenService) SignoutDevice(ctx context.Context, uid uuid.UUID, refreshToken string) error {
+	ret := m.Called(ctx, uid, refreshToken)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, string) error); ok {
+		r0 = rf(ctx, uid, refreshToken)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(error)
+		}
+	}
+	return r0
+}
+
+func (m *MockTokenService) RevokeAccessToken(ctx context.Context, token string) error {
+	ret := m.Called(ctx, token)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, token)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(error)
+		}
+	}
+	return r0
+}
+
+func (m *MockTokenService) JWKS() *domain.JWKS {
+	ret := m.Called()
+
+	var r0 *domain.JWKS
+	if rf, ok := ret.Get(0).(func() *domain.JWKS); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.JWKS)
+		}
+	}
+	return r0
+}
@@ -0,0 +1,36 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/whuangz/go-example/go-api/domain"
+)
+
+type MockAuthorRepo struct {
+	mock.Mock
+}
+
+func (m *MockAuthorRepo) FindOrCreateByAccount(ctx context.Context, a *domain.Account) (*domain.Author, error) {
+	ret := m.Called(ctx, a)
+
+	var r0 *domain.Author
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.Account) *domain.Author); ok {
+		r0 = rf(ctx, a)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.Author)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *domain.Account) error); ok {
+		r1 = rf(ctx, a)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(error)
+		}
+	}
+
+	return r0, r1
+}
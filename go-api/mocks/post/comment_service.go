@@ -0,0 +1,114 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/whuangz/go-example/go-api/domain"
+)
+
+type MockCommentService struct {
+	mock.Mock
+}
+
+func (m *MockCommentService) List(ctx context.Context, account *domain.Account, postID int32) ([]domain.Comment, error) {
+	ret := m.Called(ctx, account, postID)
+
+	var r0 []domain.Comment
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.Account, int32) []domain.Comment); ok {
+		r0 = rf(ctx, account, postID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.Comment)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *domain.Account, int32) error); ok {
+		r1 = rf(ctx, account, postID)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(error)
+		}
+	}
+
+	return r0, r1
+}
+
+func (m *MockCommentService) Create(ctx context.Context, account *domain.Account, postID int32, comment *domain.Comment) error {
+	ret := m.Called(ctx, account, postID, comment)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.Account, int32, *domain.Comment) error); ok {
+		r0 = rf(ctx, account, postID, comment)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(error)
+		}
+	}
+
+	return r0
+}
+
+func (m *MockCommentService) Update(ctx context.Context, account *domain.Account, postID int32, id int32, content string) (*domain.Comment, error) {
+	ret := m.Called(ctx, account, postID, id, content)
+
+	var r0 *domain.Comment
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.Account, int32, int32, string) *domain.Comment); ok {
+		r0 = rf(ctx, account, postID, id, content)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.Comment)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *domain.Account, int32, int32, string) error); ok {
+		r1 = rf(ctx, account, postID, id, content)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(error)
+		}
+	}
+
+	return r0, r1
+}
+
+func (m *MockCommentService) Delete(ctx context.Context, account *domain.Account, postID int32, id int32) error {
+	ret := m.Called(ctx, account, postID, id)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.Account, int32, int32) error); ok {
+		r0 = rf(ctx, account, postID, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(error)
+		}
+	}
+
+	return r0
+}
+
+func (m *MockCommentService) Moderate(ctx context.Context, account *domain.Account, postID int32, id int32, status string) (*domain.Comment, error) {
+	ret := m.Called(ctx, account, postID, id, status)
+
+	var r0 *domain.Comment
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.Account, int32, int32, string) *domain.Comment); ok {
+		r0 = rf(ctx, account, postID, id, status)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.Comment)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *domain.Account, int32, int32, string) error); ok {
+		r1 = rf(ctx, account, postID, id, status)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(error)
+		}
+	}
+
+	return r0, r1
+}
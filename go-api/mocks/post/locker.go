@@ -0,0 +1,43 @@
+package mocks
+
+import (
+	"context"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type MockLocker struct {
+	mock.Mock
+}
+
+func (m *MockLocker) Acquire(ctx context.Context, key string, ttl time.Duration) (func(), bool, error) {
+	ret := m.Called(ctx, key, ttl)
+
+	var r0 func()
+	if rf, ok := ret.Get(0).(func(context.Context, string, time.Duration) func()); ok {
+		r0 = rf(ctx, key, ttl)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(func())
+		}
+	}
+
+	var r1 bool
+	if rf, ok := ret.Get(1).(func(context.Context, string, time.Duration) bool); ok {
+		r1 = rf(ctx, key, ttl)
+	} else {
+		r1 = ret.Get(1).(bool)
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, string, time.Duration) error); ok {
+		r2 = rf(ctx, key, ttl)
+	} else {
+		if ret.Get(2) != nil {
+			r2 = ret.Get(2).(error)
+		}
+	}
+
+	return r0, r1, r2
+}
@@ -2,7 +2,9 @@ package mocks
 
 import (
 	"context"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/mock"
 	"github.com/whuangz/go-example/go-api/domain"
 )
@@ -27,13 +29,13 @@ func (m *MockPostRepo) Save(ctx context.Context, post *domain.Post) error {
 	return r0
 }
 
-func (m *MockPostRepo) FindAll(ctx context.Context) ([]domain.Post, error) {
+func (m *MockPostRepo) FindAll(ctx context.Context, filter domain.PostFilter, cursor *domain.PostCursor, limit int) ([]domain.Post, error) {
 
-	ret := m.Called(ctx)
+	ret := m.Called(ctx, filter, cursor, limit)
 
 	var r0 []domain.Post
-	if rf, ok := ret.Get(0).(func(context.Context) []domain.Post); ok {
-		r0 = rf(ctx)
+	if rf, ok := ret.Get(0).(func(context.Context, domain.PostFilter, *domain.PostCursor, int) []domain.Post); ok {
+		r0 = rf(ctx, filter, cursor, limit)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]domain.Post)
@@ -41,8 +43,8 @@ func (m *MockPostRepo) FindAll(ctx context.Context) ([]domain.Post, error) {
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
-		r1 = rf(ctx)
+	if rf, ok := ret.Get(1).(func(context.Context, domain.PostFilter, *domain.PostCursor, int) error); ok {
+		r1 = rf(ctx, filter, cursor, limit)
 	} else {
 		if ret.Get(1) != nil {
 			r1 = ret.Get(1).(error)
@@ -75,12 +77,12 @@ func (m *MockPostRepo) FindByID(ctx context.Context, id int32) (domain.Post, err
 	return r0, r1
 }
 
-func (m *MockPostRepo) Update(ctx context.Context, id int32, post *domain.Post) error {
-	ret := m.Called(ctx, id, post)
+func (m *MockPostRepo) Update(ctx context.Context, id int32, post *domain.Post, editor uuid.UUID) error {
+	ret := m.Called(ctx, id, post, editor)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, int32, *domain.Post) error); ok {
-		r0 = rf(ctx, id, post)
+	if rf, ok := ret.Get(0).(func(context.Context, int32, *domain.Post, uuid.UUID) error); ok {
+		r0 = rf(ctx, id, post, editor)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(error)
@@ -89,12 +91,12 @@ func (m *MockPostRepo) Update(ctx context.Context, id int32, post *domain.Post)
 	return r0
 }
 
-func (m *MockPostRepo) Delete(ctx context.Context, id int32) error {
-	ret := m.Called(ctx, id)
+func (m *MockPostRepo) Delete(ctx context.Context, id int32, version int32) error {
+	ret := m.Called(ctx, id, version)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, int32) error); ok {
-		r0 = rf(ctx, id)
+	if rf, ok := ret.Get(0).(func(context.Context, int32, int32) error); ok {
+		r0 = rf(ctx, id, version)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(error)
@@ -102,3 +104,123 @@ func (m *MockPostRepo) Delete(ctx context.Context, id int32) error {
 	}
 	return r0
 }
+
+func (m *MockPostRepo) FindByIDs(ctx context.Context, ids []int32) ([]domain.Post, error) {
+	ret := m.Called(ctx, ids)
+
+	var r0 []domain.Post
+	if rf, ok := ret.Get(0).(func(context.Context, []int32) []domain.Post); ok {
+		r0 = rf(ctx, ids)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.Post)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, []int32) error); ok {
+		r1 = rf(ctx, ids)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(error)
+		}
+	}
+
+	return r0, r1
+}
+
+func (m *MockPostRepo) PublishDue(ctx context.Context, now time.Time) ([]int32, error) {
+	ret := m.Called(ctx, now)
+
+	var r0 []int32
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) []int32); ok {
+		r0 = rf(ctx, now)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]int32)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, time.Time) error); ok {
+		r1 = rf(ctx, now)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(error)
+		}
+	}
+
+	return r0, r1
+}
+
+func (m *MockPostRepo) FindBySlug(ctx context.Context, slug string) (domain.Post, error) {
+	ret := m.Called(ctx, slug)
+
+	var r0 domain.Post
+	if rf, ok := ret.Get(0).(func(context.Context, string) domain.Post); ok {
+		r0 = rf(ctx, slug)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(domain.Post)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, slug)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(error)
+		}
+	}
+
+	return r0, r1
+}
+
+func (m *MockPostRepo) FindRevisions(ctx context.Context, postID int32) ([]domain.PostRevision, error) {
+	ret := m.Called(ctx, postID)
+
+	var r0 []domain.PostRevision
+	if rf, ok := ret.Get(0).(func(context.Context, int32) []domain.PostRevision); ok {
+		r0 = rf(ctx, postID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.PostRevision)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, int32) error); ok {
+		r1 = rf(ctx, postID)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(error)
+		}
+	}
+
+	return r0, r1
+}
+
+func (m *MockPostRepo) FindRevision(ctx context.Context, postID int32, number int32) (domain.PostRevision, error) {
+	ret := m.Called(ctx, postID, number)
+
+	var r0 domain.PostRevision
+	if rf, ok := ret.Get(0).(func(context.Context, int32, int32) domain.PostRevision); ok {
+		r0 = rf(ctx, postID, number)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(domain.PostRevision)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, int32, int32) error); ok {
+		r1 = rf(ctx, postID, number)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(error)
+		}
+	}
+
+	return r0, r1
+}
@@ -11,13 +11,13 @@ type MockPostService struct {
 	mock.Mock
 }
 
-func (m *MockPostService) Save(ctx context.Context, post *domain.Post) error {
+func (m *MockPostService) Save(ctx context.Context, account *domain.Account, post *domain.Post) error {
 
-	ret := m.Called(ctx, post)
+	ret := m.Called(ctx, account, post)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, *domain.Post) error); ok {
-		r0 = rf(ctx, post)
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.Account, *domain.Post) error); ok {
+		r0 = rf(ctx, account, post)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -25,37 +25,36 @@ func (m *MockPostService) Save(ctx context.Context, post *domain.Post) error {
 	return r0
 }
 
-func (m *MockPostService) FindAll(ctx context.Context) ([]domain.Post, error) {
+func (m *MockPostService) FindAll(ctx context.Context, query domain.PostQuery) (*domain.PostPage, error) {
 
-	ret := m.Called(ctx)
+	ret := m.Called(ctx, query)
 
-	var r0 []domain.Post
-	if rf, ok := ret.Get(0).(func(context.Context) []domain.Post); ok {
-		r0 = rf(ctx)
+	var r0 *domain.PostPage
+	if rf, ok := ret.Get(0).(func(context.Context, domain.PostQuery) *domain.PostPage); ok {
+		r0 = rf(ctx, query)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]domain.Post)
+			r0 = ret.Get(0).(*domain.PostPage)
 		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
-		r1 = rf(ctx)
+	if rf, ok := ret.Get(1).(func(context.Context, domain.PostQuery) error); ok {
+		r1 = rf(ctx, query)
 	} else {
 		if ret.Get(1) != nil {
 			r1 = ret.Get(1).(error)
 		}
 	}
-
 	return r0, r1
 }
 
-func (m *MockPostService) FindByID(ctx context.Context, id int32) (domain.Post, error) {
-	ret := m.Called(ctx, id)
+func (m *MockPostService) FindByID(ctx context.Context, account *domain.Account, id int32) (domain.Post, error) {
+	ret := m.Called(ctx, account, id)
 
 	var r0 domain.Post
-	if rf, ok := ret.Get(0).(func(context.Context, int32) domain.Post); ok {
-		r0 = rf(ctx, id)
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.Account, int32) domain.Post); ok {
+		r0 = rf(ctx, account, id)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(domain.Post)
@@ -63,8 +62,8 @@ func (m *MockPostService) FindByID(ctx context.Context, id int32) (domain.Post,
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, int32) error); ok {
-		r1 = rf(ctx, id)
+	if rf, ok := ret.Get(1).(func(context.Context, *domain.Account, int32) error); ok {
+		r1 = rf(ctx, account, id)
 	} else {
 		if ret.Get(1) != nil {
 			r1 = ret.Get(1).(error)
@@ -73,12 +72,12 @@ func (m *MockPostService) FindByID(ctx context.Context, id int32) (domain.Post,
 	return r0, r1
 }
 
-func (m *MockPostService) Update(ctx context.Context, id int32, post *domain.Post) error {
-	ret := m.Called(ctx, id, post)
+func (m *MockPostService) Update(ctx context.Context, account *domain.Account, id int32, post *domain.Post) error {
+	ret := m.Called(ctx, account, id, post)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, int32, *domain.Post) error); ok {
-		r0 = rf(ctx, id, post)
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.Account, int32, *domain.Post) error); ok {
+		r0 = rf(ctx, account, id, post)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(error)
@@ -87,12 +86,12 @@ func (m *MockPostService) Update(ctx context.Context, id int32, post *domain.Pos
 	return r0
 }
 
-func (m *MockPostService) Delete(ctx context.Context, id int32) error {
-	ret := m.Called(ctx, id)
+func (m *MockPostService) Delete(ctx context.Context, account *domain.Account, id int32, version int32) error {
+	ret := m.Called(ctx, account, id, version)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, int32) error); ok {
-		r0 = rf(ctx, id)
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.Account, int32, int32) error); ok {
+		r0 = rf(ctx, account, id, version)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(error)
@@ -100,3 +99,169 @@ func (m *MockPostService) Delete(ctx context.Context, id int32) error {
 	}
 	return r0
 }
+
+func (m *MockPostService) Search(ctx context.Context, query domain.SearchQuery) (*domain.SearchPage, error) {
+	ret := m.Called(ctx, query)
+
+	var r0 *domain.SearchPage
+	if rf, ok := ret.Get(0).(func(context.Context, domain.SearchQuery) *domain.SearchPage); ok {
+		r0 = rf(ctx, query)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.SearchPage)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, domain.SearchQuery) error); ok {
+		r1 = rf(ctx, query)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(error)
+		}
+	}
+
+	return r0, r1
+}
+
+func (m *MockPostService) FindByAccount(ctx context.Context, account *domain.Account, query domain.PostQuery) (*domain.PostPage, error) {
+	ret := m.Called(ctx, account, query)
+
+	var r0 *domain.PostPage
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.Account, domain.PostQuery) *domain.PostPage); ok {
+		r0 = rf(ctx, account, query)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.PostPage)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *domain.Account, domain.PostQuery) error); ok {
+		r1 = rf(ctx, account, query)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(error)
+		}
+	}
+
+	return r0, r1
+}
+
+func (m *MockPostService) PublishDue(ctx context.Context) (int, error) {
+	ret := m.Called(ctx)
+
+	var r0 int
+	if rf, ok := ret.Get(0).(func(context.Context) int); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(error)
+		}
+	}
+
+	return r0, r1
+}
+
+func (m *MockPostService) FindBySlug(ctx context.Context, slug string) (domain.Post, error) {
+	ret := m.Called(ctx, slug)
+
+	var r0 domain.Post
+	if rf, ok := ret.Get(0).(func(context.Context, string) domain.Post); ok {
+		r0 = rf(ctx, slug)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(domain.Post)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, slug)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(error)
+		}
+	}
+
+	return r0, r1
+}
+
+func (m *MockPostService) Revisions(ctx context.Context, account *domain.Account, id int32) ([]domain.PostRevision, error) {
+	ret := m.Called(ctx, account, id)
+
+	var r0 []domain.PostRevision
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.Account, int32) []domain.PostRevision); ok {
+		r0 = rf(ctx, account, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.PostRevision)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *domain.Account, int32) error); ok {
+		r1 = rf(ctx, account, id)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(error)
+		}
+	}
+
+	return r0, r1
+}
+
+func (m *MockPostService) Diff(ctx context.Context, account *domain.Account, id int32, from int32, to int32) (*domain.PostDiff, error) {
+	ret := m.Called(ctx, account, id, from, to)
+
+	var r0 *domain.PostDiff
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.Account, int32, int32, int32) *domain.PostDiff); ok {
+		r0 = rf(ctx, account, id, from, to)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.PostDiff)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *domain.Account, int32, int32, int32) error); ok {
+		r1 = rf(ctx, account, id, from, to)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(error)
+		}
+	}
+
+	return r0, r1
+}
+
+func (m *MockPostService) Restore(ctx context.Context, account *domain.Account, id int32, number int32, version int32) (*domain.Post, error) {
+	ret := m.Called(ctx, account, id, number, version)
+
+	var r0 *domain.Post
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.Account, int32, int32, int32) *domain.Post); ok {
+		r0 = rf(ctx, account, id, number, version)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.Post)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *domain.Account, int32, int32, int32) error); ok {
+		r1 = rf(ctx, account, id, number, version)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(error)
+		}
+	}
+
+	return r0, r1
+}
@@ -0,0 +1,120 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/whuangz/go-example/go-api/domain"
+)
+
+type MockReactionCounter struct {
+	mock.Mock
+}
+
+func (m *MockReactionCounter) Incr(ctx context.Context, postID int32, kind string, delta int64) error {
+	ret := m.Called(ctx, postID, kind, delta)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int32, string, int64) error); ok {
+		r0 = rf(ctx, postID, kind, delta)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(error)
+		}
+	}
+
+	return r0
+}
+
+func (m *MockReactionCounter) Get(ctx context.Context, postIDs []int32) (map[int32]domain.ReactionCounts, error) {
+	ret := m.Called(ctx, postIDs)
+
+	var r0 map[int32]domain.ReactionCounts
+	if rf, ok := ret.Get(0).(func(context.Context, []int32) map[int32]domain.ReactionCounts); ok {
+		r0 = rf(ctx, postIDs)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[int32]domain.ReactionCounts)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, []int32) error); ok {
+		r1 = rf(ctx, postIDs)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(error)
+		}
+	}
+
+	return r0, r1
+}
+
+func (m *MockReactionCounter) Set(ctx context.Context, postID int32, counts domain.ReactionCounts) error {
+	ret := m.Called(ctx, postID, counts)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int32, domain.ReactionCounts) error); ok {
+		r0 = rf(ctx, postID, counts)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(error)
+		}
+	}
+
+	return r0
+}
+
+func (m *MockReactionCounter) TakeDirty(ctx context.Context, n int) ([]int32, error) {
+	ret := m.Called(ctx, n)
+
+	var r0 []int32
+	if rf, ok := ret.Get(0).(func(context.Context, int) []int32); ok {
+		r0 = rf(ctx, n)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]int32)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, n)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(error)
+		}
+	}
+
+	return r0, r1
+}
+
+func (m *MockReactionCounter) MarkDirty(ctx context.Context, postIDs ...int32) error {
+	ret := m.Called(ctx, postIDs)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, ...int32) error); ok {
+		r0 = rf(ctx, postIDs...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(error)
+		}
+	}
+
+	return r0
+}
+
+func (m *MockReactionCounter) Remove(ctx context.Context, postID int32) error {
+	ret := m.Called(ctx, postID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int32) error); ok {
+		r0 = rf(ctx, postID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(error)
+		}
+	}
+
+	return r0
+}
@@ -0,0 +1,144 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+	"github.com/whuangz/go-example/go-api/domain"
+)
+
+type MockReactionRepo struct {
+	mock.Mock
+}
+
+func (m *MockReactionRepo) Add(ctx context.Context, postID int32, accountUID uuid.UUID, kind string) (bool, error) {
+	ret := m.Called(ctx, postID, accountUID, kind)
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(context.Context, int32, uuid.UUID, string) bool); ok {
+		r0 = rf(ctx, postID, accountUID, kind)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, int32, uuid.UUID, string) error); ok {
+		r1 = rf(ctx, postID, accountUID, kind)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(error)
+		}
+	}
+
+	return r0, r1
+}
+
+func (m *MockReactionRepo) Remove(ctx context.Context, postID int32, accountUID uuid.UUID, kind string) (bool, error) {
+	ret := m.Called(ctx, postID, accountUID, kind)
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(context.Context, int32, uuid.UUID, string) bool); ok {
+		r0 = rf(ctx, postID, accountUID, kind)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, int32, uuid.UUID, string) error); ok {
+		r1 = rf(ctx, postID, accountUID, kind)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(error)
+		}
+	}
+
+	return r0, r1
+}
+
+func (m *MockReactionRepo) FindByAccount(ctx context.Context, postID int32, accountUID uuid.UUID) ([]string, error) {
+	ret := m.Called(ctx, postID, accountUID)
+
+	var r0 []string
+	if rf, ok := ret.Get(0).(func(context.Context, int32, uuid.UUID) []string); ok {
+		r0 = rf(ctx, postID, accountUID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, int32, uuid.UUID) error); ok {
+		r1 = rf(ctx, postID, accountUID)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(error)
+		}
+	}
+
+	return r0, r1
+}
+
+func (m *MockReactionRepo) Count(ctx context.Context, postID int32) (domain.ReactionCounts, error) {
+	ret := m.Called(ctx, postID)
+
+	var r0 domain.ReactionCounts
+	if rf, ok := ret.Get(0).(func(context.Context, int32) domain.ReactionCounts); ok {
+		r0 = rf(ctx, postID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(domain.ReactionCounts)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, int32) error); ok {
+		r1 = rf(ctx, postID)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(error)
+		}
+	}
+
+	return r0, r1
+}
+
+func (m *MockReactionRepo) SaveCounts(ctx context.Context, postID int32, counts domain.ReactionCounts) error {
+	ret := m.Called(ctx, postID, counts)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int32, domain.ReactionCounts) error); ok {
+		r0 = rf(ctx, postID, counts)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(error)
+		}
+	}
+
+	return r0
+}
+
+func (m *MockReactionRepo) FindCounts(ctx context.Context, postIDs []int32) (map[int32]domain.ReactionCounts, error) {
+	ret := m.Called(ctx, postIDs)
+
+	var r0 map[int32]domain.ReactionCounts
+	if rf, ok := ret.Get(0).(func(context.Context, []int32) map[int32]domain.ReactionCounts); ok {
+		r0 = rf(ctx, postIDs)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[int32]domain.ReactionCounts)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, []int32) error); ok {
+		r1 = rf(ctx, postIDs)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(error)
+		}
+	}
+
+	return r0, r1
+}
@@ -0,0 +1,136 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/whuangz/go-example/go-api/domain"
+)
+
+type MockReactionService struct {
+	mock.Mock
+}
+
+func (m *MockReactionService) React(ctx context.Context, account *domain.Account, postID int32, kind string) ([]string, error) {
+	ret := m.Called(ctx, account, postID, kind)
+
+	var r0 []string
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.Account, int32, string) []string); ok {
+		r0 = rf(ctx, account, postID, kind)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *domain.Account, int32, string) error); ok {
+		r1 = rf(ctx, account, postID, kind)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(error)
+		}
+	}
+
+	return r0, r1
+}
+
+func (m *MockReactionService) Unreact(ctx context.Context, account *domain.Account, postID int32, kind string) ([]string, error) {
+	ret := m.Called(ctx, account, postID, kind)
+
+	var r0 []string
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.Account, int32, string) []string); ok {
+		r0 = rf(ctx, account, postID, kind)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *domain.Account, int32, string) error); ok {
+		r1 = rf(ctx, account, postID, kind)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(error)
+		}
+	}
+
+	return r0, r1
+}
+
+func (m *MockReactionService) Mine(ctx context.Context, account *domain.Account, postID int32) ([]string, error) {
+	ret := m.Called(ctx, account, postID)
+
+	var r0 []string
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.Account, int32) []string); ok {
+		r0 = rf(ctx, account, postID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *domain.Account, int32) error); ok {
+		r1 = rf(ctx, account, postID)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(error)
+		}
+	}
+
+	return r0, r1
+}
+
+func (m *MockReactionService) Count(ctx context.Context, posts []domain.Post) error {
+	ret := m.Called(ctx, posts)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, []domain.Post) error); ok {
+		r0 = rf(ctx, posts)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(error)
+		}
+	}
+
+	return r0
+}
+
+func (m *MockReactionService) Reconcile(ctx context.Context) (int, error) {
+	ret := m.Called(ctx)
+
+	var r0 int
+	if rf, ok := ret.Get(0).(func(context.Context) int); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(error)
+		}
+	}
+
+	return r0, r1
+}
+
+func (m *MockReactionService) Forget(ctx context.Context, postID int32) error {
+	ret := m.Called(ctx, postID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int32) error); ok {
+		r0 = rf(ctx, postID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(error)
+		}
+	}
+
+	return r0
+}
@@ -0,0 +1,73 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/whuangz/go-example/go-api/domain"
+)
+
+type MockSearchIndex struct {
+	mock.Mock
+}
+
+func (m *MockSearchIndex) Index(ctx context.Context, post *domain.Post) error {
+	ret := m.Called(ctx, post)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.Post) error); ok {
+		r0 = rf(ctx, post)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(error)
+		}
+	}
+
+	return r0
+}
+
+func (m *MockSearchIndex) Remove(ctx context.Context, id int32) error {
+	ret := m.Called(ctx, id)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int32) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(error)
+		}
+	}
+
+	return r0
+}
+
+func (m *MockSearchIndex) Search(ctx context.Context, query string, offset int, limit int) ([]domain.SearchHit, int, error) {
+	ret := m.Called(ctx, query, offset, limit)
+
+	var r0 []domain.SearchHit
+	if rf, ok := ret.Get(0).(func(context.Context, string, int, int) []domain.SearchHit); ok {
+		r0 = rf(ctx, query, offset, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.SearchHit)
+		}
+	}
+
+	var r1 int
+	if rf, ok := ret.Get(1).(func(context.Context, string, int, int) int); ok {
+		r1 = rf(ctx, query, offset, limit)
+	} else {
+		r1 = ret.Get(1).(int)
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, string, int, int) error); ok {
+		r2 = rf(ctx, query, offset, limit)
+	} else {
+		if ret.Get(2) != nil {
+			r2 = ret.Get(2).(error)
+		}
+	}
+
+	return r0, r1, r2
+}
@@ -0,0 +1,36 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/whuangz/go-example/go-api/domain"
+)
+
+type MockTagRepo struct {
+	mock.Mock
+}
+
+func (m *MockTagRepo) FindAll(ctx context.Context) ([]domain.Tag, error) {
+	ret := m.Called(ctx)
+
+	var r0 []domain.Tag
+	if rf, ok := ret.Get(0).(func(context.Context) []domain.Tag); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.Tag)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(error)
+		}
+	}
+
+	return r0, r1
+}
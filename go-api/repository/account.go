@@ -2,13 +2,23 @@ package repository
 
 import (
 	"context"
+	"errors"
+	"log"
+	"strings"
 	"time"
 
+	"github.com/go-sql-driver/mysql"
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
 	"github.com/whuangz/go-example/go-api/domain"
 )
 
+// mysqlDuplicateEntry is the error number of a unique constraint violation
+const mysqlDuplicateEntry = 1062
+
+// accountRolesColumn selects the roles of an account space separated
+const accountRolesColumn = `COALESCE((SELECT GROUP_CONCAT(role ORDER BY role SEPARATOR ' ') FROM account_role WHERE account_role.account_uid=account.uid), '')`
+
 type accountRepo struct {
 	db *sqlx.DB
 }
@@ -19,7 +29,8 @@ func NewAccountRepo(db *sqlx.DB) domain.AccountRepository {
 
 func (r *accountRepo) FindByEmail(ctx context.Context, email string) (*domain.Account, error) {
 	acc := &domain.Account{}
-	query := "SELECT id, uid, email, name, image_url, website FROM account WHERE email=?"
+	query := `SELECT id, uid, email, password, COALESCE(name, ''), COALESCE(image_url, ''), COALESCE(website, ''), email_verified_at, ` + accountRolesColumn + `, updated_at, created_at
+		FROM account WHERE email=?`
 	rows, err := r.db.QueryContext(ctx, query, email)
 
 	if err != nil {
@@ -28,8 +39,10 @@ func (r *accountRepo) FindByEmail(ctx context.Context, email string) (*domain.Ac
 	defer rows.Close()
 
 	if rows.Next() {
-		rows.Scan(&acc.ID, &acc.UID, &acc.Email, &acc.Name, &acc.ImageUrl, &acc.Website)
-		return acc, nil
+		var roles string
+		err := rows.Scan(&acc.ID, &acc.UID, &acc.Email, &acc.Password, &acc.Name, &acc.ImageUrl, &acc.Website, &acc.EmailVerifiedAt, &roles, &acc.UpdatedAt, &acc.CreatedAt)
+		acc.Roles = strings.Fields(roles)
+		return acc, err
 	} else {
 		return acc, domain.NewNotFound("email", email)
 	}
@@ -38,7 +51,8 @@ func (r *accountRepo) FindByEmail(ctx context.Context, email string) (*domain.Ac
 func (r *accountRepo) FindByID(ctx context.Context, uid uuid.UUID) (*domain.Account, error) {
 
 	acc := &domain.Account{}
-	query := "SELECT id, uid, email, name, password, image_url, website FROM account WHERE uid=?"
+	query := `SELECT id, uid, email, password, COALESCE(name, ''), COALESCE(image_url, ''), COALESCE(website, ''), email_verified_at, ` + accountRolesColumn + `, updated_at, created_at
+		FROM account WHERE uid=?`
 	rows, err := r.db.QueryContext(ctx, query, uid)
 
 	if err != nil {
@@ -47,8 +61,10 @@ func (r *accountRepo) FindByID(ctx context.Context, uid uuid.UUID) (*domain.Acco
 	defer rows.Close()
 
 	if rows.Next() {
-		rows.Scan(&acc.ID, &acc.UID, &acc.Email, &acc.Name, &acc.Password, &acc.ImageUrl, &acc.Website)
-		return acc, nil
+		var roles string
+		err := rows.Scan(&acc.ID, &acc.UID, &acc.Email, &acc.Password, &acc.Name, &acc.ImageUrl, &acc.Website, &acc.EmailVerifiedAt, &roles, &acc.UpdatedAt, &acc.CreatedAt)
+		acc.Roles = strings.Fields(roles)
+		return acc, err
 	} else {
 		return acc, domain.NewNotFound("uid", uid.String())
 	}
@@ -74,3 +90,37 @@ func (r *accountRepo) Create(ctx context.Context, a *domain.Account) error {
 	return nil
 
 }
+
+// Update writes the editable details of an account
+func (r *accountRepo) Update(ctx context.Context, a *domain.Account) error {
+	query := `UPDATE account SET name=?, email=?, website=?, image_url=?, email_verified_at=?, updated_at=? WHERE uid=?`
+	now := time.Now()
+
+	// rows affected isn't checked, mysql reports 0 when nothing changed
+	if _, err := r.db.ExecContext(ctx, query, a.Name, a.Email, a.Website, a.ImageUrl, a.EmailVerifiedAt, now, a.UID); err != nil {
+		var mysqlErr *mysql.MySQLError
+		if errors.As(err, &mysqlErr) && mysqlErr.Number == mysqlDuplicateEntry {
+			return domain.NewConflict("email", a.Email)
+		}
+
+		log.Printf("Could not update account with uid: %v. Reason: %v\n", a.UID, err)
+		return domain.NewInternal()
+	}
+
+	a.UpdatedAt.Time = now
+	a.UpdatedAt.Valid = true
+
+	return nil
+}
+
+// UpdatePassword replaces the password hash of an account
+func (r *accountRepo) UpdatePassword(ctx context.Context, uid uuid.UUID, password string) error {
+	query := `UPDATE account SET password=?, updated_at=? WHERE uid=?`
+
+	if _, err := r.db.ExecContext(ctx, query, password, time.Now(), uid); err != nil {
+		log.Printf("Could not update password of account with uid: %v. Reason: %v\n", uid, err)
+		return domain.NewInternal()
+	}
+
+	return nil
+}
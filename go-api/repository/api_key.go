@@ -0,0 +1,128 @@
+package repository
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/whuangz/go-example/go-api/domain"
+)
+
+type apiKeyRepo struct {
+	db *sqlx.DB
+}
+
+func NewAPIKeyRepo(db *sqlx.DB) domain.APIKeyRepository {
+	return &apiKeyRepo{db: db}
+}
+
+const apiKeyColumns = `uid, account_uid, name, prefix, key_hash, scopes, expires_at, last_used_at, created_at`
+
+type apiKeyScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanAPIKey(row apiKeyScanner) (*domain.APIKey, error) {
+	k := &domain.APIKey{}
+	var scopes string
+
+	if err := row.Scan(&k.UID, &k.AccountUID, &k.Name, &k.Prefix, &k.KeyHash, &scopes, &k.ExpiresAt, &k.LastUsedAt, &k.CreatedAt); err != nil {
+		return nil, err
+	}
+	k.Scopes = strings.Fields(scopes)
+
+	return k, nil
+}
+
+func (r *apiKeyRepo) Create(ctx context.Context, k *domain.APIKey) error {
+	query := `INSERT INTO api_key (uid, account_uid, name, prefix, key_hash, scopes, expires_at, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
+	uid := uuid.New()
+	now := time.Now()
+
+	if _, err := r.db.ExecContext(ctx, query, uid, k.AccountUID, k.Name, k.Prefix, k.KeyHash, strings.Join(k.Scopes, " "), k.ExpiresAt, now); err != nil {
+		log.Printf("Could not create API key of account with uid: %v. Reason: %v\n", k.AccountUID, err)
+		return domain.NewInternal()
+	}
+
+	k.UID = uid
+	k.CreatedAt = now
+
+	return nil
+}
+
+func (r *apiKeyRepo) FindByHash(ctx context.Context, keyHash string) (*domain.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM api_key WHERE key_hash=?`
+	rows, err := r.db.QueryContext(ctx, query, keyHash)
+
+	if err != nil {
+		log.Printf("Could not find API key. Reason: %v\n", err)
+		return nil, domain.NewInternal()
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return nil, domain.NewNotFound("api key", "key")
+	}
+
+	k, err := scanAPIKey(rows)
+	if err != nil {
+		log.Printf("Could not scan API key. Reason: %v\n", err)
+		return nil, domain.NewInternal()
+	}
+
+	return k, nil
+}
+
+// FindByAccount returns the keys of an account, newest first
+func (r *apiKeyRepo) FindByAccount(ctx context.Context, accountUID uuid.UUID) ([]*domain.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM api_key WHERE account_uid=? ORDER BY created_at DESC, id DESC`
+	rows, err := r.db.QueryContext(ctx, query, accountUID)
+
+	if err != nil {
+		log.Printf("Could not find API keys of account with uid: %v. Reason: %v\n", accountUID, err)
+		return nil, domain.NewInternal()
+	}
+	defer rows.Close()
+
+	keys := []*domain.APIKey{}
+	for rows.Next() {
+		k, err := scanAPIKey(rows)
+		if err != nil {
+			log.Printf("Could not scan API key of account with uid: %v. Reason: %v\n", accountUID, err)
+			return nil, domain.NewInternal()
+		}
+		keys = append(keys, k)
+	}
+
+	return keys, nil
+}
+
+// Delete returns a NotFound error when the account has no such key
+func (r *apiKeyRepo) Delete(ctx context.Context, accountUID uuid.UUID, uid uuid.UUID) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM api_key WHERE account_uid=? AND uid=?`, accountUID, uid)
+	if err != nil {
+		log.Printf("Could not delete API key: %v of account with uid: %v. Reason: %v\n", uid, accountUID, err)
+		return domain.NewInternal()
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return domain.NewInternal()
+	}
+
+	if affected == 0 {
+		return domain.NewNotFound("api key", uid.String())
+	}
+	return nil
+}
+
+func (r *apiKeyRepo) SetLastUsed(ctx context.Context, uid uuid.UUID, usedAt time.Time) error {
+	if _, err := r.db.ExecContext(ctx, `UPDATE api_key SET last_used_at=? WHERE uid=?`, usedAt, uid); err != nil {
+		log.Printf("Could not set last use of API key: %v. Reason: %v\n", uid, err)
+		return domain.NewInternal()
+	}
+	return nil
+}
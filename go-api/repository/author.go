@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"log"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/whuangz/go-example/go-api/domain"
+)
+
+// maxUsernameLength is the size of author.username
+const maxUsernameLength = 20
+
+type authorRepo struct {
+	db *sqlx.DB
+}
+
+func NewAuthorRepo(db *sqlx.DB) domain.AuthorRepository {
+	return &authorRepo{db: db}
+}
+
+// FindOrCreateByAccount returns the author of the account, adding it
+// the first time. Its username is the name of the account, or the
+// local part of its email when the account has no name
+func (r *authorRepo) FindOrCreateByAccount(ctx context.Context, a *domain.Account) (*domain.Author, error) {
+	author, err := r.findByAccount(ctx, a)
+	if err != nil || author != nil {
+		return author, err
+	}
+
+	username := authorUsername(a)
+
+	// ignored when a concurrent request added the author first
+	query := `INSERT IGNORE INTO author (account_uid, username, email, created_at) VALUES (?, ?, ?, NOW())`
+	if _, err := r.db.ExecContext(ctx, query, a.UID, username, a.Email); err != nil {
+		log.Printf("Could not create author of account with uid: %v. Reason: %v\n", a.UID, err)
+		return nil, domain.NewInternal()
+	}
+
+	author, err = r.findByAccount(ctx, a)
+	if err == nil && author == nil {
+		log.Printf("Author of account with uid: %v missing after creating it\n", a.UID)
+		return nil, domain.NewInternal()
+	}
+	return author, err
+}
+
+// authorUsername cuts the username to the size of author.username, which
+// counts characters like LEFT does in the migration adding the authors
+func authorUsername(a *domain.Account) string {
+	username := a.Name
+	if username == "" {
+		username = strings.SplitN(a.Email, "@", 2)[0]
+	}
+
+	runes := []rune(username)
+	if len(runes) > maxUsernameLength {
+		return string(runes[:maxUsernameLength])
+	}
+	return username
+}
+
+// findByAccount returns a nil author when the account has none yet
+func (r *authorRepo) findByAccount(ctx context.Context, a *domain.Account) (*domain.Author, error) {
+	query := `SELECT id, account_uid, username, email, updated_at, created_at FROM author WHERE account_uid=?`
+	rows, err := r.db.QueryContext(ctx, query, a.UID)
+
+	if err != nil {
+		log.Printf("Could not find author of account with uid: %v. Reason: %v\n", a.UID, err)
+		return nil, domain.NewInternal()
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return nil, nil
+	}
+
+	author := &domain.Author{}
+	if err := rows.Scan(&author.ID, &author.AccountUID, &author.Username, &author.Email, &author.UpdatedAt, &author.CreatedAt); err != nil {
+		log.Printf("Could not scan author of account with uid: %v. Reason: %v\n", a.UID, err)
+		return nil, domain.NewInternal()
+	}
+
+	return author, nil
+}
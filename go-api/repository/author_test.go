@@ -0,0 +1,32 @@
+package repository
+
+import (
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/whuangz/go-example/go-api/domain"
+)
+
+func TestAuthorUsername(t *testing.T) {
+	cases := []struct {
+		name     string
+		account  *domain.Account
+		username string
+	}{
+		{"Name", &domain.Account{Name: "William", Email: "whuangz@gmail.com"}, "William"},
+		{"Local part of the email", &domain.Account{Email: "whuangz@gmail.com"}, "whuangz"},
+		{"Long name", &domain.Account{Name: "William Huang Zhang Junior"}, "William Huang Zhang "},
+		{"Multibyte name", &domain.Account{Name: "Zoë Ångström-Økland Jr"}, "Zoë Ångström-Økland "},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			username := authorUsername(tc.account)
+
+			assert.Equal(t, tc.username, username)
+			assert.True(t, utf8.ValidString(username))
+			assert.LessOrEqual(t, utf8.RuneCountInString(username), maxUsernameLength)
+		})
+	}
+}
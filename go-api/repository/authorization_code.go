@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/whuangz/go-example/go-api/domain"
+)
+
+type authorizationCodeRepo struct {
+	redis *redis.Client
+}
+
+// NewAuthorizationCodeRepo creates a redis backed store of OAuth authorization codes
+func NewAuthorizationCodeRepo(redisClient *redis.Client) domain.AuthorizationCodeRepository {
+	return &authorizationCodeRepo{redis: redisClient}
+}
+
+func authorizationCodeKey(codeHash string) string {
+	return fmt.Sprintf("oauth_code:%s", codeHash)
+}
+
+func (r *authorizationCodeRepo) SaveCode(ctx context.Context, codeHash string, code *domain.AuthorizationCode, expiresIn time.Duration) error {
+	data, err := json.Marshal(code)
+	if err != nil {
+		log.Printf("Could not marshal authorization code of client: %s: %v\n", code.ClientID, err)
+		return domain.NewInternal()
+	}
+
+	if err := r.redis.Set(ctx, authorizationCodeKey(codeHash), data, expiresIn).Err(); err != nil {
+		log.Printf("Could not SET authorization code to redis for client: %s: %v\n", code.ClientID, err)
+		return domain.NewInternal()
+	}
+	return nil
+}
+
+// ConsumeCode returns a NotFound error when the code doesn't
+// exist, has expired or was already exchanged
+func (r *authorizationCodeRepo) ConsumeCode(ctx context.Context, codeHash string) (*domain.AuthorizationCode, error) {
+	// the script of the one time tokens gets and deletes any key
+	data, err := consumeOneTimeTokenScript.Run(ctx, r.redis, []string{authorizationCodeKey(codeHash)}).Text()
+	if err == redis.Nil {
+		return nil, domain.NewNotFound("authorization", "code")
+	}
+
+	if err != nil {
+		log.Printf("Could not consume authorization code from redis: %v\n", err)
+		return nil, domain.NewInternal()
+	}
+
+	code := &domain.AuthorizationCode{}
+	if err := json.Unmarshal([]byte(data), code); err != nil {
+		log.Printf("Could not unmarshal authorization code: %v\n", err)
+		return nil, domain.NewInternal()
+	}
+	return code, nil
+}
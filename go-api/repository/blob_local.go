@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/whuangz/go-example/go-api/domain"
+)
+
+type localBlobStore struct {
+	dir     string
+	baseURL string
+}
+
+// NewLocalBlobStore creates a blob store writing to a directory on disk,
+// the files are expected to be served under baseURL
+func NewLocalBlobStore(dir string, baseURL string) domain.BlobStore {
+	return &localBlobStore{dir: dir, baseURL: strings.TrimRight(baseURL, "/")}
+}
+
+func (s *localBlobStore) path(key string) string {
+	// Clean against the root so a key can't escape the directory
+	return filepath.Join(s.dir, filepath.FromSlash(filepath.Clean("/"+key)))
+}
+
+func (s *localBlobStore) Put(ctx context.Context, key string, contentType string, data []byte) error {
+	path := s.path(key)
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		log.Printf("Could not create directory for blob: %s: %v\n", key, err)
+		return domain.NewInternal()
+	}
+
+	// write to a temporary file first so readers never see a partial file
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0644); err != nil {
+		log.Printf("Could not write blob: %s: %v\n", key, err)
+		return domain.NewInternal()
+	}
+
+	if err := os.Rename(tmp, path); err != nil {
+		log.Printf("Could not write blob: %s: %v\n", key, err)
+		os.Remove(tmp)
+		return domain.NewInternal()
+	}
+
+	return nil
+}
+
+func (s *localBlobStore) Delete(ctx context.Context, key string) error {
+	if err := os.Remove(s.path(key)); err != nil && !os.IsNotExist(err) {
+		log.Printf("Could not delete blob: %s: %v\n", key, err)
+		return domain.NewInternal()
+	}
+	return nil
+}
+
+func (s *localBlobStore) URL(key string) string {
+	return fmt.Sprintf("%s/%s", s.baseURL, strings.TrimLeft(key, "/"))
+}
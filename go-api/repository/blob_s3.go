@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/whuangz/go-example/go-api/domain"
+	"github.com/whuangz/go-example/go-api/helpers/s3"
+)
+
+type s3BlobStore struct {
+	client    *s3.Client
+	publicURL string
+}
+
+// NewS3BlobStore creates a blob store on top of an S3 compatible storage.
+// When publicURL is empty, blobs are linked through the storage endpoint
+func NewS3BlobStore(client *s3.Client, publicURL string) domain.BlobStore {
+	return &s3BlobStore{client: client, publicURL: strings.TrimRight(publicURL, "/")}
+}
+
+func (s *s3BlobStore) Put(ctx context.Context, key string, contentType string, data []byte) error {
+	if err := s.client.PutObject(ctx, key, contentType, data); err != nil {
+		log.Printf("Could not upload blob: %s: %v\n", key, err)
+		return domain.NewInternal()
+	}
+	return nil
+}
+
+func (s *s3BlobStore) Delete(ctx context.Context, key string) error {
+	if err := s.client.DeleteObject(ctx, key); err != nil {
+		log.Printf("Could not delete blob: %s: %v\n", key, err)
+		return domain.NewInternal()
+	}
+	return nil
+}
+
+func (s *s3BlobStore) URL(key string) string {
+	if s.publicURL == "" {
+		return s.client.ObjectURL(key)
+	}
+	return fmt.Sprintf("%s/%s", s.publicURL, strings.TrimLeft(key, "/"))
+}
@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"strconv"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jmoiron/sqlx"
+	"github.com/whuangz/go-example/go-api/domain"
+)
+
+type categoryRepo struct {
+	db *sqlx.DB
+}
+
+func NewCategoryRepo(db *sqlx.DB) domain.CategoryRepository {
+	return &categoryRepo{db: db}
+}
+
+func (r *categoryRepo) Save(ctx context.Context, category *domain.Category) error {
+	query := `INSERT INTO category (parent_id, name, slug, created_at) VALUES (?, ?, ?, NOW())`
+	parentID := sql.NullInt32{Int32: category.ParentID, Valid: category.ParentID != 0}
+	result, err := r.db.ExecContext(ctx, query, parentID, category.Name, category.Slug)
+	if err != nil {
+		var mysqlErr *mysql.MySQLError
+		if errors.As(err, &mysqlErr) && mysqlErr.Number == mysqlDuplicateEntry {
+			return domain.NewConflict("slug", category.Slug)
+		}
+		log.Printf("Could not create category: %v. Reason: %v\n", category.Slug, err)
+		return domain.NewInternal()
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		log.Printf("Could not get id of category: %v. Reason: %v\n", category.Slug, err)
+		return domain.NewInternal()
+	}
+	category.ID = int32(id)
+	return nil
+}
+
+func (r *categoryRepo) FindAll(ctx context.Context) ([]domain.Category, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT id, parent_id, name, slug FROM category ORDER BY name`)
+	if err != nil {
+		log.Printf("Could not find categories. Reason: %v\n", err)
+		return nil, domain.NewInternal()
+	}
+	defer rows.Close()
+
+	categories := []domain.Category{}
+	for rows.Next() {
+		category, err := scanCategory(rows)
+		if err != nil {
+			log.Printf("Could not scan category. Reason: %v\n", err)
+			return nil, domain.NewInternal()
+		}
+		categories = append(categories, category)
+	}
+	return categories, nil
+}
+
+func (r *categoryRepo) FindByID(ctx context.Context, id int32) (domain.Category, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT id, parent_id, name, slug FROM category WHERE id = ?`, id)
+	if err != nil {
+		log.Printf("Could not find category with id: %v. Reason: %v\n", id, err)
+		return domain.Category{}, domain.NewInternal()
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return domain.Category{}, domain.NewNotFound("category_id", strconv.Itoa(int(id)))
+	}
+
+	category, err := scanCategory(rows)
+	if err != nil {
+		log.Printf("Could not scan category with id: %v. Reason: %v\n", id, err)
+		return domain.Category{}, domain.NewInternal()
+	}
+	return category, nil
+}
+
+func scanCategory(rows *sql.Rows) (domain.Category, error) {
+	category := domain.Category{}
+	var parentID sql.NullInt32
+	err := rows.Scan(&category.ID, &parentID, &category.Name, &category.Slug)
+	category.ParentID = parentID.Int32
+	return category, err
+}
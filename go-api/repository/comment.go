@@ -0,0 +1,125 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/whuangz/go-example/go-api/domain"
+)
+
+type commentRepo struct {
+	db *sqlx.DB
+}
+
+func NewCommentRepo(db *sqlx.DB) domain.CommentRepository {
+	return &commentRepo{db: db}
+}
+
+// commentColumns selects a comment along with the name of its account,
+// accounts that are gone leave the name empty
+const commentColumns = `comment.id, comment.post_id, comment.parent_id, comment.depth, comment.account_uid, COALESCE(account.name, ''),
+	comment.content, comment.status, comment.edited_at, comment.deleted_at, comment.created_at`
+
+const commentTables = `comment LEFT JOIN account ON account.uid = comment.account_uid`
+
+func scanComment(rows *sql.Rows) (domain.Comment, error) {
+	var comment domain.Comment
+	var parentID sql.NullInt32
+	var deletedAt sql.NullTime
+	err := rows.Scan(&comment.ID, &comment.PostID, &parentID, &comment.Depth, &comment.AccountUID, &comment.AccountName,
+		&comment.Content, &comment.Status, &comment.EditedAt, &deletedAt, &comment.CreatedAt)
+	comment.ParentID = parentID.Int32
+	comment.Deleted = deletedAt.Valid
+	return comment, err
+}
+
+func (r *commentRepo) Save(ctx context.Context, comment *domain.Comment) error {
+	query := `INSERT INTO comment (post_id, parent_id, depth, account_uid, content, status, created_at) VALUES (?, ?, ?, ?, ?, ?, ?)`
+	parentID := sql.NullInt32{Int32: comment.ParentID, Valid: comment.ParentID != 0}
+	now := time.Now()
+	result, err := r.db.ExecContext(ctx, query, comment.PostID, parentID, comment.Depth, comment.AccountUID,
+		comment.Content, comment.Status, now)
+	if err != nil {
+		log.Printf("Could not create comment on post: %v. Reason: %v\n", comment.PostID, err)
+		return domain.NewInternal()
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		log.Printf("Could not get id of comment on post: %v. Reason: %v\n", comment.PostID, err)
+		return domain.NewInternal()
+	}
+	comment.ID = int32(id)
+	comment.CreatedAt = now
+	return nil
+}
+
+func (r *commentRepo) FindByID(ctx context.Context, id int32) (domain.Comment, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+commentColumns+` FROM `+commentTables+` WHERE comment.id = ?`, id)
+	if err != nil {
+		log.Printf("Could not find comment with id: %v. Reason: %v\n", id, err)
+		return domain.Comment{}, domain.NewInternal()
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return domain.Comment{}, domain.NewNotFound("comment", strconv.Itoa(int(id)))
+	}
+	comment, err := scanComment(rows)
+	if err != nil {
+		log.Printf("Could not scan comment with id: %v. Reason: %v\n", id, err)
+		return domain.Comment{}, domain.NewInternal()
+	}
+	return comment, nil
+}
+
+func (r *commentRepo) FindByPost(ctx context.Context, postID int32) ([]domain.Comment, error) {
+	query := `SELECT ` + commentColumns + ` FROM ` + commentTables + ` WHERE comment.post_id = ? ORDER BY comment.created_at, comment.id`
+	rows, err := r.db.QueryContext(ctx, query, postID)
+	if err != nil {
+		log.Printf("Could not find comments of post: %v. Reason: %v\n", postID, err)
+		return nil, domain.NewInternal()
+	}
+	defer rows.Close()
+
+	comments := []domain.Comment{}
+	for rows.Next() {
+		comment, err := scanComment(rows)
+		if err != nil {
+			log.Printf("Could not scan comment of post: %v. Reason: %v\n", postID, err)
+			return nil, domain.NewInternal()
+		}
+		comments = append(comments, comment)
+	}
+	return comments, nil
+}
+
+// Update leaves deleted comments as they are. Rows left unchanged aren't
+// counted as affected by MySQL, so an update to the same status succeeds
+func (r *commentRepo) Update(ctx context.Context, comment *domain.Comment) error {
+	query := `UPDATE comment SET content = ?, status = ?, edited_at = ? WHERE id = ? AND deleted_at IS NULL`
+	_, err := r.db.ExecContext(ctx, query, comment.Content, comment.Status, comment.EditedAt, comment.ID)
+	if err != nil {
+		log.Printf("Could not update comment: %v. Reason: %v\n", comment.ID, err)
+		return domain.NewInternal()
+	}
+	return nil
+}
+
+func (r *commentRepo) Delete(ctx context.Context, id int32) error {
+	query := `UPDATE comment SET content = '', deleted_at = ? WHERE id = ? AND deleted_at IS NULL`
+	result, err := r.db.ExecContext(ctx, query, time.Now(), id)
+	if err != nil {
+		log.Printf("Could not delete comment: %v. Reason: %v\n", id, err)
+		return domain.NewInternal()
+	}
+
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return domain.NewNotFound("comment", strconv.Itoa(int(id)))
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/whuangz/go-example/go-api/domain"
+	"github.com/whuangz/go-example/go-api/helpers/crypto"
+)
+
+// releaseLockScript deletes the lock only when it still holds the token
+// of the caller, a lock which expired meanwhile may be held by another
+var releaseLockScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+type redisLocker struct {
+	redis *redis.Client
+}
+
+// NewRedisLocker creates locks held in redis, they expire
+// by themselves when the holder goes away
+func NewRedisLocker(redisClient *redis.Client) domain.Locker {
+	return &redisLocker{redis: redisClient}
+}
+
+func lockKey(key string) string {
+	return fmt.Sprintf("lock:%s", key)
+}
+
+func (l *redisLocker) Acquire(ctx context.Context, key string, ttl time.Duration) (func(), bool, error) {
+	token, err := crypto.RandomToken(16)
+	if err != nil {
+		log.Printf("Could not generate token of lock: %s: %v\n", key, err)
+		return nil, false, domain.NewInternal()
+	}
+
+	acquired, err := l.redis.SetNX(ctx, lockKey(key), token, ttl).Result()
+	if err != nil {
+		log.Printf("Could not SET lock to redis for key: %s: %v\n", key, err)
+		return nil, false, domain.NewInternal()
+	}
+	if !acquired {
+		return nil, false, nil
+	}
+
+	release := func() {
+		// released even when the context of the holder is done
+		if err := releaseLockScript.Run(context.Background(), l.redis, []string{lockKey(key)}, token).Err(); err != nil {
+			log.Printf("Could not release lock in redis for key: %s: %v\n", key, err)
+		}
+	}
+	return release, true, nil
+}
@@ -0,0 +1,145 @@
+package repository
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/whuangz/go-example/go-api/domain"
+)
+
+type mfaRepo struct {
+	db *sqlx.DB
+}
+
+func NewMFARepo(db *sqlx.DB) domain.MFARepository {
+	return &mfaRepo{db: db}
+}
+
+func (r *mfaRepo) FindByUID(ctx context.Context, uid uuid.UUID) (*domain.MFA, error) {
+	m := &domain.MFA{}
+	query := `SELECT account_uid, secret, enabled_at, last_used_step FROM account_mfa WHERE account_uid=?`
+	rows, err := r.db.QueryContext(ctx, query, uid)
+
+	if err != nil {
+		log.Printf("Could not find 2FA of account with uid: %v. Reason: %v\n", uid, err)
+		return nil, domain.NewInternal()
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return nil, domain.NewNotFound("2fa", uid.String())
+	}
+
+	if err := rows.Scan(&m.AccountUID, &m.Secret, &m.EnabledAt, &m.LastUsedStep); err != nil {
+		log.Printf("Could not scan 2FA of account with uid: %v. Reason: %v\n", uid, err)
+		return nil, domain.NewInternal()
+	}
+
+	return m, nil
+}
+
+// Save creates or replaces the 2FA of an account
+func (r *mfaRepo) Save(ctx context.Context, m *domain.MFA) error {
+	query := `INSERT INTO account_mfa (account_uid, secret, enabled_at, last_used_step, created_at) VALUES (?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE secret=VALUES(secret), enabled_at=VALUES(enabled_at), last_used_step=VALUES(last_used_step), updated_at=?`
+	now := time.Now()
+
+	if _, err := r.db.ExecContext(ctx, query, m.AccountUID, m.Secret, m.EnabledAt, m.LastUsedStep, now, now); err != nil {
+		log.Printf("Could not save 2FA of account with uid: %v. Reason: %v\n", m.AccountUID, err)
+		return domain.NewInternal()
+	}
+	return nil
+}
+
+// Delete removes the 2FA of an account along with its recovery codes
+func (r *mfaRepo) Delete(ctx context.Context, uid uuid.UUID) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		log.Printf("Could not begin transaction to delete 2FA of uid: %v. Reason: %v\n", uid, err)
+		return domain.NewInternal()
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM account_recovery_code WHERE account_uid=?`, uid); err != nil {
+		log.Printf("Could not delete recovery codes of uid: %v. Reason: %v\n", uid, err)
+		return domain.NewInternal()
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM account_mfa WHERE account_uid=?`, uid); err != nil {
+		log.Printf("Could not delete 2FA of uid: %v. Reason: %v\n", uid, err)
+		return domain.NewInternal()
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("Could not commit deleting 2FA of uid: %v. Reason: %v\n", uid, err)
+		return domain.NewInternal()
+	}
+	return nil
+}
+
+// UseStep records the time step of an accepted code. It returns false
+// when a code of that step, or a later one, was already used
+func (r *mfaRepo) UseStep(ctx context.Context, uid uuid.UUID, step int64) (bool, error) {
+	query := `UPDATE account_mfa SET last_used_step=? WHERE account_uid=? AND last_used_step < ?`
+
+	result, err := r.db.ExecContext(ctx, query, step, uid, step)
+	if err != nil {
+		log.Printf("Could not use 2FA step of uid: %v. Reason: %v\n", uid, err)
+		return false, domain.NewInternal()
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, domain.NewInternal()
+	}
+	return affected == 1, nil
+}
+
+// SetRecoveryCodes replaces the recovery codes of an account
+func (r *mfaRepo) SetRecoveryCodes(ctx context.Context, uid uuid.UUID, codeHashes []string) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		log.Printf("Could not begin transaction to set recovery codes of uid: %v. Reason: %v\n", uid, err)
+		return domain.NewInternal()
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM account_recovery_code WHERE account_uid=?`, uid); err != nil {
+		log.Printf("Could not delete recovery codes of uid: %v. Reason: %v\n", uid, err)
+		return domain.NewInternal()
+	}
+
+	for _, hash := range codeHashes {
+		if _, err := tx.ExecContext(ctx, `INSERT INTO account_recovery_code (account_uid, code_hash) VALUES (?, ?)`, uid, hash); err != nil {
+			log.Printf("Could not insert recovery code of uid: %v. Reason: %v\n", uid, err)
+			return domain.NewInternal()
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("Could not commit recovery codes of uid: %v. Reason: %v\n", uid, err)
+		return domain.NewInternal()
+	}
+	return nil
+}
+
+// UseRecoveryCode marks a recovery code as used, it
+// returns false when it doesn't exist or was already used
+func (r *mfaRepo) UseRecoveryCode(ctx context.Context, uid uuid.UUID, codeHash string) (bool, error) {
+	query := `UPDATE account_recovery_code SET used_at=? WHERE account_uid=? AND code_hash=? AND used_at IS NULL`
+
+	result, err := r.db.ExecContext(ctx, query, time.Now(), uid, codeHash)
+	if err != nil {
+		log.Printf("Could not use recovery code of uid: %v. Reason: %v\n", uid, err)
+		return false, domain.NewInternal()
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, domain.NewInternal()
+	}
+	return affected == 1, nil
+}
@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/whuangz/go-example/go-api/domain"
+)
+
+type oauthRepo struct {
+	db *sqlx.DB
+}
+
+// NewOAuthRepo creates the registry of OAuth clients and
+// the consents accounts gave them. Lists are stored space separated
+func NewOAuthRepo(db *sqlx.DB) domain.OAuthRepository {
+	return &oauthRepo{db: db}
+}
+
+func (r *oauthRepo) FindClient(ctx context.Context, clientID string) (*domain.OAuthClient, error) {
+	c := &domain.OAuthClient{}
+	var secretHash sql.NullString
+	var redirectURIs, scopes, grantTypes string
+
+	query := `SELECT id, client_id, secret_hash, name, redirect_uris, scopes, grant_types, updated_at, created_at
+		FROM oauth_client WHERE client_id=?`
+	rows, err := r.db.QueryContext(ctx, query, clientID)
+
+	if err != nil {
+		log.Printf("Could not find OAuth client: %v. Reason: %v\n", clientID, err)
+		return nil, domain.NewInternal()
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return nil, domain.NewNotFound("client_id", clientID)
+	}
+
+	if err := rows.Scan(&c.ID, &c.ClientID, &secretHash, &c.Name, &redirectURIs, &scopes, &grantTypes, &c.UpdatedAt, &c.CreatedAt); err != nil {
+		log.Printf("Could not scan OAuth client: %v. Reason: %v\n", clientID, err)
+		return nil, domain.NewInternal()
+	}
+
+	c.SecretHash = secretHash.String
+	c.RedirectURIs = strings.Fields(redirectURIs)
+	c.Scopes = strings.Fields(scopes)
+	c.GrantTypes = strings.Fields(grantTypes)
+
+	return c, nil
+}
+
+func (r *oauthRepo) CreateClient(ctx context.Context, c *domain.OAuthClient) error {
+	query := `INSERT INTO oauth_client (client_id, secret_hash, name, redirect_uris, scopes, grant_types, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`
+	now := time.Now()
+
+	// public clients have no secret
+	secretHash := sql.NullString{String: c.SecretHash, Valid: c.SecretHash != ""}
+
+	result, err := r.db.ExecContext(ctx, query, c.ClientID, secretHash, c.Name,
+		strings.Join(c.RedirectURIs, " "), strings.Join(c.Scopes, " "), strings.Join(c.GrantTypes, " "), now)
+	if err != nil {
+		var mysqlErr *mysql.MySQLError
+		if errors.As(err, &mysqlErr) && mysqlErr.Number == mysqlDuplicateEntry {
+			return domain.NewConflict("client_id", c.ClientID)
+		}
+
+		log.Printf("Could not create OAuth client: %v. Reason: %v\n", c.ClientID, err)
+		return domain.NewInternal()
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return domain.NewInternal()
+	}
+	c.ID = int32(id)
+	c.CreatedAt = now
+
+	return nil
+}
+
+func (r *oauthRepo) FindConsent(ctx context.Context, uid uuid.UUID, clientID string) (*domain.OAuthConsent, error) {
+	consent := &domain.OAuthConsent{}
+	var scopes string
+
+	query := `SELECT account_uid, client_id, scopes FROM oauth_consent WHERE account_uid=? AND client_id=?`
+	rows, err := r.db.QueryContext(ctx, query, uid, clientID)
+
+	if err != nil {
+		log.Printf("Could not find consent of uid: %v to client: %v. Reason: %v\n", uid, clientID, err)
+		return nil, domain.NewInternal()
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return nil, domain.NewNotFound("consent", clientID)
+	}
+
+	if err := rows.Scan(&consent.AccountUID, &consent.ClientID, &scopes); err != nil {
+		log.Printf("Could not scan consent of uid: %v to client: %v. Reason: %v\n", uid, clientID, err)
+		return nil, domain.NewInternal()
+	}
+	consent.Scopes = strings.Fields(scopes)
+
+	return consent, nil
+}
+
+// SaveConsent creates or replaces the consent of an account to a client
+func (r *oauthRepo) SaveConsent(ctx context.Context, consent *domain.OAuthConsent) error {
+	query := `INSERT INTO oauth_consent (account_uid, client_id, scopes, created_at) VALUES (?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE scopes=VALUES(scopes), updated_at=?`
+	now := time.Now()
+
+	if _, err := r.db.ExecContext(ctx, query, consent.AccountUID, consent.ClientID, strings.Join(consent.Scopes, " "), now, now); err != nil {
+		log.Printf("Could not save consent of uid: %v to client: %v. Reason: %v\n", consent.AccountUID, consent.ClientID, err)
+		return domain.NewInternal()
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/whuangz/go-example/go-api/domain"
+)
+
+// setOneTimeTokenScript stores a token and replaces the
+// previous token of the same kind issued for the account
+var setOneTimeTokenScript = redis.NewScript(`
+local prev = redis.call('GET', KEYS[2])
+if prev then
+	redis.call('DEL', prev)
+end
+redis.call('SET', KEYS[1], ARGV[1], 'PX', ARGV[2])
+redis.call('SET', KEYS[2], KEYS[1], 'PX', ARGV[2])
+return 1
+`)
+
+// consumeOneTimeTokenScript gets and deletes a token in one step
+// so it can't be used twice by concurrent requests
+var consumeOneTimeTokenScript = redis.NewScript(`
+local accID = redis.call('GET', KEYS[1])
+if not accID then
+	return false
+end
+redis.call('DEL', KEYS[1])
+return accID
+`)
+
+type oneTimeTokenRepo struct {
+	redis *redis.Client
+}
+
+// NewOneTimeTokenRepo creates a redis backed store of single use tokens
+func NewOneTimeTokenRepo(redisClient *redis.Client) domain.OneTimeTokenRepository {
+	return &oneTimeTokenRepo{redis: redisClient}
+}
+
+func oneTimeTokenKey(kind string, tokenHash string) string {
+	return fmt.Sprintf("%s:%s", kind, tokenHash)
+}
+
+func latestOneTimeTokenKey(kind string, accID string) string {
+	return fmt.Sprintf("%s:account:%s", kind, accID)
+}
+
+func (r *oneTimeTokenRepo) SetToken(ctx context.Context, kind string, tokenHash string, accID string, expiresIn time.Duration) error {
+	keys := []string{oneTimeTokenKey(kind, tokenHash), latestOneTimeTokenKey(kind, accID)}
+
+	if err := setOneTimeTokenScript.Run(ctx, r.redis, keys, accID, expiresIn.Milliseconds()).Err(); err != nil {
+		log.Printf("Could not SET %s token to redis for accID: %s: %v\n", kind, accID, err)
+		return domain.NewInternal()
+	}
+	return nil
+}
+
+// ConsumeToken returns a NotFound error when the
+// token doesn't exist, has expired or was already used
+func (r *oneTimeTokenRepo) ConsumeToken(ctx context.Context, kind string, tokenHash string) (string, error) {
+	accID, err := consumeOneTimeTokenScript.Run(ctx, r.redis, []string{oneTimeTokenKey(kind, tokenHash)}).Text()
+	if err == redis.Nil {
+		return "", domain.NewNotFound(kind, "token")
+	}
+
+	if err != nil {
+		log.Printf("Could not consume %s token from redis: %v\n", kind, err)
+		return "", domain.NewInternal()
+	}
+
+	return accID, nil
+}
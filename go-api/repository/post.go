@@ -2,11 +2,19 @@ package repository
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/go-sql-driver/mysql"
+	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
 	"github.com/whuangz/go-example/go-api/domain"
+	"github.com/whuangz/go-example/go-api/helpers/db"
 )
 
 type postRepo struct {
@@ -17,46 +25,165 @@ func NewPostRepo(db *sqlx.DB) domain.PostRepository {
 	return &postRepo{db: db}
 }
 
+// postColumns selects a post along with its author and category
+const postColumns = `post.id, post.title, post.slug, post.content, post.status, post.published_at, post.publish_at, post.version, post.updated_at, post.created_at,
+	author.id, author.account_uid, author.username, author.email, author.updated_at, author.created_at,
+	category.id, category.parent_id, category.name, category.slug`
+
+const postTables = `post JOIN author ON author.id = post.author_id LEFT JOIN category ON category.id = post.category_id`
+
+func scanPost(rows *sql.Rows) (domain.Post, error) {
+	post := domain.Post{}
+	var categoryID, parentID sql.NullInt32
+	var categoryName, categorySlug sql.NullString
+	err := rows.Scan(&post.ID, &post.Title, &post.Slug, &post.Content, &post.Status, &post.PublishedAt, &post.PublishAt, &post.Version, &post.UpdatedAt, &post.CreatedAt,
+		&post.Author.ID, &post.Author.AccountUID, &post.Author.Username, &post.Author.Email, &post.Author.UpdatedAt, &post.Author.CreatedAt,
+		&categoryID, &parentID, &categoryName, &categorySlug)
+	post.AuthorID = post.Author.ID
+	if categoryID.Valid {
+		post.CategoryID = categoryID.Int32
+		post.Category = &domain.Category{
+			ID:       categoryID.Int32,
+			ParentID: parentID.Int32,
+			Name:     categoryName.String,
+			Slug:     categorySlug.String,
+		}
+	}
+	return post, err
+}
+
+// nullCategory stores posts without a category as NULL
+func nullCategory(id int32) sql.NullInt32 {
+	return sql.NullInt32{Int32: id, Valid: id != 0}
+}
+
 func (p *postRepo) Save(ctx context.Context, post *domain.Post) error {
+	return db.WithTransaction(p.db, func(tx db.Transaction) error {
+		query := `INSERT INTO post(title, content, author_id, category_id, status, published_at, publish_at, created_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
+		now := time.Now()
+		result, err := tx.ExecContext(ctx, query, post.Title, post.Content, post.AuthorID, nullCategory(post.CategoryID),
+			post.Status, post.PublishedAt, post.PublishAt, now)
+		if err != nil {
+			return err
+		}
+		post.CreatedAt = now
+		post.Version = 1
+		id, err := result.LastInsertId()
+		if err != nil {
+			return err
+		}
+		post.ID = int32(id)
 
-	query := "INSERT INTO post(title, content, author_id, created_at) VALUES (?, ?, ?, ?)"
-	now := time.Now()
-	result, err := p.db.ExecContext(ctx, query, post.Title, post.Content, post.AuthorID, now)
-	if err != nil {
-		return err
+		if post.Slug, err = saveSlug(ctx, tx, post.ID, post.Slug); err != nil {
+			return err
+		}
+		if err := addRevision(ctx, tx, post.ID, post, post.Author.AccountUID, now); err != nil {
+			return err
+		}
+
+		if post.Tags == nil {
+			post.Tags = []domain.Tag{}
+		}
+		return setPostTags(ctx, tx, post.ID, post.Tags)
+	})
+}
+
+func (p *postRepo) FindAll(ctx context.Context, filter domain.PostFilter, cursor *domain.PostCursor, limit int) ([]domain.Post, error) {
+	var where []string
+	var args []interface{}
+
+	if filter.AuthorID != 0 {
+		where = append(where, "post.author_id = ?")
+		args = append(args, filter.AuthorID)
 	}
-	post.CreatedAt = now
-	id, err := result.LastInsertId()
-	if err != nil {
-		return err
+	if filter.AccountUID != uuid.Nil {
+		where = append(where, "author.account_uid = ?")
+		args = append(args, filter.AccountUID)
+	}
+	if filter.Status != "" {
+		where = append(where, "post.status = ?")
+		args = append(args, filter.Status)
+	}
+	if !filter.From.IsZero() {
+		where = append(where, "post.created_at >= ?")
+		args = append(args, filter.From)
+	}
+	if !filter.To.IsZero() {
+		where = append(where, "post.created_at <= ?")
+		args = append(args, filter.To)
+	}
+	if filter.Tag != "" {
+		where = append(where, `post.id IN (SELECT post_tag.post_id FROM post_tag JOIN tag ON tag.id = post_tag.tag_id WHERE tag.slug = ?)`)
+		args = append(args, filter.Tag)
+	}
+	if filter.Category != "" {
+		// the category and everything nested under it
+		where = append(where, `post.category_id IN (
+			WITH RECURSIVE subcategory (id) AS (
+				SELECT id FROM category WHERE slug = ?
+				UNION ALL
+				SELECT category.id FROM category JOIN subcategory ON category.parent_id = subcategory.id
+			) SELECT id FROM subcategory)`)
+		args = append(args, filter.Category)
 	}
-	post.ID = int32(id)
-	post.AuthorID = 1
-	return nil
-}
 
-func (p *postRepo) FindAll(ctx context.Context) ([]domain.Post, error) {
+	// walking backward reads the posts before the cursor in reverse
+	descending := filter.Sort != domain.OldestFirst
+	if cursor != nil && cursor.Backward {
+		descending = !descending
+	}
 
-	posts := []domain.Post{}
-	query := "SELECT * FROM post"
-	rows, err := p.db.QueryContext(ctx, query)
+	if cursor != nil {
+		op := ">"
+		if descending {
+			op = "<"
+		}
+		where = append(where, "(post.created_at, post.id) "+op+" (?, ?)")
+		args = append(args, cursor.CreatedAt, cursor.ID)
+	}
 
+	order := "ASC"
+	if descending {
+		order = "DESC"
+	}
+
+	query := `SELECT ` + postColumns + ` FROM ` + postTables
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY post.created_at " + order + ", post.id " + order + " LIMIT ?"
+	args = append(args, limit)
+
+	rows, err := p.db.QueryContext(ctx, query, args...)
 	if err != nil {
-		return posts, err
+		log.Printf("Could not find posts. Reason: %v\n", err)
+		return nil, domain.NewInternal()
 	}
 	defer rows.Close()
 
+	posts := []domain.Post{}
 	for rows.Next() {
-		post := domain.Post{}
-		rows.Scan(&post.ID, &post.Title, &post.Content, &post.Author.ID, &post.UpdatedAt, &post.CreatedAt)
+		post, err := scanPost(rows)
+		if err != nil {
+			log.Printf("Could not scan post. Reason: %v\n", err)
+			return nil, domain.NewInternal()
+		}
 		posts = append(posts, post)
 	}
-	return posts, nil
+
+	if cursor != nil && cursor.Backward {
+		for l, r := 0, len(posts)-1; l < r; l, r = l+1, r-1 {
+			posts[l], posts[r] = posts[r], posts[l]
+		}
+	}
+
+	return posts, p.findTags(ctx, posts)
 }
 
 func (p *postRepo) FindByID(ctx context.Context, id int32) (domain.Post, error) {
 	post := domain.Post{}
-	query := "SELECT * FROM post WHERE id = ? LIMIT 1"
+	query := `SELECT ` + postColumns + ` FROM ` + postTables + ` WHERE post.id = ? LIMIT 1`
 	rows, err := p.db.QueryContext(ctx, query, id)
 
 	if err != nil {
@@ -65,41 +192,317 @@ func (p *postRepo) FindByID(ctx context.Context, id int32) (domain.Post, error)
 	defer rows.Close()
 
 	if rows.Next() {
-		rows.Scan(&post.ID, &post.Title, &post.Content, &post.Author.ID, &post.UpdatedAt, &post.CreatedAt)
-		return post, nil
+		post, err := scanPost(rows)
+		if err != nil {
+			log.Printf("Could not scan post with id: %v. Reason: %v\n", id, err)
+			return post, domain.NewInternal()
+		}
+		rows.Close()
+
+		posts := []domain.Post{post}
+		err = p.findTags(ctx, posts)
+		return posts[0], err
 	} else {
 		return post, domain.NewNotFound("id", strconv.Itoa(int(id)))
 	}
 }
 
-func (p *postRepo) Update(ctx context.Context, id int32, post *domain.Post) error {
-	query := `UPDATE post set title=?, content=?, updated_at=? WHERE id = ?`
-	now := time.Now()
-	//Coalesce
-	res, err := p.db.ExecContext(ctx, query, post.Title, post.Content, now, post.ID)
+func (p *postRepo) FindBySlug(ctx context.Context, slug string) (domain.Post, error) {
+	var id int32
+	err := p.db.GetContext(ctx, &id, `SELECT post_id FROM post_slug WHERE slug = ?`, slug)
+	if err == sql.ErrNoRows {
+		return domain.Post{}, domain.NewNotFound("slug", slug)
+	}
+	if err != nil {
+		log.Printf("Could not find post with slug: %v. Reason: %v\n", slug, err)
+		return domain.Post{}, domain.NewInternal()
+	}
+
+	return p.FindByID(ctx, id)
+}
+
+func (p *postRepo) FindByIDs(ctx context.Context, ids []int32) ([]domain.Post, error) {
+	posts := []domain.Post{}
+	if len(ids) == 0 {
+		return posts, nil
+	}
+
+	query, args, err := sqlx.In(`SELECT `+postColumns+` FROM `+postTables+` WHERE post.id IN (?)`, ids)
+	if err != nil {
+		log.Printf("Could not build query of posts: %v. Reason: %v\n", ids, err)
+		return nil, domain.NewInternal()
+	}
+
+	rows, err := p.db.QueryContext(ctx, p.db.Rebind(query), args...)
 	if err != nil {
+		log.Printf("Could not find posts: %v. Reason: %v\n", ids, err)
+		return nil, domain.NewInternal()
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		post, err := scanPost(rows)
+		if err != nil {
+			log.Printf("Could not scan post. Reason: %v\n", err)
+			return nil, domain.NewInternal()
+		}
+		posts = append(posts, post)
+	}
+	return posts, p.findTags(ctx, posts)
+}
+
+func (p *postRepo) Update(ctx context.Context, id int32, post *domain.Post, editor uuid.UUID) error {
+	return db.WithTransaction(p.db, func(tx db.Transaction) error {
+		query := `UPDATE post set title=?, content=?, category_id=?, status=?, published_at=?, publish_at=?, updated_at=?, version=version+1
+			WHERE id = ? AND version = ?`
+		now := time.Now()
+		res, err := tx.ExecContext(ctx, query, post.Title, post.Content, nullCategory(post.CategoryID),
+			post.Status, post.PublishedAt, post.PublishAt, now, id, post.Version)
+		if err != nil {
+			return err
+		}
+
+		post.UpdatedAt.Time = now
+
+		if affect, _ := res.RowsAffected(); affect != 1 {
+			return versionMismatch(ctx, tx, id)
+		}
+		post.Version++
+
+		if post.Slug, err = saveSlug(ctx, tx, id, post.Slug); err != nil {
+			return err
+		}
+		if err := addRevision(ctx, tx, id, post, editor, now); err != nil {
+			return err
+		}
+
+		if post.Tags == nil {
+			return nil
+		}
+		if err := setPostTags(ctx, tx, id, post.Tags); err != nil {
+			return err
+		}
+		return deleteOrphanTags(ctx, tx)
+	})
+}
+
+func (p *postRepo) Delete(ctx context.Context, id int32, version int32) error {
+	return db.WithTransaction(p.db, func(tx db.Transaction) error {
+		query := "DELETE FROM post WHERE id = ? AND version = ?"
+		results, err := tx.ExecContext(ctx, query, id, version)
+		if err != nil {
+			return err
+		}
+
+		if rowsAfected, _ := results.RowsAffected(); rowsAfected != 1 {
+			return versionMismatch(ctx, tx, id)
+		}
+
+		// the tags of the post went along with it
+		return deleteOrphanTags(ctx, tx)
+	})
+}
+
+func (p *postRepo) PublishDue(ctx context.Context, now time.Time) ([]int32, error) {
+	ids := []int32{}
+	err := db.WithTransaction(p.db, func(tx db.Transaction) error {
+		rows, err := tx.QueryContext(ctx, `SELECT id FROM post WHERE status = ? AND publish_at <= ? FOR UPDATE`,
+			domain.ScheduledStatus, now)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var id int32
+			if err := rows.Scan(&id); err != nil {
+				return err
+			}
+			ids = append(ids, id)
+		}
+		rows.Close()
+
+		if len(ids) == 0 {
+			return nil
+		}
+
+		query, args, err := sqlx.In(`UPDATE post SET status = ?, published_at = publish_at, publish_at = NULL, version = version + 1 WHERE id IN (?)`,
+			domain.PublishedStatus, ids)
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, p.db.Rebind(query), args...)
 		return err
+	})
+
+	if err != nil {
+		log.Printf("Could not publish the scheduled posts due. Reason: %v\n", err)
+		return nil, domain.NewInternal()
 	}
+	return ids, nil
+}
 
-	post.UpdatedAt.Time = now
+// FindRevisions lists the revisions of the post, newest first, without their content
+func (p *postRepo) FindRevisions(ctx context.Context, postID int32) ([]domain.PostRevision, error) {
+	revisions := []domain.PostRevision{}
+	query := `SELECT post_id, number, title, account_uid, created_at FROM post_revision WHERE post_id = ? ORDER BY number DESC`
+	rows, err := p.db.QueryContext(ctx, query, postID)
+	if err != nil {
+		log.Printf("Could not find revisions of post: %v. Reason: %v\n", postID, err)
+		return nil, domain.NewInternal()
+	}
+	defer rows.Close()
 
-	if affect, _ := res.RowsAffected(); affect != 1 {
-		return domain.NewBadRequest("id not found")
+	for rows.Next() {
+		var r domain.PostRevision
+		if err := rows.Scan(&r.PostID, &r.Number, &r.Title, &r.AccountUID, &r.CreatedAt); err != nil {
+			log.Printf("Could not scan revision of post: %v. Reason: %v\n", postID, err)
+			return nil, domain.NewInternal()
+		}
+		revisions = append(revisions, r)
 	}
+	return revisions, nil
+}
 
-	return nil
+func (p *postRepo) FindRevision(ctx context.Context, postID int32, number int32) (domain.PostRevision, error) {
+	var r domain.PostRevision
+	query := `SELECT post_id, number, title, content, account_uid, created_at FROM post_revision WHERE post_id = ? AND number = ?`
+	err := p.db.QueryRowContext(ctx, query, postID, number).Scan(&r.PostID, &r.Number, &r.Title, &r.Content, &r.AccountUID, &r.CreatedAt)
+	if err == sql.ErrNoRows {
+		return r, domain.NewNotFound("revision", strconv.Itoa(int(number)))
+	}
+	if err != nil {
+		log.Printf("Could not find revision: %v of post: %v. Reason: %v\n", number, postID, err)
+		return r, domain.NewInternal()
+	}
+	return r, nil
 }
 
-func (p *postRepo) Delete(ctx context.Context, id int32) error {
-	query := "DELETE FROM post WHERE id = ?"
-	results, err := p.db.ExecContext(ctx, query, id)
+// findTags sets the tags of the posts
+func (p *postRepo) findTags(ctx context.Context, posts []domain.Post) error {
+	if len(posts) == 0 {
+		return nil
+	}
+
+	ids := make([]int32, len(posts))
+	byID := make(map[int32]*domain.Post, len(posts))
+	for i := range posts {
+		ids[i] = posts[i].ID
+		byID[posts[i].ID] = &posts[i]
+		posts[i].Tags = []domain.Tag{}
+	}
+
+	query, args, err := sqlx.In(`SELECT post_tag.post_id, tag.id, tag.name, tag.slug FROM post_tag
+		JOIN tag ON tag.id = post_tag.tag_id WHERE post_tag.post_id IN (?) ORDER BY tag.slug`, ids)
 	if err != nil {
-		return err
+		log.Printf("Could not build query of tags of posts: %v. Reason: %v\n", ids, err)
+		return domain.NewInternal()
 	}
 
-	if rowsAfected, _ := results.RowsAffected(); rowsAfected != 1 {
-		return domain.NewBadRequest("id not found")
+	rows, err := p.db.QueryContext(ctx, p.db.Rebind(query), args...)
+	if err != nil {
+		log.Printf("Could not find tags of posts: %v. Reason: %v\n", ids, err)
+		return domain.NewInternal()
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var postID int32
+		tag := domain.Tag{}
+		if err := rows.Scan(&postID, &tag.ID, &tag.Name, &tag.Slug); err != nil {
+			log.Printf("Could not scan tag. Reason: %v\n", err)
+			return domain.NewInternal()
+		}
+		post := byID[postID]
+		post.Tags = append(post.Tags, tag)
+	}
+	return nil
+}
+
+// setPostTags replaces the tags of the post, adding the tags not used yet.
+// The tags are given their id, and the name of the tag when it exists
+func setPostTags(ctx context.Context, tx db.Transaction, postID int32, tags []domain.Tag) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM post_tag WHERE post_id = ?`, postID); err != nil {
+		return err
 	}
 
+	for i := range tags {
+		if _, err := tx.ExecContext(ctx, `INSERT IGNORE INTO tag (name, slug) VALUES (?, ?)`, tags[i].Name, tags[i].Slug); err != nil {
+			return err
+		}
+
+		// the shared lock keeps deleteOrphanTags of other
+		// transactions from removing the tag before it is used
+		err := tx.QueryRowContext(ctx, `SELECT id, name FROM tag WHERE slug = ? LOCK IN SHARE MODE`, tags[i].Slug).
+			Scan(&tags[i].ID, &tags[i].Name)
+		if err != nil {
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx, `INSERT INTO post_tag (post_id, tag_id) VALUES (?, ?)`, postID, tags[i].ID); err != nil {
+			return err
+		}
+	}
 	return nil
 }
+
+// saveSlug gives the post the first of base, base-2, base-3... that no other
+// post has or had. Slugs are never given up, so former slugs keep leading
+// to their post
+func saveSlug(ctx context.Context, tx db.Transaction, postID int32, base string) (string, error) {
+	slug := base
+	for n := 2; ; n++ {
+		var owner int32
+		err := tx.QueryRowContext(ctx, `SELECT post_id FROM post_slug WHERE slug = ?`, slug).Scan(&owner)
+		if err == sql.ErrNoRows {
+			_, err = tx.ExecContext(ctx, `INSERT INTO post_slug (slug, post_id) VALUES (?, ?)`, slug, postID)
+			owner = postID
+
+			// taken by a concurrent request, try the next one
+			var mysqlErr *mysql.MySQLError
+			if errors.As(err, &mysqlErr) && mysqlErr.Number == mysqlDuplicateEntry {
+				owner = 0
+				err = nil
+			}
+		}
+		if err != nil {
+			return "", err
+		}
+
+		if owner == postID {
+			_, err := tx.ExecContext(ctx, `UPDATE post SET slug = ? WHERE id = ?`, slug, postID)
+			return slug, err
+		}
+		slug = fmt.Sprintf("%s-%d", base, n)
+	}
+}
+
+// versionMismatch tells why a write of the post at a version changed no
+// row, the post is either gone or at another version
+func versionMismatch(ctx context.Context, tx db.Transaction, id int32) error {
+	var version int32
+	err := tx.QueryRowContext(ctx, `SELECT version FROM post WHERE id = ?`, id).Scan(&version)
+	if err == sql.ErrNoRows {
+		return domain.NewNotFound("id", strconv.Itoa(int(id)))
+	}
+	if err != nil {
+		return err
+	}
+	return domain.NewPreconditionFailed("post", strconv.Itoa(int(id)))
+}
+
+// addRevision stores the title and content of the post as its next
+// revision. The post row is locked by the insert or update of the
+// transaction, so revisions of a post are numbered one at a time
+func addRevision(ctx context.Context, tx db.Transaction, postID int32, post *domain.Post, editor uuid.UUID, now time.Time) error {
+	query := `INSERT INTO post_revision (post_id, number, title, content, account_uid, created_at)
+		SELECT ?, COALESCE(MAX(number), 0) + 1, ?, ?, ?, ? FROM post_revision WHERE post_id = ?`
+	_, err := tx.ExecContext(ctx, query, postID, post.Title, post.Content, editor, now, postID)
+	return err
+}
+
+// deleteOrphanTags removes the tags no longer on any post
+func deleteOrphanTags(ctx context.Context, tx db.Transaction) error {
+	_, err := tx.ExecContext(ctx, `DELETE tag FROM tag LEFT JOIN post_tag ON post_tag.tag_id = tag.id WHERE post_tag.tag_id IS NULL`)
+	return err
+}
@@ -0,0 +1,192 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+	"github.com/whuangz/go-example/go-api/domain"
+)
+
+// slidingWindowScript logs the requests of the window in a sorted set
+// scored by time. It returns whether the request is allowed, the
+// remaining requests and, in ms, when the newest and oldest requests
+// of the window expire
+var slidingWindowScript = redis.NewScript(`
+local now = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local limit = tonumber(ARGV[3])
+
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', now - window)
+local count = redis.call('ZCARD', KEYS[1])
+
+local allowed = 0
+if count < limit then
+	redis.call('ZADD', KEYS[1], now, ARGV[4])
+	count = count + 1
+	allowed = 1
+end
+redis.call('PEXPIRE', KEYS[1], window)
+
+local reset = 0
+local retry = 0
+local newest = redis.call('ZRANGE', KEYS[1], -1, -1, 'WITHSCORES')
+if newest[2] then
+	reset = tonumber(newest[2]) + window - now
+end
+local oldest = redis.call('ZRANGE', KEYS[1], 0, 0, 'WITHSCORES')
+if oldest[2] then
+	retry = tonumber(oldest[2]) + window - now
+end
+
+return {allowed, limit - count, reset, retry}
+`)
+
+// tokenBucketScript refills the bucket for the time elapsed since the
+// last request, then takes a token from it. It returns whether the
+// request is allowed, the whole tokens left and, in ms, when the bucket
+// is full again and when the next token is available
+var tokenBucketScript = redis.NewScript(`
+local now = tonumber(ARGV[1])
+local capacity = tonumber(ARGV[2])
+local interval = tonumber(ARGV[3])
+
+local bucket = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(bucket[1])
+local ts = tonumber(bucket[2])
+if not tokens then
+	tokens = capacity
+	ts = now
+end
+
+tokens = math.min(capacity, tokens + math.max(0, now - ts) / interval)
+
+local allowed = 0
+local retry = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+else
+	retry = math.ceil((1 - tokens) * interval)
+end
+
+local full = math.ceil((capacity - tokens) * interval)
+redis.call('HMSET', KEYS[1], 'tokens', tostring(tokens), 'ts', now)
+redis.call('PEXPIRE', KEYS[1], math.max(full, 1))
+
+return {allowed, math.floor(tokens), full, retry}
+`)
+
+type slidingWindowLimiter struct {
+	redis  *redis.Client
+	limit  int64
+	window time.Duration
+}
+
+// NewSlidingWindowLimiter creates a redis backed limiter allowing
+// limit requests in any window of time, eg: 100 in a minute
+func NewSlidingWindowLimiter(redisClient *redis.Client, limit int64, window time.Duration) domain.RateLimiter {
+	return &slidingWindowLimiter{redis: redisClient, limit: limit, window: window}
+}
+
+func rateLimitKey(key string) string {
+	return fmt.Sprintf("ratelimit:%s", key)
+}
+
+func (l *slidingWindowLimiter) Allow(ctx context.Context, key string) (*domain.RateLimit, error) {
+	// the member has to be unique, requests may arrive in the same ms
+	args := []interface{}{time.Now().UnixNano() / int64(time.Millisecond), l.window.Milliseconds(), l.limit, uuid.New().String()}
+
+	res, err := int64Reply(slidingWindowScript.Run(ctx, l.redis, []string{rateLimitKey(key)}, args...))
+	if err != nil {
+		log.Printf("Could not take request from sliding window in redis for key: %s: %v\n", key, err)
+		return nil, domain.NewInternal()
+	}
+
+	return newRateLimit(res, l.limit), nil
+}
+
+type tokenBucketLimiter struct {
+	redis    *redis.Client
+	capacity int64
+	interval time.Duration
+}
+
+// NewTokenBucketLimiter creates a redis backed limiter allowing bursts
+// of up to limit requests, refilled at limit requests per window
+func NewTokenBucketLimiter(redisClient *redis.Client, limit int64, window time.Duration) domain.RateLimiter {
+	return &tokenBucketLimiter{redis: redisClient, capacity: limit, interval: window / time.Duration(limit)}
+}
+
+func (l *tokenBucketLimiter) Allow(ctx context.Context, key string) (*domain.RateLimit, error) {
+	args := []interface{}{time.Now().UnixNano() / int64(time.Millisecond), l.capacity, l.interval.Milliseconds()}
+
+	res, err := int64Reply(tokenBucketScript.Run(ctx, l.redis, []string{rateLimitKey(key)}, args...))
+	if err != nil {
+		log.Printf("Could not take request from token bucket in redis for key: %s: %v\n", key, err)
+		return nil, domain.NewInternal()
+	}
+
+	return newRateLimit(res, l.capacity), nil
+}
+
+// int64Reply reads the array of integers replied by a script
+func int64Reply(cmd *redis.Cmd) ([]int64, error) {
+	reply, err := cmd.Result()
+	if err != nil {
+		return nil, err
+	}
+
+	vals, ok := reply.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("unexpected script reply: %v", reply)
+	}
+
+	res := make([]int64, len(vals))
+	for i, val := range vals {
+		n, ok := val.(int64)
+		if !ok {
+			return nil, fmt.Errorf("unexpected script reply: %v", vals)
+		}
+		res[i] = n
+	}
+	return res, nil
+}
+
+// newRateLimit reads the {allowed, remaining, reset, retry}
+// reply of the scripts, durations are in ms
+func newRateLimit(res []int64, limit int64) *domain.RateLimit {
+	rl := &domain.RateLimit{
+		Allowed:   res[0] == 1,
+		Limit:     limit,
+		Remaining: res[1],
+		Reset:     time.Duration(res[2]) * time.Millisecond,
+	}
+
+	if !rl.Allowed {
+		rl.RetryAfter = time.Duration(res[3]) * time.Millisecond
+	}
+	return rl
+}
+
+type fallbackRateLimiter struct {
+	primary  domain.RateLimiter
+	fallback domain.RateLimiter
+}
+
+// NewFallbackRateLimiter uses the fallback limiter while the primary one
+// fails, so requests are still limited when redis is unreachable
+func NewFallbackRateLimiter(primary domain.RateLimiter, fallback domain.RateLimiter) domain.RateLimiter {
+	return &fallbackRateLimiter{primary: primary, fallback: fallback}
+}
+
+func (l *fallbackRateLimiter) Allow(ctx context.Context, key string) (*domain.RateLimit, error) {
+	rl, err := l.primary.Allow(ctx, key)
+	if err != nil {
+		return l.fallback.Allow(ctx, key)
+	}
+	return rl, nil
+}
@@ -0,0 +1,154 @@
+package repository
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/whuangz/go-example/go-api/domain"
+)
+
+// memoryLimiterSweep is how often keys which
+// aren't limited anymore are forgotten
+const memoryLimiterSweep = time.Minute
+
+type memorySlidingWindowLimiter struct {
+	mu        sync.Mutex
+	limit     int64
+	window    time.Duration
+	requests  map[string][]time.Time
+	lastSweep time.Time
+}
+
+// NewMemorySlidingWindowLimiter creates an in-process sliding window limiter,
+// used for tests and as a fallback when redis is unreachable. Its limits
+// are per instance of the api
+func NewMemorySlidingWindowLimiter(limit int64, window time.Duration) domain.RateLimiter {
+	return &memorySlidingWindowLimiter{
+		limit:     limit,
+		window:    window,
+		requests:  make(map[string][]time.Time),
+		lastSweep: time.Now(),
+	}
+}
+
+func (l *memorySlidingWindowLimiter) Allow(ctx context.Context, key string) (*domain.RateLimit, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	l.sweep(now)
+
+	requests := inWindow(l.requests[key], now.Add(-l.window))
+
+	rl := &domain.RateLimit{Limit: l.limit}
+	if int64(len(requests)) < l.limit {
+		requests = append(requests, now)
+		rl.Allowed = true
+	} else {
+		rl.RetryAfter = requests[0].Add(l.window).Sub(now)
+	}
+	l.requests[key] = requests
+
+	rl.Remaining = l.limit - int64(len(requests))
+	rl.Reset = requests[len(requests)-1].Add(l.window).Sub(now)
+
+	return rl, nil
+}
+
+func (l *memorySlidingWindowLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < memoryLimiterSweep {
+		return
+	}
+	l.lastSweep = now
+
+	for key, requests := range l.requests {
+		if len(inWindow(requests, now.Add(-l.window))) == 0 {
+			delete(l.requests, key)
+		}
+	}
+}
+
+// inWindow drops the requests made before start, they are kept in order
+func inWindow(requests []time.Time, start time.Time) []time.Time {
+	i := 0
+	for i < len(requests) && !requests[i].After(start) {
+		i++
+	}
+	return requests[i:]
+}
+
+type memoryBucket struct {
+	tokens float64
+	ts     time.Time
+}
+
+type memoryTokenBucketLimiter struct {
+	mu        sync.Mutex
+	capacity  int64
+	interval  time.Duration
+	buckets   map[string]*memoryBucket
+	lastSweep time.Time
+}
+
+// NewMemoryTokenBucketLimiter creates an in-process token bucket limiter,
+// used for tests and as a fallback when redis is unreachable. Its limits
+// are per instance of the api
+func NewMemoryTokenBucketLimiter(limit int64, window time.Duration) domain.RateLimiter {
+	return &memoryTokenBucketLimiter{
+		capacity:  limit,
+		interval:  window / time.Duration(limit),
+		buckets:   make(map[string]*memoryBucket),
+		lastSweep: time.Now(),
+	}
+}
+
+func (l *memoryTokenBucketLimiter) Allow(ctx context.Context, key string) (*domain.RateLimit, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	l.sweep(now)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &memoryBucket{tokens: float64(l.capacity), ts: now}
+		l.buckets[key] = b
+	}
+	l.refill(b, now)
+
+	rl := &domain.RateLimit{Limit: l.capacity}
+	if b.tokens >= 1 {
+		b.tokens--
+		rl.Allowed = true
+	} else {
+		rl.RetryAfter = time.Duration((1 - b.tokens) * float64(l.interval))
+	}
+
+	rl.Remaining = int64(math.Floor(b.tokens))
+	rl.Reset = time.Duration((float64(l.capacity) - b.tokens) * float64(l.interval))
+
+	return rl, nil
+}
+
+func (l *memoryTokenBucketLimiter) refill(b *memoryBucket, now time.Time) {
+	refilled := b.tokens + float64(now.Sub(b.ts))/float64(l.interval)
+	b.tokens = math.Min(float64(l.capacity), refilled)
+	b.ts = now
+}
+
+func (l *memoryTokenBucketLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < memoryLimiterSweep {
+		return
+	}
+	l.lastSweep = now
+
+	// a full bucket is the same as no bucket
+	for key, b := range l.buckets {
+		l.refill(b, now)
+		if b.tokens >= float64(l.capacity) {
+			delete(l.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package repository
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/whuangz/go-example/go-api/domain"
+	"github.com/whuangz/go-example/go-api/helpers/db"
+)
+
+type reactionRepo struct {
+	db *sqlx.DB
+}
+
+func NewReactionRepo(db *sqlx.DB) domain.ReactionRepository {
+	return &reactionRepo{db: db}
+}
+
+// Add relies on the primary key to keep one reaction of each kind per account
+func (r *reactionRepo) Add(ctx context.Context, postID int32, accountUID uuid.UUID, kind string) (bool, error) {
+	query := `INSERT IGNORE INTO post_reaction (post_id, account_uid, kind, created_at) VALUES (?, ?, ?, ?)`
+	result, err := r.db.ExecContext(ctx, query, postID, accountUID, kind, time.Now())
+	if err != nil {
+		log.Printf("Could not add reaction: %v of account: %v to post: %v. Reason: %v\n", kind, accountUID, postID, err)
+		return false, domain.NewInternal()
+	}
+
+	affected, _ := result.RowsAffected()
+	return affected > 0, nil
+}
+
+func (r *reactionRepo) Remove(ctx context.Context, postID int32, accountUID uuid.UUID, kind string) (bool, error) {
+	query := `DELETE FROM post_reaction WHERE post_id = ? AND account_uid = ? AND kind = ?`
+	result, err := r.db.ExecContext(ctx, query, postID, accountUID, kind)
+	if err != nil {
+		log.Printf("Could not remove reaction: %v of account: %v to post: %v. Reason: %v\n", kind, accountUID, postID, err)
+		return false, domain.NewInternal()
+	}
+
+	affected, _ := result.RowsAffected()
+	return affected > 0, nil
+}
+
+func (r *reactionRepo) FindByAccount(ctx context.Context, postID int32, accountUID uuid.UUID) ([]string, error) {
+	query := `SELECT kind FROM post_reaction WHERE post_id = ? AND account_uid = ? ORDER BY kind`
+	rows, err := r.db.QueryContext(ctx, query, postID, accountUID)
+	if err != nil {
+		log.Printf("Could not find reactions of account: %v to post: %v. Reason: %v\n", accountUID, postID, err)
+		return nil, domain.NewInternal()
+	}
+	defer rows.Close()
+
+	kinds := []string{}
+	for rows.Next() {
+		var kind string
+		if err := rows.Scan(&kind); err != nil {
+			log.Printf("Could not scan reaction of account: %v to post: %v. Reason: %v\n", accountUID, postID, err)
+			return nil, domain.NewInternal()
+		}
+		kinds = append(kinds, kind)
+	}
+	return kinds, nil
+}
+
+func (r *reactionRepo) Count(ctx context.Context, postID int32) (domain.ReactionCounts, error) {
+	query := `SELECT kind, COUNT(*) FROM post_reaction WHERE post_id = ? GROUP BY kind`
+	rows, err := r.db.QueryContext(ctx, query, postID)
+	if err != nil {
+		log.Printf("Could not count reactions to post: %v. Reason: %v\n", postID, err)
+		return nil, domain.NewInternal()
+	}
+	defer rows.Close()
+
+	counts := domain.ReactionCounts{}
+	for rows.Next() {
+		var kind string
+		var count int64
+		if err := rows.Scan(&kind, &count); err != nil {
+			log.Printf("Could not scan count of reactions to post: %v. Reason: %v\n", postID, err)
+			return nil, domain.NewInternal()
+		}
+		counts[kind] = count
+	}
+	return counts, nil
+}
+
+// SaveCounts replaces the counts of the post, kinds no longer reacted with go away
+func (r *reactionRepo) SaveCounts(ctx context.Context, postID int32, counts domain.ReactionCounts) error {
+	err := db.WithTransaction(r.db, func(tx db.Transaction) error {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM post_reaction_count WHERE post_id = ?`, postID); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		for kind, count := range counts {
+			query := `INSERT INTO post_reaction_count (post_id, kind, count, reconciled_at) VALUES (?, ?, ?, ?)`
+			if _, err := tx.ExecContext(ctx, query, postID, kind, count, now); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	if err != nil {
+		log.Printf("Could not save counts of reactions to post: %v. Reason: %v\n", postID, err)
+		return domain.NewInternal()
+	}
+	return nil
+}
+
+func (r *reactionRepo) FindCounts(ctx context.Context, postIDs []int32) (map[int32]domain.ReactionCounts, error) {
+	counts := map[int32]domain.ReactionCounts{}
+	if len(postIDs) == 0 {
+		return counts, nil
+	}
+
+	query, args, err := sqlx.In(`SELECT post_id, kind, count FROM post_reaction_count WHERE post_id IN (?) AND count > 0`, postIDs)
+	if err != nil {
+		log.Printf("Could not build query of counts of reactions to posts: %v. Reason: %v\n", postIDs, err)
+		return nil, domain.NewInternal()
+	}
+
+	rows, err := r.db.QueryContext(ctx, r.db.Rebind(query), args...)
+	if err != nil {
+		log.Printf("Could not find counts of reactions to posts: %v. Reason: %v\n", postIDs, err)
+		return nil, domain.NewInternal()
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var postID int32
+		var kind string
+		var count int64
+		if err := rows.Scan(&postID, &kind, &count); err != nil {
+			log.Printf("Could not scan count of reactions to posts: %v. Reason: %v\n", postIDs, err)
+			return nil, domain.NewInternal()
+		}
+		if counts[postID] == nil {
+			counts[postID] = domain.ReactionCounts{}
+		}
+		counts[postID][kind] = count
+	}
+	return counts, nil
+}
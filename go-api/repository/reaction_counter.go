@@ -0,0 +1,157 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/whuangz/go-example/go-api/domain"
+)
+
+// reactionsDirtyKey is the set of posts reacted to since they were reconciled
+const reactionsDirtyKey = "post_reactions_dirty"
+
+// incrReactionScript only counts the reaction when the counts of the post
+// are held, a count started from nothing would pass for the whole count
+var incrReactionScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 1 then
+	redis.call('HINCRBY', KEYS[1], ARGV[1], ARGV[2])
+end
+redis.call('SADD', KEYS[2], ARGV[3])
+return 0
+`)
+
+type redisReactionCounter struct {
+	redis *redis.Client
+}
+
+// NewRedisReactionCounter holds the counts of each post in a hash of
+// the kinds of reactions
+func NewRedisReactionCounter(redisClient *redis.Client) domain.ReactionCounter {
+	return &redisReactionCounter{redis: redisClient}
+}
+
+func reactionsKey(postID int32) string {
+	return fmt.Sprintf("post_reactions:%d", postID)
+}
+
+func (r *redisReactionCounter) Incr(ctx context.Context, postID int32, kind string, delta int64) error {
+	keys := []string{reactionsKey(postID), reactionsDirtyKey}
+	if err := incrReactionScript.Run(ctx, r.redis, keys, kind, delta, postID).Err(); err != nil {
+		log.Printf("Could not count reaction: %v to post: %v in redis: %v\n", kind, postID, err)
+		return domain.NewInternal()
+	}
+	return nil
+}
+
+func (r *redisReactionCounter) Get(ctx context.Context, postIDs []int32) (map[int32]domain.ReactionCounts, error) {
+	counts := map[int32]domain.ReactionCounts{}
+	if len(postIDs) == 0 {
+		return counts, nil
+	}
+
+	pipe := r.redis.Pipeline()
+	cmds := make([]*redis.StringStringMapCmd, len(postIDs))
+	for i, postID := range postIDs {
+		cmds[i] = pipe.HGetAll(ctx, reactionsKey(postID))
+	}
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		log.Printf("Could not get counts of reactions to posts: %v from redis: %v\n", postIDs, err)
+		return nil, domain.NewInternal()
+	}
+
+	// a missing hash reads as an empty one
+	for i, cmd := range cmds {
+		fields := cmd.Val()
+		if len(fields) == 0 {
+			continue
+		}
+
+		postCounts := domain.ReactionCounts{}
+		for kind, value := range fields {
+			if count, err := strconv.ParseInt(value, 10, 64); err == nil && count > 0 {
+				postCounts[kind] = count
+			}
+		}
+		counts[postIDs[i]] = postCounts
+	}
+	return counts, nil
+}
+
+// Set drops the hash of posts without reactions, they are then read
+// from the reconciled counts
+func (r *redisReactionCounter) Set(ctx context.Context, postID int32, counts domain.ReactionCounts) error {
+	key := reactionsKey(postID)
+	_, err := r.redis.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, key)
+
+		values := map[string]interface{}{}
+		for kind, count := range counts {
+			values[kind] = count
+		}
+		if len(values) > 0 {
+			pipe.HSet(ctx, key, values)
+		}
+		return nil
+	})
+
+	if err != nil {
+		log.Printf("Could not set counts of reactions to post: %v in redis: %v\n", postID, err)
+		return domain.NewInternal()
+	}
+	return nil
+}
+
+func (r *redisReactionCounter) TakeDirty(ctx context.Context, n int) ([]int32, error) {
+	members, err := r.redis.SPopN(ctx, reactionsDirtyKey, int64(n)).Result()
+	if err != nil && err != redis.Nil {
+		log.Printf("Could not take posts to reconcile from redis: %v\n", err)
+		return nil, domain.NewInternal()
+	}
+
+	postIDs := make([]int32, 0, len(members))
+	for _, member := range members {
+		postID, err := strconv.ParseInt(member, 10, 32)
+		if err != nil {
+			log.Printf("Skipping post to reconcile: %v. Reason: %v\n", member, err)
+			continue
+		}
+		postIDs = append(postIDs, int32(postID))
+	}
+	return postIDs, nil
+}
+
+// Remove drops the post from the posts to reconcile too, there is
+// nothing left of it to count
+func (r *redisReactionCounter) Remove(ctx context.Context, postID int32) error {
+	_, err := r.redis.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, reactionsKey(postID))
+		pipe.SRem(ctx, reactionsDirtyKey, postID)
+		return nil
+	})
+
+	if err != nil {
+		log.Printf("Could not remove counts of reactions to post: %v from redis: %v\n", postID, err)
+		return domain.NewInternal()
+	}
+	return nil
+}
+
+func (r *redisReactionCounter) MarkDirty(ctx context.Context, postIDs ...int32) error {
+	if len(postIDs) == 0 {
+		return nil
+	}
+
+	members := make([]interface{}, len(postIDs))
+	for i, postID := range postIDs {
+		members[i] = postID
+	}
+	if err := r.redis.SAdd(ctx, reactionsDirtyKey, members...).Err(); err != nil {
+		log.Printf("Could not mark posts: %v to reconcile in redis: %v\n", postIDs, err)
+		return domain.NewInternal()
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+package repository
+
+import (
+	"context"
+	"log"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/whuangz/go-example/go-api/domain"
+)
+
+type roleRepo struct {
+	db *sqlx.DB
+}
+
+func NewRoleRepo(db *sqlx.DB) domain.RoleRepository {
+	return &roleRepo{db: db}
+}
+
+// AddRole assigns a role to the account, assigning it again does nothing
+func (r *roleRepo) AddRole(ctx context.Context, accountUID uuid.UUID, role string) error {
+	query := `INSERT IGNORE INTO account_role (account_uid, role) VALUES (?, ?)`
+
+	if _, err := r.db.ExecContext(ctx, query, accountUID, role); err != nil {
+		log.Printf("Could not add role: %v to account with uid: %v. Reason: %v\n", role, accountUID, err)
+		return domain.NewInternal()
+	}
+	return nil
+}
+
+func (r *roleRepo) RemoveRole(ctx context.Context, accountUID uuid.UUID, role string) error {
+	query := `DELETE FROM account_role WHERE account_uid=? AND role=?`
+
+	if _, err := r.db.ExecContext(ctx, query, accountUID, role); err != nil {
+		log.Printf("Could not remove role: %v from account with uid: %v. Reason: %v\n", role, accountUID, err)
+		return domain.NewInternal()
+	}
+	return nil
+}
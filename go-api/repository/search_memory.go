@@ -0,0 +1,160 @@
+package repository
+
+import (
+	"context"
+	"math"
+	"sort"
+	"sync"
+
+	"github.com/whuangz/go-example/go-api/domain"
+	"github.com/whuangz/go-example/go-api/helpers/search"
+)
+
+// BM25 parameters, the usual defaults
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// titleBoost is how many times a term of the title counts
+const titleBoost = 3
+
+type indexedPost struct {
+	title   string
+	content string
+	// frequencies of the terms, title terms boosted
+	terms  map[string]int
+	length int
+}
+
+type memorySearchIndex struct {
+	mu    sync.RWMutex
+	posts map[int32]*indexedPost
+	// postings lists the posts each term appears in
+	postings    map[string]map[int32]struct{}
+	totalLength int
+}
+
+// NewMemorySearchIndex creates an in-process inverted index ranking posts
+// with BM25, used for tests and deployments without mysql. It only knows
+// the posts indexed since the api started
+func NewMemorySearchIndex() domain.SearchIndex {
+	return &memorySearchIndex{
+		posts:    make(map[int32]*indexedPost),
+		postings: make(map[string]map[int32]struct{}),
+	}
+}
+
+// Index adds the post, replacing the previous version of it
+func (s *memorySearchIndex) Index(ctx context.Context, post *domain.Post) error {
+	doc := &indexedPost{
+		title:   post.Title,
+		content: post.Content,
+		terms:   make(map[string]int),
+	}
+	for _, t := range search.Tokenize(post.Title) {
+		doc.terms[t.Term] += titleBoost
+		doc.length += titleBoost
+	}
+	for _, t := range search.Tokenize(post.Content) {
+		doc.terms[t.Term]++
+		doc.length++
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.remove(post.ID)
+
+	s.posts[post.ID] = doc
+	s.totalLength += doc.length
+	for term := range doc.terms {
+		if s.postings[term] == nil {
+			s.postings[term] = make(map[int32]struct{})
+		}
+		s.postings[term][post.ID] = struct{}{}
+	}
+
+	return nil
+}
+
+func (s *memorySearchIndex) Remove(ctx context.Context, id int32) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.remove(id)
+	return nil
+}
+
+func (s *memorySearchIndex) remove(id int32) {
+	doc, ok := s.posts[id]
+	if !ok {
+		return
+	}
+
+	for term := range doc.terms {
+		delete(s.postings[term], id)
+		if len(s.postings[term]) == 0 {
+			delete(s.postings, term)
+		}
+	}
+	s.totalLength -= doc.length
+	delete(s.posts, id)
+}
+
+// Search matches posts containing any of the terms of the query
+func (s *memorySearchIndex) Search(ctx context.Context, query string, offset int, limit int) ([]domain.SearchHit, int, error) {
+	terms := search.Terms(query)
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if len(s.posts) == 0 {
+		return []domain.SearchHit{}, 0, nil
+	}
+
+	n := float64(len(s.posts))
+	avgLength := float64(s.totalLength) / n
+
+	scores := make(map[int32]float64)
+	for _, term := range terms {
+		postings := s.postings[term]
+		df := float64(len(postings))
+		idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+
+		for id := range postings {
+			doc := s.posts[id]
+			tf := float64(doc.terms[term])
+			norm := bm25K1 * (1 - bm25B + bm25B*float64(doc.length)/avgLength)
+			scores[id] += idf * tf * (bm25K1 + 1) / (tf + norm)
+		}
+	}
+
+	hits := make([]domain.SearchHit, 0, len(scores))
+	for id, score := range scores {
+		hits = append(hits, domain.SearchHit{PostID: id, Score: score})
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].PostID > hits[j].PostID
+	})
+
+	total := len(hits)
+	if offset >= total {
+		return []domain.SearchHit{}, total, nil
+	}
+	hits = hits[offset:]
+	if len(hits) > limit {
+		hits = hits[:limit]
+	}
+
+	for i := range hits {
+		doc := s.posts[hits[i].PostID]
+		hits[i].TitleSnippet = search.Highlight(doc.title, terms, snippetLength)
+		hits[i].Snippet = search.Highlight(doc.content, terms, snippetLength)
+	}
+
+	return hits, total, nil
+}
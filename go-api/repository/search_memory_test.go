@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/whuangz/go-example/go-api/domain"
+)
+
+func searchIDs(hits []domain.SearchHit) []int32 {
+	ids := make([]int32, len(hits))
+	for i, hit := range hits {
+		ids[i] = hit.PostID
+	}
+	return ids
+}
+
+func TestMemorySearchIndex(t *testing.T) {
+	ctx := context.TODO()
+
+	t.Run("Empty index", func(t *testing.T) {
+		index := NewMemorySearchIndex()
+
+		hits, total, err := index.Search(ctx, "redis", 0, 10)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 0, total)
+		assert.Equal(t, []domain.SearchHit{}, hits)
+	})
+
+	t.Run("Ranks title matches first", func(t *testing.T) {
+		index := NewMemorySearchIndex()
+		index.Index(ctx, &domain.Post{ID: 1, Title: "Notes on caching", Content: "We put redis in front of mysql"})
+		index.Index(ctx, &domain.Post{ID: 2, Title: "Redis in production", Content: "We put a cache in front of mysql"})
+		index.Index(ctx, &domain.Post{ID: 3, Title: "Gin middlewares", Content: "Chaining handlers in gin"})
+
+		hits, total, err := index.Search(ctx, "redis", 0, 10)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 2, total)
+		assert.Equal(t, []int32{2, 1}, searchIDs(hits))
+		assert.Greater(t, hits[0].Score, hits[1].Score)
+		assert.Equal(t, "<mark>Redis</mark> in production", hits[0].TitleSnippet)
+		assert.Equal(t, "We put <mark>redis</mark> in front of mysql", hits[1].Snippet)
+	})
+
+	t.Run("Ranks posts matching more terms first", func(t *testing.T) {
+		index := NewMemorySearchIndex()
+		index.Index(ctx, &domain.Post{ID: 1, Title: "Caching", Content: "Caching with redis"})
+		index.Index(ctx, &domain.Post{ID: 2, Title: "Caching", Content: "Caching with redis and mysql"})
+
+		hits, _, err := index.Search(ctx, "redis mysql", 0, 10)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []int32{2, 1}, searchIDs(hits))
+	})
+
+	t.Run("Update replaces the terms", func(t *testing.T) {
+		index := NewMemorySearchIndex()
+		index.Index(ctx, &domain.Post{ID: 1, Title: "Go generics", Content: "Type parameters"})
+		index.Index(ctx, &domain.Post{ID: 1, Title: "Rust traits", Content: "Trait objects"})
+
+		_, total, err := index.Search(ctx, "generics", 0, 10)
+		assert.NoError(t, err)
+		assert.Equal(t, 0, total)
+
+		hits, total, err := index.Search(ctx, "traits", 0, 10)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, total)
+		assert.Equal(t, []int32{1}, searchIDs(hits))
+	})
+
+	t.Run("Remove", func(t *testing.T) {
+		index := NewMemorySearchIndex()
+		index.Index(ctx, &domain.Post{ID: 1, Title: "Redis streams"})
+		index.Index(ctx, &domain.Post{ID: 2, Title: "Redis locks"})
+
+		assert.NoError(t, index.Remove(ctx, 1))
+		// removing a post that isn't indexed does nothing
+		assert.NoError(t, index.Remove(ctx, 3))
+
+		hits, total, err := index.Search(ctx, "redis", 0, 10)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, total)
+		assert.Equal(t, []int32{2}, searchIDs(hits))
+
+		assert.NoError(t, index.Remove(ctx, 2))
+		hits, total, err = index.Search(ctx, "redis", 0, 10)
+		assert.NoError(t, err)
+		assert.Equal(t, 0, total)
+		assert.Empty(t, hits)
+	})
+
+	t.Run("Pages", func(t *testing.T) {
+		index := NewMemorySearchIndex()
+		for id := int32(1); id <= 5; id++ {
+			index.Index(ctx, &domain.Post{ID: id, Title: fmt.Sprintf("Gopher %d", id), Content: "About the gopher"})
+		}
+
+		all, total, err := index.Search(ctx, "gopher", 0, 10)
+		assert.NoError(t, err)
+		assert.Equal(t, 5, total)
+
+		hits, total, err := index.Search(ctx, "gopher", 2, 2)
+		assert.NoError(t, err)
+		assert.Equal(t, 5, total)
+		assert.Equal(t, searchIDs(all[2:4]), searchIDs(hits))
+
+		hits, total, err = index.Search(ctx, "gopher", 4, 2)
+		assert.NoError(t, err)
+		assert.Equal(t, 5, total)
+		assert.Equal(t, searchIDs(all[4:]), searchIDs(hits))
+
+		hits, total, err = index.Search(ctx, "gopher", 5, 2)
+		assert.NoError(t, err)
+		assert.Equal(t, 5, total)
+		assert.Empty(t, hits)
+	})
+}
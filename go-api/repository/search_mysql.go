@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"context"
+	"log"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/whuangz/go-example/go-api/domain"
+	"github.com/whuangz/go-example/go-api/helpers/search"
+)
+
+// snippetLength is about how long the snippets of search hits are
+const snippetLength = 160
+
+type mysqlSearchIndex struct {
+	db *sqlx.DB
+}
+
+// NewMySQLSearchIndex searches posts through the FULLTEXT index of
+// post.title and post.content, which mysql keeps up to date itself
+func NewMySQLSearchIndex(db *sqlx.DB) domain.SearchIndex {
+	return &mysqlSearchIndex{db: db}
+}
+
+func (s *mysqlSearchIndex) Index(ctx context.Context, post *domain.Post) error {
+	return nil
+}
+
+func (s *mysqlSearchIndex) Remove(ctx context.Context, id int32) error {
+	return nil
+}
+
+func (s *mysqlSearchIndex) Search(ctx context.Context, query string, offset int, limit int) ([]domain.SearchHit, int, error) {
+	match := `MATCH(title, content) AGAINST (? IN NATURAL LANGUAGE MODE)`
+	// only published posts are searched
+	where := `status = 'published' AND ` + match
+
+	var total int
+	if err := s.db.GetContext(ctx, &total, `SELECT COUNT(*) FROM post WHERE `+where, query); err != nil {
+		log.Printf("Could not count posts matching: %v. Reason: %v\n", query, err)
+		return nil, 0, domain.NewInternal()
+	}
+
+	rows, err := s.db.QueryContext(ctx, `SELECT id, title, content, `+match+` AS score FROM post WHERE `+where+`
+		ORDER BY score DESC, id DESC LIMIT ? OFFSET ?`, query, query, limit, offset)
+	if err != nil {
+		log.Printf("Could not search posts matching: %v. Reason: %v\n", query, err)
+		return nil, 0, domain.NewInternal()
+	}
+	defer rows.Close()
+
+	terms := search.Terms(query)
+	hits := []domain.SearchHit{}
+	for rows.Next() {
+		var title, content string
+		hit := domain.SearchHit{}
+
+		if err := rows.Scan(&hit.PostID, &title, &content, &hit.Score); err != nil {
+			log.Printf("Could not scan post matching: %v. Reason: %v\n", query, err)
+			return nil, 0, domain.NewInternal()
+		}
+		hit.TitleSnippet = search.Highlight(title, terms, snippetLength)
+		hit.Snippet = search.Highlight(content, terms, snippetLength)
+
+		hits = append(hits, hit)
+	}
+
+	return hits, total, nil
+}
@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/whuangz/go-example/go-api/domain"
+)
+
+// addFailureScript increments the failures and restarts
+// their window in one step
+var addFailureScript = redis.NewScript(`
+local failures = redis.call('INCR', KEYS[1])
+redis.call('PEXPIRE', KEYS[1], ARGV[1])
+return failures
+`)
+
+type signinAttemptRepo struct {
+	redis *redis.Client
+}
+
+// NewSigninAttemptRepo creates a redis backed store of failed signins
+func NewSigninAttemptRepo(redisClient *redis.Client) domain.SigninAttemptRepository {
+	return &signinAttemptRepo{redis: redisClient}
+}
+
+func signinFailuresKey(key string) string {
+	return fmt.Sprintf("signin_failures:%s", key)
+}
+
+func signinLockKey(key string) string {
+	return fmt.Sprintf("signin_lock:%s", key)
+}
+
+func (r *signinAttemptRepo) AddFailure(ctx context.Context, key string, window time.Duration) (int64, error) {
+	failures, err := addFailureScript.Run(ctx, r.redis, []string{signinFailuresKey(key)}, window.Milliseconds()).Int64()
+	if err != nil {
+		log.Printf("Could not count failed signin in redis for key: %s: %v\n", key, err)
+		return 0, domain.NewInternal()
+	}
+	return failures, nil
+}
+
+func (r *signinAttemptRepo) Lock(ctx context.Context, key string, d time.Duration) error {
+	if err := r.redis.Set(ctx, signinLockKey(key), 0, d).Err(); err != nil {
+		log.Printf("Could not SET signin lock to redis for key: %s: %v\n", key, err)
+		return domain.NewInternal()
+	}
+	return nil
+}
+
+func (r *signinAttemptRepo) LockedFor(ctx context.Context, keys ...string) (time.Duration, error) {
+	pipe := r.redis.Pipeline()
+	cmds := make([]*redis.DurationCmd, len(keys))
+	for i, key := range keys {
+		cmds[i] = pipe.PTTL(ctx, signinLockKey(key))
+	}
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		log.Printf("Could not check signin locks in redis for keys: %v: %v\n", keys, err)
+		return 0, domain.NewInternal()
+	}
+
+	// PTTL is negative when the key doesn't exist
+	var lockedFor time.Duration
+	for _, cmd := range cmds {
+		if d := cmd.Val(); d > lockedFor {
+			lockedFor = d
+		}
+	}
+	return lockedFor, nil
+}
+
+func (r *signinAttemptRepo) Reset(ctx context.Context, key string) error {
+	if err := r.redis.Del(ctx, signinFailuresKey(key), signinLockKey(key)).Err(); err != nil {
+		log.Printf("Could not reset failed signins in redis for key: %s: %v\n", key, err)
+		return domain.NewInternal()
+	}
+	return nil
+}
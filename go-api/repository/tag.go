@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"context"
+	"log"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/whuangz/go-example/go-api/domain"
+)
+
+type tagRepo struct {
+	db *sqlx.DB
+}
+
+func NewTagRepo(db *sqlx.DB) domain.TagRepository {
+	return &tagRepo{db: db}
+}
+
+// FindAll returns the most used tags first
+func (r *tagRepo) FindAll(ctx context.Context) ([]domain.Tag, error) {
+	query := `SELECT tag.id, tag.name, tag.slug, COUNT(*) AS count FROM tag
+		JOIN post_tag ON post_tag.tag_id = tag.id
+		GROUP BY tag.id, tag.name, tag.slug ORDER BY count DESC, tag.slug`
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		log.Printf("Could not find tags. Reason: %v\n", err)
+		return nil, domain.NewInternal()
+	}
+	defer rows.Close()
+
+	tags := []domain.Tag{}
+	for rows.Next() {
+		tag := domain.Tag{}
+		if err := rows.Scan(&tag.ID, &tag.Name, &tag.Slug, &tag.Count); err != nil {
+			log.Printf("Could not scan tag. Reason: %v\n", err)
+			return nil, domain.NewInternal()
+		}
+		tags = append(tags, tag)
+	}
+	return tags, nil
+}
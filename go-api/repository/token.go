@@ -10,6 +10,30 @@ import (
 	"github.com/whuangz/go-example/go-api/domain"
 )
 
+// rotatedPrefix marks a refresh token that has been exchanged already.
+// The key is kept until it expires so a replay of it can be detected
+const rotatedPrefix = "rotated:"
+
+// rotateScript atomically marks a refresh token as rotated out and returns
+// its family. The first element of the reply is 1 when the token was
+// active, 2 when it had already been rotated and 0 when it doesn't exist
+var rotateScript = redis.NewScript(`
+local family = redis.call('GET', KEYS[1])
+if not family then
+	return {0, ''}
+end
+if string.sub(family, 1, string.len(ARGV[1])) == ARGV[1] then
+	return {2, string.sub(family, string.len(ARGV[1]) + 1)}
+end
+local ttl = redis.call('PTTL', KEYS[1])
+if ttl > 0 then
+	redis.call('SET', KEYS[1], ARGV[1] .. family, 'PX', ttl)
+else
+	redis.call('SET', KEYS[1], ARGV[1] .. family)
+end
+return {1, family}
+`)
+
 type tokenRepo struct {
 	redis *redis.Client
 }
@@ -18,19 +42,114 @@ func NewTokenRepo(redisClient *redis.Client) domain.TokenRepository {
 	return &tokenRepo{redis: redisClient}
 }
 
-func (r *tokenRepo) SetRefreshToken(ctx context.Context, accID string, accessToken string, expiresIn time.Duration) error {
-	key := fmt.Sprintf("%s:%s", accID, accessToken)
-	if err := r.redis.Set(ctx, key, 0, expiresIn).Err(); err != nil {
-		log.Printf("Could not SET refresh token to redis for accID/accToken: %s/%s: %v\n", accID, accessToken, err)
+func refreshTokenKey(accID string, tokenID string) string {
+	return fmt.Sprintf("%s:%s", accID, tokenID)
+}
+
+func tokenFamilyKey(accID string, familyID string) string {
+	return fmt.Sprintf("%s:family:%s", accID, familyID)
+}
+
+// SetRefreshToken whitelists a refresh token and records it
+// as a member of its token family
+func (r *tokenRepo) SetRefreshToken(ctx context.Context, accID string, tokenID string, familyID string, expiresIn time.Duration) error {
+	key := refreshTokenKey(accID, tokenID)
+	familyKey := tokenFamilyKey(accID, familyID)
+
+	_, err := r.redis.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, key, familyID, expiresIn)
+		pipe.SAdd(ctx, familyKey, tokenID)
+		pipe.Expire(ctx, familyKey, expiresIn)
+		return nil
+	})
+
+	if err != nil {
+		log.Printf("Could not SET refresh token to redis for accID/tokenID: %s/%s: %v\n", accID, tokenID, err)
+		return domain.NewInternal()
+	}
+	return nil
+}
+
+// RotateRefreshToken marks the previous refresh token as used and returns
+// the family it belongs to. If the token had already been rotated out, the
+// family is returned together with a TokenReused error so the caller can
+// revoke it
+func (r *tokenRepo) RotateRefreshToken(ctx context.Context, accID string, prevTokenID string) (string, error) {
+	key := refreshTokenKey(accID, prevTokenID)
+
+	res, err := rotateScript.Run(ctx, r.redis, []string{key}, rotatedPrefix).Result()
+	if err != nil {
+		log.Printf("Could not rotate refresh token in redis for accID/tokenID: %s/%s: %v\n", accID, prevTokenID, err)
+		return "", domain.NewInternal()
+	}
+
+	reply, ok := res.([]interface{})
+	if !ok || len(reply) != 2 {
+		log.Printf("Unexpected reply rotating refresh token for accID/tokenID: %s/%s: %v\n", accID, prevTokenID, res)
+		return "", domain.NewInternal()
+	}
+
+	status, _ := reply[0].(int64)
+	familyID, _ := reply[1].(string)
+
+	switch status {
+	case 1:
+		return familyID, nil
+	case 2:
+		log.Printf("Refresh token reuse detected for accID/tokenID: %s/%s\n", accID, prevTokenID)
+		return familyID, domain.NewTokenReused()
+	default:
+		log.Printf("Refresh token to redis for accID/tokenID: %s/%s does not exist\n", accID, prevTokenID)
+		return "", domain.NewAuthorization("Invalid refresh token")
+	}
+}
+
+// DeleteTokenFamily deletes every refresh token issued
+// in a family, including the ones already rotated out
+func (r *tokenRepo) DeleteTokenFamily(ctx context.Context, accID string, familyID string) error {
+	familyKey := tokenFamilyKey(accID, familyID)
+
+	tokenIDs, err := r.redis.SMembers(ctx, familyKey).Result()
+	if err != nil {
+		log.Printf("Could not get token family from redis for accID/familyID: %s/%s: %v\n", accID, familyID, err)
+		return domain.NewInternal()
+	}
+
+	keys := []string{familyKey}
+	for _, tokenID := range tokenIDs {
+		keys = append(keys, refreshTokenKey(accID, tokenID))
+	}
+
+	if err := r.redis.Del(ctx, keys...).Err(); err != nil {
+		log.Printf("Could not delete token family from redis for accID/familyID: %s/%s: %v\n", accID, familyID, err)
 		return domain.NewInternal()
 	}
+
 	return nil
 }
 
-func (r *tokenRepo) DeleteRefreshToken(ctx context.Context, accID string, prevAccessToken string) error {
-	key := fmt.Sprintf("%s:%s", accID, prevAccessToken)
-	if err := r.redis.Del(ctx, key).Err(); err != nil {
-		log.Printf("Could not delete refresh token to redis for accID/accToken: %s/%s: %v\n", accID, prevAccessToken, err)
+// DeleteUserRefreshTokens looks for all the refresh tokens
+// belonging to an account and deletes them
+func (r *tokenRepo) DeleteUserRefreshTokens(ctx context.Context, accID string) error {
+	pattern := fmt.Sprintf("%s:*", accID)
+
+	iter := r.redis.Scan(ctx, 0, pattern, 5).Iterator()
+	failCount := 0
+
+	for iter.Next(ctx) {
+		if err := r.redis.Del(ctx, iter.Val()).Err(); err != nil {
+			log.Printf("Failed to delete refresh token: %s\n", iter.Val())
+			failCount++
+		}
+	}
+
+	// a failed SCAN leaves the tokens it didn't reach alive
+	if err := iter.Err(); err != nil {
+		log.Printf("Could not scan refresh tokens in redis for accID: %s: %v\n", accID, err)
+		return domain.NewInternal()
+	}
+
+	if failCount > 0 {
 		return domain.NewInternal()
 	}
 
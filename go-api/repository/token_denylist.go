@@ -0,0 +1,151 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/whuangz/go-example/go-api/domain"
+)
+
+type tokenDenylist struct {
+	redis *redis.Client
+}
+
+// NewTokenDenylist creates a redis backed denylist of access tokens
+func NewTokenDenylist(redisClient *redis.Client) domain.TokenDenylist {
+	return &tokenDenylist{redis: redisClient}
+}
+
+func deniedTokenKey(tokenID string) string {
+	return fmt.Sprintf("denylist:%s", tokenID)
+}
+
+func revokedBeforeKey(accID string) string {
+	return fmt.Sprintf("revoked_before:%s", accID)
+}
+
+func (d *tokenDenylist) DenyAccessToken(ctx context.Context, tokenID string, expiresIn time.Duration) error {
+	if err := d.redis.Set(ctx, deniedTokenKey(tokenID), 0, expiresIn).Err(); err != nil {
+		log.Printf("Could not SET denied access token to redis for tokenID: %s: %v\n", tokenID, err)
+		return domain.NewInternal()
+	}
+	return nil
+}
+
+func (d *tokenDenylist) IsAccessTokenDenied(ctx context.Context, tokenID string) (bool, error) {
+	count, err := d.redis.Exists(ctx, deniedTokenKey(tokenID)).Result()
+	if err != nil {
+		log.Printf("Could not check denied access token in redis for tokenID: %s: %v\n", tokenID, err)
+		return false, domain.NewInternal()
+	}
+	return count > 0, nil
+}
+
+// SetRevokedBefore stores the watermark for an account. It only needs to live
+// as long as an access token, older tokens have expired by themselves anyway
+func (d *tokenDenylist) SetRevokedBefore(ctx context.Context, accID string, revokedBefore time.Time, expiresIn time.Duration) error {
+	if err := d.redis.Set(ctx, revokedBeforeKey(accID), revokedBefore.Unix(), expiresIn).Err(); err != nil {
+		log.Printf("Could not SET access token watermark to redis for accID: %s: %v\n", accID, err)
+		return domain.NewInternal()
+	}
+	return nil
+}
+
+// GetRevokedBefore returns the zero time when
+// the account has no watermark
+func (d *tokenDenylist) GetRevokedBefore(ctx context.Context, accID string) (time.Time, error) {
+	val, err := d.redis.Get(ctx, revokedBeforeKey(accID)).Result()
+	if err == redis.Nil {
+		return time.Time{}, nil
+	}
+
+	if err != nil {
+		log.Printf("Could not GET access token watermark from redis for accID: %s: %v\n", accID, err)
+		return time.Time{}, domain.NewInternal()
+	}
+
+	unix, err := strconv.ParseInt(val, 10, 64)
+	if err != nil {
+		log.Printf("Could not parse access token watermark for accID: %s: %v\n", accID, err)
+		return time.Time{}, domain.NewInternal()
+	}
+
+	return time.Unix(unix, 0), nil
+}
+
+type memoryEntry struct {
+	value     time.Time
+	expiresAt time.Time
+}
+
+type memoryTokenDenylist struct {
+	mu            sync.Mutex
+	denied        map[string]time.Time
+	revokedBefore map[string]memoryEntry
+}
+
+// NewMemoryTokenDenylist creates an in-process denylist, used
+// for tests and for running without redis
+func NewMemoryTokenDenylist() domain.TokenDenylist {
+	return &memoryTokenDenylist{
+		denied:        make(map[string]time.Time),
+		revokedBefore: make(map[string]memoryEntry),
+	}
+}
+
+func (d *memoryTokenDenylist) DenyAccessToken(ctx context.Context, tokenID string, expiresIn time.Duration) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.denied[tokenID] = time.Now().Add(expiresIn)
+	return nil
+}
+
+func (d *memoryTokenDenylist) IsAccessTokenDenied(ctx context.Context, tokenID string) (bool, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	expiresAt, ok := d.denied[tokenID]
+	if !ok {
+		return false, nil
+	}
+
+	if time.Now().After(expiresAt) {
+		delete(d.denied, tokenID)
+		return false, nil
+	}
+	return true, nil
+}
+
+func (d *memoryTokenDenylist) SetRevokedBefore(ctx context.Context, accID string, revokedBefore time.Time, expiresIn time.Duration) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	// only keep second precision, like the redis backed denylist
+	d.revokedBefore[accID] = memoryEntry{
+		value:     time.Unix(revokedBefore.Unix(), 0),
+		expiresAt: time.Now().Add(expiresIn),
+	}
+	return nil
+}
+
+func (d *memoryTokenDenylist) GetRevokedBefore(ctx context.Context, accID string) (time.Time, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	entry, ok := d.revokedBefore[accID]
+	if !ok {
+		return time.Time{}, nil
+	}
+
+	if time.Now().After(entry.expiresAt) {
+		delete(d.revokedBefore, accID)
+		return time.Time{}, nil
+	}
+	return entry.value, nil
+}
@@ -1,21 +1,110 @@
 package router
 
 import (
+	"log"
+	"time"
+
 	"github.com/whuangz/go-example/go-api/config"
+	"github.com/whuangz/go-example/go-api/domain"
 	"github.com/whuangz/go-example/go-api/handler"
+	"github.com/whuangz/go-example/go-api/helpers/jwt"
+	"github.com/whuangz/go-example/go-api/helpers/mailer"
+	"github.com/whuangz/go-example/go-api/helpers/s3"
+	"github.com/whuangz/go-example/go-api/middleware"
 	"github.com/whuangz/go-example/go-api/repository"
 	"github.com/whuangz/go-example/go-api/service"
 )
 
-func accountRoutes() {
+func accountRoutes() (domain.TokenService, domain.APIKeyService) {
 
 	accRepo := repository.NewAccountRepo(database)
-	accService := service.NewAccountService(accRepo)
+	oneTimeTokenRepo := repository.NewOneTimeTokenRepo(redisClient)
+	outbox := mailer.NewOutboxMailer(config.MAIL_OUTBOX_DIR, config.MAIL_FROM)
+	accService := service.NewAccountService(
+		accRepo, blobStore(), oneTimeTokenRepo, outbox,
+		config.APP_URL, config.PASSWORD_RESET_TOKEN_EXP, config.EMAIL_VERIFICATION_TOKEN_EXP)
 	tokenRepo := repository.NewTokenRepo(redisClient)
+	tokenDenylist := repository.NewTokenDenylist(redisClient)
+	keys := keyring()
 	tokenService := service.NewTokenService(
-		tokenRepo,
-		config.JWT_PRIVATE_KEY, config.JWT_PUBLIC_KEY, config.JWT_REFRESH_SECRET,
+		tokenRepo, tokenDenylist, keys, config.JWT_REFRESH_SECRET,
 		config.ACCESS_TOKEN_EXP, config.REFRESH_TOKEN_EXP)
 
-	handler.NewAccountHandler(router, accService, tokenService)
+	mfaRepo := repository.NewMFARepo(database)
+	mfaService := service.NewMFAService(
+		mfaRepo, oneTimeTokenRepo,
+		config.MFA_ENCRYPTION_KEY, config.MFA_ISSUER, config.MFA_CHALLENGE_EXP)
+
+	signinAttemptRepo := repository.NewSigninAttemptRepo(redisClient)
+	lockoutService := service.NewLockoutService(signinAttemptRepo, domain.LockoutPolicy{
+		MaxFailures:   config.SIGNIN_MAX_FAILURES,
+		MaxIPFailures: config.SIGNIN_MAX_IP_FAILURES,
+		Window:        time.Duration(config.SIGNIN_FAILURE_WINDOW) * time.Second,
+		BaseLockout:   time.Duration(config.SIGNIN_LOCKOUT_BASE) * time.Second,
+		MaxLockout:    time.Duration(config.SIGNIN_LOCKOUT_MAX) * time.Second,
+	})
+
+	oauthRepo := repository.NewOAuthRepo(database)
+	authorizationCodeRepo := repository.NewAuthorizationCodeRepo(redisClient)
+	oauthService := service.NewOAuthService(
+		oauthRepo, authorizationCodeRepo, accRepo, tokenDenylist, keys,
+		config.OAUTH_ISSUER, config.OAUTH_ACCESS_TOKEN_EXP, config.OAUTH_CODE_EXP)
+
+	apiKeyRepo := repository.NewAPIKeyRepo(database)
+	apiKeyService := service.NewAPIKeyService(apiKeyRepo, accRepo)
+
+	roleRepo := repository.NewRoleRepo(database)
+	roleService := service.NewRoleService(roleRepo, accRepo)
+
+	// most account routes are used before signing in, so they're limited by IP
+	accountRouter := rateLimited("account", config.RATE_LIMIT_ACCOUNT, config.RATE_LIMIT_ACCOUNT_WINDOW, false, middleware.KeyByIP)
+	handler.NewAccountHandler(accountRouter, accService, tokenService, mfaService, lockoutService, config.MAX_IMAGE_SIZE)
+	handler.NewAPIKeyHandler(accountRouter, apiKeyService, tokenService)
+
+	handler.NewAdminHandler(accountRouter, lockoutService, oauthService, roleService, tokenService, apiKeyService,
+		config.ADMIN_API_KEY, config.REQUIRE_VERIFIED_EMAIL)
+
+	// the token endpoints are used by clients without an account
+	handler.NewOAuthHandler(accountRouter, oauthService, tokenService)
+
+	handler.NewWellKnownHandler(router, tokenService)
+
+	return tokenService, apiKeyService
+}
+
+func keyring() *jwt.Keyring {
+	if config.JWT_KEY_DIR == "" {
+		return jwt.NewKeyring(jwt.NewKey(config.JWT_PRIVATE_KEY), jwt.NewPublicKey(config.JWT_PUBLIC_KEY))
+	}
+
+	keys := &jwt.Keyring{}
+	if err := keys.LoadDir(config.JWT_KEY_DIR); err != nil {
+		log.Fatalf("could not load signing keys: %v", err)
+	}
+
+	// runs for the lifetime of the api
+	keys.WatchDir(config.JWT_KEY_DIR, time.Duration(config.JWT_KEY_RELOAD)*time.Second)
+
+	return keys
+}
+
+func blobStore() domain.BlobStore {
+	switch config.BLOB_STORE {
+	case "s3":
+		client := &s3.Client{
+			Endpoint:  config.S3_ENDPOINT,
+			Region:    config.S3_REGION,
+			Bucket:    config.S3_BUCKET,
+			AccessKey: config.S3_ACCESS_KEY,
+			SecretKey: config.S3_SECRET_KEY,
+		}
+		return repository.NewS3BlobStore(client, config.S3_PUBLIC_URL)
+	case "local":
+		// the uploaded files are served by the api itself
+		router.Static("/uploads", config.BLOB_DIR)
+		return repository.NewLocalBlobStore(config.BLOB_DIR, config.BLOB_BASE_URL)
+	default:
+		log.Fatalf("unknown BLOB_STORE: %s", config.BLOB_STORE)
+		return nil
+	}
 }
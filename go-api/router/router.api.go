@@ -14,8 +14,10 @@ import (
 	"github.com/go-redis/redis/v8"
 	"github.com/jmoiron/sqlx"
 	"github.com/whuangz/go-example/go-api/config"
+	"github.com/whuangz/go-example/go-api/domain"
 	"github.com/whuangz/go-example/go-api/helpers/db"
 	"github.com/whuangz/go-example/go-api/middleware"
+	"github.com/whuangz/go-example/go-api/repository"
 )
 
 var (
@@ -29,7 +31,11 @@ func init() {
 	router = gin.New()
 	router.Use(middleware.Cors())
 
-	database = db.Configure("")
+	if config.IS_DEBUG_MODE {
+		fmt.Println("Service RUN on DEBUG mode")
+	}
+
+	database = db.Configure(config.URI)
 	err := database.Ping()
 	if err != nil {
 		log.Fatal("Database Open Connection: ", err)
@@ -63,6 +69,28 @@ func close() {
 
 }
 
+// rateLimited returns the root for a group of routes taking their requests
+// from the limiter. The limits are kept in redis, with an in-process
+// limiter of the same kind used while redis is unreachable
+func rateLimited(name string, limit int64, window time.Duration, tokenBucket bool, keys ...middleware.KeyFunc) gin.IRouter {
+	if limit == 0 {
+		return router
+	}
+
+	var limiter domain.RateLimiter
+	if tokenBucket {
+		limiter = repository.NewFallbackRateLimiter(
+			repository.NewTokenBucketLimiter(redisClient, limit, window),
+			repository.NewMemoryTokenBucketLimiter(limit, window))
+	} else {
+		limiter = repository.NewFallbackRateLimiter(
+			repository.NewSlidingWindowLimiter(redisClient, limit, window),
+			repository.NewMemorySlidingWindowLimiter(limit, window))
+	}
+
+	return router.Group("", middleware.RateLimit(limiter, name, keys...))
+}
+
 func Routing() {
 
 	defer func() {
@@ -78,8 +106,8 @@ func Routing() {
 		})
 	})
 
-	accountRoutes()
-	blogRoutes()
+	tokenService, apiKeyService := accountRoutes()
+	blogRoutes(tokenService, apiKeyService)
 
 	srv := &http.Server{
 		Addr:    config.PORT,
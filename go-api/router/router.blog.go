@@ -1,13 +1,76 @@
 package router
 
 import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/whuangz/go-example/go-api/config"
+	"github.com/whuangz/go-example/go-api/domain"
 	"github.com/whuangz/go-example/go-api/handler"
+	"github.com/whuangz/go-example/go-api/middleware"
 	"github.com/whuangz/go-example/go-api/repository"
 	"github.com/whuangz/go-example/go-api/service"
 )
 
-func blogRoutes() {
+func blogRoutes(tokenService domain.TokenService, apiKeyService domain.APIKeyService) {
 	repo := repository.NewPostRepo(database)
-	service := service.NewPostService(repo)
-	handler.NewPostHandler(router, service)
+	authorRepo := repository.NewAuthorRepo(database)
+	categoryRepo := repository.NewCategoryRepo(database)
+	reactionService := service.NewReactionService(repository.NewReactionRepo(database),
+		repository.NewRedisReactionCounter(redisClient), repo)
+	postService := service.NewPostService(repo, authorRepo, categoryRepo, searchIndex(repo), reactionService)
+	tagService := service.NewTagService(repository.NewTagRepo(database))
+	categoryService := service.NewCategoryService(categoryRepo)
+
+	if config.POST_SCHEDULER_INTERVAL > 0 {
+		interval := time.Duration(config.POST_SCHEDULER_INTERVAL) * time.Second
+		service.NewPostScheduler(postService, repository.NewRedisLocker(redisClient), interval).Start()
+	}
+	if config.REACTION_RECONCILE_INTERVAL > 0 {
+		interval := time.Duration(config.REACTION_RECONCILE_INTERVAL) * time.Second
+		service.NewReactionReconciler(reactionService, repository.NewRedisLocker(redisClient), interval).Start()
+	}
+	// bursts of reads are fine, they are limited by a token bucket
+	blogRouter := rateLimited("blog", config.RATE_LIMIT_BLOG, config.RATE_LIMIT_BLOG_WINDOW, true,
+		middleware.KeyByAccount(tokenService), middleware.KeyByAPIKey, middleware.KeyByIP)
+	posts := handler.NewPostHandler(blogRouter, postService, tokenService, apiKeyService, config.REQUIRE_VERIFIED_EMAIL)
+	commentService := service.NewCommentService(repository.NewCommentRepo(database), repo,
+		time.Duration(config.COMMENT_EDIT_WINDOW)*time.Second)
+	handler.NewCommentHandler(blogRouter, posts, commentService, tokenService, apiKeyService, config.REQUIRE_VERIFIED_EMAIL)
+	handler.NewReactionHandler(blogRouter, reactionService, tokenService, apiKeyService, config.REQUIRE_VERIFIED_EMAIL)
+	handler.NewTagHandler(blogRouter, tagService)
+	handler.NewCategoryHandler(blogRouter, categoryService, tokenService, apiKeyService, config.REQUIRE_VERIFIED_EMAIL)
+}
+
+func searchIndex(repo domain.PostRepository) domain.SearchIndex {
+	switch config.SEARCH_INDEX {
+	case "memory":
+		index := repository.NewMemorySearchIndex()
+
+		// walk every page of posts to index them
+		ctx := context.Background()
+		filter := domain.PostFilter{Status: domain.PublishedStatus, Sort: domain.OldestFirst}
+		var cursor *domain.PostCursor
+		for {
+			posts, err := repo.FindAll(ctx, filter, cursor, domain.MaxPostLimit)
+			if err != nil {
+				log.Fatalf("could not index posts: %v", err)
+			}
+			for i := range posts {
+				index.Index(ctx, &posts[i])
+			}
+			if len(posts) < domain.MaxPostLimit {
+				return index
+			}
+
+			last := posts[len(posts)-1]
+			cursor = &domain.PostCursor{CreatedAt: last.CreatedAt, ID: last.ID, Sort: filter.Sort}
+		}
+	case "mysql":
+		return repository.NewMySQLSearchIndex(database)
+	default:
+		log.Fatalf("unknown SEARCH_INDEX: %s", config.SEARCH_INDEX)
+		return nil
+	}
 }
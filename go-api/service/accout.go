@@ -1,22 +1,57 @@
 package service
 
 import (
+	"bytes"
 	"context"
+	"database/sql"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
 	"log"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/whuangz/go-example/go-api/domain"
 	"github.com/whuangz/go-example/go-api/helpers/crypto"
+	"github.com/whuangz/go-example/go-api/helpers/images"
 	"github.com/whuangz/go-example/go-api/helpers/jwt"
 )
 
+// profileImageSizes are the sizes, in pixels, of the square
+// profile images stored. image_url links to the first one
+var profileImageSizes = []int{512, 256, 128, 64}
+
+// maxImagePixels guards against images which are small
+// on the wire but huge once decoded
+const maxImagePixels = 50 * 1000 * 1000
+
+const profileImageQuality = 85
+
+// oneTimeTokenBytes is the entropy of the tokens mailed to an account
+const oneTimeTokenBytes = 32
+
 type accountService struct {
-	repo domain.AccountRepository
+	repo           domain.AccountRepository
+	blobs          domain.BlobStore
+	oneTimeTokens  domain.OneTimeTokenRepository
+	mailer         domain.Mailer
+	appURL         string
+	resetTokenExp  int64
+	verifyTokenExp int64
 }
 
-func NewAccountService(repo domain.AccountRepository) domain.AccountService {
-	return &accountService{repo: repo}
+func NewAccountService(repo domain.AccountRepository, blobs domain.BlobStore, oneTimeTokens domain.OneTimeTokenRepository, mailer domain.Mailer, appURL string, resetTokenExp int64, verifyTokenExp int64) domain.AccountService {
+	return &accountService{
+		repo:           repo,
+		blobs:          blobs,
+		oneTimeTokens:  oneTimeTokens,
+		mailer:         mailer,
+		appURL:         appURL,
+		resetTokenExp:  resetTokenExp,
+		verifyTokenExp: verifyTokenExp,
+	}
 }
 
 func (s *accountService) Get(ctx context.Context, uid uuid.UUID) (*domain.Account, error) {
@@ -38,6 +73,13 @@ func (s *accountService) Signup(ctx context.Context, a *domain.Account) error {
 	if err := s.repo.Create(c, a); err != nil {
 		return err
 	}
+
+	// the account exists already, failing to mail shouldn't fail the signup.
+	// The email can be sent again through ResendVerificationEmail
+	if err := s.sendVerificationEmail(ctx, a); err != nil {
+		log.Printf("Unable to send verification email for uid: %v. Reason: %v\n", a.UID, err)
+	}
+
 	// If we get around to adding events, we'd Publish it here
 	// err := s.EventsBroker.PublishAccountUpdated(a, true)
 
@@ -71,6 +113,280 @@ func (s *accountService) Signin(ctx context.Context, a *domain.Account) error {
 	return nil
 }
 
+// Update saves the details of the account. Changing the email is
+// only allowed if no other account is already using it
+func (s *accountService) Update(ctx context.Context, a *domain.Account) error {
+	current, err := s.repo.FindByID(ctx, a.UID)
+	if err != nil {
+		return err
+	}
+
+	if a.Email != current.Email {
+		_, err := s.repo.FindByEmail(ctx, a.Email)
+		if err == nil {
+			return domain.NewConflict("email", a.Email)
+		}
+
+		if !isNotFound(err) {
+			log.Printf("Unable to check email: %v is available. Reason: %v\n", a.Email, err)
+			return domain.NewInternal()
+		}
+	}
+
+	// fields that aren't editable through details are kept as they are
+	updated := *current
+	updated.Name = a.Name
+	updated.Email = a.Email
+	updated.Website = a.Website
+
+	// a new email has to be verified again
+	emailChanged := updated.Email != current.Email
+	if emailChanged {
+		updated.EmailVerifiedAt = sql.NullTime{}
+	}
+
+	if err := s.repo.Update(ctx, &updated); err != nil {
+		return err
+	}
+
+	if emailChanged {
+		if err := s.sendVerificationEmail(ctx, &updated); err != nil {
+			log.Printf("Unable to send verification email for uid: %v. Reason: %v\n", updated.UID, err)
+		}
+	}
+
+	*a = updated
+	return nil
+}
+
+// profileImageKey is the key of the profile image of an account for a size
+func profileImageKey(uid uuid.UUID, size int) string {
+	return fmt.Sprintf("profile/%s/%d.jpg", uid, size)
+}
+
+// SetProfileImage resizes the image to the profile image sizes,
+// stores them and links the largest one in the account
+func (s *accountService) SetProfileImage(ctx context.Context, uid uuid.UUID, data []byte) (*domain.Account, error) {
+	a, err := s.repo.FindByID(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, domain.NewBadRequest("Unable to decode image")
+	}
+
+	if cfg.Width*cfg.Height > maxImagePixels {
+		return nil, domain.NewBadRequest(fmt.Sprintf("Image dimensions of %vx%v are too large", cfg.Width, cfg.Height))
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, domain.NewBadRequest("Unable to decode image")
+	}
+
+	for _, size := range profileImageSizes {
+		thumb, err := images.EncodeJPEG(images.Thumbnail(img, size), profileImageQuality)
+		if err != nil {
+			log.Printf("Unable to encode profile image for uid: %v. Reason: %v\n", uid, err)
+			return nil, domain.NewInternal()
+		}
+
+		if err := s.blobs.Put(ctx, profileImageKey(uid, size), "image/jpeg", thumb); err != nil {
+			return nil, err
+		}
+	}
+
+	// keys are the same on every upload, the version busts caches
+	a.ImageUrl = fmt.Sprintf("%s?v=%d", s.blobs.URL(profileImageKey(uid, profileImageSizes[0])), time.Now().Unix())
+
+	if err := s.repo.Update(ctx, a); err != nil {
+		return nil, err
+	}
+
+	return a, nil
+}
+
+// ClearProfileImage unlinks the profile image from the account and
+// deletes the stored images. Clearing it again is a no-op
+func (s *accountService) ClearProfileImage(ctx context.Context, uid uuid.UUID) (*domain.Account, error) {
+	a, err := s.repo.FindByID(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+
+	// the account is updated first so it never links to a deleted image
+	if a.ImageUrl != "" {
+		a.ImageUrl = ""
+		if err := s.repo.Update(ctx, a); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, size := range profileImageSizes {
+		if err := s.blobs.Delete(ctx, profileImageKey(uid, size)); err != nil {
+			return nil, err
+		}
+	}
+
+	return a, nil
+}
+
+// ForgotPassword mails a password reset link to the account. To not
+// reveal which emails have an account, an unknown email isn't an error
+func (s *accountService) ForgotPassword(ctx context.Context, email string) error {
+	a, err := s.repo.FindByEmail(ctx, email)
+	if err != nil {
+		if isNotFound(err) {
+			log.Printf("Password reset requested for unknown email: %v\n", email)
+			return nil
+		}
+
+		log.Printf("Unable to find account for password reset of email: %v. Reason: %v\n", email, err)
+		return domain.NewInternal()
+	}
+
+	token, err := crypto.RandomToken(oneTimeTokenBytes)
+	if err != nil {
+		log.Printf("Unable to generate password reset token for uid: %v. Reason: %v\n", a.UID, err)
+		return domain.NewInternal()
+	}
+
+	// only the hash is stored, a leaked redis can't be used to reset passwords
+	expiresIn := time.Duration(s.resetTokenExp) * time.Second
+	if err := s.oneTimeTokens.SetToken(ctx, domain.PasswordResetToken, crypto.HashToken(token), a.UID.String(), expiresIn); err != nil {
+		return err
+	}
+
+	msg := &domain.Message{
+		To:      a.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Someone asked to reset the password of your account.\n\n"+
+			"Follow this link within %v to choose a new password:\n%s/reset-password?token=%s\n\n"+
+			"If it wasn't you, you can ignore this email.", expiresIn, s.appURL, token),
+	}
+
+	if err := s.mailer.Send(ctx, msg); err != nil {
+		log.Printf("Unable to send password reset email for uid: %v. Reason: %v\n", a.UID, err)
+		return domain.NewInternal()
+	}
+
+	return nil
+}
+
+// ResetPassword sets a new password for the account the reset token
+// was issued for. The token can only be used once
+func (s *accountService) ResetPassword(ctx context.Context, token string, password string) (*domain.Account, error) {
+	accID, err := s.oneTimeTokens.ConsumeToken(ctx, domain.PasswordResetToken, crypto.HashToken(token))
+	if err != nil {
+		if isNotFound(err) {
+			return nil, domain.NewBadRequest("Invalid or expired password reset token")
+		}
+		return nil, err
+	}
+
+	uid, err := uuid.Parse(accID)
+	if err != nil {
+		log.Printf("Password reset token account could not be parsed as UUID: %s\n%v\n", accID, err)
+		return nil, domain.NewInternal()
+	}
+
+	a, err := s.repo.FindByID(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+
+	hash, err := crypto.HashPassword(password)
+	if err != nil {
+		return nil, domain.NewInternal()
+	}
+
+	if err := s.repo.UpdatePassword(ctx, uid, hash); err != nil {
+		return nil, err
+	}
+
+	a.Password = hash
+	return a, nil
+}
+
+// sendVerificationEmail mails a link verifying the email of the account.
+// It replaces any verification link sent before
+func (s *accountService) sendVerificationEmail(ctx context.Context, a *domain.Account) error {
+	token, err := crypto.RandomToken(oneTimeTokenBytes)
+	if err != nil {
+		return err
+	}
+
+	expiresIn := time.Duration(s.verifyTokenExp) * time.Second
+	if err := s.oneTimeTokens.SetToken(ctx, domain.EmailVerificationToken, crypto.HashToken(token), a.UID.String(), expiresIn); err != nil {
+		return err
+	}
+
+	msg := &domain.Message{
+		To:      a.Email,
+		Subject: "Verify your email",
+		Body: fmt.Sprintf("Follow this link within %v to verify your email:\n"+
+			"%s/api/account/verify?token=%s", expiresIn, s.appURL, token),
+	}
+
+	return s.mailer.Send(ctx, msg)
+}
+
+// VerifyEmail marks the email of the account the verification
+// token was issued for as verified. The token can only be used once
+func (s *accountService) VerifyEmail(ctx context.Context, token string) (*domain.Account, error) {
+	accID, err := s.oneTimeTokens.ConsumeToken(ctx, domain.EmailVerificationToken, crypto.HashToken(token))
+	if err != nil {
+		if isNotFound(err) {
+			return nil, domain.NewBadRequest("Invalid or expired verification token")
+		}
+		return nil, err
+	}
+
+	uid, err := uuid.Parse(accID)
+	if err != nil {
+		log.Printf("Verification token account could not be parsed as UUID: %s\n%v\n", accID, err)
+		return nil, domain.NewInternal()
+	}
+
+	a, err := s.repo.FindByID(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+
+	if a.EmailVerifiedAt.Valid {
+		return a, nil
+	}
+
+	a.EmailVerifiedAt = sql.NullTime{Time: time.Now(), Valid: true}
+	if err := s.repo.Update(ctx, a); err != nil {
+		return nil, err
+	}
+
+	return a, nil
+}
+
+// ResendVerificationEmail mails a new verification link
+// to an account which hasn't verified its email yet
+func (s *accountService) ResendVerificationEmail(ctx context.Context, uid uuid.UUID) error {
+	a, err := s.repo.FindByID(ctx, uid)
+	if err != nil {
+		return err
+	}
+
+	if a.EmailVerifiedAt.Valid {
+		return domain.NewBadRequest("Email is already verified")
+	}
+
+	if err := s.sendVerificationEmail(ctx, a); err != nil {
+		log.Printf("Unable to send verification email for uid: %v. Reason: %v\n", uid, err)
+		return domain.NewInternal()
+	}
+
+	return nil
+}
+
 func (s *tokenService) ValidateRefreshToken(tokenString string) (*domain.RefreshToken, error) {
 	// validate actual JWT with string a secret
 	claims, err := jwt.ValidateRefreshToken(tokenString, s.refreshSecret)
@@ -91,8 +407,9 @@ func (s *tokenService) ValidateRefreshToken(tokenString string) (*domain.Refresh
 	}
 
 	return &domain.RefreshToken{
-		SS:  tokenString,
-		ID:  tokenUUID,
-		UID: claims.UID,
+		SS:       tokenString,
+		ID:       tokenUUID,
+		UID:      claims.UID,
+		FamilyID: claims.FamilyID,
 	}, nil
 }
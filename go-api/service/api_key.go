@@ -0,0 +1,133 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/whuangz/go-example/go-api/domain"
+	"github.com/whuangz/go-example/go-api/helpers/crypto"
+)
+
+const (
+	// apiKeyPrefix makes keys easy to recognize, eg: by secret scanners
+	apiKeyPrefix = "gxk_"
+	apiKeyBytes  = 32
+	// apiKeyDisplayLength is how much of a key is kept to tell it apart
+	apiKeyDisplayLength = len(apiKeyPrefix) + 8
+
+	maxAPIKeys = 25
+
+	// the last use of a key is only written once per interval
+	apiKeyLastUsedInterval = time.Minute
+)
+
+type apiKeyService struct {
+	repo     domain.APIKeyRepository
+	accounts domain.AccountRepository
+}
+
+func NewAPIKeyService(repo domain.APIKeyRepository, accounts domain.AccountRepository) domain.APIKeyService {
+	return &apiKeyService{repo, accounts}
+}
+
+// Create generates a key for the account and returns it along
+// with its details. The key itself can't be retrieved afterwards
+func (s *apiKeyService) Create(ctx context.Context, accountUID uuid.UUID, name string, scopes []string, expiresIn time.Duration) (*domain.APIKey, string, error) {
+	if len(scopes) == 0 {
+		return nil, "", domain.NewBadRequest("at least one scope is required")
+	}
+
+	for _, scope := range scopes {
+		if scope != domain.APIKeyReadScope && scope != domain.APIKeyWriteScope {
+			return nil, "", domain.NewBadRequest("unknown scope: " + scope)
+		}
+	}
+
+	if expiresIn < 0 {
+		return nil, "", domain.NewBadRequest("expiry has to be in the future")
+	}
+
+	keys, err := s.repo.FindByAccount(ctx, accountUID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if len(keys) >= maxAPIKeys {
+		return nil, "", domain.NewBadRequest("too many API keys, revoke unused ones first")
+	}
+
+	secret, err := crypto.RandomToken(apiKeyBytes)
+	if err != nil {
+		log.Printf("Unable to generate API key for uid: %v. Reason: %v\n", accountUID, err)
+		return nil, "", domain.NewInternal()
+	}
+	key := apiKeyPrefix + secret
+
+	k := &domain.APIKey{
+		AccountUID: accountUID,
+		Name:       name,
+		Prefix:     key[:apiKeyDisplayLength],
+		KeyHash:    crypto.HashToken(key),
+		Scopes:     scopes,
+	}
+
+	if expiresIn > 0 {
+		k.ExpiresAt = sql.NullTime{Time: time.Now().Add(expiresIn), Valid: true}
+	}
+
+	if err := s.repo.Create(ctx, k); err != nil {
+		return nil, "", err
+	}
+
+	return k, key, nil
+}
+
+func (s *apiKeyService) List(ctx context.Context, accountUID uuid.UUID) ([]*domain.APIKey, error) {
+	return s.repo.FindByAccount(ctx, accountUID)
+}
+
+func (s *apiKeyService) Revoke(ctx context.Context, accountUID uuid.UUID, uid uuid.UUID) error {
+	return s.repo.Delete(ctx, accountUID, uid)
+}
+
+// Authenticate returns the account of a key, along with the key so its
+// scopes can be checked. Revoked, expired and unknown keys are rejected alike
+func (s *apiKeyService) Authenticate(ctx context.Context, key string) (*domain.Account, *domain.APIKey, error) {
+	invalid := domain.NewAuthorization("Provided API key is invalid")
+
+	if !strings.HasPrefix(key, apiKeyPrefix) {
+		return nil, nil, invalid
+	}
+
+	k, err := s.repo.FindByHash(ctx, crypto.HashToken(key))
+	if err != nil {
+		if isNotFound(err) {
+			return nil, nil, invalid
+		}
+		return nil, nil, err
+	}
+
+	now := time.Now()
+	if k.ExpiresAt.Valid && !now.Before(k.ExpiresAt.Time) {
+		return nil, nil, invalid
+	}
+
+	account, err := s.accounts.FindByID(ctx, k.AccountUID)
+	if err != nil {
+		log.Printf("Unable to find account of API key: %v. Reason: %v\n", k.UID, err)
+		return nil, nil, invalid
+	}
+
+	if !k.LastUsedAt.Valid || now.Sub(k.LastUsedAt.Time) >= apiKeyLastUsedInterval {
+		// a failed write isn't worth failing the request for
+		if err := s.repo.SetLastUsed(ctx, k.UID, now); err == nil {
+			k.LastUsedAt = sql.NullTime{Time: now, Valid: true}
+		}
+	}
+
+	return account, k, nil
+}
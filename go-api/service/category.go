@@ -0,0 +1,61 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/whuangz/go-example/go-api/domain"
+	"github.com/whuangz/go-example/go-api/helpers/slug"
+)
+
+type categoryService struct {
+	repo domain.CategoryRepository
+}
+
+func NewCategoryService(repo domain.CategoryRepository) domain.CategoryService {
+	return &categoryService{repo: repo}
+}
+
+func (s *categoryService) Save(ctx context.Context, category *domain.Category) error {
+	category.Name = strings.TrimSpace(category.Name)
+	category.Slug = slug.Make(category.Name)
+	if category.Slug == "" {
+		return domain.NewBadRequest("the name of a category needs letters or digits")
+	}
+
+	if category.ParentID != 0 {
+		if _, err := s.repo.FindByID(ctx, category.ParentID); err != nil {
+			if domain.Status(err) == http.StatusNotFound {
+				return domain.NewBadRequest("unknown parent category")
+			}
+			return err
+		}
+	}
+
+	return s.repo.Save(ctx, category)
+}
+
+func (s *categoryService) Tree(ctx context.Context) ([]domain.Category, error) {
+	categories, err := s.repo.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	children := make(map[int32][]domain.Category)
+	for _, c := range categories {
+		children[c.ParentID] = append(children[c.ParentID], c)
+	}
+	return categoryTree(children, 0), nil
+}
+
+// categoryTree nests the children of the parent, top level
+// categories are the children of 0
+func categoryTree(children map[int32][]domain.Category, parentID int32) []domain.Category {
+	tree := []domain.Category{}
+	for _, c := range children[parentID] {
+		c.Children = categoryTree(children, c.ID)
+		tree = append(tree, c)
+	}
+	return tree
+}
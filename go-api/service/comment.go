@@ -0,0 +1,202 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/whuangz/go-example/go-api/domain"
+)
+
+type commentService struct {
+	repo       domain.CommentRepository
+	posts      domain.PostRepository
+	editWindow time.Duration
+}
+
+// NewCommentService lets authors edit their comments for editWindow
+// after posting them
+func NewCommentService(repo domain.CommentRepository, posts domain.PostRepository, editWindow time.Duration) domain.CommentService {
+	return &commentService{repo: repo, posts: posts, editWindow: editWindow}
+}
+
+func (s *commentService) List(ctx context.Context, account *domain.Account, postID int32) ([]domain.Comment, error) {
+	post, err := s.posts.FindByID(ctx, postID)
+	if err != nil {
+		return nil, err
+	}
+
+	moderator := account != nil && post.CanBeModifiedBy(account)
+	if !isPublic(post) && !moderator {
+		return nil, domain.NewNotFound("id", strconv.Itoa(int(postID)))
+	}
+
+	comments, err := s.repo.FindByPost(ctx, postID)
+	if err != nil {
+		return nil, err
+	}
+
+	// replies of a comment that can't be seen are left out along with it
+	replies := map[int32][]domain.Comment{}
+	for _, comment := range comments {
+		if moderator || comment.Status == domain.CommentApproved || (account != nil && comment.AccountUID == account.UID) {
+			replies[comment.ParentID] = append(replies[comment.ParentID], comment)
+		}
+	}
+	return thread(replies, 0), nil
+}
+
+// thread nests the replies below the comment, deleted
+// comments are only kept to hold their replies
+func thread(replies map[int32][]domain.Comment, parentID int32) []domain.Comment {
+	comments := []domain.Comment{}
+	for _, comment := range replies[parentID] {
+		comment.Replies = thread(replies, comment.ID)
+		if comment.Deleted && len(comment.Replies) == 0 {
+			continue
+		}
+		comments = append(comments, comment)
+	}
+	return comments
+}
+
+func (s *commentService) Create(ctx context.Context, account *domain.Account, postID int32, comment *domain.Comment) error {
+	post, err := s.posts.FindByID(ctx, postID)
+	if err != nil {
+		return err
+	}
+	if post.Status != domain.PublishedStatus {
+		if post.Status == domain.ArchivedStatus {
+			return domain.NewForbidden("Comments are closed on archived posts")
+		}
+		return domain.NewNotFound("id", strconv.Itoa(int(postID)))
+	}
+
+	content, err := commentContent(comment.Content)
+	if err != nil {
+		return err
+	}
+
+	comment.Depth = 0
+	if comment.ParentID != 0 {
+		parent, err := s.repo.FindByID(ctx, comment.ParentID)
+		if err != nil && domain.Status(err) != http.StatusNotFound {
+			return err
+		}
+		if err != nil || parent.PostID != postID {
+			return domain.NewBadRequest("unknown parent comment")
+		}
+		if parent.Deleted || parent.Status != domain.CommentApproved {
+			return domain.NewBadRequest("only approved comments can be replied to")
+		}
+		if parent.Depth >= domain.MaxCommentDepth {
+			return domain.NewBadRequest(fmt.Sprintf("replies can nest at most %d levels deep", domain.MaxCommentDepth))
+		}
+		comment.Depth = parent.Depth + 1
+	}
+
+	comment.PostID = postID
+	comment.AccountUID = account.UID
+	comment.AccountName = account.Name
+	comment.Content = content
+	comment.Status = domain.CommentPending
+	if post.CanBeModifiedBy(account) {
+		comment.Status = domain.CommentApproved
+	}
+	comment.Deleted = false
+	comment.EditedAt = nil
+	comment.Replies = nil
+	return s.repo.Save(ctx, comment)
+}
+
+func (s *commentService) Update(ctx context.Context, account *domain.Account, postID int32, id int32, content string) (*domain.Comment, error) {
+	post, comment, err := s.find(ctx, postID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if comment.AccountUID != account.UID {
+		return nil, domain.NewForbidden("Only the author of the comment can edit it")
+	}
+	if time.Since(comment.CreatedAt) > s.editWindow {
+		return nil, domain.NewForbidden(fmt.Sprintf("Comments can only be edited within %v of posting them", s.editWindow))
+	}
+
+	if comment.Content, err = commentContent(content); err != nil {
+		return nil, err
+	}
+	if !post.CanBeModifiedBy(account) {
+		comment.Status = domain.CommentPending
+	}
+	now := time.Now()
+	comment.EditedAt = &now
+
+	if err := s.repo.Update(ctx, &comment); err != nil {
+		return nil, err
+	}
+	return &comment, nil
+}
+
+func (s *commentService) Delete(ctx context.Context, account *domain.Account, postID int32, id int32) error {
+	post, comment, err := s.find(ctx, postID, id)
+	if err != nil {
+		return err
+	}
+
+	if comment.AccountUID != account.UID && !post.CanBeModifiedBy(account) {
+		return domain.NewForbidden("Only the author of the comment or a moderator of the post can delete it")
+	}
+	return s.repo.Delete(ctx, id)
+}
+
+func (s *commentService) Moderate(ctx context.Context, account *domain.Account, postID int32, id int32, status string) (*domain.Comment, error) {
+	if !domain.IsCommentStatus(status) {
+		return nil, domain.NewBadRequest("unknown status: " + status)
+	}
+
+	post, comment, err := s.find(ctx, postID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !post.CanBeModifiedBy(account) {
+		return nil, domain.NewForbidden("Only the author of the post or an editor can moderate its comments")
+	}
+
+	comment.Status = status
+	if err := s.repo.Update(ctx, &comment); err != nil {
+		return nil, err
+	}
+	return &comment, nil
+}
+
+// find returns the comment of the post along with the post, comments of
+// other posts and deleted comments aren't found
+func (s *commentService) find(ctx context.Context, postID int32, id int32) (domain.Post, domain.Comment, error) {
+	comment, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return domain.Post{}, comment, err
+	}
+	if comment.PostID != postID || comment.Deleted {
+		return domain.Post{}, comment, domain.NewNotFound("comment", strconv.Itoa(int(id)))
+	}
+
+	post, err := s.posts.FindByID(ctx, postID)
+	return post, comment, err
+}
+
+// commentContent trims the content of a comment and checks its length
+func commentContent(content string) (string, error) {
+	content = strings.TrimSpace(content)
+	if content == "" {
+		return "", domain.NewBadRequest("a comment can't be empty")
+	}
+	if utf8.RuneCountInString(content) > domain.MaxCommentLength {
+		return "", domain.NewBadRequest(fmt.Sprintf("a comment can have at most %d characters", domain.MaxCommentLength))
+	}
+	return content, nil
+}
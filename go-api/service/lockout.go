@@ -0,0 +1,103 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/whuangz/go-example/go-api/domain"
+)
+
+type lockoutService struct {
+	repo   domain.SigninAttemptRepository
+	policy domain.LockoutPolicy
+}
+
+func NewLockoutService(repo domain.SigninAttemptRepository, policy domain.LockoutPolicy) domain.LockoutService {
+	return &lockoutService{repo, policy}
+}
+
+func emailAttemptKey(email string) string {
+	return "email:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+func ipAttemptKey(ip string) string {
+	return "ip:" + ip
+}
+
+// Check rejects the signin while either the email or the IP is locked
+func (s *lockoutService) Check(ctx context.Context, email string, ip string) error {
+	lockedFor, err := s.repo.LockedFor(ctx, emailAttemptKey(email), ipAttemptKey(ip))
+	if err != nil {
+		return err
+	}
+
+	if lockedFor > 0 {
+		return domain.NewTooManyRequests(lockedFor)
+	}
+	return nil
+}
+
+// RegisterFailure counts the failure against both the email and the IP.
+// When it locks either of them, the TooManyRequests error is returned
+// so the client knows how long to wait before the next attempt
+func (s *lockoutService) RegisterFailure(ctx context.Context, email string, ip string) error {
+	attempts := []struct {
+		key         string
+		maxFailures int64
+	}{
+		{emailAttemptKey(email), s.policy.MaxFailures},
+		{ipAttemptKey(ip), s.policy.MaxIPFailures},
+	}
+
+	var lockedFor time.Duration
+	for _, a := range attempts {
+		failures, err := s.repo.AddFailure(ctx, a.key, s.policy.Window)
+		if err != nil {
+			return err
+		}
+
+		if failures < a.maxFailures {
+			continue
+		}
+
+		d := s.lockout(failures - a.maxFailures)
+		if err := s.repo.Lock(ctx, a.key, d); err != nil {
+			return err
+		}
+
+		if d > lockedFor {
+			lockedFor = d
+		}
+	}
+
+	if lockedFor > 0 {
+		return domain.NewTooManyRequests(lockedFor)
+	}
+	return nil
+}
+
+// RegisterSuccess resets the failures of the email. The failures of the IP
+// are left to expire, otherwise signing in to an account of their own
+// would let anyone keep guessing the passwords of others
+func (s *lockoutService) RegisterSuccess(ctx context.Context, email string) error {
+	return s.repo.Reset(ctx, emailAttemptKey(email))
+}
+
+// Unlock lets an admin lift the lock of an account before it expires
+func (s *lockoutService) Unlock(ctx context.Context, email string) error {
+	return s.repo.Reset(ctx, emailAttemptKey(email))
+}
+
+// lockout doubles BaseLockout for every failure over the maximum
+func (s *lockoutService) lockout(overMax int64) time.Duration {
+	d := s.policy.BaseLockout
+	for i := int64(0); i < overMax && d < s.policy.MaxLockout; i++ {
+		d *= 2
+	}
+
+	if d > s.policy.MaxLockout {
+		return s.policy.MaxLockout
+	}
+	return d
+}
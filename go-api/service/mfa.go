@@ -0,0 +1,291 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/skip2/go-qrcode"
+	"github.com/whuangz/go-example/go-api/domain"
+	"github.com/whuangz/go-example/go-api/helpers/crypto"
+	"github.com/whuangz/go-example/go-api/helpers/totp"
+)
+
+const (
+	// totpSkew accepts the codes of the previous and next time steps
+	totpSkew = 1
+
+	recoveryCodeCount = 10
+	// recoveryCodeBytes gives 16 base32 characters, shown in groups of 4
+	recoveryCodeBytes = 10
+
+	qrCodeSize = 256
+)
+
+var recoveryCodeEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+type mfaService struct {
+	repo          domain.MFARepository
+	oneTimeTokens domain.OneTimeTokenRepository
+	encryptionKey []byte
+	issuer        string
+	challengeExp  int64
+}
+
+func NewMFAService(repo domain.MFARepository, oneTimeTokens domain.OneTimeTokenRepository, encryptionKey []byte, issuer string, challengeExp int64) domain.MFAService {
+	return &mfaService{repo, oneTimeTokens, encryptionKey, issuer, challengeExp}
+}
+
+// Enroll generates a new secret for the account. It isn't
+// enabled until a code generated from it is confirmed
+func (s *mfaService) Enroll(ctx context.Context, a *domain.Account) (*domain.MFAEnrollment, error) {
+	current, err := s.repo.FindByUID(ctx, a.UID)
+	if err != nil && !isNotFound(err) {
+		return nil, err
+	}
+
+	if current != nil && current.EnabledAt.Valid {
+		return nil, domain.NewBadRequest("2FA is already enabled")
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		log.Printf("Unable to generate 2FA secret for uid: %v. Reason: %v\n", a.UID, err)
+		return nil, domain.NewInternal()
+	}
+
+	encrypted, err := crypto.Encrypt(s.encryptionKey, secret)
+	if err != nil {
+		log.Printf("Unable to encrypt 2FA secret for uid: %v. Reason: %v\n", a.UID, err)
+		return nil, domain.NewInternal()
+	}
+
+	if err := s.repo.Save(ctx, &domain.MFA{AccountUID: a.UID, Secret: encrypted}); err != nil {
+		return nil, err
+	}
+
+	uri := totp.URI(s.issuer, a.Email, secret)
+	png, err := qrcode.Encode(uri, qrcode.Medium, qrCodeSize)
+	if err != nil {
+		log.Printf("Unable to encode 2FA QR code for uid: %v. Reason: %v\n", a.UID, err)
+		return nil, domain.NewInternal()
+	}
+
+	return &domain.MFAEnrollment{
+		Secret: secret,
+		URI:    uri,
+		QRCode: png,
+	}, nil
+}
+
+// Confirm enables 2FA once the account proves its authenticator
+// works. The recovery codes are returned only this once
+func (s *mfaService) Confirm(ctx context.Context, uid uuid.UUID, code string) ([]string, error) {
+	m, err := s.repo.FindByUID(ctx, uid)
+	if err != nil {
+		if isNotFound(err) {
+			return nil, domain.NewBadRequest("2FA enrollment has not been started")
+		}
+		return nil, err
+	}
+
+	if m.EnabledAt.Valid {
+		return nil, domain.NewBadRequest("2FA is already enabled")
+	}
+
+	secret, err := crypto.Decrypt(s.encryptionKey, m.Secret)
+	if err != nil {
+		log.Printf("Unable to decrypt 2FA secret for uid: %v. Reason: %v\n", uid, err)
+		return nil, domain.NewInternal()
+	}
+
+	step, ok := totp.Validate(secret, code, time.Now(), totpSkew)
+	if !ok {
+		return nil, domain.NewAuthorization("Invalid 2FA code")
+	}
+
+	m.EnabledAt.Time = time.Now()
+	m.EnabledAt.Valid = true
+	m.LastUsedStep = step
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		log.Printf("Unable to generate recovery codes for uid: %v. Reason: %v\n", uid, err)
+		return nil, domain.NewInternal()
+	}
+
+	if err := s.repo.SetRecoveryCodes(ctx, uid, hashes); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Save(ctx, m); err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// Disable turns 2FA off, it takes a current code
+// or a recovery code so a stolen session isn't enough
+func (s *mfaService) Disable(ctx context.Context, uid uuid.UUID, code string) error {
+	m, err := s.enabledMFA(ctx, uid)
+	if err != nil {
+		return err
+	}
+
+	if err := s.verifyCode(ctx, m, code); err != nil {
+		return err
+	}
+
+	return s.repo.Delete(ctx, uid)
+}
+
+func (s *mfaService) IsEnabled(ctx context.Context, uid uuid.UUID) (bool, error) {
+	m, err := s.repo.FindByUID(ctx, uid)
+	if err != nil {
+		if isNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return m.EnabledAt.Valid, nil
+}
+
+// NewChallenge issues the short lived, single use token
+// which has to be verified with a code to complete a signin
+func (s *mfaService) NewChallenge(ctx context.Context, uid uuid.UUID) (*domain.MFAChallenge, error) {
+	token, err := crypto.RandomToken(oneTimeTokenBytes)
+	if err != nil {
+		log.Printf("Unable to generate 2FA challenge for uid: %v. Reason: %v\n", uid, err)
+		return nil, domain.NewInternal()
+	}
+
+	expiresIn := time.Duration(s.challengeExp) * time.Second
+	if err := s.oneTimeTokens.SetToken(ctx, domain.MFAChallengeToken, crypto.HashToken(token), uid.String(), expiresIn); err != nil {
+		return nil, err
+	}
+
+	return &domain.MFAChallenge{
+		MFARequired:    true,
+		ChallengeToken: token,
+		ExpiresIn:      s.challengeExp,
+	}, nil
+}
+
+// VerifyChallenge returns the account a challenge was issued for when the
+// code is valid. The challenge is used up either way, a wrong code means
+// signing in with the password again
+func (s *mfaService) VerifyChallenge(ctx context.Context, challengeToken string, code string) (uuid.UUID, error) {
+	accID, err := s.oneTimeTokens.ConsumeToken(ctx, domain.MFAChallengeToken, crypto.HashToken(challengeToken))
+	if err != nil {
+		if isNotFound(err) {
+			return uuid.Nil, domain.NewAuthorization("Invalid or expired 2FA challenge")
+		}
+		return uuid.Nil, err
+	}
+
+	uid, err := uuid.Parse(accID)
+	if err != nil {
+		log.Printf("2FA challenge account could not be parsed as UUID: %s\n%v\n", accID, err)
+		return uuid.Nil, domain.NewInternal()
+	}
+
+	m, err := s.enabledMFA(ctx, uid)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	if err := s.verifyCode(ctx, m, code); err != nil {
+		return uuid.Nil, err
+	}
+
+	return uid, nil
+}
+
+func (s *mfaService) enabledMFA(ctx context.Context, uid uuid.UUID) (*domain.MFA, error) {
+	m, err := s.repo.FindByUID(ctx, uid)
+	if err != nil {
+		if isNotFound(err) {
+			return nil, domain.NewBadRequest("2FA is not enabled")
+		}
+		return nil, err
+	}
+
+	if !m.EnabledAt.Valid {
+		return nil, domain.NewBadRequest("2FA is not enabled")
+	}
+	return m, nil
+}
+
+// verifyCode accepts either a TOTP code, which can't be replayed,
+// or one of the single use recovery codes
+func (s *mfaService) verifyCode(ctx context.Context, m *domain.MFA, code string) error {
+	invalid := domain.NewAuthorization("Invalid 2FA code")
+
+	if len(code) == totp.Digits {
+		secret, err := crypto.Decrypt(s.encryptionKey, m.Secret)
+		if err != nil {
+			log.Printf("Unable to decrypt 2FA secret for uid: %v. Reason: %v\n", m.AccountUID, err)
+			return domain.NewInternal()
+		}
+
+		step, ok := totp.Validate(secret, code, time.Now(), totpSkew)
+		if !ok {
+			return invalid
+		}
+
+		fresh, err := s.repo.UseStep(ctx, m.AccountUID, step)
+		if err != nil {
+			return err
+		}
+		if !fresh {
+			return invalid
+		}
+		return nil
+	}
+
+	used, err := s.repo.UseRecoveryCode(ctx, m.AccountUID, crypto.HashToken(normalizeRecoveryCode(code)))
+	if err != nil {
+		return err
+	}
+	if !used {
+		return invalid
+	}
+	return nil
+}
+
+// generateRecoveryCodes returns the codes to show, formatted as
+// XXXX-XXXX-XXXX-XXXX, and the hashes to store
+func generateRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+
+	for i := 0; i < recoveryCodeCount; i++ {
+		b := make([]byte, recoveryCodeBytes)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, err
+		}
+
+		raw := recoveryCodeEncoding.EncodeToString(b)
+
+		var groups []string
+		for j := 0; j < len(raw); j += 4 {
+			groups = append(groups, raw[j:j+4])
+		}
+
+		codes = append(codes, strings.Join(groups, "-"))
+		hashes = append(hashes, crypto.HashToken(raw))
+	}
+
+	return codes, hashes, nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToUpper(code)
+	code = strings.ReplaceAll(code, "-", "")
+	return strings.ReplaceAll(code, " ", "")
+}
@@ -0,0 +1,591 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/whuangz/go-example/go-api/domain"
+	"github.com/whuangz/go-example/go-api/helpers/crypto"
+	"github.com/whuangz/go-example/go-api/helpers/jwt"
+)
+
+const (
+	oauthClientIDBytes     = 16
+	oauthClientSecretBytes = 32
+	authorizationCodeBytes = 32
+
+	// RFC 7636 code verifiers are 43 to 128 characters long
+	minCodeVerifierLength = 43
+	maxCodeVerifierLength = 128
+)
+
+// oidcScopes release claims about the account,
+// they can't be granted to a client acting on its own behalf
+var oidcScopes = []string{"openid", "profile", "email"}
+
+type oauthService struct {
+	repo           domain.OAuthRepository
+	codes          domain.AuthorizationCodeRepository
+	accounts       domain.AccountRepository
+	denylist       domain.TokenDenylist
+	keys           *jwt.Keyring
+	issuer         string
+	accessTokenExp int64
+	codeExp        int64
+}
+
+// NewOAuthService creates an OAuth 2 and OpenID Connect provider. Its
+// tokens are signed with the keys of the access tokens of the api,
+// and revoked through the same denylist
+func NewOAuthService(repo domain.OAuthRepository, codes domain.AuthorizationCodeRepository, accounts domain.AccountRepository, denylist domain.TokenDenylist, keys *jwt.Keyring, issuer string, accessTokenExp int64, codeExp int64) domain.OAuthService {
+	return &oauthService{repo, codes, accounts, denylist, keys, strings.TrimSuffix(issuer, "/"), accessTokenExp, codeExp}
+}
+
+// RegisterClient adds a client to the registry and returns its secret,
+// which is only stored hashed. Public clients get no secret
+func (s *oauthService) RegisterClient(ctx context.Context, c *domain.OAuthClient, public bool) (string, error) {
+	if len(c.GrantTypes) == 0 {
+		return "", domain.NewBadRequest("at least one grant type is required")
+	}
+
+	for _, grantType := range c.GrantTypes {
+		switch grantType {
+		case domain.AuthorizationCodeGrant:
+			if len(c.RedirectURIs) == 0 {
+				return "", domain.NewBadRequest("the authorization_code grant requires a redirect uri")
+			}
+		case domain.ClientCredentialsGrant:
+			if public {
+				return "", domain.NewBadRequest("the client_credentials grant requires a confidential client")
+			}
+		default:
+			return "", domain.NewBadRequest("unsupported grant type: " + grantType)
+		}
+	}
+
+	for _, redirectURI := range c.RedirectURIs {
+		u, err := url.Parse(redirectURI)
+		if err != nil || !u.IsAbs() || u.Host == "" || u.Fragment != "" {
+			return "", domain.NewBadRequest("redirect uris must be absolute urls without a fragment: " + redirectURI)
+		}
+	}
+
+	clientID, err := crypto.RandomToken(oauthClientIDBytes)
+	if err != nil {
+		log.Printf("Unable to generate OAuth client id. Reason: %v\n", err)
+		return "", domain.NewInternal()
+	}
+	c.ClientID = clientID
+
+	secret := ""
+	if !public {
+		if secret, err = crypto.RandomToken(oauthClientSecretBytes); err != nil {
+			log.Printf("Unable to generate OAuth client secret. Reason: %v\n", err)
+			return "", domain.NewInternal()
+		}
+		c.SecretHash = crypto.HashToken(secret)
+	}
+
+	if err := s.repo.CreateClient(ctx, c); err != nil {
+		return "", err
+	}
+
+	return secret, nil
+}
+
+// AuthenticateClient checks the credentials of a client at the token,
+// introspection and revocation endpoints. Public clients only send their id
+func (s *oauthService) AuthenticateClient(ctx context.Context, clientID string, secret string) (*domain.OAuthClient, error) {
+	if clientID == "" {
+		return nil, domain.NewOAuthError("invalid_client", "client authentication is required")
+	}
+
+	client, err := s.repo.FindClient(ctx, clientID)
+	if err != nil {
+		if isNotFound(err) {
+			return nil, domain.NewOAuthError("invalid_client", "unknown client")
+		}
+		return nil, err
+	}
+
+	if client.IsPublic() {
+		if secret != "" {
+			return nil, domain.NewOAuthError("invalid_client", "public clients have no secret")
+		}
+		return client, nil
+	}
+
+	if subtle.ConstantTimeCompare([]byte(crypto.HashToken(secret)), []byte(client.SecretHash)) != 1 {
+		log.Printf("Invalid secret presented for OAuth client: %v\n", clientID)
+		return nil, domain.NewOAuthError("invalid_client", "invalid client credentials")
+	}
+
+	return client, nil
+}
+
+// Authorize sends the account back to the client with an authorization
+// code when it already consented to the requested scopes. Errors about the
+// client or its redirect uri are returned, since the account can't be sent
+// back. Other errors are reported to the client through its redirect uri
+func (s *oauthService) Authorize(ctx context.Context, uid uuid.UUID, req *domain.AuthorizeRequest) (*domain.AuthorizeResponse, error) {
+	client, redirectURI, err := s.authorizationClient(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	scopes, oauthErr := validateAuthorizeRequest(client, req)
+	if oauthErr != nil {
+		return &domain.AuthorizeResponse{RedirectTo: authorizeRedirect(redirectURI, req.State, errorParams(oauthErr))}, nil
+	}
+
+	consent, err := s.repo.FindConsent(ctx, uid, client.ClientID)
+	if err != nil && !isNotFound(err) {
+		return nil, err
+	}
+
+	if consent == nil || !containsAll(consent.Scopes, scopes) {
+		return &domain.AuthorizeResponse{ConsentRequired: true, ClientName: client.Name, Scopes: scopes}, nil
+	}
+
+	return s.issueCode(ctx, uid, client, redirectURI, req)
+}
+
+// Consent records the decision of the account about the requested scopes,
+// then sends it back to the client with a code, or an access_denied error
+func (s *oauthService) Consent(ctx context.Context, uid uuid.UUID, req *domain.AuthorizeRequest, approve bool) (*domain.AuthorizeResponse, error) {
+	client, redirectURI, err := s.authorizationClient(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	scopes, oauthErr := validateAuthorizeRequest(client, req)
+	if oauthErr == nil && !approve {
+		oauthErr = domain.NewOAuthError("access_denied", "the account denied the request")
+	}
+	if oauthErr != nil {
+		return &domain.AuthorizeResponse{RedirectTo: authorizeRedirect(redirectURI, req.State, errorParams(oauthErr))}, nil
+	}
+
+	consent, err := s.repo.FindConsent(ctx, uid, client.ClientID)
+	if err != nil && !isNotFound(err) {
+		return nil, err
+	}
+
+	// scopes granted before are kept
+	if consent != nil {
+		for _, scope := range consent.Scopes {
+			if !contains(scopes, scope) {
+				scopes = append(scopes, scope)
+			}
+		}
+	}
+
+	if err := s.repo.SaveConsent(ctx, &domain.OAuthConsent{AccountUID: uid, ClientID: client.ClientID, Scopes: scopes}); err != nil {
+		return nil, err
+	}
+
+	return s.issueCode(ctx, uid, client, redirectURI, req)
+}
+
+// authorizationClient finds the client of an authorization request and the
+// redirect uri to send the account back to, which has to be registered.
+// It may only be left out when the client has a single redirect uri
+func (s *oauthService) authorizationClient(ctx context.Context, req *domain.AuthorizeRequest) (*domain.OAuthClient, string, error) {
+	client, err := s.repo.FindClient(ctx, req.ClientID)
+	if err != nil {
+		if isNotFound(err) {
+			return nil, "", domain.NewBadRequest("unknown client_id")
+		}
+		return nil, "", err
+	}
+
+	if req.RedirectURI == "" {
+		if len(client.RedirectURIs) != 1 {
+			return nil, "", domain.NewBadRequest("redirect_uri is required")
+		}
+		return client, client.RedirectURIs[0], nil
+	}
+
+	if !contains(client.RedirectURIs, req.RedirectURI) {
+		return nil, "", domain.NewBadRequest("redirect_uri is not registered for the client")
+	}
+	return client, req.RedirectURI, nil
+}
+
+// validateAuthorizeRequest returns the requested scopes. Every client
+// has to send an S256 PKCE challenge, confidential ones included
+func validateAuthorizeRequest(client *domain.OAuthClient, req *domain.AuthorizeRequest) ([]string, *domain.OAuthError) {
+	if req.ResponseType != "code" {
+		return nil, domain.NewOAuthError("unsupported_response_type", "only the code response type is supported")
+	}
+
+	if !contains(client.GrantTypes, domain.AuthorizationCodeGrant) {
+		return nil, domain.NewOAuthError("unauthorized_client", "the client can't use the authorization_code grant")
+	}
+
+	if req.CodeChallenge == "" || req.CodeChallengeMethod != "S256" {
+		return nil, domain.NewOAuthError("invalid_request", "a code_challenge with the S256 code_challenge_method is required")
+	}
+
+	scopes := strings.Fields(req.Scope)
+	if len(scopes) == 0 {
+		return nil, domain.NewOAuthError("invalid_scope", "scope is required")
+	}
+
+	if !containsAll(client.Scopes, scopes) {
+		return nil, domain.NewOAuthError("invalid_scope", "the client isn't allowed the requested scope")
+	}
+
+	return scopes, nil
+}
+
+func (s *oauthService) issueCode(ctx context.Context, uid uuid.UUID, client *domain.OAuthClient, redirectURI string, req *domain.AuthorizeRequest) (*domain.AuthorizeResponse, error) {
+	code, err := crypto.RandomToken(authorizationCodeBytes)
+	if err != nil {
+		log.Printf("Unable to generate authorization code for uid: %v. Reason: %v\n", uid, err)
+		return nil, domain.NewInternal()
+	}
+
+	authorizationCode := &domain.AuthorizationCode{
+		ClientID:   client.ClientID,
+		AccountUID: uid,
+		// as sent, the token request has to repeat it
+		RedirectURI:   req.RedirectURI,
+		Scope:         strings.Join(strings.Fields(req.Scope), " "),
+		CodeChallenge: req.CodeChallenge,
+		Nonce:         req.Nonce,
+	}
+
+	if err := s.codes.SaveCode(ctx, crypto.HashToken(code), authorizationCode, time.Duration(s.codeExp)*time.Second); err != nil {
+		return nil, err
+	}
+
+	return &domain.AuthorizeResponse{RedirectTo: authorizeRedirect(redirectURI, req.State, url.Values{"code": {code}})}, nil
+}
+
+// Exchange issues tokens to an authenticated client for an
+// authorization code, or for itself with its credentials
+func (s *oauthService) Exchange(ctx context.Context, client *domain.OAuthClient, req *domain.TokenRequest) (*domain.OAuthTokens, error) {
+	switch req.GrantType {
+	case domain.AuthorizationCodeGrant, domain.ClientCredentialsGrant:
+	default:
+		return nil, domain.NewOAuthError("unsupported_grant_type", "only the authorization_code and client_credentials grants are supported")
+	}
+
+	if !contains(client.GrantTypes, req.GrantType) {
+		return nil, domain.NewOAuthError("unauthorized_client", "the client can't use the "+req.GrantType+" grant")
+	}
+
+	if req.GrantType == domain.ClientCredentialsGrant {
+		return s.exchangeClientCredentials(client, req)
+	}
+	return s.exchangeAuthorizationCode(ctx, client, req)
+}
+
+func (s *oauthService) exchangeAuthorizationCode(ctx context.Context, client *domain.OAuthClient, req *domain.TokenRequest) (*domain.OAuthTokens, error) {
+	if req.Code == "" {
+		return nil, domain.NewOAuthError("invalid_request", "code is required")
+	}
+
+	// consumed first, a code can only be tried once
+	code, err := s.codes.ConsumeCode(ctx, crypto.HashToken(req.Code))
+	if err != nil {
+		if isNotFound(err) {
+			return nil, domain.NewOAuthError("invalid_grant", "the code is invalid, expired or already used")
+		}
+		return nil, err
+	}
+
+	if code.ClientID != client.ClientID {
+		log.Printf("Authorization code of client: %v presented by client: %v\n", code.ClientID, client.ClientID)
+		return nil, domain.NewOAuthError("invalid_grant", "the code was issued to another client")
+	}
+
+	if code.RedirectURI != req.RedirectURI {
+		return nil, domain.NewOAuthError("invalid_grant", "redirect_uri doesn't match the authorization request")
+	}
+
+	if !verifyCodeChallenge(req.CodeVerifier, code.CodeChallenge) {
+		return nil, domain.NewOAuthError("invalid_grant", "code_verifier doesn't match the code_challenge")
+	}
+
+	account, err := s.accounts.FindByID(ctx, code.AccountUID)
+	if err != nil {
+		if isNotFound(err) {
+			return nil, domain.NewOAuthError("invalid_grant", "the account doesn't exist anymore")
+		}
+		log.Printf("Unable to find account of authorization code with uid: %v. Reason: %v\n", code.AccountUID, err)
+		return nil, domain.NewInternal()
+	}
+
+	tokens, err := s.newAccessToken(account.UID.String(), client.ClientID, code.Scope)
+	if err != nil {
+		return nil, err
+	}
+
+	scopes := strings.Fields(code.Scope)
+	if contains(scopes, "openid") {
+		if tokens.IDToken, err = s.newIDToken(account, client.ClientID, scopes, code.Nonce, tokens.AccessToken); err != nil {
+			return nil, err
+		}
+	}
+
+	return tokens, nil
+}
+
+// exchangeClientCredentials issues a token to the client itself, by
+// default with every scope it is allowed besides the OpenID Connect ones
+func (s *oauthService) exchangeClientCredentials(client *domain.OAuthClient, req *domain.TokenRequest) (*domain.OAuthTokens, error) {
+	if client.IsPublic() {
+		return nil, domain.NewOAuthError("unauthorized_client", "public clients can't use the client_credentials grant")
+	}
+
+	scopes := strings.Fields(req.Scope)
+	if len(scopes) == 0 {
+		for _, scope := range client.Scopes {
+			if !contains(oidcScopes, scope) {
+				scopes = append(scopes, scope)
+			}
+		}
+	}
+
+	for _, scope := range scopes {
+		if contains(oidcScopes, scope) || !contains(client.Scopes, scope) {
+			return nil, domain.NewOAuthError("invalid_scope", "the client isn't allowed the scope: "+scope)
+		}
+	}
+
+	return s.newAccessToken(client.ClientID, client.ClientID, strings.Join(scopes, " "))
+}
+
+// verifyCodeChallenge checks the PKCE code verifier against
+// the challenge, BASE64URL(SHA256(code_verifier))
+func verifyCodeChallenge(verifier string, challenge string) bool {
+	if len(verifier) < minCodeVerifierLength || len(verifier) > maxCodeVerifierLength {
+		return false
+	}
+
+	sum := sha256.Sum256([]byte(verifier))
+	computed := base64.RawURLEncoding.EncodeToString(sum[:])
+	return subtle.ConstantTimeCompare([]byte(computed), []byte(challenge)) == 1
+}
+
+func (s *oauthService) newAccessToken(subject string, clientID string, scope string) (*domain.OAuthTokens, error) {
+	accessToken, err := jwt.GenerateOAuthAccessToken(s.issuer, subject, clientID, scope, s.keys.SigningKey(), s.accessTokenExp)
+	if err != nil {
+		log.Printf("Unable to sign OAuth access token for client: %v. Reason: %v\n", clientID, err)
+		return nil, domain.NewInternal()
+	}
+
+	return &domain.OAuthTokens{
+		AccessToken: accessToken,
+		TokenType:   "Bearer",
+		ExpiresIn:   s.accessTokenExp,
+		Scope:       scope,
+	}, nil
+}
+
+func (s *oauthService) newIDToken(a *domain.Account, clientID string, scopes []string, nonce string, accessToken string) (string, error) {
+	idToken, err := jwt.GenerateIDToken(s.issuer, clientID, userInfo(a, scopes), nonce, accessToken, s.keys.SigningKey(), s.accessTokenExp)
+	if err != nil {
+		log.Printf("Unable to sign ID token for uid: %v. Reason: %v\n", a.UID, err)
+		return "", domain.NewInternal()
+	}
+	return idToken, nil
+}
+
+// Introspect describes an access token issued by the provider, tokens
+// which are invalid, expired or revoked are only reported as inactive
+func (s *oauthService) Introspect(ctx context.Context, token string) (*domain.Introspection, error) {
+	claims, err := s.validateAccessToken(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	if claims == nil {
+		return &domain.Introspection{Active: false}, nil
+	}
+
+	return &domain.Introspection{
+		Active:    true,
+		Scope:     claims.Scope,
+		ClientID:  claims.ClientID,
+		Subject:   claims.Subject,
+		Audience:  claims.Audience,
+		Issuer:    claims.Issuer,
+		ExpiresAt: claims.ExpiresAt,
+		IssuedAt:  claims.IssuedAt,
+		TokenID:   claims.Id,
+		TokenType: "Bearer",
+	}, nil
+}
+
+// Revoke denies an access token for the time it has left. Only the client
+// it was issued to can revoke it, tokens which aren't valid anymore are ignored
+func (s *oauthService) Revoke(ctx context.Context, client *domain.OAuthClient, token string) error {
+	claims, err := s.validateAccessToken(ctx, token)
+	if err != nil || claims == nil {
+		return err
+	}
+
+	if claims.ClientID != client.ClientID {
+		log.Printf("Access token of client: %v presented for revocation by client: %v\n", claims.ClientID, client.ClientID)
+		return domain.NewOAuthError("unauthorized_client", "the token was issued to another client")
+	}
+
+	expiresIn := time.Until(time.Unix(claims.ExpiresAt, 0))
+	return s.denylist.DenyAccessToken(ctx, claims.Id, expiresIn)
+}
+
+// UserInfo returns the claims about the account of an access
+// token granted the openid scope, limited to the scopes of the token
+func (s *oauthService) UserInfo(ctx context.Context, accessToken string) (*domain.UserInfo, error) {
+	claims, err := s.validateAccessToken(ctx, accessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	if claims == nil {
+		return nil, domain.NewOAuthError("invalid_token", "the access token is invalid, expired or revoked")
+	}
+
+	scopes := strings.Fields(claims.Scope)
+	if !contains(scopes, "openid") {
+		return nil, domain.NewOAuthError("insufficient_scope", "the access token wasn't granted the openid scope")
+	}
+
+	uid, err := uuid.Parse(claims.Subject)
+	if err != nil {
+		return nil, domain.NewOAuthError("invalid_token", "the access token wasn't issued for an account")
+	}
+
+	account, err := s.accounts.FindByID(ctx, uid)
+	if err != nil {
+		if isNotFound(err) {
+			return nil, domain.NewOAuthError("invalid_token", "the account doesn't exist anymore")
+		}
+		log.Printf("Unable to find account of access token with uid: %v. Reason: %v\n", uid, err)
+		return nil, domain.NewInternal()
+	}
+
+	return userInfo(account, scopes), nil
+}
+
+// validateAccessToken returns the claims of a valid access token, or nil
+// when it is invalid, expired or was revoked. Signing an account out
+// everywhere also revokes the tokens issued to clients on its behalf
+func (s *oauthService) validateAccessToken(ctx context.Context, token string) (*jwt.OAuthAccessTokenClaims, error) {
+	claims, err := jwt.ValidateOAuthAccessToken(token, s.keys)
+	if err != nil || claims.Issuer != s.issuer {
+		return nil, nil
+	}
+
+	denied, err := s.denylist.IsAccessTokenDenied(ctx, claims.Id)
+	if err != nil {
+		return nil, err
+	}
+	if denied {
+		return nil, nil
+	}
+
+	if _, err := uuid.Parse(claims.Subject); err == nil {
+		revokedBefore, err := s.denylist.GetRevokedBefore(ctx, claims.Subject)
+		if err != nil {
+			return nil, err
+		}
+		if claims.IssuedAt < revokedBefore.Unix() {
+			return nil, nil
+		}
+	}
+
+	return claims, nil
+}
+
+// Discovery returns the OpenID Connect discovery document
+func (s *oauthService) Discovery() *domain.OIDCDiscovery {
+	return &domain.OIDCDiscovery{
+		Issuer:                            s.issuer,
+		AuthorizationEndpoint:             s.issuer + "/oauth/authorize",
+		TokenEndpoint:                     s.issuer + "/oauth/token",
+		UserinfoEndpoint:                  s.issuer + "/userinfo",
+		JwksURI:                           s.issuer + "/.well-known/jwks.json",
+		IntrospectionEndpoint:             s.issuer + "/oauth/introspect",
+		RevocationEndpoint:                s.issuer + "/oauth/revoke",
+		ScopesSupported:                   oidcScopes,
+		ResponseTypesSupported:            []string{"code"},
+		GrantTypesSupported:               []string{domain.AuthorizationCodeGrant, domain.ClientCredentialsGrant},
+		SubjectTypesSupported:             []string{"public"},
+		IDTokenSigningAlgValuesSupported:  []string{"RS256"},
+		TokenEndpointAuthMethodsSupported: []string{"client_secret_basic", "client_secret_post", "none"},
+		CodeChallengeMethodsSupported:     []string{"S256"},
+		ClaimsSupported:                   []string{"sub", "name", "picture", "website", "email", "email_verified"},
+	}
+}
+
+// userInfo releases the claims of the profile and email scopes
+func userInfo(a *domain.Account, scopes []string) *domain.UserInfo {
+	info := &domain.UserInfo{Subject: a.UID.String()}
+
+	if contains(scopes, "profile") {
+		info.Name = a.Name
+		info.Picture = a.ImageUrl
+		info.Website = a.Website
+	}
+
+	if contains(scopes, "email") {
+		verified := a.EmailVerifiedAt.Valid
+		info.Email = a.Email
+		info.EmailVerified = &verified
+	}
+
+	return info
+}
+
+// authorizeRedirect adds the params and the state to the redirect uri
+func authorizeRedirect(redirectURI string, state string, params url.Values) string {
+	u, err := url.Parse(redirectURI)
+	if err != nil {
+		// registered redirect uris are validated
+		return redirectURI
+	}
+
+	query := u.Query()
+	for key, values := range params {
+		query[key] = values
+	}
+	if state != "" {
+		query.Set("state", state)
+	}
+	u.RawQuery = query.Encode()
+
+	return u.String()
+}
+
+func errorParams(err *domain.OAuthError) url.Values {
+	return url.Values{"error": {err.Code}, "error_description": {err.Description}}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func containsAll(values []string, subset []string) bool {
+	for _, value := range subset {
+		if !contains(values, value) {
+			return false
+		}
+	}
+	return true
+}
@@ -2,50 +2,600 @@ package service
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/whuangz/go-example/go-api/domain"
+	"github.com/whuangz/go-example/go-api/helpers/diff"
+	"github.com/whuangz/go-example/go-api/helpers/slug"
 )
 
 type postService struct {
-	repo domain.PostRepository
+	repo       domain.PostRepository
+	authors    domain.AuthorRepository
+	categories domain.CategoryRepository
+	index      domain.SearchIndex
+	reactions  domain.ReactionService
 }
 
-func NewPostService(repo domain.PostRepository) domain.PostService {
-	return &postService{repo: repo}
+// NewPostService keeps the search index in sync with the posts saved,
+// updated and deleted through it. Posts are read without their
+// reactions when reactions is nil
+func NewPostService(repo domain.PostRepository, authors domain.AuthorRepository, categories domain.CategoryRepository, index domain.SearchIndex, reactions domain.ReactionService) domain.PostService {
+	return &postService{repo: repo, authors: authors, categories: categories, index: index, reactions: reactions}
 }
 
-func (p *postService) Save(ctx context.Context, post *domain.Post) error {
+func (p *postService) Save(ctx context.Context, account *domain.Account, post *domain.Post) error {
 	err := post.Validate()
 	if err != nil {
 		return err
 	}
-	post.AuthorID = 1
-	return p.repo.Save(ctx, post)
+	if err := p.normalize(ctx, post); err != nil {
+		return err
+	}
+	if err := applyStatus(post, nil, time.Now()); err != nil {
+		return err
+	}
+	post.Slug = postSlug(post.Title)
+
+	author, err := p.authors.FindOrCreateByAccount(ctx, account)
+	if err != nil {
+		return err
+	}
+	post.Author = *author
+	post.AuthorID = author.ID
+
+	if err := p.repo.Save(ctx, post); err != nil {
+		return err
+	}
+
+	p.indexPost(ctx, post)
+	return nil
 }
 
-func (p *postService) FindAll(ctx context.Context) ([]domain.Post, error) {
-	posts, err := p.repo.FindAll(ctx)
-	return posts, err
+func (p *postService) FindAll(ctx context.Context, query domain.PostQuery) (*domain.PostPage, error) {
+	filter := query.PostFilter
+	filter.Status = domain.PublishedStatus
+	filter.AccountUID = uuid.Nil
+	return p.findPage(ctx, filter, query)
+}
+
+func (p *postService) FindByAccount(ctx context.Context, account *domain.Account, query domain.PostQuery) (*domain.PostPage, error) {
+	filter := query.PostFilter
+	if filter.Status != "" && !domain.IsPostStatus(filter.Status) {
+		return nil, domain.NewBadRequest("unknown status: " + filter.Status)
+	}
+	filter.AccountUID = account.UID
+	return p.findPage(ctx, filter, query)
+}
+
+// findPage returns a page of posts along with the cursors of the pages
+// before and after it. Cursors keep the sort they were issued for
+func (p *postService) findPage(ctx context.Context, filter domain.PostFilter, query domain.PostQuery) (*domain.PostPage, error) {
+	if filter.Sort == "" {
+		filter.Sort = domain.NewestFirst
+	}
+	if filter.Sort != domain.NewestFirst && filter.Sort != domain.OldestFirst {
+		return nil, domain.NewBadRequest("unknown sort: " + filter.Sort)
+	}
+
+	if !filter.From.IsZero() && !filter.To.IsZero() && filter.To.Before(filter.From) {
+		return nil, domain.NewBadRequest("from has to be before to")
+	}
+
+	// ?tag=Web%20Development finds the posts tagged web-development
+	if filter.Tag != "" {
+		filter.Tag = slug.Make(filter.Tag)
+	}
+	if filter.Category != "" {
+		filter.Category = slug.Make(filter.Category)
+	}
+
+	limit, err := postLimit(query.Limit)
+	if err != nil {
+		return nil, err
+	}
+
+	var cursor *domain.PostCursor
+	if query.Cursor != "" {
+		c, err := decodePostCursor(query.Cursor)
+		if err != nil || c.Sort != filter.Sort {
+			return nil, domain.NewBadRequest("invalid cursor")
+		}
+		cursor = c
+	}
+
+	// one more post than the limit tells whether there's another page
+	posts, err := p.repo.FindAll(ctx, filter, cursor, limit+1)
+	if err != nil {
+		return nil, err
+	}
+
+	backward := cursor != nil && cursor.Backward
+	more := len(posts) > limit
+	if more {
+		// the extra post is the furthest from the cursor
+		if backward {
+			posts = posts[1:]
+		} else {
+			posts = posts[:limit]
+		}
+	}
+
+	page := &domain.PostPage{Data: posts}
+	if len(posts) == 0 {
+		return page, nil
+	}
+	p.countReactions(ctx, page.Data)
+
+	// a page reached from a cursor always has one on the side it came from
+	if more || backward {
+		page.NextCursor = encodePostCursor(posts[len(posts)-1], filter.Sort, false)
+	}
+	if (more && backward) || (cursor != nil && !backward) {
+		page.PrevCursor = encodePostCursor(posts[0], filter.Sort, true)
+	}
+
+	return page, nil
 }
 
-func (p *postService) FindByID(ctx context.Context, id int32) (domain.Post, error) {
+func (p *postService) FindByID(ctx context.Context, account *domain.Account, id int32) (domain.Post, error) {
 	post, err := p.repo.FindByID(ctx, id)
-	return post, err
+	if err != nil {
+		return post, err
+	}
+
+	if !isPublic(post) {
+		return domain.Post{}, domain.NewNotFound("id", strconv.Itoa(int(id)))
+	}
+
+	posts := []domain.Post{post}
+	p.countReactions(ctx, posts)
+	post = posts[0]
+	if account != nil && p.reactions != nil {
+		mine, err := p.reactions.Mine(ctx, account, id)
+		if err != nil {
+			return domain.Post{}, err
+		}
+		post.MyReaction = mine
+	}
+	return post, nil
+}
+
+func (p *postService) FindBySlug(ctx context.Context, slug string) (domain.Post, error) {
+	post, err := p.repo.FindBySlug(ctx, slug)
+	if err != nil {
+		return post, err
+	}
+
+	if !isPublic(post) {
+		return domain.Post{}, domain.NewNotFound("slug", slug)
+	}
+
+	posts := []domain.Post{post}
+	p.countReactions(ctx, posts)
+	return posts[0], nil
+}
+
+// countReactions sets the reactions of the posts. Posts are still
+// read when their counts can't be, they are left without them
+func (p *postService) countReactions(ctx context.Context, posts []domain.Post) {
+	if p.reactions == nil {
+		return
+	}
+	if err := p.reactions.Count(ctx, posts); err != nil {
+		log.Printf("Could not count the reactions to posts. Reason: %v\n", err)
+	}
+}
+
+// isPublic reports whether anyone may read the post, authors
+// see their drafts through FindByAccount
+func isPublic(post domain.Post) bool {
+	return post.Status != domain.DraftStatus && post.Status != domain.ScheduledStatus
+}
+
+// postSlug makes the slug of a title, the repository
+// suffixes it when another post has it
+func postSlug(title string) string {
+	s := slug.Truncate(slug.Make(title), domain.MaxSlugLength)
+	if s == "" {
+		return "post"
+	}
+	return s
 }
 
-func (p *postService) Update(ctx context.Context, id int32, post *domain.Post) error {
+func (p *postService) Update(ctx context.Context, account *domain.Account, id int32, post *domain.Post) error {
 	if id == 0 {
 		return domain.NewNotFound("id", strconv.Itoa(int(id)))
 	}
+	current, err := p.authorize(ctx, account, id)
+	if err != nil {
+		return err
+	}
+	// the repository checks the version again as it writes
+	if post.Version != current.Version {
+		return domain.NewPreconditionFailed("post", strconv.Itoa(int(id)))
+	}
+	if err := p.normalize(ctx, post); err != nil {
+		return err
+	}
+	if err := applyStatus(post, &current, time.Now()); err != nil {
+		return err
+	}
+	post.ID = id
+
+	// the slug only follows changes of the title, the former slug redirects
+	post.Slug = current.Slug
+	if post.Title != current.Title {
+		post.Slug = postSlug(post.Title)
+	}
 	post.UpdatedAt.Time = time.Now()
-	return p.repo.Update(ctx, id, post)
+	if err := p.repo.Update(ctx, id, post, account.UID); err != nil {
+		return err
+	}
+
+	p.indexPost(ctx, post)
+	return nil
 }
 
-func (p *postService) Delete(ctx context.Context, id int32) error {
+func (p *postService) Delete(ctx context.Context, account *domain.Account, id int32, version int32) error {
 	if id == 0 {
 		return domain.NewNotFound("id", strconv.Itoa(int(id)))
 	}
-	return p.repo.Delete(ctx, id)
+	current, err := p.authorize(ctx, account, id)
+	if err != nil {
+		return err
+	}
+	if version != current.Version {
+		return domain.NewPreconditionFailed("post", strconv.Itoa(int(id)))
+	}
+	if err := p.repo.Delete(ctx, id, version); err != nil {
+		return err
+	}
+
+	if err := p.index.Remove(ctx, id); err != nil {
+		log.Printf("Could not remove post: %v from the search index. Reason: %v\n", id, err)
+	}
+	if p.reactions != nil {
+		if err := p.reactions.Forget(ctx, id); err != nil {
+			log.Printf("Could not forget the reactions to post: %v. Reason: %v\n", id, err)
+		}
+	}
+	return nil
+}
+
+// Revisions lists the revisions of the post, newest first
+func (p *postService) Revisions(ctx context.Context, account *domain.Account, id int32) ([]domain.PostRevision, error) {
+	if _, err := p.authorize(ctx, account, id); err != nil {
+		return nil, err
+	}
+	return p.repo.FindRevisions(ctx, id)
+}
+
+// Diff compares the title and content of two revisions of the post line by line
+func (p *postService) Diff(ctx context.Context, account *domain.Account, id int32, from int32, to int32) (*domain.PostDiff, error) {
+	if _, err := p.authorize(ctx, account, id); err != nil {
+		return nil, err
+	}
+
+	a, err := p.repo.FindRevision(ctx, id, from)
+	if err != nil {
+		return nil, err
+	}
+	b, err := p.repo.FindRevision(ctx, id, to)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.PostDiff{
+		From:    from,
+		To:      to,
+		Title:   diffLines(a.Title, b.Title),
+		Content: diffLines(a.Content, b.Content),
+	}, nil
+}
+
+func diffLines(a string, b string) []domain.DiffLine {
+	lines := []domain.DiffLine{}
+	for _, line := range diff.Lines(a, b) {
+		lines = append(lines, domain.DiffLine{Op: line.Op, Text: line.Text})
+	}
+	return lines
+}
+
+// Restore brings the title and content of the revision back, the rest of
+// the post stays as it is. The slug follows the title like on any update
+func (p *postService) Restore(ctx context.Context, account *domain.Account, id int32, number int32, version int32) (*domain.Post, error) {
+	current, err := p.authorize(ctx, account, id)
+	if err != nil {
+		return nil, err
+	}
+
+	revision, err := p.repo.FindRevision(ctx, id, number)
+	if err != nil {
+		return nil, err
+	}
+
+	post := &domain.Post{
+		Title:      revision.Title,
+		Content:    revision.Content,
+		CategoryID: current.CategoryID,
+		Status:     current.Status,
+		Version:    version,
+	}
+	if err := p.Update(ctx, account, id, post); err != nil {
+		return nil, err
+	}
+	return post, nil
+}
+
+// PublishDue publishes the scheduled posts whose publish_at has passed
+func (p *postService) PublishDue(ctx context.Context) (int, error) {
+	ids, err := p.repo.PublishDue(ctx, time.Now())
+	if err != nil || len(ids) == 0 {
+		return 0, err
+	}
+
+	posts, err := p.repo.FindByIDs(ctx, ids)
+	if err != nil {
+		log.Printf("Could not index published posts: %v. Reason: %v\n", ids, err)
+		return len(ids), nil
+	}
+	for i := range posts {
+		p.indexPost(ctx, &posts[i])
+	}
+	return len(ids), nil
+}
+
+// applyStatus checks the status the post is saved with and sets its
+// timestamps. New posts are published unless they have a publish_at,
+// updates keep the status of the current post when none is given
+func applyStatus(post *domain.Post, current *domain.Post, now time.Time) error {
+	if post.Status == "" {
+		switch {
+		case current != nil:
+			post.Status = current.Status
+		case post.PublishAt != nil:
+			post.Status = domain.ScheduledStatus
+		default:
+			post.Status = domain.PublishedStatus
+		}
+	}
+	if !domain.IsPostStatus(post.Status) {
+		return domain.NewBadRequest("unknown status: " + post.Status)
+	}
+
+	var publishedAt *time.Time
+	if current != nil {
+		publishedAt = current.PublishedAt
+	}
+	publishAt := post.PublishAt
+	post.PublishAt = nil
+
+	switch post.Status {
+	case domain.PublishedStatus:
+		if publishedAt == nil {
+			publishedAt = &now
+		}
+	case domain.ScheduledStatus:
+		if publishAt == nil && current != nil && current.Status == domain.ScheduledStatus {
+			publishAt = current.PublishAt
+		}
+		if publishAt == nil || !publishAt.After(now) {
+			return domain.NewBadRequest("publish_at has to be in the future to schedule a post")
+		}
+		post.PublishAt = publishAt
+		publishedAt = nil
+	case domain.DraftStatus:
+		publishedAt = nil
+	}
+	// archived posts keep the time they were published
+
+	post.PublishedAt = publishedAt
+	return nil
+}
+
+// indexPost updates the search index, the post is already stored so a
+// failure isn't returned. Only published posts can be searched
+func (p *postService) indexPost(ctx context.Context, post *domain.Post) {
+	if post.Status != domain.PublishedStatus {
+		if err := p.index.Remove(ctx, post.ID); err != nil {
+			log.Printf("Could not remove post: %v from the search index. Reason: %v\n", post.ID, err)
+		}
+		return
+	}
+
+	if err := p.index.Index(ctx, post); err != nil {
+		log.Printf("Could not index post: %v. Reason: %v\n", post.ID, err)
+	}
+}
+
+// normalize gives the tags of the post their slug, merging the tags sharing
+// one, and makes sure the category exists. Nil tags are left as they are
+func (p *postService) normalize(ctx context.Context, post *domain.Post) error {
+	if post.Tags != nil {
+		tags := []domain.Tag{}
+		seen := map[string]bool{}
+		for _, tag := range post.Tags {
+			name := strings.TrimSpace(tag.Name)
+			tagSlug := slug.Make(name)
+			if tagSlug == "" || seen[tagSlug] {
+				continue
+			}
+			seen[tagSlug] = true
+			tags = append(tags, domain.Tag{Name: name, Slug: tagSlug})
+		}
+
+		if len(tags) > domain.MaxPostTags {
+			return domain.NewBadRequest(fmt.Sprintf("a post can have at most %d tags", domain.MaxPostTags))
+		}
+		post.Tags = tags
+	}
+
+	if post.CategoryID != 0 {
+		category, err := p.categories.FindByID(ctx, post.CategoryID)
+		if err != nil {
+			if domain.Status(err) == http.StatusNotFound {
+				return domain.NewBadRequest("unknown category")
+			}
+			return err
+		}
+		post.Category = &category
+	} else {
+		post.Category = nil
+	}
+	return nil
+}
+
+// authorize makes sure the account owns the post or is an editor
+// and returns the post as it is stored
+func (p *postService) authorize(ctx context.Context, account *domain.Account, id int32) (domain.Post, error) {
+	post, err := p.repo.FindByID(ctx, id)
+	if err != nil {
+		return post, err
+	}
+
+	if !post.CanBeModifiedBy(account) {
+		return post, domain.NewForbidden("Only the author of the post or an editor can change it")
+	}
+	return post, nil
+}
+
+// Search returns a page of the posts most relevant to the query
+func (p *postService) Search(ctx context.Context, query domain.SearchQuery) (*domain.SearchPage, error) {
+	q := strings.TrimSpace(query.Query)
+	if q == "" {
+		return nil, domain.NewBadRequest("a search query is required")
+	}
+
+	limit, err := postLimit(query.Limit)
+	if err != nil {
+		return nil, err
+	}
+
+	offset := 0
+	if query.Cursor != "" {
+		c, err := decodeSearchCursor(query.Cursor)
+		if err != nil || c.Query != q || c.Offset < 0 {
+			return nil, domain.NewBadRequest("invalid cursor")
+		}
+		offset = c.Offset
+	}
+
+	hits, total, err := p.index.Search(ctx, q, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]int32, len(hits))
+	for i, hit := range hits {
+		ids[i] = hit.PostID
+	}
+
+	posts, err := p.repo.FindByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[int32]domain.Post, len(posts))
+	for _, post := range posts {
+		byID[post.ID] = post
+	}
+
+	page := &domain.SearchPage{Data: []domain.SearchResult{}, Total: total}
+	for _, hit := range hits {
+		// deleted or unpublished since it was indexed
+		post, ok := byID[hit.PostID]
+		if !ok || post.Status != domain.PublishedStatus {
+			continue
+		}
+
+		page.Data = append(page.Data, domain.SearchResult{
+			Post:         post,
+			Score:        hit.Score,
+			TitleSnippet: hit.TitleSnippet,
+			Snippet:      hit.Snippet,
+		})
+	}
+
+	results := make([]domain.Post, len(page.Data))
+	for i, result := range page.Data {
+		results[i] = result.Post
+	}
+	p.countReactions(ctx, results)
+	for i := range page.Data {
+		page.Data[i].Post = results[i]
+	}
+
+	if next := offset + len(hits); next < total {
+		page.NextCursor = encodeSearchCursor(searchCursor{Query: q, Offset: next})
+	}
+
+	return page, nil
+}
+
+// postLimit returns the default limit when none is given
+func postLimit(limit int) (int, error) {
+	if limit == 0 {
+		return domain.DefaultPostLimit, nil
+	}
+	if limit < 0 || limit > domain.MaxPostLimit {
+		return 0, domain.NewBadRequest(fmt.Sprintf("limit has to be between 1 and %d", domain.MaxPostLimit))
+	}
+	return limit, nil
+}
+
+func encodePostCursor(post domain.Post, sort string, backward bool) string {
+	b, _ := json.Marshal(domain.PostCursor{
+		CreatedAt: post.CreatedAt,
+		ID:        post.ID,
+		Sort:      sort,
+		Backward:  backward,
+	})
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodePostCursor(cursor string) (*domain.PostCursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &domain.PostCursor{}
+	if err := json.Unmarshal(b, c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// searchCursor is the position of a page of search results, ranking
+// doesn't give a stable key to page on so the offset is used
+type searchCursor struct {
+	Query  string `json:"q"`
+	Offset int    `json:"o"`
+}
+
+func encodeSearchCursor(c searchCursor) string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeSearchCursor(cursor string) (*searchCursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &searchCursor{}
+	if err := json.Unmarshal(b, c); err != nil {
+		return nil, err
+	}
+	return c, nil
 }
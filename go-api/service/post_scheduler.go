@@ -0,0 +1,63 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/whuangz/go-example/go-api/domain"
+)
+
+// postSchedulerLock is held by the replica publishing the due posts
+const postSchedulerLock = "post_scheduler"
+
+// PostScheduler publishes the scheduled posts once they are due. Every
+// replica runs one, the lock makes sure only one of them publishes at a time
+type PostScheduler struct {
+	posts    domain.PostService
+	locker   domain.Locker
+	interval time.Duration
+}
+
+func NewPostScheduler(posts domain.PostService, locker domain.Locker, interval time.Duration) *PostScheduler {
+	return &PostScheduler{posts: posts, locker: locker, interval: interval}
+}
+
+// Start checks for due posts every interval until stopped
+func (s *PostScheduler) Start() (stop func()) {
+	done := make(chan struct{})
+	ticker := time.NewTicker(s.interval)
+
+	go func() {
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if err := s.Run(context.Background()); err != nil {
+					log.Printf("Could not publish the scheduled posts: %v\n", err)
+				}
+			}
+		}
+	}()
+
+	return func() { close(done) }
+}
+
+// Run publishes the due posts unless another replica is at it. The lock
+// expires after an interval, so a replica going away doesn't hold it
+func (s *PostScheduler) Run(ctx context.Context) error {
+	release, acquired, err := s.locker.Acquire(ctx, postSchedulerLock, s.interval)
+	if err != nil || !acquired {
+		return err
+	}
+	defer release()
+
+	published, err := s.posts.PublishDue(ctx)
+	if published > 0 {
+		log.Printf("Published %d scheduled posts\n", published)
+	}
+	return err
+}
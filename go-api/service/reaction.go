@@ -0,0 +1,159 @@
+package service
+
+import (
+	"context"
+	"log"
+	"strconv"
+
+	"github.com/whuangz/go-example/go-api/domain"
+)
+
+// reconcileBatch is how many posts are taken at a time to be reconciled
+const reconcileBatch = 100
+
+type reactionService struct {
+	repo    domain.ReactionRepository
+	counter domain.ReactionCounter
+	posts   domain.PostRepository
+}
+
+// NewReactionService keeps the reactions in the repository, the counter
+// follows them until the posts are reconciled
+func NewReactionService(repo domain.ReactionRepository, counter domain.ReactionCounter, posts domain.PostRepository) domain.ReactionService {
+	return &reactionService{repo: repo, counter: counter, posts: posts}
+}
+
+func (s *reactionService) React(ctx context.Context, account *domain.Account, postID int32, kind string) ([]string, error) {
+	if !domain.IsReaction(kind) {
+		return nil, domain.NewBadRequest("unknown reaction: " + kind)
+	}
+
+	post, err := s.posts.FindByID(ctx, postID)
+	if err != nil {
+		return nil, err
+	}
+	if post.Status != domain.PublishedStatus {
+		if post.Status == domain.ArchivedStatus {
+			return nil, domain.NewForbidden("Reactions are closed on archived posts")
+		}
+		return nil, domain.NewNotFound("id", strconv.Itoa(int(postID)))
+	}
+
+	added, err := s.repo.Add(ctx, postID, account.UID, kind)
+	if err != nil {
+		return nil, err
+	}
+	if added {
+		s.count(ctx, postID, kind, 1)
+	}
+	return s.repo.FindByAccount(ctx, postID, account.UID)
+}
+
+// Unreact lets accounts take back their reactions to posts archived since
+func (s *reactionService) Unreact(ctx context.Context, account *domain.Account, postID int32, kind string) ([]string, error) {
+	if !domain.IsReaction(kind) {
+		return nil, domain.NewBadRequest("unknown reaction: " + kind)
+	}
+
+	removed, err := s.repo.Remove(ctx, postID, account.UID, kind)
+	if err != nil {
+		return nil, err
+	}
+	if removed {
+		s.count(ctx, postID, kind, -1)
+	}
+	return s.repo.FindByAccount(ctx, postID, account.UID)
+}
+
+// count updates the counter, the reaction is already stored so a failure
+// is only logged. The counts are right again once the post is reconciled
+func (s *reactionService) count(ctx context.Context, postID int32, kind string, delta int64) {
+	if err := s.counter.Incr(ctx, postID, kind, delta); err != nil {
+		log.Printf("Could not count reaction: %v to post: %v. Reason: %v\n", kind, postID, err)
+	}
+}
+
+func (s *reactionService) Mine(ctx context.Context, account *domain.Account, postID int32) ([]string, error) {
+	return s.repo.FindByAccount(ctx, postID, account.UID)
+}
+
+func (s *reactionService) Count(ctx context.Context, posts []domain.Post) error {
+	if len(posts) == 0 {
+		return nil
+	}
+
+	ids := make([]int32, len(posts))
+	for i, post := range posts {
+		ids[i] = post.ID
+	}
+
+	counts, err := s.counter.Get(ctx, ids)
+	if err != nil {
+		// every post is read from the reconciled counts then
+		counts = map[int32]domain.ReactionCounts{}
+	}
+
+	var missing []int32
+	for _, id := range ids {
+		if _, ok := counts[id]; !ok {
+			missing = append(missing, id)
+		}
+	}
+
+	if len(missing) > 0 {
+		reconciled, err := s.repo.FindCounts(ctx, missing)
+		if err != nil {
+			return err
+		}
+		for id, postCounts := range reconciled {
+			counts[id] = postCounts
+		}
+	}
+
+	for i := range posts {
+		posts[i].Reactions = domain.ReactionCounts{}
+		for kind, count := range counts[posts[i].ID] {
+			posts[i].Reactions[kind] = count
+		}
+	}
+	return nil
+}
+
+func (s *reactionService) Forget(ctx context.Context, postID int32) error {
+	return s.counter.Remove(ctx, postID)
+}
+
+// Reconcile takes the posts to reconcile in batches until none are left.
+// Posts that fail are marked again, to be reconciled on the next run
+func (s *reactionService) Reconcile(ctx context.Context) (int, error) {
+	reconciled := 0
+	for {
+		ids, err := s.counter.TakeDirty(ctx, reconcileBatch)
+		if err != nil || len(ids) == 0 {
+			return reconciled, err
+		}
+
+		for i, id := range ids {
+			if err := s.reconcile(ctx, id); err != nil {
+				if err := s.counter.MarkDirty(ctx, ids[i:]...); err != nil {
+					log.Printf("Could not mark posts: %v to reconcile again. Reason: %v\n", ids[i:], err)
+				}
+				return reconciled, err
+			}
+			reconciled++
+		}
+	}
+}
+
+// reconcile recounts the post. Reactions counted in between are lost by
+// the counter when it's set, but they mark the post to be reconciled again
+func (s *reactionService) reconcile(ctx context.Context, postID int32) error {
+	counts, err := s.repo.Count(ctx, postID)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.SaveCounts(ctx, postID, counts); err != nil {
+		return err
+	}
+	return s.counter.Set(ctx, postID, counts)
+}
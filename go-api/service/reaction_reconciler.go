@@ -0,0 +1,63 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/whuangz/go-example/go-api/domain"
+)
+
+// reactionReconcilerLock is held by the replica reconciling the reactions
+const reactionReconcilerLock = "reaction_reconciler"
+
+// ReactionReconciler stores the counts of the posts reacted to since they
+// were last reconciled. Every replica runs one, the lock makes sure only
+// one of them reconciles at a time
+type ReactionReconciler struct {
+	reactions domain.ReactionService
+	locker    domain.Locker
+	interval  time.Duration
+}
+
+func NewReactionReconciler(reactions domain.ReactionService, locker domain.Locker, interval time.Duration) *ReactionReconciler {
+	return &ReactionReconciler{reactions: reactions, locker: locker, interval: interval}
+}
+
+// Start reconciles the reactions every interval until stopped
+func (r *ReactionReconciler) Start() (stop func()) {
+	done := make(chan struct{})
+	ticker := time.NewTicker(r.interval)
+
+	go func() {
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if err := r.Run(context.Background()); err != nil {
+					log.Printf("Could not reconcile the reactions: %v\n", err)
+				}
+			}
+		}
+	}()
+
+	return func() { close(done) }
+}
+
+// Run reconciles the reactions unless another replica is at it
+func (r *ReactionReconciler) Run(ctx context.Context) error {
+	release, acquired, err := r.locker.Acquire(ctx, reactionReconcilerLock, r.interval)
+	if err != nil || !acquired {
+		return err
+	}
+	defer release()
+
+	reconciled, err := r.reactions.Reconcile(ctx)
+	if reconciled > 0 {
+		log.Printf("Reconciled the reactions to %d posts\n", reconciled)
+	}
+	return err
+}
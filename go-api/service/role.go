@@ -0,0 +1,53 @@
+package service
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/whuangz/go-example/go-api/domain"
+)
+
+type roleService struct {
+	repo     domain.RoleRepository
+	accounts domain.AccountRepository
+}
+
+func NewRoleService(repo domain.RoleRepository, accounts domain.AccountRepository) domain.RoleService {
+	return &roleService{repo, accounts}
+}
+
+// Grant assigns a built-in role to the account and returns the account
+// with its roles. Tokens issued before keep the roles they were issued with
+func (s *roleService) Grant(ctx context.Context, accountUID uuid.UUID, role string) (*domain.Account, error) {
+	if !domain.IsRole(role) {
+		return nil, domain.NewBadRequest("unknown role: " + role)
+	}
+
+	if _, err := s.accounts.FindByID(ctx, accountUID); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.AddRole(ctx, accountUID, role); err != nil {
+		return nil, err
+	}
+
+	return s.accounts.FindByID(ctx, accountUID)
+}
+
+// Revoke removes a role from the account. An account left
+// without any role falls back to the default role
+func (s *roleService) Revoke(ctx context.Context, accountUID uuid.UUID, role string) (*domain.Account, error) {
+	if !domain.IsRole(role) {
+		return nil, domain.NewBadRequest("unknown role: " + role)
+	}
+
+	if _, err := s.accounts.FindByID(ctx, accountUID); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.RemoveRole(ctx, accountUID, role); err != nil {
+		return nil, err
+	}
+
+	return s.accounts.FindByID(ctx, accountUID)
+}
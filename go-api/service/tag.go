@@ -0,0 +1,19 @@
+package service
+
+import (
+	"context"
+
+	"github.com/whuangz/go-example/go-api/domain"
+)
+
+type tagService struct {
+	repo domain.TagRepository
+}
+
+func NewTagService(repo domain.TagRepository) domain.TagService {
+	return &tagService{repo: repo}
+}
+
+func (s *tagService) FindAll(ctx context.Context) ([]domain.Tag, error) {
+	return s.repo.FindAll(ctx)
+}
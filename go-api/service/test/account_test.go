@@ -1,9 +1,17 @@
 package service_test
 
 import (
+	"bytes"
 	"context"
+	"database/sql"
 	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
@@ -25,7 +33,7 @@ func TestFindMe(t *testing.T) {
 		}
 
 		mockAccountRepository := new(mocks.MockAccountRepo)
-		us := service.NewAccountService(mockAccountRepository)
+		us := service.NewAccountService(mockAccountRepository, nil, nil, nil, "", 0, 0)
 
 		mockAccountRepository.On("FindByID", mock.Anything, uid).Return(mockAccResp, nil)
 
@@ -41,7 +49,7 @@ func TestFindMe(t *testing.T) {
 		uid, _ := uuid.NewRandom()
 
 		mockAccountRepository := new(mocks.MockAccountRepo)
-		us := service.NewAccountService(mockAccountRepository)
+		us := service.NewAccountService(mockAccountRepository, nil, nil, nil, "", 0, 0)
 
 		mockAccountRepository.On("FindByID", mock.Anything, uid).Return(nil, fmt.Errorf("Some error down the call chain"))
 
@@ -64,13 +72,17 @@ func TestSignUp(t *testing.T) {
 		}
 
 		mockAccountRepository := new(mocks.MockAccountRepo)
-		us := service.NewAccountService(mockAccountRepository)
+		mockOneTimeTokenRepo := new(mocks.MockOneTimeTokenRepo)
+		mockMailer := new(mocks.MockMailer)
+		us := service.NewAccountService(mockAccountRepository, nil, mockOneTimeTokenRepo, mockMailer, "", 0, 86400)
 
 		mockAccountRepository.On("Create", mock.Anything, mockAcc).
 			Run(func(args mock.Arguments) {
 				accArg := args.Get(1).(*domain.Account)
 				accArg.UID = uid
 			}).Return(nil)
+		mockOneTimeTokenRepo.On("SetToken", mock.Anything, domain.EmailVerificationToken, mock.AnythingOfType("string"), uid.String(), 24*time.Hour).Return(nil)
+		mockMailer.On("Send", mock.Anything, mock.AnythingOfType("*domain.Message")).Return(nil)
 
 		ctx := context.TODO()
 		err := us.Signup(ctx, mockAcc)
@@ -78,6 +90,8 @@ func TestSignUp(t *testing.T) {
 		assert.NoError(t, err)
 		assert.Equal(t, uid, mockAcc.UID)
 		mockAccountRepository.AssertExpectations(t)
+		mockOneTimeTokenRepo.AssertExpectations(t)
+		mockMailer.AssertExpectations(t)
 	})
 
 	t.Run("Error", func(t *testing.T) {
@@ -87,7 +101,7 @@ func TestSignUp(t *testing.T) {
 		}
 
 		mockAccountRepository := new(mocks.MockAccountRepo)
-		us := service.NewAccountService(mockAccountRepository)
+		us := service.NewAccountService(mockAccountRepository, nil, nil, nil, "", 0, 0)
 
 		mockErr := domain.NewConflict("email", mockAcc.Email)
 
@@ -111,7 +125,7 @@ func TestSignin(t *testing.T) {
 	invalidPW := "howdyhodufus!"
 
 	mockAccRepo := new(mocks.MockAccountRepo)
-	us := service.NewAccountService(mockAccRepo)
+	us := service.NewAccountService(mockAccRepo, nil, nil, nil, "", 0, 0)
 
 	t.Run("Success", func(t *testing.T) {
 		uid, _ := uuid.NewRandom()
@@ -176,3 +190,425 @@ func TestSignin(t *testing.T) {
 		mockAccRepo.AssertCalled(t, "FindByEmail", mockArgs...)
 	})
 }
+
+func TestUpdateDetails(t *testing.T) {
+	uid, _ := uuid.NewRandom()
+
+	mockCurrent := &domain.Account{
+		ID:       1,
+		UID:      uid,
+		Email:    "whuangz@gmail.com",
+		Password: "ahashedpassword",
+		Name:     "William",
+		ImageUrl: "http://localhost/image.png",
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		mockAccountRepository := new(mocks.MockAccountRepo)
+		us := service.NewAccountService(mockAccountRepository, nil, nil, nil, "", 0, 0)
+
+		current := *mockCurrent
+		mockAccountRepository.On("FindByID", mock.Anything, uid).Return(&current, nil)
+		mockAccountRepository.On("Update", mock.Anything, mock.AnythingOfType("*domain.Account")).Return(nil)
+
+		a := &domain.Account{
+			UID:     uid,
+			Email:   mockCurrent.Email,
+			Name:    "Willy",
+			Website: "https://whuangz.dev",
+		}
+
+		err := us.Update(context.TODO(), a)
+		assert.NoError(t, err)
+
+		assert.Equal(t, "Willy", a.Name)
+		assert.Equal(t, "https://whuangz.dev", a.Website)
+		// non editable fields are kept
+		assert.Equal(t, mockCurrent.ID, a.ID)
+		assert.Equal(t, mockCurrent.ImageUrl, a.ImageUrl)
+		assert.Equal(t, mockCurrent.Password, a.Password)
+
+		// email didn't change so there's no need to check it
+		mockAccountRepository.AssertNotCalled(t, "FindByEmail", mock.Anything, mock.Anything)
+		mockAccountRepository.AssertExpectations(t)
+	})
+
+	t.Run("Change to available email", func(t *testing.T) {
+		mockAccountRepository := new(mocks.MockAccountRepo)
+		mockOneTimeTokenRepo := new(mocks.MockOneTimeTokenRepo)
+		mockMailer := new(mocks.MockMailer)
+		us := service.NewAccountService(mockAccountRepository, nil, mockOneTimeTokenRepo, mockMailer, "", 0, 0)
+
+		newEmail := "william@gmail.com"
+
+		current := *mockCurrent
+		current.EmailVerifiedAt = sql.NullTime{Time: time.Now(), Valid: true}
+		mockAccountRepository.On("FindByID", mock.Anything, uid).Return(&current, nil)
+		mockAccountRepository.On("FindByEmail", mock.Anything, newEmail).Return(nil, domain.NewNotFound("email", newEmail))
+		mockAccountRepository.On("Update", mock.Anything, mock.AnythingOfType("*domain.Account")).Return(nil)
+		mockOneTimeTokenRepo.On("SetToken", mock.Anything, domain.EmailVerificationToken, mock.Anything, uid.String(), mock.Anything).Return(nil)
+
+		var sent *domain.Message
+		mockMailer.On("Send", mock.Anything, mock.AnythingOfType("*domain.Message")).
+			Run(func(args mock.Arguments) {
+				sent = args.Get(1).(*domain.Message)
+			}).Return(nil)
+
+		a := &domain.Account{
+			UID:   uid,
+			Email: newEmail,
+		}
+
+		err := us.Update(context.TODO(), a)
+		assert.NoError(t, err)
+		assert.Equal(t, newEmail, a.Email)
+
+		// the new email has to be verified again
+		assert.False(t, a.EmailVerifiedAt.Valid)
+		assert.Equal(t, newEmail, sent.To)
+
+		mockAccountRepository.AssertExpectations(t)
+		mockOneTimeTokenRepo.AssertExpectations(t)
+	})
+
+	t.Run("Email already taken", func(t *testing.T) {
+		mockAccountRepository := new(mocks.MockAccountRepo)
+		us := service.NewAccountService(mockAccountRepository, nil, nil, nil, "", 0, 0)
+
+		takenEmail := "taken@gmail.com"
+
+		current := *mockCurrent
+		mockAccountRepository.On("FindByID", mock.Anything, uid).Return(&current, nil)
+		mockAccountRepository.On("FindByEmail", mock.Anything, takenEmail).Return(&domain.Account{Email: takenEmail}, nil)
+
+		a := &domain.Account{
+			UID:   uid,
+			Email: takenEmail,
+		}
+
+		err := us.Update(context.TODO(), a)
+		assert.Error(t, err)
+		assert.Equal(t, http.StatusConflict, domain.Status(err))
+
+		mockAccountRepository.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Account not found", func(t *testing.T) {
+		mockAccountRepository := new(mocks.MockAccountRepo)
+		us := service.NewAccountService(mockAccountRepository, nil, nil, nil, "", 0, 0)
+
+		mockErr := domain.NewNotFound("uid", uid.String())
+		mockAccountRepository.On("FindByID", mock.Anything, uid).Return(nil, mockErr)
+
+		err := us.Update(context.TODO(), &domain.Account{UID: uid, Email: mockCurrent.Email})
+		assert.EqualError(t, err, mockErr.Error())
+
+		mockAccountRepository.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	})
+}
+
+func TestSetProfileImage(t *testing.T) {
+	uid, _ := uuid.NewRandom()
+
+	pngImage := &bytes.Buffer{}
+	png.Encode(pngImage, image.NewRGBA(image.Rect(0, 0, 600, 400)))
+
+	t.Run("Success", func(t *testing.T) {
+		mockAccountRepository := new(mocks.MockAccountRepo)
+		mockBlobStore := new(mocks.MockBlobStore)
+		us := service.NewAccountService(mockAccountRepository, mockBlobStore, nil, nil, "", 0, 0)
+
+		mockAccountRepository.On("FindByID", mock.Anything, uid).Return(&domain.Account{UID: uid}, nil)
+		mockAccountRepository.On("Update", mock.Anything, mock.AnythingOfType("*domain.Account")).Return(nil)
+
+		sizes := map[string]int{}
+		mockBlobStore.On("Put", mock.Anything, mock.AnythingOfType("string"), "image/jpeg", mock.AnythingOfType("[]uint8")).
+			Run(func(args mock.Arguments) {
+				cfg, err := jpeg.DecodeConfig(bytes.NewReader(args.Get(3).([]byte)))
+				assert.NoError(t, err)
+				// thumbnails are square
+				assert.Equal(t, cfg.Width, cfg.Height)
+				sizes[args.String(1)] = cfg.Width
+			}).Return(nil)
+		mockBlobStore.On("URL", fmt.Sprintf("profile/%s/512.jpg", uid)).Return("http://localhost/profile/512.jpg")
+
+		a, err := us.SetProfileImage(context.TODO(), uid, pngImage.Bytes())
+		assert.NoError(t, err)
+
+		// the 400px image isn't upscaled to 512px
+		assert.Equal(t, map[string]int{
+			fmt.Sprintf("profile/%s/512.jpg", uid): 400,
+			fmt.Sprintf("profile/%s/256.jpg", uid): 256,
+			fmt.Sprintf("profile/%s/128.jpg", uid): 128,
+			fmt.Sprintf("profile/%s/64.jpg", uid):  64,
+		}, sizes)
+		assert.True(t, strings.HasPrefix(a.ImageUrl, "http://localhost/profile/512.jpg?v="))
+
+		mockAccountRepository.AssertExpectations(t)
+		mockBlobStore.AssertExpectations(t)
+	})
+
+	t.Run("Invalid image", func(t *testing.T) {
+		mockAccountRepository := new(mocks.MockAccountRepo)
+		mockBlobStore := new(mocks.MockBlobStore)
+		us := service.NewAccountService(mockAccountRepository, mockBlobStore, nil, nil, "", 0, 0)
+
+		mockAccountRepository.On("FindByID", mock.Anything, uid).Return(&domain.Account{UID: uid}, nil)
+
+		a, err := us.SetProfileImage(context.TODO(), uid, pngImage.Bytes()[:100])
+		assert.Nil(t, a)
+		assert.Equal(t, http.StatusBadRequest, domain.Status(err))
+
+		mockBlobStore.AssertNotCalled(t, "Put", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		mockAccountRepository.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Blob store error", func(t *testing.T) {
+		mockAccountRepository := new(mocks.MockAccountRepo)
+		mockBlobStore := new(mocks.MockBlobStore)
+		us := service.NewAccountService(mockAccountRepository, mockBlobStore, nil, nil, "", 0, 0)
+
+		mockAccountRepository.On("FindByID", mock.Anything, uid).Return(&domain.Account{UID: uid}, nil)
+		mockBlobStore.On("Put", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(domain.NewInternal())
+
+		a, err := us.SetProfileImage(context.TODO(), uid, pngImage.Bytes())
+		assert.Nil(t, a)
+		assert.Equal(t, http.StatusInternalServerError, domain.Status(err))
+
+		mockAccountRepository.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	})
+}
+
+func TestClearProfileImage(t *testing.T) {
+	uid, _ := uuid.NewRandom()
+
+	t.Run("Success", func(t *testing.T) {
+		mockAccountRepository := new(mocks.MockAccountRepo)
+		mockBlobStore := new(mocks.MockBlobStore)
+		us := service.NewAccountService(mockAccountRepository, mockBlobStore, nil, nil, "", 0, 0)
+
+		current := &domain.Account{UID: uid, ImageUrl: "http://localhost/profile/512.jpg?v=1"}
+		mockAccountRepository.On("FindByID", mock.Anything, uid).Return(current, nil)
+		mockAccountRepository.On("Update", mock.Anything, current).Return(nil)
+		mockBlobStore.On("Delete", mock.Anything, mock.AnythingOfType("string")).Return(nil)
+
+		a, err := us.ClearProfileImage(context.TODO(), uid)
+		assert.NoError(t, err)
+		assert.Equal(t, "", a.ImageUrl)
+
+		mockAccountRepository.AssertExpectations(t)
+		mockBlobStore.AssertNumberOfCalls(t, "Delete", 4)
+	})
+
+	t.Run("No image", func(t *testing.T) {
+		mockAccountRepository := new(mocks.MockAccountRepo)
+		mockBlobStore := new(mocks.MockBlobStore)
+		us := service.NewAccountService(mockAccountRepository, mockBlobStore, nil, nil, "", 0, 0)
+
+		mockAccountRepository.On("FindByID", mock.Anything, uid).Return(&domain.Account{UID: uid}, nil)
+		mockBlobStore.On("Delete", mock.Anything, mock.AnythingOfType("string")).Return(nil)
+
+		a, err := us.ClearProfileImage(context.TODO(), uid)
+		assert.NoError(t, err)
+		assert.Equal(t, "", a.ImageUrl)
+
+		mockAccountRepository.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	})
+}
+
+func TestForgotPassword(t *testing.T) {
+	uid, _ := uuid.NewRandom()
+	email := "whuangz@gmail.com"
+
+	t.Run("Success", func(t *testing.T) {
+		mockAccountRepository := new(mocks.MockAccountRepo)
+		mockOneTimeTokenRepo := new(mocks.MockOneTimeTokenRepo)
+		mockMailer := new(mocks.MockMailer)
+		us := service.NewAccountService(mockAccountRepository, nil, mockOneTimeTokenRepo, mockMailer, "http://localhost:8080", 3600, 86400)
+
+		mockAccountRepository.On("FindByEmail", mock.Anything, email).Return(&domain.Account{UID: uid, Email: email}, nil)
+
+		var storedHash string
+		mockOneTimeTokenRepo.On("SetToken", mock.Anything, domain.PasswordResetToken, mock.AnythingOfType("string"), uid.String(), time.Hour).
+			Run(func(args mock.Arguments) {
+				storedHash = args.String(2)
+			}).Return(nil)
+
+		var sent *domain.Message
+		mockMailer.On("Send", mock.Anything, mock.AnythingOfType("*domain.Message")).
+			Run(func(args mock.Arguments) {
+				sent = args.Get(1).(*domain.Message)
+			}).Return(nil)
+
+		err := us.ForgotPassword(context.TODO(), email)
+		assert.NoError(t, err)
+
+		assert.Equal(t, email, sent.To)
+		prefix := "http://localhost:8080/reset-password?token="
+		i := strings.Index(sent.Body, prefix)
+		assert.NotEqual(t, -1, i)
+		token := strings.Fields(sent.Body[i+len(prefix):])[0]
+
+		// the token is mailed, only its hash is stored
+		assert.NotEqual(t, token, storedHash)
+		assert.Equal(t, crypto.HashToken(token), storedHash)
+
+		mockAccountRepository.AssertExpectations(t)
+		mockOneTimeTokenRepo.AssertExpectations(t)
+		mockMailer.AssertExpectations(t)
+	})
+
+	t.Run("Unknown email", func(t *testing.T) {
+		mockAccountRepository := new(mocks.MockAccountRepo)
+		mockOneTimeTokenRepo := new(mocks.MockOneTimeTokenRepo)
+		mockMailer := new(mocks.MockMailer)
+		us := service.NewAccountService(mockAccountRepository, nil, mockOneTimeTokenRepo, mockMailer, "http://localhost:8080", 3600, 86400)
+
+		mockAccountRepository.On("FindByEmail", mock.Anything, email).Return(nil, domain.NewNotFound("email", email))
+
+		err := us.ForgotPassword(context.TODO(), email)
+		assert.NoError(t, err)
+
+		mockOneTimeTokenRepo.AssertNotCalled(t, "SetToken", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		mockMailer.AssertNotCalled(t, "Send", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Mailer error", func(t *testing.T) {
+		mockAccountRepository := new(mocks.MockAccountRepo)
+		mockOneTimeTokenRepo := new(mocks.MockOneTimeTokenRepo)
+		mockMailer := new(mocks.MockMailer)
+		us := service.NewAccountService(mockAccountRepository, nil, mockOneTimeTokenRepo, mockMailer, "http://localhost:8080", 3600, 86400)
+
+		mockAccountRepository.On("FindByEmail", mock.Anything, email).Return(&domain.Account{UID: uid, Email: email}, nil)
+		mockOneTimeTokenRepo.On("SetToken", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+		mockMailer.On("Send", mock.Anything, mock.Anything).Return(fmt.Errorf("disk full"))
+
+		err := us.ForgotPassword(context.TODO(), email)
+		assert.Equal(t, http.StatusInternalServerError, domain.Status(err))
+	})
+}
+
+func TestResetPassword(t *testing.T) {
+	uid, _ := uuid.NewRandom()
+	token := "aResetToken"
+
+	t.Run("Success", func(t *testing.T) {
+		mockAccountRepository := new(mocks.MockAccountRepo)
+		mockOneTimeTokenRepo := new(mocks.MockOneTimeTokenRepo)
+		us := service.NewAccountService(mockAccountRepository, nil, mockOneTimeTokenRepo, nil, "", 3600, 86400)
+
+		mockOneTimeTokenRepo.On("ConsumeToken", mock.Anything, domain.PasswordResetToken, crypto.HashToken(token)).Return(uid.String(), nil)
+		mockAccountRepository.On("FindByID", mock.Anything, uid).Return(&domain.Account{UID: uid}, nil)
+
+		var storedPassword string
+		mockAccountRepository.On("UpdatePassword", mock.Anything, uid, mock.AnythingOfType("string")).
+			Run(func(args mock.Arguments) {
+				storedPassword = args.String(2)
+			}).Return(nil)
+
+		a, err := us.ResetPassword(context.TODO(), token, "aNewPassword")
+		assert.NoError(t, err)
+		assert.Equal(t, uid, a.UID)
+
+		match, err := crypto.ValidateHash(storedPassword, "aNewPassword")
+		assert.NoError(t, err)
+		assert.True(t, match)
+
+		mockAccountRepository.AssertExpectations(t)
+		mockOneTimeTokenRepo.AssertExpectations(t)
+	})
+
+	t.Run("Invalid or used token", func(t *testing.T) {
+		mockAccountRepository := new(mocks.MockAccountRepo)
+		mockOneTimeTokenRepo := new(mocks.MockOneTimeTokenRepo)
+		us := service.NewAccountService(mockAccountRepository, nil, mockOneTimeTokenRepo, nil, "", 3600, 86400)
+
+		mockOneTimeTokenRepo.On("ConsumeToken", mock.Anything, domain.PasswordResetToken, crypto.HashToken(token)).
+			Return("", domain.NewNotFound(domain.PasswordResetToken, "token"))
+
+		a, err := us.ResetPassword(context.TODO(), token, "aNewPassword")
+		assert.Nil(t, a)
+		assert.Equal(t, http.StatusBadRequest, domain.Status(err))
+
+		mockAccountRepository.AssertNotCalled(t, "UpdatePassword", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestVerifyEmail(t *testing.T) {
+	uid, _ := uuid.NewRandom()
+	token := "aVerificationToken"
+
+	t.Run("Success", func(t *testing.T) {
+		mockAccountRepository := new(mocks.MockAccountRepo)
+		mockOneTimeTokenRepo := new(mocks.MockOneTimeTokenRepo)
+		us := service.NewAccountService(mockAccountRepository, nil, mockOneTimeTokenRepo, nil, "", 0, 86400)
+
+		mockOneTimeTokenRepo.On("ConsumeToken", mock.Anything, domain.EmailVerificationToken, crypto.HashToken(token)).Return(uid.String(), nil)
+		mockAccountRepository.On("FindByID", mock.Anything, uid).Return(&domain.Account{UID: uid}, nil)
+		mockAccountRepository.On("Update", mock.Anything, mock.AnythingOfType("*domain.Account")).Return(nil)
+
+		a, err := us.VerifyEmail(context.TODO(), token)
+		assert.NoError(t, err)
+		assert.True(t, a.EmailVerifiedAt.Valid)
+
+		mockAccountRepository.AssertExpectations(t)
+		mockOneTimeTokenRepo.AssertExpectations(t)
+	})
+
+	t.Run("Invalid or used token", func(t *testing.T) {
+		mockAccountRepository := new(mocks.MockAccountRepo)
+		mockOneTimeTokenRepo := new(mocks.MockOneTimeTokenRepo)
+		us := service.NewAccountService(mockAccountRepository, nil, mockOneTimeTokenRepo, nil, "", 0, 86400)
+
+		mockOneTimeTokenRepo.On("ConsumeToken", mock.Anything, domain.EmailVerificationToken, crypto.HashToken(token)).
+			Return("", domain.NewNotFound(domain.EmailVerificationToken, "token"))
+
+		a, err := us.VerifyEmail(context.TODO(), token)
+		assert.Nil(t, a)
+		assert.Equal(t, http.StatusBadRequest, domain.Status(err))
+
+		mockAccountRepository.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	})
+}
+
+func TestResendVerificationEmail(t *testing.T) {
+	uid, _ := uuid.NewRandom()
+
+	t.Run("Success", func(t *testing.T) {
+		mockAccountRepository := new(mocks.MockAccountRepo)
+		mockOneTimeTokenRepo := new(mocks.MockOneTimeTokenRepo)
+		mockMailer := new(mocks.MockMailer)
+		us := service.NewAccountService(mockAccountRepository, nil, mockOneTimeTokenRepo, mockMailer, "http://localhost:8080", 0, 86400)
+
+		mockAccountRepository.On("FindByID", mock.Anything, uid).Return(&domain.Account{UID: uid, Email: "whuangz@gmail.com"}, nil)
+		mockOneTimeTokenRepo.On("SetToken", mock.Anything, domain.EmailVerificationToken, mock.Anything, uid.String(), 24*time.Hour).Return(nil)
+
+		var sent *domain.Message
+		mockMailer.On("Send", mock.Anything, mock.AnythingOfType("*domain.Message")).
+			Run(func(args mock.Arguments) {
+				sent = args.Get(1).(*domain.Message)
+			}).Return(nil)
+
+		err := us.ResendVerificationEmail(context.TODO(), uid)
+		assert.NoError(t, err)
+		assert.Equal(t, "whuangz@gmail.com", sent.To)
+		assert.Contains(t, sent.Body, "http://localhost:8080/api/account/verify?token=")
+
+		mockOneTimeTokenRepo.AssertExpectations(t)
+		mockMailer.AssertExpectations(t)
+	})
+
+	t.Run("Already verified", func(t *testing.T) {
+		mockAccountRepository := new(mocks.MockAccountRepo)
+		mockMailer := new(mocks.MockMailer)
+		us := service.NewAccountService(mockAccountRepository, nil, nil, mockMailer, "", 0, 86400)
+
+		verified := &domain.Account{UID: uid, EmailVerifiedAt: sql.NullTime{Time: time.Now(), Valid: true}}
+		mockAccountRepository.On("FindByID", mock.Anything, uid).Return(verified, nil)
+
+		err := us.ResendVerificationEmail(context.TODO(), uid)
+		assert.Equal(t, http.StatusBadRequest, domain.Status(err))
+
+		mockMailer.AssertNotCalled(t, "Send", mock.Anything, mock.Anything)
+	})
+}
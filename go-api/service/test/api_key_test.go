@@ -0,0 +1,161 @@
+package service_test
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/whuangz/go-example/go-api/domain"
+	"github.com/whuangz/go-example/go-api/helpers/crypto"
+	mocks "github.com/whuangz/go-example/go-api/mocks/account"
+	"github.com/whuangz/go-example/go-api/service"
+)
+
+func TestCreateAPIKey(t *testing.T) {
+	accountUID := uuid.New()
+
+	t.Run("Success", func(t *testing.T) {
+		mockRepo := new(mocks.MockAPIKeyRepo)
+		s := service.NewAPIKeyService(mockRepo, nil)
+
+		mockRepo.On("FindByAccount", mock.Anything, accountUID).Return([]*domain.APIKey{}, nil)
+		mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.APIKey")).Return(nil)
+
+		k, key, err := s.Create(context.TODO(), accountUID, "ci", []string{domain.APIKeyReadScope}, 24*time.Hour)
+
+		assert.NoError(t, err)
+		assert.True(t, strings.HasPrefix(key, "gxk_"))
+		assert.True(t, strings.HasPrefix(key, k.Prefix))
+		assert.Len(t, k.Prefix, 12)
+		// only the hash of the key is stored
+		assert.Equal(t, crypto.HashToken(key), k.KeyHash)
+		assert.Equal(t, accountUID, k.AccountUID)
+		assert.True(t, k.ExpiresAt.Valid)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Without expiry", func(t *testing.T) {
+		mockRepo := new(mocks.MockAPIKeyRepo)
+		s := service.NewAPIKeyService(mockRepo, nil)
+
+		mockRepo.On("FindByAccount", mock.Anything, accountUID).Return([]*domain.APIKey{}, nil)
+		mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.APIKey")).Return(nil)
+
+		k, _, err := s.Create(context.TODO(), accountUID, "ci", []string{domain.APIKeyWriteScope}, 0)
+
+		assert.NoError(t, err)
+		assert.False(t, k.ExpiresAt.Valid)
+	})
+
+	t.Run("Invalid scopes", func(t *testing.T) {
+		mockRepo := new(mocks.MockAPIKeyRepo)
+		s := service.NewAPIKeyService(mockRepo, nil)
+
+		_, _, err := s.Create(context.TODO(), accountUID, "ci", nil, 0)
+		assert.Equal(t, http.StatusBadRequest, domain.Status(err))
+
+		_, _, err = s.Create(context.TODO(), accountUID, "ci", []string{"admin"}, 0)
+		assert.Equal(t, http.StatusBadRequest, domain.Status(err))
+
+		mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Too many keys", func(t *testing.T) {
+		mockRepo := new(mocks.MockAPIKeyRepo)
+		s := service.NewAPIKeyService(mockRepo, nil)
+
+		keys := make([]*domain.APIKey, 25)
+		mockRepo.On("FindByAccount", mock.Anything, accountUID).Return(keys, nil)
+
+		_, _, err := s.Create(context.TODO(), accountUID, "ci", []string{domain.APIKeyReadScope}, 0)
+
+		assert.Equal(t, http.StatusBadRequest, domain.Status(err))
+		mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+}
+
+func TestAuthenticateAPIKey(t *testing.T) {
+	account := &domain.Account{UID: uuid.New(), Email: "whuangz@gmail.com"}
+	key := "gxk_aValidKey"
+
+	t.Run("Success", func(t *testing.T) {
+		mockRepo := new(mocks.MockAPIKeyRepo)
+		mockAccRepo := new(mocks.MockAccountRepo)
+		s := service.NewAPIKeyService(mockRepo, mockAccRepo)
+
+		k := &domain.APIKey{UID: uuid.New(), AccountUID: account.UID}
+		mockRepo.On("FindByHash", mock.Anything, crypto.HashToken(key)).Return(k, nil)
+		mockRepo.On("SetLastUsed", mock.Anything, k.UID, mock.AnythingOfType("time.Time")).Return(nil)
+		mockAccRepo.On("FindByID", mock.Anything, account.UID).Return(account, nil)
+
+		acc, apiKey, err := s.Authenticate(context.TODO(), key)
+
+		assert.NoError(t, err)
+		assert.Equal(t, account, acc)
+		assert.Equal(t, k, apiKey)
+		assert.True(t, apiKey.LastUsedAt.Valid)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Recently used", func(t *testing.T) {
+		mockRepo := new(mocks.MockAPIKeyRepo)
+		mockAccRepo := new(mocks.MockAccountRepo)
+		s := service.NewAPIKeyService(mockRepo, mockAccRepo)
+
+		k := &domain.APIKey{
+			UID:        uuid.New(),
+			AccountUID: account.UID,
+			LastUsedAt: sql.NullTime{Time: time.Now().Add(-10 * time.Second), Valid: true},
+		}
+		mockRepo.On("FindByHash", mock.Anything, crypto.HashToken(key)).Return(k, nil)
+		mockAccRepo.On("FindByID", mock.Anything, account.UID).Return(account, nil)
+
+		_, _, err := s.Authenticate(context.TODO(), key)
+
+		assert.NoError(t, err)
+		mockRepo.AssertNotCalled(t, "SetLastUsed", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Expired", func(t *testing.T) {
+		mockRepo := new(mocks.MockAPIKeyRepo)
+		s := service.NewAPIKeyService(mockRepo, nil)
+
+		k := &domain.APIKey{
+			UID:        uuid.New(),
+			AccountUID: account.UID,
+			ExpiresAt:  sql.NullTime{Time: time.Now().Add(-time.Minute), Valid: true},
+		}
+		mockRepo.On("FindByHash", mock.Anything, crypto.HashToken(key)).Return(k, nil)
+
+		_, _, err := s.Authenticate(context.TODO(), key)
+
+		assert.Equal(t, http.StatusUnauthorized, domain.Status(err))
+	})
+
+	t.Run("Unknown", func(t *testing.T) {
+		mockRepo := new(mocks.MockAPIKeyRepo)
+		s := service.NewAPIKeyService(mockRepo, nil)
+
+		mockRepo.On("FindByHash", mock.Anything, crypto.HashToken(key)).Return(nil, domain.NewNotFound("api key", "key"))
+
+		_, _, err := s.Authenticate(context.TODO(), key)
+
+		assert.Equal(t, http.StatusUnauthorized, domain.Status(err))
+	})
+
+	t.Run("Wrong prefix", func(t *testing.T) {
+		mockRepo := new(mocks.MockAPIKeyRepo)
+		s := service.NewAPIKeyService(mockRepo, nil)
+
+		_, _, err := s.Authenticate(context.TODO(), "aValidKey")
+
+		assert.Equal(t, http.StatusUnauthorized, domain.Status(err))
+		mockRepo.AssertNotCalled(t, "FindByHash", mock.Anything, mock.Anything)
+	})
+}
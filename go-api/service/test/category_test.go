@@ -0,0 +1,75 @@
+package service_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/whuangz/go-example/go-api/domain"
+	mocks "github.com/whuangz/go-example/go-api/mocks/post"
+	"github.com/whuangz/go-example/go-api/service"
+)
+
+func TestCategorySave(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		mockRepo := new(mocks.MockCategoryRepo)
+		mockRepo.On("FindByID", mock.Anything, int32(1)).Return(domain.Category{ID: 1, Name: "Programming", Slug: "programming"}, nil)
+		mockRepo.On("Save", mock.Anything, mock.AnythingOfType("*domain.Category")).Return(nil).Once()
+
+		s := service.NewCategoryService(mockRepo)
+
+		category := &domain.Category{Name: " Go & Rust ", ParentID: 1}
+		err := s.Save(context.TODO(), category)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "Go & Rust", category.Name)
+		assert.Equal(t, "go-rust", category.Slug)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Unknown parent", func(t *testing.T) {
+		mockRepo := new(mocks.MockCategoryRepo)
+		mockRepo.On("FindByID", mock.Anything, int32(404)).Return(domain.Category{}, domain.NewNotFound("category_id", "404"))
+
+		s := service.NewCategoryService(mockRepo)
+		err := s.Save(context.TODO(), &domain.Category{Name: "Go", ParentID: 404})
+
+		assert.Equal(t, http.StatusBadRequest, domain.Status(err))
+		mockRepo.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Name without a slug", func(t *testing.T) {
+		mockRepo := new(mocks.MockCategoryRepo)
+
+		s := service.NewCategoryService(mockRepo)
+		err := s.Save(context.TODO(), &domain.Category{Name: "!!"})
+
+		assert.Equal(t, http.StatusBadRequest, domain.Status(err))
+		mockRepo.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+	})
+}
+
+func TestCategoryTree(t *testing.T) {
+	mockRepo := new(mocks.MockCategoryRepo)
+	mockRepo.On("FindAll", mock.Anything).Return([]domain.Category{
+		{ID: 2, ParentID: 1, Name: "Go", Slug: "go"},
+		{ID: 4, Name: "Life", Slug: "life"},
+		{ID: 1, Name: "Programming", Slug: "programming"},
+		{ID: 3, ParentID: 2, Name: "Tooling", Slug: "tooling"},
+	}, nil)
+
+	s := service.NewCategoryService(mockRepo)
+	tree, err := s.Tree(context.TODO())
+
+	assert.NoError(t, err)
+	assert.Equal(t, []domain.Category{
+		{ID: 4, Name: "Life", Slug: "life", Children: []domain.Category{}},
+		{ID: 1, Name: "Programming", Slug: "programming", Children: []domain.Category{
+			{ID: 2, ParentID: 1, Name: "Go", Slug: "go", Children: []domain.Category{
+				{ID: 3, ParentID: 2, Name: "Tooling", Slug: "tooling", Children: []domain.Category{}},
+			}},
+		}},
+	}, tree)
+}
@@ -0,0 +1,300 @@
+package service_test
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/whuangz/go-example/go-api/domain"
+	mocks "github.com/whuangz/go-example/go-api/mocks/post"
+	"github.com/whuangz/go-example/go-api/service"
+)
+
+var commenter = &domain.Account{UID: uuid.New(), Name: "reader", Roles: []string{domain.ReaderRole}}
+
+func TestCreateComment(t *testing.T) {
+	var postID int32 = 1
+
+	newService := func(post domain.Post) (domain.CommentService, *mocks.MockCommentRepo) {
+		mockPostRepo := new(mocks.MockPostRepo)
+		mockPostRepo.On("FindByID", mock.Anything, postID).Return(post, nil)
+
+		mockRepo := new(mocks.MockCommentRepo)
+		mockRepo.On("Save", mock.Anything, mock.AnythingOfType("*domain.Comment")).Return(nil)
+		mockRepo.On("FindByID", mock.Anything, int32(10)).
+			Return(domain.Comment{ID: 10, PostID: postID, Depth: 1, Status: domain.CommentApproved}, nil)
+		mockRepo.On("FindByID", mock.Anything, int32(11)).
+			Return(domain.Comment{ID: 11, PostID: postID, Depth: domain.MaxCommentDepth, Status: domain.CommentApproved}, nil)
+		mockRepo.On("FindByID", mock.Anything, int32(12)).
+			Return(domain.Comment{ID: 12, PostID: postID, Status: domain.CommentPending}, nil)
+		mockRepo.On("FindByID", mock.Anything, int32(13)).
+			Return(domain.Comment{ID: 13, PostID: 2, Status: domain.CommentApproved}, nil)
+		return service.NewCommentService(mockRepo, mockPostRepo, time.Minute), mockRepo
+	}
+
+	t.Run("Awaits moderation", func(t *testing.T) {
+		cs, mockRepo := newService(ownedPost(postID))
+
+		comment := &domain.Comment{Content: "  Nice post  "}
+		err := cs.Create(context.TODO(), commenter, postID, comment)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "Nice post", comment.Content)
+		assert.Equal(t, domain.CommentPending, comment.Status)
+		assert.Equal(t, commenter.UID, comment.AccountUID)
+		assert.Equal(t, postID, comment.PostID)
+		mockRepo.AssertCalled(t, "Save", mock.Anything, comment)
+	})
+
+	t.Run("By the owner of the post", func(t *testing.T) {
+		cs, _ := newService(ownedPost(postID))
+
+		comment := &domain.Comment{Content: "Thanks for reading"}
+		assert.NoError(t, cs.Create(context.TODO(), postOwner, postID, comment))
+		assert.Equal(t, domain.CommentApproved, comment.Status)
+	})
+
+	t.Run("Reply", func(t *testing.T) {
+		cs, _ := newService(ownedPost(postID))
+
+		comment := &domain.Comment{Content: "Agreed", ParentID: 10}
+		assert.NoError(t, cs.Create(context.TODO(), commenter, postID, comment))
+		assert.Equal(t, 2, comment.Depth)
+	})
+
+	t.Run("Invalid replies", func(t *testing.T) {
+		cs, mockRepo := newService(ownedPost(postID))
+
+		parents := map[string]int32{
+			"too deep":             11,
+			"to a pending comment": 12,
+			"on another post":      13,
+		}
+		for name, parentID := range parents {
+			err := cs.Create(context.TODO(), commenter, postID, &domain.Comment{Content: "Reply", ParentID: parentID})
+			assert.Equal(t, http.StatusBadRequest, domain.Status(err), name)
+		}
+		mockRepo.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Invalid content", func(t *testing.T) {
+		cs, _ := newService(ownedPost(postID))
+
+		for _, content := range []string{" ", strings.Repeat("a", domain.MaxCommentLength+1)} {
+			err := cs.Create(context.TODO(), commenter, postID, &domain.Comment{Content: content})
+			assert.Equal(t, http.StatusBadRequest, domain.Status(err))
+		}
+	})
+
+	t.Run("Post not published", func(t *testing.T) {
+		statuses := map[string]int{
+			domain.DraftStatus:    http.StatusNotFound,
+			domain.ArchivedStatus: http.StatusForbidden,
+		}
+		for status, code := range statuses {
+			post := ownedPost(postID)
+			post.Status = status
+			cs, _ := newService(post)
+
+			err := cs.Create(context.TODO(), commenter, postID, &domain.Comment{Content: "Hello"})
+			assert.Equal(t, code, domain.Status(err), status)
+		}
+	})
+}
+
+func TestListComments(t *testing.T) {
+	var postID int32 = 1
+	other := uuid.New()
+
+	comments := []domain.Comment{
+		{ID: 1, PostID: postID, AccountUID: other, Content: "First", Status: domain.CommentApproved},
+		{ID: 2, PostID: postID, ParentID: 1, Depth: 1, AccountUID: commenter.UID, Content: "Pending reply", Status: domain.CommentPending},
+		{ID: 3, PostID: postID, AccountUID: other, Status: domain.CommentApproved, Deleted: true},
+		{ID: 4, PostID: postID, ParentID: 3, Depth: 1, AccountUID: other, Content: "Reply to deleted", Status: domain.CommentApproved},
+		{ID: 5, PostID: postID, AccountUID: other, Status: domain.CommentApproved, Deleted: true},
+		{ID: 6, PostID: postID, AccountUID: other, Content: "Spam", Status: domain.CommentRejected},
+		{ID: 7, PostID: postID, ParentID: 6, Depth: 1, AccountUID: other, Content: "Reply to spam", Status: domain.CommentApproved},
+	}
+
+	mockPostRepo := new(mocks.MockPostRepo)
+	mockPostRepo.On("FindByID", mock.Anything, postID).Return(ownedPost(postID), nil)
+	mockRepo := new(mocks.MockCommentRepo)
+	mockRepo.On("FindByPost", mock.Anything, postID).Return(comments, nil)
+	cs := service.NewCommentService(mockRepo, mockPostRepo, time.Minute)
+
+	// ids of the threads, replies after their parent
+	ids := func(threads []domain.Comment) []int32 {
+		var ids []int32
+		var walk func([]domain.Comment)
+		walk = func(comments []domain.Comment) {
+			for _, comment := range comments {
+				ids = append(ids, comment.ID)
+				walk(comment.Replies)
+			}
+		}
+		walk(threads)
+		return ids
+	}
+
+	t.Run("Anonymous", func(t *testing.T) {
+		threads, err := cs.List(context.TODO(), nil, postID)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []int32{1, 3, 4}, ids(threads))
+		assert.Len(t, threads, 2)
+		assert.Equal(t, int32(4), threads[1].Replies[0].ID)
+	})
+
+	t.Run("Author of a pending comment", func(t *testing.T) {
+		threads, err := cs.List(context.TODO(), commenter, postID)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []int32{1, 2, 3, 4}, ids(threads))
+	})
+
+	t.Run("Moderator", func(t *testing.T) {
+		threads, err := cs.List(context.TODO(), postOwner, postID)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []int32{1, 2, 3, 4, 6, 7}, ids(threads))
+	})
+}
+
+func TestUpdateComment(t *testing.T) {
+	var postID int32 = 1
+
+	newService := func(comment domain.Comment) (domain.CommentService, *mocks.MockCommentRepo) {
+		mockPostRepo := new(mocks.MockPostRepo)
+		mockPostRepo.On("FindByID", mock.Anything, postID).Return(ownedPost(postID), nil)
+		mockRepo := new(mocks.MockCommentRepo)
+		mockRepo.On("FindByID", mock.Anything, comment.ID).Return(comment, nil)
+		mockRepo.On("Update", mock.Anything, mock.AnythingOfType("*domain.Comment")).Return(nil)
+		mockRepo.On("Delete", mock.Anything, comment.ID).Return(nil)
+		return service.NewCommentService(mockRepo, mockPostRepo, time.Minute), mockRepo
+	}
+
+	recent := domain.Comment{ID: 1, PostID: postID, AccountUID: commenter.UID, Content: "Frist", Status: domain.CommentApproved, CreatedAt: time.Now()}
+
+	t.Run("Edit", func(t *testing.T) {
+		cs, _ := newService(recent)
+
+		comment, err := cs.Update(context.TODO(), commenter, postID, 1, "First")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "First", comment.Content)
+		assert.NotNil(t, comment.EditedAt)
+		// approved again by a moderator
+		assert.Equal(t, domain.CommentPending, comment.Status)
+	})
+
+	t.Run("Edit window passed", func(t *testing.T) {
+		old := recent
+		old.CreatedAt = time.Now().Add(-2 * time.Minute)
+		cs, mockRepo := newService(old)
+
+		_, err := cs.Update(context.TODO(), commenter, postID, 1, "First")
+
+		assert.Equal(t, http.StatusForbidden, domain.Status(err))
+		mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Edit by another account", func(t *testing.T) {
+		cs, _ := newService(recent)
+
+		_, err := cs.Update(context.TODO(), postOwner, postID, 1, "First")
+
+		assert.Equal(t, http.StatusForbidden, domain.Status(err))
+	})
+
+	t.Run("Comment of another post", func(t *testing.T) {
+		cs, _ := newService(recent)
+
+		_, err := cs.Update(context.TODO(), commenter, 2, 1, "First")
+
+		assert.Equal(t, http.StatusNotFound, domain.Status(err))
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		for _, account := range []*domain.Account{commenter, postOwner} {
+			cs, mockRepo := newService(recent)
+
+			assert.NoError(t, cs.Delete(context.TODO(), account, postID, 1))
+			mockRepo.AssertCalled(t, "Delete", mock.Anything, int32(1))
+		}
+	})
+
+	t.Run("Delete by another account", func(t *testing.T) {
+		cs, mockRepo := newService(recent)
+		other := &domain.Account{UID: uuid.New(), Roles: []string{domain.AuthorRole}}
+
+		err := cs.Delete(context.TODO(), other, postID, 1)
+
+		assert.Equal(t, http.StatusForbidden, domain.Status(err))
+		mockRepo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Delete a deleted comment", func(t *testing.T) {
+		deleted := recent
+		deleted.Deleted = true
+		cs, _ := newService(deleted)
+
+		err := cs.Delete(context.TODO(), commenter, postID, 1)
+
+		assert.Equal(t, http.StatusNotFound, domain.Status(err))
+	})
+}
+
+func TestModerateComment(t *testing.T) {
+	var postID int32 = 1
+	pending := domain.Comment{ID: 1, PostID: postID, AccountUID: commenter.UID, Content: "Hello", Status: domain.CommentPending}
+
+	newService := func() (domain.CommentService, *mocks.MockCommentRepo) {
+		mockPostRepo := new(mocks.MockPostRepo)
+		mockPostRepo.On("FindByID", mock.Anything, postID).Return(ownedPost(postID), nil)
+		mockRepo := new(mocks.MockCommentRepo)
+		mockRepo.On("FindByID", mock.Anything, int32(1)).Return(pending, nil)
+		mockRepo.On("Update", mock.Anything, mock.AnythingOfType("*domain.Comment")).Return(nil)
+		return service.NewCommentService(mockRepo, mockPostRepo, time.Minute), mockRepo
+	}
+
+	t.Run("By the owner of the post", func(t *testing.T) {
+		cs, _ := newService()
+
+		comment, err := cs.Moderate(context.TODO(), postOwner, postID, 1, domain.CommentApproved)
+
+		assert.NoError(t, err)
+		assert.Equal(t, domain.CommentApproved, comment.Status)
+	})
+
+	t.Run("By an editor", func(t *testing.T) {
+		cs, _ := newService()
+		editor := &domain.Account{UID: uuid.New(), Roles: []string{domain.EditorRole}}
+
+		comment, err := cs.Moderate(context.TODO(), editor, postID, 1, domain.CommentRejected)
+
+		assert.NoError(t, err)
+		assert.Equal(t, domain.CommentRejected, comment.Status)
+	})
+
+	t.Run("By the author of the comment", func(t *testing.T) {
+		cs, mockRepo := newService()
+
+		_, err := cs.Moderate(context.TODO(), commenter, postID, 1, domain.CommentApproved)
+
+		assert.Equal(t, http.StatusForbidden, domain.Status(err))
+		mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Unknown status", func(t *testing.T) {
+		cs, _ := newService()
+
+		_, err := cs.Moderate(context.TODO(), postOwner, postID, 1, "spam")
+
+		assert.Equal(t, http.StatusBadRequest, domain.Status(err))
+	})
+}
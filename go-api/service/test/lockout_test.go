@@ -0,0 +1,134 @@
+package service_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/whuangz/go-example/go-api/domain"
+	mocks "github.com/whuangz/go-example/go-api/mocks/account"
+	"github.com/whuangz/go-example/go-api/service"
+)
+
+var lockoutPolicy = domain.LockoutPolicy{
+	MaxFailures:   5,
+	MaxIPFailures: 50,
+	Window:        15 * time.Minute,
+	BaseLockout:   time.Minute,
+	MaxLockout:    time.Hour,
+}
+
+func TestCheckLockout(t *testing.T) {
+	keys := []string{"email:whuangz@gmail.com", "ip:10.0.0.1"}
+
+	t.Run("Not locked", func(t *testing.T) {
+		mockRepo := new(mocks.MockSigninAttemptRepo)
+		s := service.NewLockoutService(mockRepo, lockoutPolicy)
+
+		mockRepo.On("LockedFor", mock.Anything, keys).Return(time.Duration(0), nil)
+
+		err := s.Check(context.TODO(), "WHuangz@gmail.com ", "10.0.0.1")
+
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Locked", func(t *testing.T) {
+		mockRepo := new(mocks.MockSigninAttemptRepo)
+		s := service.NewLockoutService(mockRepo, lockoutPolicy)
+
+		mockRepo.On("LockedFor", mock.Anything, keys).Return(1500*time.Millisecond, nil)
+
+		err := s.Check(context.TODO(), "whuangz@gmail.com", "10.0.0.1")
+
+		assert.Equal(t, http.StatusTooManyRequests, domain.Status(err))
+		assert.Equal(t, int64(2), err.(*domain.Error).RetryAfter)
+	})
+}
+
+func TestRegisterFailure(t *testing.T) {
+	emailKey := "email:whuangz@gmail.com"
+	ipKey := "ip:10.0.0.1"
+
+	t.Run("Under the maximum", func(t *testing.T) {
+		mockRepo := new(mocks.MockSigninAttemptRepo)
+		s := service.NewLockoutService(mockRepo, lockoutPolicy)
+
+		mockRepo.On("AddFailure", mock.Anything, emailKey, lockoutPolicy.Window).Return(int64(4), nil)
+		mockRepo.On("AddFailure", mock.Anything, ipKey, lockoutPolicy.Window).Return(int64(4), nil)
+
+		err := s.RegisterFailure(context.TODO(), "whuangz@gmail.com", "10.0.0.1")
+
+		assert.NoError(t, err)
+		mockRepo.AssertNotCalled(t, "Lock", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Exponential backoff", func(t *testing.T) {
+		cases := []struct {
+			failures int64
+			lockout  time.Duration
+		}{
+			{5, time.Minute},
+			{6, 2 * time.Minute},
+			{8, 8 * time.Minute},
+			{11, time.Hour},
+			{100, time.Hour},
+		}
+
+		for _, tc := range cases {
+			mockRepo := new(mocks.MockSigninAttemptRepo)
+			s := service.NewLockoutService(mockRepo, lockoutPolicy)
+
+			mockRepo.On("AddFailure", mock.Anything, emailKey, lockoutPolicy.Window).Return(tc.failures, nil)
+			mockRepo.On("AddFailure", mock.Anything, ipKey, lockoutPolicy.Window).Return(int64(1), nil)
+			mockRepo.On("Lock", mock.Anything, emailKey, tc.lockout).Return(nil)
+
+			err := s.RegisterFailure(context.TODO(), "whuangz@gmail.com", "10.0.0.1")
+
+			assert.Equal(t, http.StatusTooManyRequests, domain.Status(err))
+			assert.Equal(t, int64(tc.lockout.Seconds()), err.(*domain.Error).RetryAfter)
+			mockRepo.AssertExpectations(t)
+		}
+	})
+
+	t.Run("IP over the maximum", func(t *testing.T) {
+		mockRepo := new(mocks.MockSigninAttemptRepo)
+		s := service.NewLockoutService(mockRepo, lockoutPolicy)
+
+		mockRepo.On("AddFailure", mock.Anything, emailKey, lockoutPolicy.Window).Return(int64(1), nil)
+		mockRepo.On("AddFailure", mock.Anything, ipKey, lockoutPolicy.Window).Return(int64(51), nil)
+		mockRepo.On("Lock", mock.Anything, ipKey, 2*time.Minute).Return(nil)
+
+		err := s.RegisterFailure(context.TODO(), "whuangz@gmail.com", "10.0.0.1")
+
+		assert.Equal(t, http.StatusTooManyRequests, domain.Status(err))
+		mockRepo.AssertExpectations(t)
+	})
+}
+
+func TestRegisterSuccess(t *testing.T) {
+	mockRepo := new(mocks.MockSigninAttemptRepo)
+	s := service.NewLockoutService(mockRepo, lockoutPolicy)
+
+	mockRepo.On("Reset", mock.Anything, "email:whuangz@gmail.com").Return(nil)
+
+	err := s.RegisterSuccess(context.TODO(), "whuangz@gmail.com")
+
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestUnlock(t *testing.T) {
+	mockRepo := new(mocks.MockSigninAttemptRepo)
+	s := service.NewLockoutService(mockRepo, lockoutPolicy)
+
+	mockRepo.On("Reset", mock.Anything, "email:whuangz@gmail.com").Return(nil)
+
+	err := s.Unlock(context.TODO(), "Whuangz@gmail.com")
+
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
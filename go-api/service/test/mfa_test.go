@@ -0,0 +1,225 @@
+package service_test
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"image/png"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/whuangz/go-example/go-api/domain"
+	"github.com/whuangz/go-example/go-api/helpers/crypto"
+	"github.com/whuangz/go-example/go-api/helpers/totp"
+	mocks "github.com/whuangz/go-example/go-api/mocks/account"
+	"github.com/whuangz/go-example/go-api/service"
+)
+
+var mfaKey = []byte("0123456789abcdef0123456789abcdef")
+
+func enabledMFA(t *testing.T, uid uuid.UUID, secret string) *domain.MFA {
+	encrypted, err := crypto.Encrypt(mfaKey, secret)
+	assert.NoError(t, err)
+
+	return &domain.MFA{
+		AccountUID: uid,
+		Secret:     encrypted,
+		EnabledAt:  sql.NullTime{Time: time.Now(), Valid: true},
+	}
+}
+
+func TestEnrollMFA(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		a := &domain.Account{UID: uuid.New(), Email: "whuangz@gmail.com"}
+
+		mockMFARepo := new(mocks.MockMFARepo)
+		s := service.NewMFAService(mockMFARepo, nil, mfaKey, "go-example", 300)
+
+		var saved *domain.MFA
+		mockMFARepo.On("FindByUID", mock.Anything, a.UID).Return(nil, domain.NewNotFound("uid", a.UID.String()))
+		mockMFARepo.On("Save", mock.Anything, mock.AnythingOfType("*domain.MFA")).
+			Run(func(args mock.Arguments) {
+				saved = args.Get(1).(*domain.MFA)
+			}).Return(nil)
+
+		enrollment, err := s.Enroll(context.TODO(), a)
+
+		assert.NoError(t, err)
+		assert.Contains(t, enrollment.URI, "secret="+enrollment.Secret)
+
+		// the secret is never stored in clear
+		assert.NotEqual(t, enrollment.Secret, saved.Secret)
+		secret, err := crypto.Decrypt(mfaKey, saved.Secret)
+		assert.NoError(t, err)
+		assert.Equal(t, enrollment.Secret, secret)
+		assert.False(t, saved.EnabledAt.Valid)
+
+		_, err = png.Decode(bytes.NewReader(enrollment.QRCode))
+		assert.NoError(t, err)
+		mockMFARepo.AssertExpectations(t)
+	})
+
+	t.Run("Already enabled", func(t *testing.T) {
+		a := &domain.Account{UID: uuid.New()}
+		secret, _ := totp.GenerateSecret()
+
+		mockMFARepo := new(mocks.MockMFARepo)
+		s := service.NewMFAService(mockMFARepo, nil, mfaKey, "go-example", 300)
+
+		mockMFARepo.On("FindByUID", mock.Anything, a.UID).Return(enabledMFA(t, a.UID, secret), nil)
+
+		_, err := s.Enroll(context.TODO(), a)
+
+		assert.Equal(t, http.StatusBadRequest, domain.Status(err))
+		mockMFARepo.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+	})
+}
+
+func TestConfirmMFA(t *testing.T) {
+	uid := uuid.New()
+	secret, _ := totp.GenerateSecret()
+
+	pending := func() *domain.MFA {
+		m := enabledMFA(t, uid, secret)
+		m.EnabledAt = sql.NullTime{}
+		return m
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		mockMFARepo := new(mocks.MockMFARepo)
+		s := service.NewMFAService(mockMFARepo, nil, mfaKey, "go-example", 300)
+
+		now := time.Now()
+		code, _ := totp.Code(secret, now)
+
+		var hashes []string
+		mockMFARepo.On("FindByUID", mock.Anything, uid).Return(pending(), nil)
+		mockMFARepo.On("SetRecoveryCodes", mock.Anything, uid, mock.AnythingOfType("[]string")).
+			Run(func(args mock.Arguments) {
+				hashes = args.Get(2).([]string)
+			}).Return(nil)
+		mockMFARepo.On("Save", mock.Anything, mock.MatchedBy(func(m *domain.MFA) bool {
+			return m.EnabledAt.Valid && m.LastUsedStep == totp.Step(now)
+		})).Return(nil)
+
+		codes, err := s.Confirm(context.TODO(), uid, code)
+
+		assert.NoError(t, err)
+		assert.Len(t, codes, 10)
+		assert.Len(t, hashes, 10)
+		assert.Regexp(t, "^[A-Z2-7]{4}-[A-Z2-7]{4}-[A-Z2-7]{4}-[A-Z2-7]{4}$", codes[0])
+		assert.NotContains(t, hashes, codes[0])
+		mockMFARepo.AssertExpectations(t)
+	})
+
+	t.Run("Invalid code", func(t *testing.T) {
+		mockMFARepo := new(mocks.MockMFARepo)
+		s := service.NewMFAService(mockMFARepo, nil, mfaKey, "go-example", 300)
+
+		mockMFARepo.On("FindByUID", mock.Anything, uid).Return(pending(), nil)
+
+		code, _ := totp.Code(secret, time.Now().Add(-time.Hour))
+		codes, err := s.Confirm(context.TODO(), uid, code)
+
+		assert.Nil(t, codes)
+		assert.Equal(t, http.StatusUnauthorized, domain.Status(err))
+		mockMFARepo.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Enrollment not started", func(t *testing.T) {
+		mockMFARepo := new(mocks.MockMFARepo)
+		s := service.NewMFAService(mockMFARepo, nil, mfaKey, "go-example", 300)
+
+		mockMFARepo.On("FindByUID", mock.Anything, uid).Return(nil, domain.NewNotFound("uid", uid.String()))
+
+		_, err := s.Confirm(context.TODO(), uid, "123456")
+
+		assert.Equal(t, http.StatusBadRequest, domain.Status(err))
+	})
+}
+
+func TestDisableMFA(t *testing.T) {
+	uid := uuid.New()
+	secret, _ := totp.GenerateSecret()
+
+	t.Run("Replayed code", func(t *testing.T) {
+		mockMFARepo := new(mocks.MockMFARepo)
+		s := service.NewMFAService(mockMFARepo, nil, mfaKey, "go-example", 300)
+
+		mockMFARepo.On("FindByUID", mock.Anything, uid).Return(enabledMFA(t, uid, secret), nil)
+		mockMFARepo.On("UseStep", mock.Anything, uid, mock.AnythingOfType("int64")).Return(false, nil)
+
+		code, _ := totp.Code(secret, time.Now())
+		err := s.Disable(context.TODO(), uid, code)
+
+		assert.Equal(t, http.StatusUnauthorized, domain.Status(err))
+		mockMFARepo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Recovery code", func(t *testing.T) {
+		mockMFARepo := new(mocks.MockMFARepo)
+		s := service.NewMFAService(mockMFARepo, nil, mfaKey, "go-example", 300)
+
+		mockMFARepo.On("FindByUID", mock.Anything, uid).Return(enabledMFA(t, uid, secret), nil)
+		mockMFARepo.On("UseRecoveryCode", mock.Anything, uid, crypto.HashToken("AAAABBBBCCCCDDDD")).Return(true, nil)
+		mockMFARepo.On("Delete", mock.Anything, uid).Return(nil)
+
+		err := s.Disable(context.TODO(), uid, "aaaa-bbbb-cccc-dddd")
+
+		assert.NoError(t, err)
+		mockMFARepo.AssertExpectations(t)
+	})
+}
+
+func TestVerifyMFAChallenge(t *testing.T) {
+	uid := uuid.New()
+	secret, _ := totp.GenerateSecret()
+
+	t.Run("Success", func(t *testing.T) {
+		mockMFARepo := new(mocks.MockMFARepo)
+		mockOneTimeTokenRepo := new(mocks.MockOneTimeTokenRepo)
+		s := service.NewMFAService(mockMFARepo, mockOneTimeTokenRepo, mfaKey, "go-example", 300)
+
+		var tokenHash string
+		mockOneTimeTokenRepo.On("SetToken", mock.Anything, domain.MFAChallengeToken, mock.AnythingOfType("string"), uid.String(), 300*time.Second).
+			Run(func(args mock.Arguments) {
+				tokenHash = args.String(2)
+			}).Return(nil)
+
+		challenge, err := s.NewChallenge(context.TODO(), uid)
+		assert.NoError(t, err)
+		assert.True(t, challenge.MFARequired)
+		assert.Equal(t, crypto.HashToken(challenge.ChallengeToken), tokenHash)
+
+		mockOneTimeTokenRepo.On("ConsumeToken", mock.Anything, domain.MFAChallengeToken, tokenHash).Return(uid.String(), nil)
+		mockMFARepo.On("FindByUID", mock.Anything, uid).Return(enabledMFA(t, uid, secret), nil)
+		mockMFARepo.On("UseStep", mock.Anything, uid, mock.AnythingOfType("int64")).Return(true, nil)
+
+		code, _ := totp.Code(secret, time.Now())
+		verified, err := s.VerifyChallenge(context.TODO(), challenge.ChallengeToken, code)
+
+		assert.NoError(t, err)
+		assert.Equal(t, uid, verified)
+		mockOneTimeTokenRepo.AssertExpectations(t)
+		mockMFARepo.AssertExpectations(t)
+	})
+
+	t.Run("Invalid challenge", func(t *testing.T) {
+		mockMFARepo := new(mocks.MockMFARepo)
+		mockOneTimeTokenRepo := new(mocks.MockOneTimeTokenRepo)
+		s := service.NewMFAService(mockMFARepo, mockOneTimeTokenRepo, mfaKey, "go-example", 300)
+
+		mockOneTimeTokenRepo.On("ConsumeToken", mock.Anything, domain.MFAChallengeToken, crypto.HashToken("aUsedChallenge")).
+			Return("", domain.NewNotFound("token", "aUsedChallenge"))
+
+		verified, err := s.VerifyChallenge(context.TODO(), "aUsedChallenge", "123456")
+
+		assert.Equal(t, uuid.Nil, verified)
+		assert.Equal(t, http.StatusUnauthorized, domain.Status(err))
+		mockMFARepo.AssertNotCalled(t, "FindByUID", mock.Anything, mock.Anything)
+	})
+}
@@ -0,0 +1,594 @@
+package service_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/whuangz/go-example/go-api/domain"
+	"github.com/whuangz/go-example/go-api/helpers/crypto"
+	jwtHelper "github.com/whuangz/go-example/go-api/helpers/jwt"
+	mocks "github.com/whuangz/go-example/go-api/mocks/account"
+	"github.com/whuangz/go-example/go-api/repository"
+	"github.com/whuangz/go-example/go-api/service"
+)
+
+const (
+	oauthIssuer   = "https://api.example.com"
+	codeVerifier  = "dBjftJeZ4CQP-0mxW6ZQBgbBSjJJQm0ZhEUi1aa6zBPkqc5Xg"
+	oauthRedirect = "https://app.example.com/callback"
+)
+
+func codeChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func oauthKeys() *jwtHelper.Keyring {
+	priv, _ := ioutil.ReadFile("../../config/rsa_private_test.pem")
+	privKey, _ := jwt.ParseRSAPrivateKeyFromPEM(priv)
+	return jwtHelper.NewKeyring(jwtHelper.NewKey(privKey))
+}
+
+func webClient() *domain.OAuthClient {
+	return &domain.OAuthClient{
+		ClientID:     "aWebClient",
+		SecretHash:   crypto.HashToken("aClientSecret"),
+		Name:         "A web app",
+		RedirectURIs: []string{oauthRedirect},
+		Scopes:       []string{"openid", "profile", "email", "posts:read"},
+		GrantTypes:   []string{domain.AuthorizationCodeGrant, domain.ClientCredentialsGrant},
+	}
+}
+
+func authorizeRequest() *domain.AuthorizeRequest {
+	return &domain.AuthorizeRequest{
+		ResponseType:        "code",
+		ClientID:            "aWebClient",
+		RedirectURI:         oauthRedirect,
+		Scope:               "openid email",
+		State:               "aState",
+		CodeChallenge:       codeChallenge(codeVerifier),
+		CodeChallengeMethod: "S256",
+		Nonce:               "aNonce",
+	}
+}
+
+func TestRegisterClient(t *testing.T) {
+	t.Run("Confidential client", func(t *testing.T) {
+		mockRepo := new(mocks.MockOAuthRepo)
+		mockRepo.On("CreateClient", mock.Anything, mock.AnythingOfType("*domain.OAuthClient")).Return(nil)
+		s := service.NewOAuthService(mockRepo, nil, nil, nil, nil, oauthIssuer, 3600, 60)
+
+		client := &domain.OAuthClient{
+			Name:         "A web app",
+			RedirectURIs: []string{oauthRedirect},
+			Scopes:       []string{"openid"},
+			GrantTypes:   []string{domain.AuthorizationCodeGrant},
+		}
+		secret, err := s.RegisterClient(context.Background(), client, false)
+
+		assert.NoError(t, err)
+		assert.NotEmpty(t, client.ClientID)
+		assert.NotEmpty(t, secret)
+		// only the hash is stored
+		assert.Equal(t, crypto.HashToken(secret), client.SecretHash)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Public client", func(t *testing.T) {
+		mockRepo := new(mocks.MockOAuthRepo)
+		mockRepo.On("CreateClient", mock.Anything, mock.AnythingOfType("*domain.OAuthClient")).Return(nil)
+		s := service.NewOAuthService(mockRepo, nil, nil, nil, nil, oauthIssuer, 3600, 60)
+
+		client := &domain.OAuthClient{
+			Name:         "A mobile app",
+			RedirectURIs: []string{"com.example.app://callback"},
+			Scopes:       []string{"openid"},
+			GrantTypes:   []string{domain.AuthorizationCodeGrant},
+		}
+		secret, err := s.RegisterClient(context.Background(), client, true)
+
+		assert.NoError(t, err)
+		assert.Empty(t, secret)
+		assert.True(t, client.IsPublic())
+	})
+
+	t.Run("Invalid clients", func(t *testing.T) {
+		mockRepo := new(mocks.MockOAuthRepo)
+		s := service.NewOAuthService(mockRepo, nil, nil, nil, nil, oauthIssuer, 3600, 60)
+
+		invalid := map[string]struct {
+			client *domain.OAuthClient
+			public bool
+		}{
+			"No redirect uri":            {&domain.OAuthClient{GrantTypes: []string{domain.AuthorizationCodeGrant}}, false},
+			"Relative redirect uri":      {&domain.OAuthClient{GrantTypes: []string{domain.AuthorizationCodeGrant}, RedirectURIs: []string{"/callback"}}, false},
+			"Public client credentials":  {&domain.OAuthClient{GrantTypes: []string{domain.ClientCredentialsGrant}}, true},
+			"Unsupported grant type":     {&domain.OAuthClient{GrantTypes: []string{"password"}}, false},
+			"Redirect uri with fragment": {&domain.OAuthClient{GrantTypes: []string{domain.AuthorizationCodeGrant}, RedirectURIs: []string{oauthRedirect + "#a"}}, false},
+		}
+
+		for name, tc := range invalid {
+			_, err := s.RegisterClient(context.Background(), tc.client, tc.public)
+			assert.Equal(t, http.StatusBadRequest, domain.Status(err), name)
+		}
+		mockRepo.AssertNotCalled(t, "CreateClient", mock.Anything, mock.Anything)
+	})
+}
+
+func TestAuthenticateClient(t *testing.T) {
+	mockRepo := new(mocks.MockOAuthRepo)
+	mockRepo.On("FindClient", mock.Anything, "aWebClient").Return(webClient(), nil)
+	mockRepo.On("FindClient", mock.Anything, "aPublicClient").Return(&domain.OAuthClient{ClientID: "aPublicClient"}, nil)
+	mockRepo.On("FindClient", mock.Anything, "unknown").Return(nil, domain.NewNotFound("client_id", "unknown"))
+	s := service.NewOAuthService(mockRepo, nil, nil, nil, nil, oauthIssuer, 3600, 60)
+	ctx := context.Background()
+
+	client, err := s.AuthenticateClient(ctx, "aWebClient", "aClientSecret")
+	assert.NoError(t, err)
+	assert.Equal(t, "aWebClient", client.ClientID)
+
+	client, err = s.AuthenticateClient(ctx, "aPublicClient", "")
+	assert.NoError(t, err)
+	assert.Equal(t, "aPublicClient", client.ClientID)
+
+	for _, creds := range [][2]string{{"aWebClient", "wrong"}, {"aWebClient", ""}, {"aPublicClient", "aSecret"}, {"unknown", ""}, {"", ""}} {
+		_, err := s.AuthenticateClient(ctx, creds[0], creds[1])
+		assert.Equal(t, domain.NewOAuthError("invalid_client", err.(*domain.OAuthError).Description), err)
+		assert.Equal(t, http.StatusUnauthorized, err.(*domain.OAuthError).Status())
+	}
+}
+
+func TestAuthorize(t *testing.T) {
+	uid, _ := uuid.NewRandom()
+	ctx := context.Background()
+
+	t.Run("Consent required", func(t *testing.T) {
+		mockRepo := new(mocks.MockOAuthRepo)
+		mockRepo.On("FindClient", mock.Anything, "aWebClient").Return(webClient(), nil)
+		mockRepo.On("FindConsent", mock.Anything, uid, "aWebClient").Return(nil, domain.NewNotFound("consent", "aWebClient"))
+		s := service.NewOAuthService(mockRepo, nil, nil, nil, nil, oauthIssuer, 3600, 60)
+
+		res, err := s.Authorize(ctx, uid, authorizeRequest())
+
+		assert.NoError(t, err)
+		assert.Equal(t, &domain.AuthorizeResponse{ConsentRequired: true, ClientName: "A web app", Scopes: []string{"openid", "email"}}, res)
+	})
+
+	t.Run("Consent for fewer scopes", func(t *testing.T) {
+		mockRepo := new(mocks.MockOAuthRepo)
+		mockRepo.On("FindClient", mock.Anything, "aWebClient").Return(webClient(), nil)
+		mockRepo.On("FindConsent", mock.Anything, uid, "aWebClient").Return(&domain.OAuthConsent{Scopes: []string{"openid"}}, nil)
+		s := service.NewOAuthService(mockRepo, nil, nil, nil, nil, oauthIssuer, 3600, 60)
+
+		res, err := s.Authorize(ctx, uid, authorizeRequest())
+
+		assert.NoError(t, err)
+		assert.True(t, res.ConsentRequired)
+	})
+
+	t.Run("Already consented", func(t *testing.T) {
+		mockRepo := new(mocks.MockOAuthRepo)
+		mockRepo.On("FindClient", mock.Anything, "aWebClient").Return(webClient(), nil)
+		mockRepo.On("FindConsent", mock.Anything, uid, "aWebClient").Return(&domain.OAuthConsent{Scopes: []string{"openid", "email", "profile"}}, nil)
+
+		var savedHash string
+		var saved *domain.AuthorizationCode
+		mockCodes := new(mocks.MockAuthorizationCodeRepo)
+		mockCodes.On("SaveCode", mock.Anything, mock.AnythingOfType("string"), mock.AnythingOfType("*domain.AuthorizationCode"), time.Minute).
+			Run(func(args mock.Arguments) {
+				savedHash = args.Get(1).(string)
+				saved = args.Get(2).(*domain.AuthorizationCode)
+			}).Return(nil)
+		s := service.NewOAuthService(mockRepo, mockCodes, nil, nil, nil, oauthIssuer, 3600, 60)
+
+		res, err := s.Authorize(ctx, uid, authorizeRequest())
+		assert.NoError(t, err)
+
+		redirect, _ := url.Parse(res.RedirectTo)
+		code := redirect.Query().Get("code")
+		assert.Equal(t, "aState", redirect.Query().Get("state"))
+		assert.Equal(t, oauthRedirect, redirect.Scheme+"://"+redirect.Host+redirect.Path)
+
+		// only the hash of the code is stored
+		assert.Equal(t, crypto.HashToken(code), savedHash)
+		assert.Equal(t, &domain.AuthorizationCode{
+			ClientID:      "aWebClient",
+			AccountUID:    uid,
+			RedirectURI:   oauthRedirect,
+			Scope:         "openid email",
+			CodeChallenge: codeChallenge(codeVerifier),
+			Nonce:         "aNonce",
+		}, saved)
+	})
+
+	t.Run("Errors not redirected", func(t *testing.T) {
+		mockRepo := new(mocks.MockOAuthRepo)
+		mockRepo.On("FindClient", mock.Anything, "aWebClient").Return(webClient(), nil)
+		mockRepo.On("FindClient", mock.Anything, "unknown").Return(nil, domain.NewNotFound("client_id", "unknown"))
+		s := service.NewOAuthService(mockRepo, nil, nil, nil, nil, oauthIssuer, 3600, 60)
+
+		req := authorizeRequest()
+		req.ClientID = "unknown"
+		_, err := s.Authorize(ctx, uid, req)
+		assert.Equal(t, http.StatusBadRequest, domain.Status(err))
+
+		// an open redirector otherwise
+		req = authorizeRequest()
+		req.RedirectURI = "https://evil.example.com/callback"
+		_, err = s.Authorize(ctx, uid, req)
+		assert.Equal(t, http.StatusBadRequest, domain.Status(err))
+	})
+
+	t.Run("Errors redirected", func(t *testing.T) {
+		mockRepo := new(mocks.MockOAuthRepo)
+		mockRepo.On("FindClient", mock.Anything, "aWebClient").Return(webClient(), nil)
+		s := service.NewOAuthService(mockRepo, nil, nil, nil, nil, oauthIssuer, 3600, 60)
+
+		cases := []struct {
+			name  string
+			edit  func(req *domain.AuthorizeRequest)
+			error string
+		}{
+			{"No PKCE", func(req *domain.AuthorizeRequest) { req.CodeChallenge = "" }, "invalid_request"},
+			{"Plain PKCE", func(req *domain.AuthorizeRequest) { req.CodeChallengeMethod = "plain" }, "invalid_request"},
+			{"Implicit flow", func(req *domain.AuthorizeRequest) { req.ResponseType = "token" }, "unsupported_response_type"},
+			{"Scope not allowed", func(req *domain.AuthorizeRequest) { req.Scope = "openid admin" }, "invalid_scope"},
+			{"No scope", func(req *domain.AuthorizeRequest) { req.Scope = "" }, "invalid_scope"},
+		}
+
+		for _, tc := range cases {
+			req := authorizeRequest()
+			tc.edit(req)
+
+			res, err := s.Authorize(ctx, uid, req)
+			assert.NoError(t, err, tc.name)
+
+			redirect, _ := url.Parse(res.RedirectTo)
+			assert.Equal(t, tc.error, redirect.Query().Get("error"), tc.name)
+			assert.Equal(t, "aState", redirect.Query().Get("state"), tc.name)
+			assert.Empty(t, redirect.Query().Get("code"), tc.name)
+		}
+		mockRepo.AssertNotCalled(t, "FindConsent", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestConsent(t *testing.T) {
+	uid, _ := uuid.NewRandom()
+	ctx := context.Background()
+
+	t.Run("Approved", func(t *testing.T) {
+		mockRepo := new(mocks.MockOAuthRepo)
+		mockRepo.On("FindClient", mock.Anything, "aWebClient").Return(webClient(), nil)
+		mockRepo.On("FindConsent", mock.Anything, uid, "aWebClient").Return(&domain.OAuthConsent{Scopes: []string{"profile"}}, nil)
+		mockRepo.On("SaveConsent", mock.Anything, &domain.OAuthConsent{AccountUID: uid, ClientID: "aWebClient", Scopes: []string{"openid", "email", "profile"}}).Return(nil)
+
+		mockCodes := new(mocks.MockAuthorizationCodeRepo)
+		mockCodes.On("SaveCode", mock.Anything, mock.AnythingOfType("string"), mock.AnythingOfType("*domain.AuthorizationCode"), time.Minute).Return(nil)
+		s := service.NewOAuthService(mockRepo, mockCodes, nil, nil, nil, oauthIssuer, 3600, 60)
+
+		res, err := s.Consent(ctx, uid, authorizeRequest(), true)
+		assert.NoError(t, err)
+
+		redirect, _ := url.Parse(res.RedirectTo)
+		assert.NotEmpty(t, redirect.Query().Get("code"))
+		mockRepo.AssertExpectations(t)
+		mockCodes.AssertExpectations(t)
+	})
+
+	t.Run("Denied", func(t *testing.T) {
+		mockRepo := new(mocks.MockOAuthRepo)
+		mockRepo.On("FindClient", mock.Anything, "aWebClient").Return(webClient(), nil)
+		mockCodes := new(mocks.MockAuthorizationCodeRepo)
+		s := service.NewOAuthService(mockRepo, mockCodes, nil, nil, nil, oauthIssuer, 3600, 60)
+
+		res, err := s.Consent(ctx, uid, authorizeRequest(), false)
+		assert.NoError(t, err)
+
+		redirect, _ := url.Parse(res.RedirectTo)
+		assert.Equal(t, "access_denied", redirect.Query().Get("error"))
+		assert.Equal(t, "aState", redirect.Query().Get("state"))
+		mockRepo.AssertNotCalled(t, "SaveConsent", mock.Anything, mock.Anything)
+		mockCodes.AssertNotCalled(t, "SaveCode", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestExchangeAuthorizationCode(t *testing.T) {
+	uid, _ := uuid.NewRandom()
+	account := &domain.Account{
+		UID:             uid,
+		Email:           "whuangz@gmail.com",
+		Name:            "William",
+		EmailVerifiedAt: sql.NullTime{Time: time.Now(), Valid: true},
+	}
+	ctx := context.Background()
+	keys := oauthKeys()
+
+	newCode := func() *domain.AuthorizationCode {
+		return &domain.AuthorizationCode{
+			ClientID:      "aWebClient",
+			AccountUID:    uid,
+			RedirectURI:   oauthRedirect,
+			Scope:         "openid email",
+			CodeChallenge: codeChallenge(codeVerifier),
+			Nonce:         "aNonce",
+		}
+	}
+	tokenRequest := func() *domain.TokenRequest {
+		return &domain.TokenRequest{
+			GrantType:    domain.AuthorizationCodeGrant,
+			Code:         "aCode",
+			RedirectURI:  oauthRedirect,
+			CodeVerifier: codeVerifier,
+		}
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		mockCodes := new(mocks.MockAuthorizationCodeRepo)
+		mockCodes.On("ConsumeCode", mock.Anything, crypto.HashToken("aCode")).Return(newCode(), nil)
+		mockAccRepo := new(mocks.MockAccountRepo)
+		mockAccRepo.On("FindByID", mock.Anything, uid).Return(account, nil)
+		s := service.NewOAuthService(new(mocks.MockOAuthRepo), mockCodes, mockAccRepo, repository.NewMemoryTokenDenylist(), keys, oauthIssuer, 3600, 60)
+
+		tokens, err := s.Exchange(ctx, webClient(), tokenRequest())
+		assert.NoError(t, err)
+		assert.Equal(t, "Bearer", tokens.TokenType)
+		assert.Equal(t, int64(3600), tokens.ExpiresIn)
+		assert.Equal(t, "openid email", tokens.Scope)
+
+		accessClaims, err := jwtHelper.ValidateOAuthAccessToken(tokens.AccessToken, keys)
+		assert.NoError(t, err)
+		assert.Equal(t, uid.String(), accessClaims.Subject)
+		assert.Equal(t, "aWebClient", accessClaims.ClientID)
+		assert.Equal(t, oauthIssuer, accessClaims.Issuer)
+
+		// the ID token is signed with the signing key of the api
+		idClaims := &jwtHelper.IDTokenClaims{}
+		idToken, err := jwt.ParseWithClaims(tokens.IDToken, idClaims, func(token *jwt.Token) (interface{}, error) {
+			return keys.SigningKey().Public, nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, keys.SigningKey().ID, idToken.Header["kid"])
+		assert.Equal(t, "aWebClient", idClaims.Audience)
+		assert.Equal(t, uid.String(), idClaims.Subject)
+		assert.Equal(t, "aNonce", idClaims.Nonce)
+		assert.Equal(t, jwtHelper.AccessTokenHash(tokens.AccessToken), idClaims.AccessTokenHash)
+		assert.Equal(t, "whuangz@gmail.com", idClaims.Email)
+		assert.True(t, *idClaims.EmailVerified)
+		// the profile scope wasn't granted
+		assert.Empty(t, idClaims.Name)
+
+		// an ID token isn't an access token
+		_, err = jwtHelper.ValidateOAuthAccessToken(tokens.IDToken, keys)
+		assert.Error(t, err)
+	})
+
+	t.Run("Without openid scope", func(t *testing.T) {
+		code := newCode()
+		code.Scope = "posts:read"
+		mockCodes := new(mocks.MockAuthorizationCodeRepo)
+		mockCodes.On("ConsumeCode", mock.Anything, crypto.HashToken("aCode")).Return(code, nil)
+		mockAccRepo := new(mocks.MockAccountRepo)
+		mockAccRepo.On("FindByID", mock.Anything, uid).Return(account, nil)
+		s := service.NewOAuthService(new(mocks.MockOAuthRepo), mockCodes, mockAccRepo, repository.NewMemoryTokenDenylist(), keys, oauthIssuer, 3600, 60)
+
+		tokens, err := s.Exchange(ctx, webClient(), tokenRequest())
+		assert.NoError(t, err)
+		assert.NotEmpty(t, tokens.AccessToken)
+		assert.Empty(t, tokens.IDToken)
+	})
+
+	t.Run("Invalid grants", func(t *testing.T) {
+		wrongVerifier := tokenRequest()
+		wrongVerifier.CodeVerifier = codeVerifier[1:] + "x"
+		noVerifier := tokenRequest()
+		noVerifier.CodeVerifier = ""
+		wrongRedirect := tokenRequest()
+		wrongRedirect.RedirectURI = "https://app.example.com/other"
+
+		for name, req := range map[string]*domain.TokenRequest{
+			"Wrong verifier": wrongVerifier,
+			"No verifier":    noVerifier,
+			"Wrong redirect": wrongRedirect,
+		} {
+			mockCodes := new(mocks.MockAuthorizationCodeRepo)
+			mockCodes.On("ConsumeCode", mock.Anything, crypto.HashToken("aCode")).Return(newCode(), nil)
+			mockAccRepo := new(mocks.MockAccountRepo)
+			s := service.NewOAuthService(new(mocks.MockOAuthRepo), mockCodes, mockAccRepo, repository.NewMemoryTokenDenylist(), keys, oauthIssuer, 3600, 60)
+
+			_, err := s.Exchange(ctx, webClient(), req)
+			assert.Equal(t, "invalid_grant", err.(*domain.OAuthError).Code, name)
+			mockAccRepo.AssertNotCalled(t, "FindByID", mock.Anything, mock.Anything)
+		}
+	})
+
+	t.Run("Code of another client", func(t *testing.T) {
+		mockCodes := new(mocks.MockAuthorizationCodeRepo)
+		mockCodes.On("ConsumeCode", mock.Anything, crypto.HashToken("aCode")).Return(newCode(), nil)
+		s := service.NewOAuthService(new(mocks.MockOAuthRepo), mockCodes, new(mocks.MockAccountRepo), repository.NewMemoryTokenDenylist(), keys, oauthIssuer, 3600, 60)
+
+		other := webClient()
+		other.ClientID = "anotherClient"
+		_, err := s.Exchange(ctx, other, tokenRequest())
+		assert.Equal(t, "invalid_grant", err.(*domain.OAuthError).Code)
+	})
+
+	t.Run("Used code", func(t *testing.T) {
+		mockCodes := new(mocks.MockAuthorizationCodeRepo)
+		mockCodes.On("ConsumeCode", mock.Anything, crypto.HashToken("aCode")).Return(nil, domain.NewNotFound("authorization", "code"))
+		s := service.NewOAuthService(new(mocks.MockOAuthRepo), mockCodes, new(mocks.MockAccountRepo), repository.NewMemoryTokenDenylist(), keys, oauthIssuer, 3600, 60)
+
+		_, err := s.Exchange(ctx, webClient(), tokenRequest())
+		assert.Equal(t, "invalid_grant", err.(*domain.OAuthError).Code)
+	})
+
+	t.Run("Unsupported grants", func(t *testing.T) {
+		s := service.NewOAuthService(new(mocks.MockOAuthRepo), nil, nil, nil, keys, oauthIssuer, 3600, 60)
+
+		_, err := s.Exchange(ctx, webClient(), &domain.TokenRequest{GrantType: "password"})
+		assert.Equal(t, "unsupported_grant_type", err.(*domain.OAuthError).Code)
+
+		client := webClient()
+		client.GrantTypes = []string{domain.ClientCredentialsGrant}
+		_, err = s.Exchange(ctx, client, tokenRequest())
+		assert.Equal(t, "unauthorized_client", err.(*domain.OAuthError).Code)
+	})
+}
+
+func TestExchangeClientCredentials(t *testing.T) {
+	ctx := context.Background()
+	keys := oauthKeys()
+	s := service.NewOAuthService(new(mocks.MockOAuthRepo), nil, nil, repository.NewMemoryTokenDenylist(), keys, oauthIssuer, 3600, 60)
+
+	t.Run("Default scopes", func(t *testing.T) {
+		tokens, err := s.Exchange(ctx, webClient(), &domain.TokenRequest{GrantType: domain.ClientCredentialsGrant})
+		assert.NoError(t, err)
+		// the OpenID Connect scopes are about accounts
+		assert.Equal(t, "posts:read", tokens.Scope)
+		assert.Empty(t, tokens.IDToken)
+
+		claims, err := jwtHelper.ValidateOAuthAccessToken(tokens.AccessToken, keys)
+		assert.NoError(t, err)
+		assert.Equal(t, "aWebClient", claims.Subject)
+	})
+
+	t.Run("Invalid scopes", func(t *testing.T) {
+		for _, scope := range []string{"openid", "posts:write"} {
+			_, err := s.Exchange(ctx, webClient(), &domain.TokenRequest{GrantType: domain.ClientCredentialsGrant, Scope: scope})
+			assert.Equal(t, "invalid_scope", err.(*domain.OAuthError).Code)
+		}
+	})
+
+	t.Run("Public client", func(t *testing.T) {
+		client := webClient()
+		client.SecretHash = ""
+		_, err := s.Exchange(ctx, client, &domain.TokenRequest{GrantType: domain.ClientCredentialsGrant})
+		assert.Equal(t, "unauthorized_client", err.(*domain.OAuthError).Code)
+	})
+}
+
+func TestIntrospectAndRevoke(t *testing.T) {
+	uid, _ := uuid.NewRandom()
+	ctx := context.Background()
+	keys := oauthKeys()
+	denylist := repository.NewMemoryTokenDenylist()
+	s := service.NewOAuthService(new(mocks.MockOAuthRepo), nil, nil, denylist, keys, oauthIssuer, 3600, 60)
+
+	accessToken, _ := jwtHelper.GenerateOAuthAccessToken(oauthIssuer, uid.String(), "aWebClient", "openid", keys.SigningKey(), 3600)
+
+	t.Run("Active", func(t *testing.T) {
+		introspection, err := s.Introspect(ctx, accessToken)
+
+		assert.NoError(t, err)
+		assert.True(t, introspection.Active)
+		assert.Equal(t, uid.String(), introspection.Subject)
+		assert.Equal(t, "aWebClient", introspection.ClientID)
+		assert.Equal(t, "openid", introspection.Scope)
+	})
+
+	t.Run("Not an access token", func(t *testing.T) {
+		a := &domain.Account{UID: uid}
+		accountToken, _ := jwtHelper.GenerateAccessToken(a, keys.SigningKey(), 3600)
+		otherIssuer, _ := jwtHelper.GenerateOAuthAccessToken("https://other.example.com", uid.String(), "aWebClient", "openid", keys.SigningKey(), 3600)
+
+		for _, token := range []string{"notAToken", accountToken, otherIssuer} {
+			introspection, err := s.Introspect(ctx, token)
+			assert.NoError(t, err)
+			assert.Equal(t, &domain.Introspection{Active: false}, introspection)
+		}
+	})
+
+	t.Run("Revoked by another client", func(t *testing.T) {
+		other := webClient()
+		other.ClientID = "anotherClient"
+
+		err := s.Revoke(ctx, other, accessToken)
+		assert.Equal(t, "unauthorized_client", err.(*domain.OAuthError).Code)
+
+		introspection, _ := s.Introspect(ctx, accessToken)
+		assert.True(t, introspection.Active)
+	})
+
+	t.Run("Revoked", func(t *testing.T) {
+		err := s.Revoke(ctx, webClient(), accessToken)
+		assert.NoError(t, err)
+
+		introspection, _ := s.Introspect(ctx, accessToken)
+		assert.False(t, introspection.Active)
+
+		// revoking again, or an invalid token, succeeds
+		assert.NoError(t, s.Revoke(ctx, webClient(), accessToken))
+		assert.NoError(t, s.Revoke(ctx, webClient(), "notAToken"))
+	})
+
+	t.Run("Account signed out everywhere", func(t *testing.T) {
+		signedOut, _ := uuid.NewRandom()
+		token, _ := jwtHelper.GenerateOAuthAccessToken(oauthIssuer, signedOut.String(), "aWebClient", "openid", keys.SigningKey(), 3600)
+		denylist.SetRevokedBefore(ctx, signedOut.String(), time.Now().Add(time.Second), time.Hour)
+
+		introspection, _ := s.Introspect(ctx, token)
+		assert.False(t, introspection.Active)
+	})
+}
+
+func TestUserInfo(t *testing.T) {
+	uid, _ := uuid.NewRandom()
+	account := &domain.Account{
+		UID:      uid,
+		Email:    "whuangz@gmail.com",
+		Name:     "William",
+		ImageUrl: "https://cdn.example.com/william.png",
+	}
+	ctx := context.Background()
+	keys := oauthKeys()
+
+	mockAccRepo := new(mocks.MockAccountRepo)
+	mockAccRepo.On("FindByID", mock.Anything, uid).Return(account, nil)
+	s := service.NewOAuthService(new(mocks.MockOAuthRepo), nil, mockAccRepo, repository.NewMemoryTokenDenylist(), keys, oauthIssuer, 3600, 60)
+
+	t.Run("Claims of the scopes", func(t *testing.T) {
+		profile, _ := jwtHelper.GenerateOAuthAccessToken(oauthIssuer, uid.String(), "aWebClient", "openid profile", keys.SigningKey(), 3600)
+
+		info, err := s.UserInfo(ctx, profile)
+		assert.NoError(t, err)
+		assert.Equal(t, &domain.UserInfo{
+			Subject: uid.String(),
+			Name:    "William",
+			Picture: "https://cdn.example.com/william.png",
+		}, info)
+
+		email, _ := jwtHelper.GenerateOAuthAccessToken(oauthIssuer, uid.String(), "aWebClient", "openid email", keys.SigningKey(), 3600)
+		verified := false
+
+		info, err = s.UserInfo(ctx, email)
+		assert.NoError(t, err)
+		assert.Equal(t, &domain.UserInfo{
+			Subject:       uid.String(),
+			Email:         "whuangz@gmail.com",
+			EmailVerified: &verified,
+		}, info)
+	})
+
+	t.Run("Without openid scope", func(t *testing.T) {
+		token, _ := jwtHelper.GenerateOAuthAccessToken(oauthIssuer, uid.String(), "aWebClient", "posts:read", keys.SigningKey(), 3600)
+
+		_, err := s.UserInfo(ctx, token)
+		assert.Equal(t, http.StatusForbidden, err.(*domain.OAuthError).Status())
+	})
+
+	t.Run("Invalid token", func(t *testing.T) {
+		_, err := s.UserInfo(ctx, "notAToken")
+		assert.Equal(t, "invalid_token", err.(*domain.OAuthError).Code)
+	})
+}
@@ -0,0 +1,101 @@
+package service_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/whuangz/go-example/go-api/domain"
+	mocks "github.com/whuangz/go-example/go-api/mocks/post"
+	"github.com/whuangz/go-example/go-api/repository"
+	"github.com/whuangz/go-example/go-api/service"
+)
+
+func TestRevisions(t *testing.T) {
+	var id int32 = 1
+	first := domain.PostRevision{PostID: id, Number: 1, Title: "Hello", Content: "one\ntwo\nthree", AccountUID: postOwner.UID}
+	second := domain.PostRevision{PostID: id, Number: 2, Title: "Hello", Content: "one\n2\nthree", AccountUID: postOwner.UID}
+
+	newService := func() (domain.PostService, *mocks.MockPostRepo) {
+		mockRepo := new(mocks.MockPostRepo)
+		mockRepo.On("FindByID", mock.Anything, id).Return(ownedPost(id), nil)
+		mockRepo.On("FindRevision", mock.Anything, id, int32(1)).Return(first, nil)
+		mockRepo.On("FindRevision", mock.Anything, id, int32(2)).Return(second, nil)
+		mockRepo.On("FindRevision", mock.Anything, id, int32(3)).Return(domain.PostRevision{}, domain.NewNotFound("revision", "3"))
+		return service.NewPostService(mockRepo, nil, nil, repository.NewMemorySearchIndex(), nil), mockRepo
+	}
+
+	t.Run("List", func(t *testing.T) {
+		ps, mockRepo := newService()
+		revisions := []domain.PostRevision{second, first}
+		mockRepo.On("FindRevisions", mock.Anything, id).Return(revisions, nil)
+
+		found, err := ps.Revisions(context.TODO(), postOwner, id)
+
+		assert.NoError(t, err)
+		assert.Equal(t, revisions, found)
+	})
+
+	t.Run("Not the owner", func(t *testing.T) {
+		ps, mockRepo := newService()
+		other := &domain.Account{UID: uuid.New(), Roles: []string{domain.AuthorRole}}
+
+		_, err := ps.Revisions(context.TODO(), other, id)
+
+		assert.Equal(t, http.StatusForbidden, domain.Status(err))
+		mockRepo.AssertNotCalled(t, "FindRevisions", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Diff", func(t *testing.T) {
+		ps, _ := newService()
+
+		diff, err := ps.Diff(context.TODO(), postOwner, id, 1, 2)
+
+		assert.NoError(t, err)
+		assert.Equal(t, &domain.PostDiff{
+			From:  1,
+			To:    2,
+			Title: []domain.DiffLine{{Op: domain.DiffEqual, Text: "Hello"}},
+			Content: []domain.DiffLine{
+				{Op: domain.DiffEqual, Text: "one"},
+				{Op: domain.DiffDelete, Text: "two"},
+				{Op: domain.DiffInsert, Text: "2"},
+				{Op: domain.DiffEqual, Text: "three"},
+			},
+		}, diff)
+	})
+
+	t.Run("Diff with an unknown revision", func(t *testing.T) {
+		ps, _ := newService()
+
+		_, err := ps.Diff(context.TODO(), postOwner, id, 1, 3)
+
+		assert.Equal(t, http.StatusNotFound, domain.Status(err))
+	})
+
+	t.Run("Restore", func(t *testing.T) {
+		ps, mockRepo := newService()
+		editor := &domain.Account{UID: uuid.New(), Roles: []string{domain.EditorRole}}
+		mockRepo.On("Update", mock.Anything, id, mock.AnythingOfType("*domain.Post"), editor.UID).Return(nil).Once()
+
+		post, err := ps.Restore(context.TODO(), editor, id, 1, 0)
+
+		assert.NoError(t, err)
+		assert.Equal(t, first.Title, post.Title)
+		assert.Equal(t, first.Content, post.Content)
+		assert.Equal(t, domain.PublishedStatus, post.Status)
+		mockRepo.AssertCalled(t, "Update", mock.Anything, id, post, editor.UID)
+	})
+
+	t.Run("Restore an unknown revision", func(t *testing.T) {
+		ps, mockRepo := newService()
+
+		_, err := ps.Restore(context.TODO(), postOwner, id, 3, 0)
+
+		assert.Equal(t, http.StatusNotFound, domain.Status(err))
+		mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
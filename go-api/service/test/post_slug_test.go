@@ -0,0 +1,94 @@
+package service_test
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/whuangz/go-example/go-api/domain"
+	mocks "github.com/whuangz/go-example/go-api/mocks/post"
+	"github.com/whuangz/go-example/go-api/repository"
+	"github.com/whuangz/go-example/go-api/service"
+)
+
+func TestSaveSlug(t *testing.T) {
+	author := &domain.Author{ID: 1, AccountUID: postOwner.UID}
+
+	titles := map[string]string{
+		"Straße Café, 2021":         "strasse-cafe-2021",
+		"Привет мир":                "privet-mir",
+		"!!!":                       "post",
+		strings.Repeat("word ", 30): strings.TrimSuffix(strings.Repeat("word-", 16), "-"),
+	}
+
+	for title, want := range titles {
+		mockRepo := new(mocks.MockPostRepo)
+		mockAuthorRepo := new(mocks.MockAuthorRepo)
+		mockAuthorRepo.On("FindOrCreateByAccount", mock.Anything, postOwner).Return(author, nil)
+		mockRepo.On("Save", mock.Anything, mock.AnythingOfType("*domain.Post")).Return(nil)
+
+		ps := service.NewPostService(mockRepo, mockAuthorRepo, nil, repository.NewMemorySearchIndex(), nil)
+
+		post := &domain.Post{Title: title, Content: "Content"}
+		assert.NoError(t, ps.Save(context.TODO(), postOwner, post))
+		assert.Equal(t, want, post.Slug, title)
+	}
+}
+
+func TestUpdateSlug(t *testing.T) {
+	var id int32 = 1
+	current := ownedPost(id)
+	current.Title = "Hello World"
+	current.Slug = "hello-world-2"
+
+	newService := func() domain.PostService {
+		mockRepo := new(mocks.MockPostRepo)
+		mockRepo.On("FindByID", mock.Anything, id).Return(current, nil)
+		mockRepo.On("Update", mock.Anything, id, mock.AnythingOfType("*domain.Post"), postOwner.UID).Return(nil)
+		return service.NewPostService(mockRepo, nil, nil, repository.NewMemorySearchIndex(), nil)
+	}
+
+	t.Run("Same title", func(t *testing.T) {
+		post := &domain.Post{Title: "Hello World", Content: "Changed"}
+		assert.NoError(t, newService().Update(context.TODO(), postOwner, id, post))
+		assert.Equal(t, "hello-world-2", post.Slug)
+	})
+
+	t.Run("Renamed", func(t *testing.T) {
+		post := &domain.Post{Title: "Hello Gophers", Content: "Changed"}
+		assert.NoError(t, newService().Update(context.TODO(), postOwner, id, post))
+		assert.Equal(t, "hello-gophers", post.Slug)
+	})
+}
+
+func TestFindBySlug(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		post := ownedPost(1)
+		post.Slug = "hello-world"
+
+		mockRepo := new(mocks.MockPostRepo)
+		mockRepo.On("FindBySlug", mock.Anything, "hello-world").Return(post, nil)
+
+		ps := service.NewPostService(mockRepo, nil, nil, repository.NewMemorySearchIndex(), nil)
+		found, err := ps.FindBySlug(context.TODO(), "hello-world")
+
+		assert.NoError(t, err)
+		assert.Equal(t, post, found)
+	})
+
+	t.Run("Draft", func(t *testing.T) {
+		post := ownedPost(1)
+		post.Status = domain.DraftStatus
+
+		mockRepo := new(mocks.MockPostRepo)
+		mockRepo.On("FindBySlug", mock.Anything, "hello-world").Return(post, nil)
+
+		ps := service.NewPostService(mockRepo, nil, nil, repository.NewMemorySearchIndex(), nil)
+		_, err := ps.FindBySlug(context.TODO(), "hello-world")
+
+		assert.Equal(t, http.StatusNotFound, domain.Status(err))
+	})
+}
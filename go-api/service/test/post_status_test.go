@@ -0,0 +1,212 @@
+package service_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/whuangz/go-example/go-api/domain"
+	mocks "github.com/whuangz/go-example/go-api/mocks/post"
+	"github.com/whuangz/go-example/go-api/repository"
+	"github.com/whuangz/go-example/go-api/service"
+)
+
+func TestSaveStatus(t *testing.T) {
+	author := &domain.Author{ID: 1, AccountUID: postOwner.UID}
+	inAnHour := time.Now().Add(time.Hour)
+	anHourAgo := time.Now().Add(-time.Hour)
+
+	newService := func() (domain.PostService, *mocks.MockPostRepo) {
+		mockRepo := new(mocks.MockPostRepo)
+		mockAuthorRepo := new(mocks.MockAuthorRepo)
+		mockAuthorRepo.On("FindOrCreateByAccount", mock.Anything, postOwner).Return(author, nil)
+		mockRepo.On("Save", mock.Anything, mock.AnythingOfType("*domain.Post")).Return(nil)
+		mockRepo.On("FindByIDs", mock.Anything, mock.Anything).Return([]domain.Post{}, nil)
+		return service.NewPostService(mockRepo, mockAuthorRepo, nil, repository.NewMemorySearchIndex(), nil), mockRepo
+	}
+
+	t.Run("Published by default", func(t *testing.T) {
+		ps, _ := newService()
+		post := &domain.Post{Title: "Go", Content: "Go"}
+
+		assert.NoError(t, ps.Save(context.TODO(), postOwner, post))
+		assert.Equal(t, domain.PublishedStatus, post.Status)
+		assert.NotNil(t, post.PublishedAt)
+		assert.Nil(t, post.PublishAt)
+	})
+
+	t.Run("Draft", func(t *testing.T) {
+		ps, _ := newService()
+		post := &domain.Post{Title: "Go", Content: "Go", Status: domain.DraftStatus}
+
+		assert.NoError(t, ps.Save(context.TODO(), postOwner, post))
+		assert.Nil(t, post.PublishedAt)
+
+		// drafts can't be searched
+		page, err := ps.Search(context.TODO(), domain.SearchQuery{Query: "go"})
+		assert.NoError(t, err)
+		assert.Equal(t, 0, page.Total)
+	})
+
+	t.Run("Scheduled by publish_at", func(t *testing.T) {
+		ps, _ := newService()
+		post := &domain.Post{Title: "Go", Content: "Go", PublishAt: &inAnHour}
+
+		assert.NoError(t, ps.Save(context.TODO(), postOwner, post))
+		assert.Equal(t, domain.ScheduledStatus, post.Status)
+		assert.Equal(t, &inAnHour, post.PublishAt)
+		assert.Nil(t, post.PublishedAt)
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		ps, mockRepo := newService()
+
+		posts := map[string]*domain.Post{
+			"unknown status":     {Title: "Go", Content: "Go", Status: "hidden"},
+			"scheduled no time":  {Title: "Go", Content: "Go", Status: domain.ScheduledStatus},
+			"scheduled the past": {Title: "Go", Content: "Go", Status: domain.ScheduledStatus, PublishAt: &anHourAgo},
+		}
+
+		for name, post := range posts {
+			err := ps.Save(context.TODO(), postOwner, post)
+			assert.Equal(t, http.StatusBadRequest, domain.Status(err), name)
+		}
+		mockRepo.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+	})
+}
+
+func TestUpdateStatus(t *testing.T) {
+	var id int32 = 1
+	inAnHour := time.Now().Add(time.Hour)
+	publishedAt := time.Date(2021, 7, 1, 0, 0, 0, 0, time.UTC)
+
+	newService := func(current domain.Post) domain.PostService {
+		mockRepo := new(mocks.MockPostRepo)
+		mockRepo.On("FindByID", mock.Anything, id).Return(current, nil)
+		mockRepo.On("Update", mock.Anything, id, mock.AnythingOfType("*domain.Post"), postOwner.UID).Return(nil)
+		return service.NewPostService(mockRepo, nil, nil, repository.NewMemorySearchIndex(), nil)
+	}
+
+	t.Run("Keeps the status", func(t *testing.T) {
+		current := ownedPost(id)
+		current.Status = domain.ScheduledStatus
+		current.PublishAt = &inAnHour
+
+		post := &domain.Post{Title: "Go", Content: "Go"}
+		assert.NoError(t, newService(current).Update(context.TODO(), postOwner, id, post))
+		assert.Equal(t, domain.ScheduledStatus, post.Status)
+		assert.Equal(t, &inAnHour, post.PublishAt)
+	})
+
+	t.Run("Publishes a draft", func(t *testing.T) {
+		current := ownedPost(id)
+		current.Status = domain.DraftStatus
+
+		post := &domain.Post{Title: "Go", Content: "Go", Status: domain.PublishedStatus}
+		assert.NoError(t, newService(current).Update(context.TODO(), postOwner, id, post))
+		assert.NotNil(t, post.PublishedAt)
+	})
+
+	t.Run("Archives a published post", func(t *testing.T) {
+		current := ownedPost(id)
+		current.PublishedAt = &publishedAt
+
+		post := &domain.Post{Title: "Go", Content: "Go", Status: domain.ArchivedStatus}
+		assert.NoError(t, newService(current).Update(context.TODO(), postOwner, id, post))
+		assert.Equal(t, &publishedAt, post.PublishedAt)
+	})
+}
+
+func TestFindByIDHidesUnpublished(t *testing.T) {
+	for _, status := range []string{domain.DraftStatus, domain.ScheduledStatus} {
+		post := ownedPost(1)
+		post.Status = status
+
+		mockRepo := new(mocks.MockPostRepo)
+		mockRepo.On("FindByID", mock.Anything, int32(1)).Return(post, nil)
+
+		ps := service.NewPostService(mockRepo, nil, nil, repository.NewMemorySearchIndex(), nil)
+		_, err := ps.FindByID(context.TODO(), nil, 1)
+
+		assert.Equal(t, http.StatusNotFound, domain.Status(err), status)
+	}
+}
+
+func TestFindByAccount(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		filter := domain.PostFilter{AccountUID: postOwner.UID, Status: domain.DraftStatus, Sort: domain.NewestFirst}
+		mockRepo := new(mocks.MockPostRepo)
+		mockRepo.On("FindAll", mock.Anything, filter, (*domain.PostCursor)(nil), domain.DefaultPostLimit+1).Return(postsBetween(2, 1), nil).Once()
+
+		ps := service.NewPostService(mockRepo, nil, nil, repository.NewMemorySearchIndex(), nil)
+		page, err := ps.FindByAccount(context.TODO(), postOwner, domain.PostQuery{
+			PostFilter: domain.PostFilter{Status: domain.DraftStatus},
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, postsBetween(2, 1), page.Data)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Unknown status", func(t *testing.T) {
+		mockRepo := new(mocks.MockPostRepo)
+
+		ps := service.NewPostService(mockRepo, nil, nil, repository.NewMemorySearchIndex(), nil)
+		_, err := ps.FindByAccount(context.TODO(), postOwner, domain.PostQuery{
+			PostFilter: domain.PostFilter{Status: "hidden"},
+		})
+
+		assert.Equal(t, http.StatusBadRequest, domain.Status(err))
+		mockRepo.AssertNotCalled(t, "FindAll", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestPublishDue(t *testing.T) {
+	published := domain.Post{ID: 4, Title: "Scheduled Go post", Content: "Go", Status: domain.PublishedStatus}
+
+	mockRepo := new(mocks.MockPostRepo)
+	mockRepo.On("PublishDue", mock.Anything, mock.AnythingOfType("time.Time")).Return([]int32{4}, nil).Once()
+	mockRepo.On("FindByIDs", mock.Anything, []int32{4}).Return([]domain.Post{published}, nil)
+
+	ps := service.NewPostService(mockRepo, nil, nil, repository.NewMemorySearchIndex(), nil)
+
+	count, err := ps.PublishDue(context.TODO())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	// published posts can be searched
+	page, err := ps.Search(context.TODO(), domain.SearchQuery{Query: "scheduled"})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, page.Total)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestPostScheduler(t *testing.T) {
+	t.Run("Holding the lock", func(t *testing.T) {
+		released := false
+		mockLocker := new(mocks.MockLocker)
+		mockLocker.On("Acquire", mock.Anything, "post_scheduler", time.Minute).Return(func() { released = true }, true, nil)
+		mockService := new(mocks.MockPostService)
+		mockService.On("PublishDue", mock.Anything).Return(2, nil).Once()
+
+		err := service.NewPostScheduler(mockService, mockLocker, time.Minute).Run(context.TODO())
+
+		assert.NoError(t, err)
+		assert.True(t, released)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("Held by another replica", func(t *testing.T) {
+		mockLocker := new(mocks.MockLocker)
+		mockLocker.On("Acquire", mock.Anything, "post_scheduler", time.Minute).Return(nil, false, nil)
+		mockService := new(mocks.MockPostService)
+
+		err := service.NewPostScheduler(mockService, mockLocker, time.Minute).Run(context.TODO())
+
+		assert.NoError(t, err)
+		mockService.AssertNotCalled(t, "PublishDue", mock.Anything)
+	})
+}
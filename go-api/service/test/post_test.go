@@ -3,62 +3,133 @@ package service_test
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"testing"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/whuangz/go-example/go-api/domain"
 	mocks "github.com/whuangz/go-example/go-api/mocks/post"
+	"github.com/whuangz/go-example/go-api/repository"
 	"github.com/whuangz/go-example/go-api/service"
 )
 
 func TestSave(t *testing.T) {
-	mockRepo := new(mocks.MockPostRepo)
+	account := &domain.Account{UID: uuid.New(), Email: "whuangz@gmail.com"}
+	author := &domain.Author{ID: 7, AccountUID: account.UID, Username: "whuangz", Email: "whuangz@gmail.com"}
 	mockPost := domain.Post{
 		Title:   "Test Mock 1",
 		Content: "Test Mock Desc",
 	}
 
 	t.Run("Success", func(t *testing.T) {
+		mockRepo := new(mocks.MockPostRepo)
+		mockAuthorRepo := new(mocks.MockAuthorRepo)
 
 		tempMockPost := mockPost
 
-		mockArgs := mock.Arguments{
-			mock.AnythingOfType("*context.emptyCtx"),
-			&tempMockPost,
-		}
-		mockRepo.On("Save", mockArgs...).Return(nil).Once()
+		mockAuthorRepo.On("FindOrCreateByAccount", mock.Anything, account).Return(author, nil)
+		mockRepo.On("Save", mock.Anything, &tempMockPost).Return(nil).Once()
 
-		ps := service.NewPostService(mockRepo)
+		ps := service.NewPostService(mockRepo, mockAuthorRepo, nil, repository.NewMemorySearchIndex(), nil)
 
 		ctx := context.TODO()
-		err := ps.Save(ctx, &tempMockPost)
+		err := ps.Save(ctx, account, &tempMockPost)
 		assert.NoError(t, err)
 
-		assert.Equal(t, tempMockPost.AuthorID, int32(1))
+		// published under the author of the account
+		assert.Equal(t, author.ID, tempMockPost.AuthorID)
+		assert.Equal(t, *author, tempMockPost.Author)
 		mockRepo.AssertExpectations(t)
+		mockAuthorRepo.AssertExpectations(t)
 	})
 
-	t.Run("Missing Param", func(t *testing.T) {
+	t.Run("Repository error", func(t *testing.T) {
+		mockRepo := new(mocks.MockPostRepo)
+		mockAuthorRepo := new(mocks.MockAuthorRepo)
 
 		tempMockPost := mockPost
 		tempMockPost.Title = ""
 
-		mockArgs := mock.Arguments{
-			mock.AnythingOfType("*context.emptyCtx"),
-			&tempMockPost,
-		}
-		mockRepo.On("Save", mockArgs...).Return(domain.NewBadRequest("missing title")).Once()
+		mockAuthorRepo.On("FindOrCreateByAccount", mock.Anything, account).Return(author, nil)
+		mockRepo.On("Save", mock.Anything, &tempMockPost).Return(domain.NewBadRequest("missing title")).Once()
 
-		ps := service.NewPostService(mockRepo)
+		ps := service.NewPostService(mockRepo, mockAuthorRepo, nil, repository.NewMemorySearchIndex(), nil)
 
 		ctx := context.TODO()
-		err := ps.Save(ctx, &tempMockPost)
+		err := ps.Save(ctx, account, &tempMockPost)
 		assert.Error(t, err)
 
 		mockRepo.AssertExpectations(t)
 	})
+
+	t.Run("Tags and category", func(t *testing.T) {
+		mockRepo := new(mocks.MockPostRepo)
+		mockAuthorRepo := new(mocks.MockAuthorRepo)
+		mockCategoryRepo := new(mocks.MockCategoryRepo)
+
+		tempMockPost := mockPost
+		tempMockPost.Tags = []domain.Tag{{Name: " Go "}, {Name: "go!"}, {Name: "Web Development"}, {Name: "--"}}
+		tempMockPost.CategoryID = 3
+		category := domain.Category{ID: 3, ParentID: 1, Name: "Backend", Slug: "backend"}
+
+		mockCategoryRepo.On("FindByID", mock.Anything, int32(3)).Return(category, nil)
+		mockAuthorRepo.On("FindOrCreateByAccount", mock.Anything, account).Return(author, nil)
+		mockRepo.On("Save", mock.Anything, &tempMockPost).Return(nil).Once()
+
+		ps := service.NewPostService(mockRepo, mockAuthorRepo, mockCategoryRepo, repository.NewMemorySearchIndex(), nil)
+
+		err := ps.Save(context.TODO(), account, &tempMockPost)
+		assert.NoError(t, err)
+
+		// tags sharing a slug are merged, tags without one dropped
+		assert.Equal(t, []domain.Tag{
+			{Name: "Go", Slug: "go"},
+			{Name: "Web Development", Slug: "web-development"},
+		}, tempMockPost.Tags)
+		assert.Equal(t, &category, tempMockPost.Category)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Invalid tags and category", func(t *testing.T) {
+		mockRepo := new(mocks.MockPostRepo)
+		mockCategoryRepo := new(mocks.MockCategoryRepo)
+		mockCategoryRepo.On("FindByID", mock.Anything, int32(404)).Return(domain.Category{}, domain.NewNotFound("category_id", "404"))
+
+		ps := service.NewPostService(mockRepo, new(mocks.MockAuthorRepo), mockCategoryRepo, repository.NewMemorySearchIndex(), nil)
+
+		unknownCategory := mockPost
+		unknownCategory.CategoryID = 404
+		err := ps.Save(context.TODO(), account, &unknownCategory)
+		assert.Equal(t, http.StatusBadRequest, domain.Status(err))
+
+		tooManyTags := mockPost
+		for i := 0; i <= domain.MaxPostTags; i++ {
+			tooManyTags.Tags = append(tooManyTags.Tags, domain.Tag{Name: fmt.Sprintf("tag %d", i)})
+		}
+		err = ps.Save(context.TODO(), account, &tooManyTags)
+		assert.Equal(t, http.StatusBadRequest, domain.Status(err))
+
+		mockRepo.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Author not provisioned", func(t *testing.T) {
+		mockRepo := new(mocks.MockPostRepo)
+		mockAuthorRepo := new(mocks.MockAuthorRepo)
+
+		tempMockPost := mockPost
+
+		mockAuthorRepo.On("FindOrCreateByAccount", mock.Anything, account).Return(nil, domain.NewInternal())
+
+		ps := service.NewPostService(mockRepo, mockAuthorRepo, nil, repository.NewMemorySearchIndex(), nil)
+
+		err := ps.Save(context.TODO(), account, &tempMockPost)
+
+		assert.Equal(t, http.StatusInternalServerError, domain.Status(err))
+		mockRepo.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+	})
 }
 
 func TestFindAll(t *testing.T) {
@@ -78,32 +149,133 @@ func TestFindAll(t *testing.T) {
 		mockListPostResp := make([]domain.Post, 0)
 		mockListPostResp = append(mockListPostResp, mockPost)
 
+		filter := domain.PostFilter{Status: domain.PublishedStatus, Sort: domain.NewestFirst}
 		mockRepository := new(mocks.MockPostRepo)
-		mockRepository.On("FindAll", mock.Anything).Return(mockListPostResp, nil).Once()
+		mockRepository.On("FindAll", mock.Anything, filter, (*domain.PostCursor)(nil), domain.DefaultPostLimit+1).Return(mockListPostResp, nil).Once()
 
-		ps := service.NewPostService(mockRepository)
+		ps := service.NewPostService(mockRepository, nil, nil, repository.NewMemorySearchIndex(), nil)
 
 		ctx := context.TODO()
-		u, err := ps.FindAll(ctx)
+		u, err := ps.FindAll(ctx, domain.PostQuery{})
 
 		assert.NoError(t, err)
-		assert.Equal(t, u, mockListPostResp)
+		assert.Equal(t, u.Data, mockListPostResp)
+		// a single page
+		assert.Empty(t, u.NextCursor)
+		assert.Empty(t, u.PrevCursor)
 		mockRepository.AssertExpectations(t)
 	})
 
 	t.Run("Error", func(t *testing.T) {
 		mockRepository := new(mocks.MockPostRepo)
-		mockRepository.On("FindAll", mock.Anything).Return(nil, fmt.Errorf("Some error down the call chain")).Once()
+		mockRepository.On("FindAll", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil, fmt.Errorf("Some error down the call chain")).Once()
 
-		ps := service.NewPostService(mockRepository)
+		ps := service.NewPostService(mockRepository, nil, nil, repository.NewMemorySearchIndex(), nil)
 
 		ctx := context.TODO()
-		u, err := ps.FindAll(ctx)
+		u, err := ps.FindAll(ctx, domain.PostQuery{})
 
 		assert.Nil(t, u)
 		assert.Error(t, err)
 		mockRepository.AssertExpectations(t)
 	})
+
+	t.Run("Tag and category filters", func(t *testing.T) {
+		filter := domain.PostFilter{Tag: "web-development", Category: "backend", Status: domain.PublishedStatus, Sort: domain.NewestFirst}
+		mockRepository := new(mocks.MockPostRepo)
+		mockRepository.On("FindAll", mock.Anything, filter, (*domain.PostCursor)(nil), domain.DefaultPostLimit+1).Return([]domain.Post{}, nil).Once()
+
+		ps := service.NewPostService(mockRepository, nil, nil, repository.NewMemorySearchIndex(), nil)
+
+		// names are matched by their slug
+		_, err := ps.FindAll(context.TODO(), domain.PostQuery{
+			PostFilter: domain.PostFilter{Tag: "Web Development", Category: "Backend"},
+		})
+
+		assert.NoError(t, err)
+		mockRepository.AssertExpectations(t)
+	})
+
+	t.Run("Invalid query", func(t *testing.T) {
+		mockRepository := new(mocks.MockPostRepo)
+		ps := service.NewPostService(mockRepository, nil, nil, repository.NewMemorySearchIndex(), nil)
+
+		queries := map[string]domain.PostQuery{
+			"unknown sort":    {PostFilter: domain.PostFilter{Sort: "popular"}},
+			"limit too large": {Limit: domain.MaxPostLimit + 1},
+			"negative limit":  {Limit: -1},
+			"reversed range":  {PostFilter: domain.PostFilter{From: time.Now(), To: time.Now().Add(-time.Hour)}},
+			"garbled cursor":  {Cursor: "not a cursor"},
+		}
+
+		for name, query := range queries {
+			_, err := ps.FindAll(context.TODO(), query)
+			assert.Equal(t, http.StatusBadRequest, domain.Status(err), name)
+		}
+		mockRepository.AssertNotCalled(t, "FindAll", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+// postsBetween mocks posts with ids from to to, newer posts having higher ids
+func postsBetween(from int32, to int32) []domain.Post {
+	base := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+	posts := []domain.Post{}
+	step := int32(1)
+	if from > to {
+		step = -1
+	}
+	for id := from; id != to+step; id += step {
+		posts = append(posts, domain.Post{ID: id, CreatedAt: base.Add(time.Duration(id) * time.Minute)})
+	}
+	return posts
+}
+
+func TestFindAllCursors(t *testing.T) {
+	filter := domain.PostFilter{Status: domain.PublishedStatus, Sort: domain.NewestFirst}
+
+	mockRepository := new(mocks.MockPostRepo)
+	ps := service.NewPostService(mockRepository, nil, nil, repository.NewMemorySearchIndex(), nil)
+
+	// first page, posts 10 to 8 and one more
+	mockRepository.On("FindAll", mock.Anything, filter, (*domain.PostCursor)(nil), 4).Return(postsBetween(10, 7), nil).Once()
+
+	first, err := ps.FindAll(context.TODO(), domain.PostQuery{Limit: 3})
+	assert.NoError(t, err)
+	assert.Equal(t, postsBetween(10, 8), first.Data)
+	assert.NotEmpty(t, first.NextCursor)
+	assert.Empty(t, first.PrevCursor)
+
+	// the next page starts after post 8
+	mockRepository.On("FindAll", mock.Anything, filter, mock.MatchedBy(func(c *domain.PostCursor) bool {
+		return c != nil && c.ID == 8 && !c.Backward && c.CreatedAt.Equal(postsBetween(8, 8)[0].CreatedAt)
+	}), 4).Return(postsBetween(7, 5), nil).Once()
+
+	second, err := ps.FindAll(context.TODO(), domain.PostQuery{Cursor: first.NextCursor, Limit: 3})
+	assert.NoError(t, err)
+	assert.Equal(t, postsBetween(7, 5), second.Data)
+	// the last page
+	assert.Empty(t, second.NextCursor)
+	assert.NotEmpty(t, second.PrevCursor)
+
+	// walking back from post 7 returns the posts before it in order
+	mockRepository.On("FindAll", mock.Anything, filter, mock.MatchedBy(func(c *domain.PostCursor) bool {
+		return c != nil && c.ID == 7 && c.Backward
+	}), 4).Return(postsBetween(10, 8), nil).Once()
+
+	back, err := ps.FindAll(context.TODO(), domain.PostQuery{Cursor: second.PrevCursor, Limit: 3})
+	assert.NoError(t, err)
+	assert.Equal(t, postsBetween(10, 8), back.Data)
+	assert.NotEmpty(t, back.NextCursor)
+	assert.Empty(t, back.PrevCursor)
+
+	// a cursor only works with the sort it was issued for
+	_, err = ps.FindAll(context.TODO(), domain.PostQuery{
+		PostFilter: domain.PostFilter{Sort: domain.OldestFirst},
+		Cursor:     first.NextCursor,
+	})
+	assert.Equal(t, http.StatusBadRequest, domain.Status(err))
+
+	mockRepository.AssertExpectations(t)
 }
 
 func TestFindById(t *testing.T) {
@@ -112,6 +284,7 @@ func TestFindById(t *testing.T) {
 	mockPost := domain.Post{
 		// ID:        1,
 		Title:     "Test Mock 1",
+		Status:    domain.PublishedStatus,
 		Content:   "Test Mock Desc",
 		CreatedAt: time.Now(),
 		Author: domain.Author{
@@ -125,10 +298,10 @@ func TestFindById(t *testing.T) {
 
 		mockRepository.On("FindByID", mock.Anything, mock.AnythingOfType("int32")).Return(mockPost, nil).Once()
 
-		ps := service.NewPostService(mockRepository)
+		ps := service.NewPostService(mockRepository, nil, nil, repository.NewMemorySearchIndex(), nil)
 
 		ctx := context.TODO()
-		p, err := ps.FindByID(ctx, mockPost.ID)
+		p, err := ps.FindByID(ctx, nil, mockPost.ID)
 
 		assert.NoError(t, err)
 		assert.NotNil(t, p)
@@ -139,10 +312,10 @@ func TestFindById(t *testing.T) {
 	t.Run("Error", func(t *testing.T) {
 		mockRepository.On("FindByID", mock.Anything, mock.AnythingOfType("int32")).Return(domain.Post{}, domain.NewNotFound("id", "id")).Once()
 
-		ps := service.NewPostService(mockRepository)
+		ps := service.NewPostService(mockRepository, nil, nil, repository.NewMemorySearchIndex(), nil)
 
 		ctx := context.TODO()
-		p, err := ps.FindByID(ctx, mockPost.ID)
+		p, err := ps.FindByID(ctx, nil, mockPost.ID)
 
 		assert.Error(t, err)
 		assert.Equal(t, domain.Post{}, p)
@@ -150,6 +323,13 @@ func TestFindById(t *testing.T) {
 	})
 }
 
+// owner of the posts returned by the mocked FindByID
+var postOwner = &domain.Account{UID: uuid.New(), Roles: []string{domain.AuthorRole}}
+
+func ownedPost(id int32) domain.Post {
+	return domain.Post{ID: id, Status: domain.PublishedStatus, Author: domain.Author{ID: 1, AccountUID: postOwner.UID}}
+}
+
 func TestUpdate(t *testing.T) {
 	var id int32 = 1
 	mockUpdatePostParam := domain.Post{
@@ -172,14 +352,16 @@ func TestUpdate(t *testing.T) {
 			mock.Anything,
 			mock.AnythingOfType("int32"),
 			mock.AnythingOfType("*domain.Post"),
+			mock.Anything,
 		}
 
+		mockRepo.On("FindByID", mock.Anything, id).Return(ownedPost(id), nil)
 		mockRepo.On("Update", mockArgs...).Return(nil).Once()
 
-		ps := service.NewPostService(mockRepo)
+		ps := service.NewPostService(mockRepo, nil, nil, repository.NewMemorySearchIndex(), nil)
 
 		ctx := context.TODO()
-		err := ps.Update(ctx, id, &tempMockPost)
+		err := ps.Update(ctx, postOwner, id, &tempMockPost)
 
 		assert.NoError(t, err)
 		assert.Equal(t, tempMockPost.Title, mockUpdatePostResp.Title)
@@ -197,17 +379,61 @@ func TestUpdate(t *testing.T) {
 			mock.AnythingOfType("*context.emptyCtx"),
 			mock.AnythingOfType("int32"),
 			mock.AnythingOfType("*domain.Post"),
+			mock.Anything,
 		}
 		mockRepo.On("Update", mockArgs...).Return(nil).Once()
 
-		ps := service.NewPostService(mockRepo)
+		ps := service.NewPostService(mockRepo, nil, nil, repository.NewMemorySearchIndex(), nil)
 
 		ctx := context.TODO()
-		err := ps.Update(ctx, 0, &tempMockPost)
+		err := ps.Update(ctx, postOwner, 0, &tempMockPost)
 
 		assert.Error(t, err)
 		assert.Equal(t, domain.Status(err), domain.Status(errResp))
-		mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything)
+		mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Editor of another account's post", func(t *testing.T) {
+		mockRepo := new(mocks.MockPostRepo)
+		tempMockPost := mockUpdatePostParam
+		editor := &domain.Account{UID: uuid.New(), Roles: []string{domain.EditorRole}}
+
+		mockRepo.On("FindByID", mock.Anything, id).Return(ownedPost(id), nil)
+		mockRepo.On("Update", mock.Anything, id, mock.AnythingOfType("*domain.Post"), editor.UID).Return(nil).Once()
+
+		ps := service.NewPostService(mockRepo, nil, nil, repository.NewMemorySearchIndex(), nil)
+		err := ps.Update(context.TODO(), editor, id, &tempMockPost)
+
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Not the owner", func(t *testing.T) {
+		mockRepo := new(mocks.MockPostRepo)
+		tempMockPost := mockUpdatePostParam
+		author := &domain.Account{UID: uuid.New(), Roles: []string{domain.AuthorRole}}
+
+		mockRepo.On("FindByID", mock.Anything, id).Return(ownedPost(id), nil)
+
+		ps := service.NewPostService(mockRepo, nil, nil, repository.NewMemorySearchIndex(), nil)
+		err := ps.Update(context.TODO(), author, id, &tempMockPost)
+
+		assert.Equal(t, http.StatusForbidden, domain.Status(err))
+		mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Owner without write permission", func(t *testing.T) {
+		mockRepo := new(mocks.MockPostRepo)
+		tempMockPost := mockUpdatePostParam
+		reader := &domain.Account{UID: postOwner.UID, Roles: []string{domain.ReaderRole}}
+
+		mockRepo.On("FindByID", mock.Anything, id).Return(ownedPost(id), nil)
+
+		ps := service.NewPostService(mockRepo, nil, nil, repository.NewMemorySearchIndex(), nil)
+		err := ps.Update(context.TODO(), reader, id, &tempMockPost)
+
+		assert.Equal(t, http.StatusForbidden, domain.Status(err))
+		mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
 	})
 }
 
@@ -219,16 +445,18 @@ func TestDelete(t *testing.T) {
 		var id int32 = 1
 
 		mockArgs := mock.Arguments{
-			mock.AnythingOfType("*context.emptyCtx"),
+			mock.Anything,
+			mock.AnythingOfType("int32"),
 			mock.AnythingOfType("int32"),
 		}
 
+		mockRepo.On("FindByID", mock.Anything, id).Return(ownedPost(id), nil)
 		mockRepo.On("Delete", mockArgs...).Return(nil).Once()
 
-		ps := service.NewPostService(mockRepo)
+		ps := service.NewPostService(mockRepo, nil, nil, repository.NewMemorySearchIndex(), nil)
 
 		ctx := context.TODO()
-		err := ps.Delete(ctx, id)
+		err := ps.Delete(ctx, postOwner, id, ownedPost(id).Version)
 
 		assert.NoError(t, err)
 		mockRepo.AssertExpectations(t)
@@ -240,16 +468,128 @@ func TestDelete(t *testing.T) {
 		mockArgs := mock.Arguments{
 			mock.Anything,
 			mock.Anything,
+			mock.Anything,
 		}
 		mockRepo.On("Delete", mockArgs...).Return(nil).Once()
 
-		ps := service.NewPostService(mockRepo)
+		ps := service.NewPostService(mockRepo, nil, nil, repository.NewMemorySearchIndex(), nil)
 
 		ctx := context.TODO()
-		err := ps.Delete(ctx, 0)
+		err := ps.Delete(ctx, postOwner, 0, 0)
 
 		assert.Error(t, err)
-		mockRepo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+		mockRepo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything, mock.Anything)
+
+	})
+
+	t.Run("Not the owner", func(t *testing.T) {
+		mockRepo := new(mocks.MockPostRepo)
+
+		var id int32 = 1
+		author := &domain.Account{UID: uuid.New()}
+
+		mockRepo.On("FindByID", mock.Anything, id).Return(ownedPost(id), nil)
 
+		ps := service.NewPostService(mockRepo, nil, nil, repository.NewMemorySearchIndex(), nil)
+		err := ps.Delete(context.TODO(), author, id, ownedPost(id).Version)
+
+		assert.Equal(t, http.StatusForbidden, domain.Status(err))
+		mockRepo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestSearch(t *testing.T) {
+	posts := map[int32]domain.Post{
+		1: {ID: 1, Title: "Getting started with Go", Content: "A tour of the language", Status: domain.PublishedStatus, Author: ownedPost(1).Author},
+		2: {ID: 2, Title: "Cooking pasta", Content: "Boil the water and let it go for ten minutes", Status: domain.PublishedStatus, Author: ownedPost(2).Author},
+		3: {ID: 3, Title: "Gardening", Content: "Tomatoes need a lot of sun", Status: domain.PublishedStatus, Author: ownedPost(3).Author},
+	}
+
+	index := repository.NewMemorySearchIndex()
+	for _, post := range posts {
+		post := post
+		assert.NoError(t, index.Index(context.TODO(), &post))
+	}
+
+	mockRepo := new(mocks.MockPostRepo)
+	mockRepo.On("FindByIDs", mock.Anything, mock.Anything).Return(func(ctx context.Context, ids []int32) []domain.Post {
+		found := []domain.Post{}
+		for _, id := range ids {
+			if post, ok := posts[id]; ok {
+				found = append(found, post)
+			}
+		}
+		return found
+	}, nil)
+
+	ps := service.NewPostService(mockRepo, nil, nil, index, nil)
+
+	t.Run("Ranked", func(t *testing.T) {
+		page, err := ps.Search(context.TODO(), domain.SearchQuery{Query: "go"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, 2, page.Total)
+		assert.Len(t, page.Data, 2)
+		// a match in the title ranks higher
+		assert.Equal(t, int32(1), page.Data[0].Post.ID)
+		assert.Equal(t, int32(2), page.Data[1].Post.ID)
+		assert.Greater(t, page.Data[0].Score, page.Data[1].Score)
+		assert.Equal(t, "Getting started with <mark>Go</mark>", page.Data[0].TitleSnippet)
+		assert.Contains(t, page.Data[1].Snippet, "let it <mark>go</mark> for")
+		assert.Empty(t, page.NextCursor)
+	})
+
+	t.Run("Paged", func(t *testing.T) {
+		first, err := ps.Search(context.TODO(), domain.SearchQuery{Query: "go", Limit: 1})
+		assert.NoError(t, err)
+		assert.Len(t, first.Data, 1)
+		assert.Equal(t, int32(1), first.Data[0].Post.ID)
+		assert.NotEmpty(t, first.NextCursor)
+
+		second, err := ps.Search(context.TODO(), domain.SearchQuery{Query: "go", Cursor: first.NextCursor, Limit: 1})
+		assert.NoError(t, err)
+		assert.Len(t, second.Data, 1)
+		assert.Equal(t, int32(2), second.Data[0].Post.ID)
+		assert.Empty(t, second.NextCursor)
+
+		// a cursor only works with the query it was issued for
+		_, err = ps.Search(context.TODO(), domain.SearchQuery{Query: "pasta", Cursor: first.NextCursor})
+		assert.Equal(t, http.StatusBadRequest, domain.Status(err))
+	})
+
+	t.Run("Invalid query", func(t *testing.T) {
+		queries := map[string]domain.SearchQuery{
+			"empty query":     {Query: "  "},
+			"limit too large": {Query: "go", Limit: domain.MaxPostLimit + 1},
+			"garbled cursor":  {Query: "go", Cursor: "not a cursor"},
+		}
+
+		for name, query := range queries {
+			_, err := ps.Search(context.TODO(), query)
+			assert.Equal(t, http.StatusBadRequest, domain.Status(err), name)
+		}
+	})
+
+	t.Run("Kept in sync", func(t *testing.T) {
+		mockRepo.On("FindByID", mock.Anything, mock.AnythingOfType("int32")).Return(func(ctx context.Context, id int32) domain.Post {
+			return ownedPost(id)
+		}, nil)
+		mockRepo.On("Update", mock.Anything, int32(3), mock.AnythingOfType("*domain.Post"), postOwner.UID).Return(nil).Once()
+		mockRepo.On("Delete", mock.Anything, int32(1), int32(0)).Return(nil).Once()
+
+		updated := domain.Post{Title: "Gardening", Content: "Let the tomatoes go to seed"}
+		assert.NoError(t, ps.Update(context.TODO(), postOwner, 3, &updated))
+		assert.NoError(t, ps.Delete(context.TODO(), postOwner, 1, 0))
+
+		page, err := ps.Search(context.TODO(), domain.SearchQuery{Query: "go"})
+		assert.NoError(t, err)
+		assert.Equal(t, 2, page.Total)
+
+		ids := []int32{}
+		for _, result := range page.Data {
+			ids = append(ids, result.Post.ID)
+		}
+		assert.ElementsMatch(t, []int32{2, 3}, ids)
+		mockRepo.AssertExpectations(t)
 	})
 }
@@ -0,0 +1,97 @@
+package service_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/whuangz/go-example/go-api/domain"
+	mocks "github.com/whuangz/go-example/go-api/mocks/post"
+	"github.com/whuangz/go-example/go-api/repository"
+	"github.com/whuangz/go-example/go-api/service"
+)
+
+func TestPostVersion(t *testing.T) {
+	var id int32 = 1
+	current := ownedPost(id)
+	current.Version = 3
+
+	newService := func() (domain.PostService, *mocks.MockPostRepo) {
+		mockRepo := new(mocks.MockPostRepo)
+		mockRepo.On("FindByID", mock.Anything, id).Return(current, nil)
+		return service.NewPostService(mockRepo, nil, nil, repository.NewMemorySearchIndex(), nil), mockRepo
+	}
+
+	t.Run("Update of the current version", func(t *testing.T) {
+		ps, mockRepo := newService()
+		mockRepo.On("Update", mock.Anything, id, mock.AnythingOfType("*domain.Post"), postOwner.UID).Return(nil)
+
+		post := &domain.Post{Title: "Title", Content: "Content", Version: 3}
+		assert.NoError(t, ps.Update(context.TODO(), postOwner, id, post))
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Update of a former version", func(t *testing.T) {
+		ps, mockRepo := newService()
+
+		post := &domain.Post{Title: "Title", Content: "Content", Version: 2}
+		err := ps.Update(context.TODO(), postOwner, id, post)
+
+		assert.Equal(t, http.StatusPreconditionFailed, domain.Status(err))
+		mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Changed while updating", func(t *testing.T) {
+		ps, mockRepo := newService()
+		mockRepo.On("Update", mock.Anything, id, mock.AnythingOfType("*domain.Post"), postOwner.UID).
+			Return(domain.NewPreconditionFailed("post", "1"))
+
+		post := &domain.Post{Title: "Title", Content: "Content", Version: 3}
+		err := ps.Update(context.TODO(), postOwner, id, post)
+
+		assert.Equal(t, http.StatusPreconditionFailed, domain.Status(err))
+	})
+
+	t.Run("Deleted while updating", func(t *testing.T) {
+		ps, mockRepo := newService()
+		mockRepo.On("Update", mock.Anything, id, mock.AnythingOfType("*domain.Post"), postOwner.UID).
+			Return(domain.NewNotFound("id", "1"))
+
+		post := &domain.Post{Title: "Title", Content: "Content", Version: 3}
+		err := ps.Update(context.TODO(), postOwner, id, post)
+
+		assert.Equal(t, http.StatusNotFound, domain.Status(err))
+	})
+
+	t.Run("Delete of a former version", func(t *testing.T) {
+		ps, mockRepo := newService()
+
+		err := ps.Delete(context.TODO(), postOwner, id, 2)
+
+		assert.Equal(t, http.StatusPreconditionFailed, domain.Status(err))
+		mockRepo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Restore of the current version", func(t *testing.T) {
+		ps, mockRepo := newService()
+		mockRepo.On("FindRevision", mock.Anything, id, int32(1)).Return(domain.PostRevision{PostID: id, Number: 1, Title: "Old"}, nil)
+		mockRepo.On("Update", mock.Anything, id, mock.AnythingOfType("*domain.Post"), postOwner.UID).Return(nil)
+
+		post, err := ps.Restore(context.TODO(), postOwner, id, 1, 3)
+
+		assert.NoError(t, err)
+		assert.Equal(t, int32(3), post.Version)
+	})
+
+	t.Run("Restore of a former version", func(t *testing.T) {
+		ps, mockRepo := newService()
+		mockRepo.On("FindRevision", mock.Anything, id, int32(1)).Return(domain.PostRevision{PostID: id, Number: 1, Title: "Old"}, nil)
+
+		_, err := ps.Restore(context.TODO(), postOwner, id, 1, 2)
+
+		assert.Equal(t, http.StatusPreconditionFailed, domain.Status(err))
+		mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
@@ -0,0 +1,326 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/whuangz/go-example/go-api/domain"
+	mocks "github.com/whuangz/go-example/go-api/mocks/post"
+	"github.com/whuangz/go-example/go-api/repository"
+	"github.com/whuangz/go-example/go-api/service"
+)
+
+func TestReact(t *testing.T) {
+	var postID int32 = 1
+
+	newService := func(post domain.Post) (domain.ReactionService, *mocks.MockReactionRepo, *mocks.MockReactionCounter) {
+		mockPostRepo := new(mocks.MockPostRepo)
+		mockPostRepo.On("FindByID", mock.Anything, postID).Return(post, nil)
+
+		mockRepo := new(mocks.MockReactionRepo)
+		mockRepo.On("FindByAccount", mock.Anything, postID, commenter.UID).Return([]string{domain.LikeReaction}, nil)
+		mockCounter := new(mocks.MockReactionCounter)
+		mockCounter.On("Incr", mock.Anything, postID, domain.LikeReaction, int64(1)).Return(nil)
+		return service.NewReactionService(mockRepo, mockCounter, mockPostRepo), mockRepo, mockCounter
+	}
+
+	t.Run("Counted", func(t *testing.T) {
+		rs, mockRepo, mockCounter := newService(ownedPost(postID))
+		mockRepo.On("Add", mock.Anything, postID, commenter.UID, domain.LikeReaction).Return(true, nil)
+
+		mine, err := rs.React(context.TODO(), commenter, postID, domain.LikeReaction)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{domain.LikeReaction}, mine)
+		mockCounter.AssertExpectations(t)
+	})
+
+	t.Run("Twice", func(t *testing.T) {
+		rs, mockRepo, mockCounter := newService(ownedPost(postID))
+		mockRepo.On("Add", mock.Anything, postID, commenter.UID, domain.LikeReaction).Return(false, nil)
+
+		mine, err := rs.React(context.TODO(), commenter, postID, domain.LikeReaction)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{domain.LikeReaction}, mine)
+		mockCounter.AssertNotCalled(t, "Incr", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Counter unavailable", func(t *testing.T) {
+		mockPostRepo := new(mocks.MockPostRepo)
+		mockPostRepo.On("FindByID", mock.Anything, postID).Return(ownedPost(postID), nil)
+		mockRepo := new(mocks.MockReactionRepo)
+		mockRepo.On("Add", mock.Anything, postID, commenter.UID, domain.LoveReaction).Return(true, nil)
+		mockRepo.On("FindByAccount", mock.Anything, postID, commenter.UID).Return([]string{domain.LoveReaction}, nil)
+		mockCounter := new(mocks.MockReactionCounter)
+		mockCounter.On("Incr", mock.Anything, postID, domain.LoveReaction, int64(1)).Return(domain.NewInternal())
+		rs := service.NewReactionService(mockRepo, mockCounter, mockPostRepo)
+
+		// the reaction is stored, it's counted once the post is reconciled
+		mine, err := rs.React(context.TODO(), commenter, postID, domain.LoveReaction)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{domain.LoveReaction}, mine)
+	})
+
+	t.Run("Unknown kind", func(t *testing.T) {
+		rs, mockRepo, _ := newService(ownedPost(postID))
+
+		_, err := rs.React(context.TODO(), commenter, postID, "angry")
+
+		assert.Equal(t, http.StatusBadRequest, domain.Status(err))
+		mockRepo.AssertNotCalled(t, "Add", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Unpublished posts", func(t *testing.T) {
+		statuses := map[string]int{
+			domain.DraftStatus:     http.StatusNotFound,
+			domain.ScheduledStatus: http.StatusNotFound,
+			domain.ArchivedStatus:  http.StatusForbidden,
+		}
+		for status, code := range statuses {
+			post := ownedPost(postID)
+			post.Status = status
+			rs, mockRepo, _ := newService(post)
+
+			_, err := rs.React(context.TODO(), commenter, postID, domain.LikeReaction)
+
+			assert.Equal(t, code, domain.Status(err), status)
+			mockRepo.AssertNotCalled(t, "Add", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		}
+	})
+}
+
+func TestUnreact(t *testing.T) {
+	var postID int32 = 1
+
+	t.Run("Counted", func(t *testing.T) {
+		mockRepo := new(mocks.MockReactionRepo)
+		mockRepo.On("Remove", mock.Anything, postID, commenter.UID, domain.SadReaction).Return(true, nil)
+		mockRepo.On("FindByAccount", mock.Anything, postID, commenter.UID).Return([]string{}, nil)
+		mockCounter := new(mocks.MockReactionCounter)
+		mockCounter.On("Incr", mock.Anything, postID, domain.SadReaction, int64(-1)).Return(nil)
+		rs := service.NewReactionService(mockRepo, mockCounter, nil)
+
+		mine, err := rs.Unreact(context.TODO(), commenter, postID, domain.SadReaction)
+
+		assert.NoError(t, err)
+		assert.Empty(t, mine)
+		mockCounter.AssertExpectations(t)
+	})
+
+	t.Run("Not reacted", func(t *testing.T) {
+		mockRepo := new(mocks.MockReactionRepo)
+		mockRepo.On("Remove", mock.Anything, postID, commenter.UID, domain.SadReaction).Return(false, nil)
+		mockRepo.On("FindByAccount", mock.Anything, postID, commenter.UID).Return([]string{}, nil)
+		mockCounter := new(mocks.MockReactionCounter)
+		rs := service.NewReactionService(mockRepo, mockCounter, nil)
+
+		_, err := rs.Unreact(context.TODO(), commenter, postID, domain.SadReaction)
+
+		assert.NoError(t, err)
+		mockCounter.AssertNotCalled(t, "Incr", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestCountReactions(t *testing.T) {
+	t.Run("Falls back to the reconciled counts", func(t *testing.T) {
+		mockCounter := new(mocks.MockReactionCounter)
+		mockCounter.On("Get", mock.Anything, []int32{1, 2, 3}).
+			Return(map[int32]domain.ReactionCounts{1: {domain.LikeReaction: 5}}, nil)
+		mockRepo := new(mocks.MockReactionRepo)
+		mockRepo.On("FindCounts", mock.Anything, []int32{2, 3}).
+			Return(map[int32]domain.ReactionCounts{2: {domain.WowReaction: 1}}, nil)
+		rs := service.NewReactionService(mockRepo, mockCounter, nil)
+
+		posts := []domain.Post{{ID: 1}, {ID: 2}, {ID: 3}}
+		err := rs.Count(context.TODO(), posts)
+
+		assert.NoError(t, err)
+		assert.Equal(t, domain.ReactionCounts{domain.LikeReaction: 5}, posts[0].Reactions)
+		assert.Equal(t, domain.ReactionCounts{domain.WowReaction: 1}, posts[1].Reactions)
+		assert.Equal(t, domain.ReactionCounts{}, posts[2].Reactions)
+	})
+
+	t.Run("Counter unavailable", func(t *testing.T) {
+		mockCounter := new(mocks.MockReactionCounter)
+		mockCounter.On("Get", mock.Anything, []int32{1}).Return(nil, domain.NewInternal())
+		mockRepo := new(mocks.MockReactionRepo)
+		mockRepo.On("FindCounts", mock.Anything, []int32{1}).
+			Return(map[int32]domain.ReactionCounts{1: {domain.LikeReaction: 4}}, nil)
+		rs := service.NewReactionService(mockRepo, mockCounter, nil)
+
+		posts := []domain.Post{{ID: 1}}
+		err := rs.Count(context.TODO(), posts)
+
+		assert.NoError(t, err)
+		assert.Equal(t, domain.ReactionCounts{domain.LikeReaction: 4}, posts[0].Reactions)
+	})
+}
+
+func TestReconcileReactions(t *testing.T) {
+	t.Run("Every dirty post", func(t *testing.T) {
+		counts := domain.ReactionCounts{domain.LikeReaction: 3}
+		mockCounter := new(mocks.MockReactionCounter)
+		mockCounter.On("TakeDirty", mock.Anything, mock.AnythingOfType("int")).Return([]int32{1, 2}, nil).Once()
+		mockCounter.On("TakeDirty", mock.Anything, mock.AnythingOfType("int")).Return([]int32{}, nil).Once()
+		mockCounter.On("Set", mock.Anything, mock.AnythingOfType("int32"), counts).Return(nil)
+		mockRepo := new(mocks.MockReactionRepo)
+		mockRepo.On("Count", mock.Anything, mock.AnythingOfType("int32")).Return(counts, nil)
+		mockRepo.On("SaveCounts", mock.Anything, mock.AnythingOfType("int32"), counts).Return(nil)
+		rs := service.NewReactionService(mockRepo, mockCounter, nil)
+
+		reconciled, err := rs.Reconcile(context.TODO())
+
+		assert.NoError(t, err)
+		assert.Equal(t, 2, reconciled)
+		mockRepo.AssertCalled(t, "SaveCounts", mock.Anything, int32(1), counts)
+		mockRepo.AssertCalled(t, "SaveCounts", mock.Anything, int32(2), counts)
+		mockCounter.AssertNumberOfCalls(t, "Set", 2)
+	})
+
+	t.Run("Failing posts are marked again", func(t *testing.T) {
+		counts := domain.ReactionCounts{domain.LikeReaction: 3}
+		mockCounter := new(mocks.MockReactionCounter)
+		mockCounter.On("TakeDirty", mock.Anything, mock.AnythingOfType("int")).Return([]int32{1, 2, 3}, nil).Once()
+		mockCounter.On("Set", mock.Anything, int32(1), counts).Return(nil)
+		mockCounter.On("MarkDirty", mock.Anything, []int32{2, 3}).Return(nil)
+		mockRepo := new(mocks.MockReactionRepo)
+		mockRepo.On("Count", mock.Anything, int32(1)).Return(counts, nil)
+		mockRepo.On("Count", mock.Anything, int32(2)).Return(nil, domain.NewInternal())
+		mockRepo.On("SaveCounts", mock.Anything, int32(1), counts).Return(nil)
+		rs := service.NewReactionService(mockRepo, mockCounter, nil)
+
+		reconciled, err := rs.Reconcile(context.TODO())
+
+		assert.Error(t, err)
+		assert.Equal(t, 1, reconciled)
+		mockCounter.AssertExpectations(t)
+	})
+}
+
+func TestReactionReconciler(t *testing.T) {
+	t.Run("Holding the lock", func(t *testing.T) {
+		released := false
+		mockLocker := new(mocks.MockLocker)
+		mockLocker.On("Acquire", mock.Anything, "reaction_reconciler", time.Minute).Return(func() { released = true }, true, nil)
+		mockService := new(mocks.MockReactionService)
+		mockService.On("Reconcile", mock.Anything).Return(2, nil).Once()
+
+		err := service.NewReactionReconciler(mockService, mockLocker, time.Minute).Run(context.TODO())
+
+		assert.NoError(t, err)
+		assert.True(t, released)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("Held by another replica", func(t *testing.T) {
+		mockLocker := new(mocks.MockLocker)
+		mockLocker.On("Acquire", mock.Anything, "reaction_reconciler", time.Minute).Return(nil, false, nil)
+		mockService := new(mocks.MockReactionService)
+
+		err := service.NewReactionReconciler(mockService, mockLocker, time.Minute).Run(context.TODO())
+
+		assert.NoError(t, err)
+		mockService.AssertNotCalled(t, "Reconcile", mock.Anything)
+	})
+}
+
+func TestFindByIDReactions(t *testing.T) {
+	var postID int32 = 1
+	counts := domain.ReactionCounts{domain.LikeReaction: 2}
+
+	newService := func() (domain.PostService, *mocks.MockReactionService) {
+		mockRepo := new(mocks.MockPostRepo)
+		mockRepo.On("FindByID", mock.Anything, postID).Return(ownedPost(postID), nil)
+		mockReactions := new(mocks.MockReactionService)
+		mockReactions.On("Count", mock.Anything, mock.AnythingOfType("[]domain.Post")).
+			Run(func(args mock.Arguments) {
+				args.Get(1).([]domain.Post)[0].Reactions = counts
+			}).Return(nil)
+		mockReactions.On("Mine", mock.Anything, commenter, postID).Return([]string{domain.LikeReaction}, nil)
+		return service.NewPostService(mockRepo, nil, nil, repository.NewMemorySearchIndex(), mockReactions), mockReactions
+	}
+
+	t.Run("Signed in", func(t *testing.T) {
+		ps, _ := newService()
+
+		post, err := ps.FindByID(context.TODO(), commenter, postID)
+
+		assert.NoError(t, err)
+		assert.Equal(t, counts, post.Reactions)
+		assert.Equal(t, []string{domain.LikeReaction}, post.MyReaction)
+	})
+
+	t.Run("Anonymous", func(t *testing.T) {
+		ps, mockReactions := newService()
+
+		post, err := ps.FindByID(context.TODO(), nil, postID)
+
+		assert.NoError(t, err)
+		assert.Equal(t, counts, post.Reactions)
+		assert.Nil(t, post.MyReaction)
+		mockReactions.AssertNotCalled(t, "Mine", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Counts unavailable", func(t *testing.T) {
+		mockRepo := new(mocks.MockPostRepo)
+		mockRepo.On("FindByID", mock.Anything, postID).Return(ownedPost(postID), nil)
+		mockReactions := new(mocks.MockReactionService)
+		mockReactions.On("Count", mock.Anything, mock.Anything).Return(errors.New("unavailable"))
+		ps := service.NewPostService(mockRepo, nil, nil, repository.NewMemorySearchIndex(), mockReactions)
+
+		post, err := ps.FindByID(context.TODO(), nil, postID)
+
+		assert.NoError(t, err)
+		assert.Nil(t, post.Reactions)
+	})
+}
+
+func TestDeletePostReactions(t *testing.T) {
+	var postID int32 = 1
+
+	t.Run("Forgotten with the post", func(t *testing.T) {
+		mockRepo := new(mocks.MockPostRepo)
+		mockRepo.On("FindByID", mock.Anything, postID).Return(ownedPost(postID), nil)
+		mockRepo.On("Delete", mock.Anything, postID, int32(0)).Return(nil)
+		mockCounter := new(mocks.MockReactionCounter)
+		mockCounter.On("Remove", mock.Anything, postID).Return(nil)
+		reactions := service.NewReactionService(new(mocks.MockReactionRepo), mockCounter, mockRepo)
+		ps := service.NewPostService(mockRepo, nil, nil, repository.NewMemorySearchIndex(), reactions)
+
+		assert.NoError(t, ps.Delete(context.TODO(), postOwner, postID, 0))
+		mockCounter.AssertExpectations(t)
+	})
+
+	t.Run("Counter unavailable", func(t *testing.T) {
+		mockRepo := new(mocks.MockPostRepo)
+		mockRepo.On("FindByID", mock.Anything, postID).Return(ownedPost(postID), nil)
+		mockRepo.On("Delete", mock.Anything, postID, int32(0)).Return(nil)
+		mockReactions := new(mocks.MockReactionService)
+		mockReactions.On("Forget", mock.Anything, postID).Return(domain.NewInternal())
+		ps := service.NewPostService(mockRepo, nil, nil, repository.NewMemorySearchIndex(), mockReactions)
+
+		// the post is gone already, the counts held are only a cache
+		assert.NoError(t, ps.Delete(context.TODO(), postOwner, postID, 0))
+		mockReactions.AssertExpectations(t)
+	})
+
+	t.Run("Not deleted", func(t *testing.T) {
+		mockRepo := new(mocks.MockPostRepo)
+		mockRepo.On("FindByID", mock.Anything, postID).Return(ownedPost(postID), nil)
+		mockRepo.On("Delete", mock.Anything, postID, int32(0)).Return(domain.NewPreconditionFailed("post", "1"))
+		mockReactions := new(mocks.MockReactionService)
+		ps := service.NewPostService(mockRepo, nil, nil, repository.NewMemorySearchIndex(), mockReactions)
+
+		err := ps.Delete(context.TODO(), postOwner, postID, 0)
+
+		assert.Equal(t, http.StatusPreconditionFailed, domain.Status(err))
+		mockReactions.AssertNotCalled(t, "Forget", mock.Anything, mock.Anything)
+	})
+}
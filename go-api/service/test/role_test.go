@@ -0,0 +1,77 @@
+package service_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/whuangz/go-example/go-api/domain"
+	mocks "github.com/whuangz/go-example/go-api/mocks/account"
+	"github.com/whuangz/go-example/go-api/service"
+)
+
+func TestGrantRole(t *testing.T) {
+	uid := uuid.New()
+
+	t.Run("Success", func(t *testing.T) {
+		mockRepo := new(mocks.MockRoleRepo)
+		mockAccRepo := new(mocks.MockAccountRepo)
+		s := service.NewRoleService(mockRepo, mockAccRepo)
+
+		account := &domain.Account{UID: uid, Roles: []string{domain.EditorRole}}
+		mockAccRepo.On("FindByID", mock.Anything, uid).Return(account, nil)
+		mockRepo.On("AddRole", mock.Anything, uid, domain.EditorRole).Return(nil)
+
+		acc, err := s.Grant(context.TODO(), uid, domain.EditorRole)
+
+		assert.NoError(t, err)
+		assert.Equal(t, account, acc)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Unknown role", func(t *testing.T) {
+		mockRepo := new(mocks.MockRoleRepo)
+		s := service.NewRoleService(mockRepo, nil)
+
+		_, err := s.Grant(context.TODO(), uid, "owner")
+
+		assert.Equal(t, http.StatusBadRequest, domain.Status(err))
+		mockRepo.AssertNotCalled(t, "AddRole", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Unknown account", func(t *testing.T) {
+		mockRepo := new(mocks.MockRoleRepo)
+		mockAccRepo := new(mocks.MockAccountRepo)
+		s := service.NewRoleService(mockRepo, mockAccRepo)
+
+		mockAccRepo.On("FindByID", mock.Anything, uid).Return(nil, domain.NewNotFound("uid", uid.String()))
+
+		_, err := s.Grant(context.TODO(), uid, domain.AdminRole)
+
+		assert.Equal(t, http.StatusNotFound, domain.Status(err))
+		mockRepo.AssertNotCalled(t, "AddRole", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestRevokeRole(t *testing.T) {
+	uid := uuid.New()
+
+	mockRepo := new(mocks.MockRoleRepo)
+	mockAccRepo := new(mocks.MockAccountRepo)
+	s := service.NewRoleService(mockRepo, mockAccRepo)
+
+	account := &domain.Account{UID: uid}
+	mockAccRepo.On("FindByID", mock.Anything, uid).Return(account, nil)
+	mockRepo.On("RemoveRole", mock.Anything, uid, domain.EditorRole).Return(nil)
+
+	acc, err := s.Revoke(context.TODO(), uid, domain.EditorRole)
+
+	assert.NoError(t, err)
+	// accounts left without a role fall back to the default role
+	assert.True(t, acc.HasPermission(domain.WritePostsPermission))
+	assert.False(t, acc.HasPermission(domain.EditAnyPostPermission))
+	mockRepo.AssertExpectations(t)
+}
@@ -2,8 +2,11 @@ package service_test
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/rsa"
 	"fmt"
 	"io/ioutil"
+	"net/http"
 	"testing"
 	"time"
 
@@ -14,6 +17,7 @@ import (
 	"github.com/whuangz/go-example/go-api/domain"
 	jwtHelper "github.com/whuangz/go-example/go-api/helpers/jwt"
 	mocks "github.com/whuangz/go-example/go-api/mocks/account"
+	"github.com/whuangz/go-example/go-api/repository"
 	"github.com/whuangz/go-example/go-api/service"
 )
 
@@ -29,7 +33,7 @@ func TestNewPairFromUser(t *testing.T) {
 
 	// instantiate a common token service to be used by all tests
 	mockTokenRepository := new(mocks.MockTokenRepo)
-	tokenService := service.NewTokenService(mockTokenRepository, privKey, pubKey, secret, accExp, refreshExp)
+	tokenService := service.NewTokenService(mockTokenRepository, repository.NewMemoryTokenDenylist(), jwtHelper.NewKeyring(jwtHelper.NewKey(privKey), jwtHelper.NewPublicKey(pubKey)), secret, accExp, refreshExp)
 
 	// include password to make sure it is not serialized
 	// since json tag is "-"
@@ -47,30 +51,33 @@ func TestNewPairFromUser(t *testing.T) {
 		Password: "blarghedymcblarghface",
 	}
 	prevAccessToken := "a_previous_tokenID"
+	familyID, _ := uuid.NewRandom()
 
 	setSuccessArguments := mock.Arguments{
-		mock.AnythingOfType("*context.emptyCtx"),
+		mock.Anything,
 		a.UID.String(),
 		mock.AnythingOfType("string"),
+		mock.AnythingOfType("string"),
 		mock.AnythingOfType("time.Duration"),
 	}
 
 	setErrorArguments := mock.Arguments{
-		mock.AnythingOfType("*context.emptyCtx"),
+		mock.Anything,
 		uErrorCase.UID.String(),
 		mock.AnythingOfType("string"),
+		mock.AnythingOfType("string"),
 		mock.AnythingOfType("time.Duration"),
 	}
 
-	deleteWithPrevIDArguments := mock.Arguments{
-		mock.AnythingOfType("*context.emptyCtx"),
+	rotateWithPrevIDArguments := mock.Arguments{
+		mock.Anything,
 		a.UID.String(),
 		prevAccessToken,
 	}
 
 	mockTokenRepository.On("SetRefreshToken", setSuccessArguments...).Return(nil)
 	mockTokenRepository.On("SetRefreshToken", setErrorArguments...).Return(fmt.Errorf("Error setting refresh token"))
-	mockTokenRepository.On("DeleteRefreshToken", deleteWithPrevIDArguments...).Return(nil)
+	mockTokenRepository.On("RotateRefreshToken", rotateWithPrevIDArguments...).Return(familyID.String(), nil)
 
 	t.Run("Returns a token pair with values", func(t *testing.T) {
 		ctx := context.Background()
@@ -78,8 +85,8 @@ func TestNewPairFromUser(t *testing.T) {
 		assert.NoError(t, err)
 
 		mockTokenRepository.AssertCalled(t, "SetRefreshToken", setSuccessArguments...)
-		// DeleteRefreshToken should not be called since prevID is ""
-		mockTokenRepository.AssertCalled(t, "DeleteRefreshToken", deleteWithPrevIDArguments...)
+		// the previous refresh token is rotated out
+		mockTokenRepository.AssertCalled(t, "RotateRefreshToken", rotateWithPrevIDArguments...)
 
 		var s string
 		assert.IsType(t, s, tokenPair.AccessToken)
@@ -129,6 +136,8 @@ func TestNewPairFromUser(t *testing.T) {
 		// assert claims on refresh token
 		assert.NoError(t, err)
 		assert.Equal(t, a.UID, refreshTokenClaims.UID)
+		// the new refresh token stays in the family of the rotated one
+		assert.Equal(t, familyID, refreshTokenClaims.FamilyID)
 
 		expiresAt = time.Unix(refreshTokenClaims.StandardClaims.ExpiresAt, 0)
 		expectedExpiresAt = time.Now().Add(time.Duration(refreshExp) * time.Second)
@@ -142,8 +151,8 @@ func TestNewPairFromUser(t *testing.T) {
 
 		// SetRefreshToken should be called with setErrorArguments
 		mockTokenRepository.AssertCalled(t, "SetRefreshToken", setErrorArguments...)
-		// DeleteRefreshToken should not be since SetRefreshToken causes method to return
-		mockTokenRepository.AssertNotCalled(t, "DeleteRefreshToken")
+		// RotateRefreshToken should not be called since prevID is ""
+		mockTokenRepository.AssertNotCalled(t, "RotateRefreshToken", mock.Anything, uErrorCase.UID.String(), mock.Anything)
 	})
 
 	t.Run("Empty string provided for Prev acc token", func(t *testing.T) {
@@ -153,8 +162,8 @@ func TestNewPairFromUser(t *testing.T) {
 
 		// SetRefreshToken should be called with setSuccessArguments
 		mockTokenRepository.AssertCalled(t, "SetRefreshToken", setSuccessArguments...)
-		// DeleteRefreshToken should not be called since prevID is ""
-		mockTokenRepository.AssertNotCalled(t, "DeleteRefreshToken")
+		// RotateRefreshToken should only have been called by the first test
+		mockTokenRepository.AssertNumberOfCalls(t, "RotateRefreshToken", 1)
 	})
 }
 
@@ -170,7 +179,7 @@ func TestValidateIDToken(t *testing.T) {
 
 	// instantiate a common token service to be used by all tests
 	mockTokenRepository := new(mocks.MockTokenRepo)
-	tokenService := service.NewTokenService(mockTokenRepository, privKey, pubKey, secret, accExp, refreshExp)
+	tokenService := service.NewTokenService(mockTokenRepository, repository.NewMemoryTokenDenylist(), jwtHelper.NewKeyring(jwtHelper.NewKey(privKey), jwtHelper.NewPublicKey(pubKey)), secret, accExp, refreshExp)
 
 	// include password to make sure it is not serialized
 	// since json tag is "-"
@@ -184,9 +193,9 @@ func TestValidateIDToken(t *testing.T) {
 	t.Run("Valid token", func(t *testing.T) {
 		// maybe not the best approach to depend on utility method
 		// token will be valid for 15 minutes
-		ss, _ := jwtHelper.GenerateAccessToken(a, privKey, accExp)
+		ss, _ := jwtHelper.GenerateAccessToken(a, jwtHelper.NewKey(privKey), accExp)
 
-		aFromToken, err := tokenService.ValidateAccessToken(ss)
+		aFromToken, err := tokenService.ValidateAccessToken(context.Background(), ss)
 		assert.NoError(t, err)
 
 		assert.ElementsMatch(
@@ -199,12 +208,174 @@ func TestValidateIDToken(t *testing.T) {
 	t.Run("Expired token", func(t *testing.T) {
 		// maybe not the best approach to depend on utility method
 		// token will be valid for 15 minutes
-		ss, _ := jwtHelper.GenerateAccessToken(a, privKey, -1) // expires one second ago
+		ss, _ := jwtHelper.GenerateAccessToken(a, jwtHelper.NewKey(privKey), -1) // expires one second ago
+
+		expectedErr := domain.NewAuthorization("Unable to verify user")
+
+		_, err := tokenService.ValidateAccessToken(context.Background(), ss)
+		assert.EqualError(t, err, expectedErr.Message)
+	})
+
+	t.Run("Revoked token", func(t *testing.T) {
+		ss, _ := jwtHelper.GenerateAccessToken(a, jwtHelper.NewKey(privKey), accExp)
+		otherSS, _ := jwtHelper.GenerateAccessToken(a, jwtHelper.NewKey(privKey), accExp)
+
+		ctx := context.Background()
+		err := tokenService.RevokeAccessToken(ctx, ss)
+		assert.NoError(t, err)
 
 		expectedErr := domain.NewAuthorization("Unable to verify user")
 
-		_, err := tokenService.ValidateAccessToken(ss)
+		_, err = tokenService.ValidateAccessToken(ctx, ss)
 		assert.EqualError(t, err, expectedErr.Message)
+
+		// other tokens of the account are still valid
+		_, err = tokenService.ValidateAccessToken(ctx, otherSS)
+		assert.NoError(t, err)
+	})
+}
+
+func TestSigningKeyRotation(t *testing.T) {
+	var accExp int64 = 15 * 60
+
+	priv, _ := ioutil.ReadFile("../../config/rsa_private_test.pem")
+	privKey, _ := jwt.ParseRSAPrivateKeyFromPEM(priv)
+	newPrivKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+
+	oldKey := jwtHelper.NewKey(privKey)
+	newKey := jwtHelper.NewKey(newPrivKey)
+
+	keys := jwtHelper.NewKeyring(oldKey)
+	tokenService := service.NewTokenService(new(mocks.MockTokenRepo), repository.NewMemoryTokenDenylist(), keys, "anotsorandomtestsecret", accExp, 0)
+
+	uid, _ := uuid.NewRandom()
+	a := &domain.Account{
+		UID:   uid,
+		Email: "whuangz@gmail.com",
+	}
+	ctx := context.Background()
+
+	oldSS, _ := jwtHelper.GenerateAccessToken(a, keys.SigningKey(), accExp)
+
+	// rotated, the old key is kept to verify the tokens it signed
+	keys.Set(newKey, jwtHelper.NewPublicKey(&privKey.PublicKey))
+	newSS, _ := jwtHelper.GenerateAccessToken(a, keys.SigningKey(), accExp)
+
+	t.Run("Tokens carry the kid of their key", func(t *testing.T) {
+		oldToken, _, _ := new(jwt.Parser).ParseUnverified(oldSS, &jwtHelper.AccessTokenTokenCustomClaims{})
+		newToken, _, _ := new(jwt.Parser).ParseUnverified(newSS, &jwtHelper.AccessTokenTokenCustomClaims{})
+
+		assert.Equal(t, oldKey.ID, oldToken.Header["kid"])
+		assert.Equal(t, newKey.ID, newToken.Header["kid"])
+	})
+
+	t.Run("Tokens of both keys are valid", func(t *testing.T) {
+		_, err := tokenService.ValidateAccessToken(ctx, oldSS)
+		assert.NoError(t, err)
+
+		_, err = tokenService.ValidateAccessToken(ctx, newSS)
+		assert.NoError(t, err)
+	})
+
+	t.Run("Both keys are published", func(t *testing.T) {
+		jwks := tokenService.JWKS()
+
+		assert.Len(t, jwks.Keys, 2)
+		assert.Equal(t, newKey.ID, jwks.Keys[0].Kid)
+		assert.Equal(t, oldKey.ID, jwks.Keys[1].Kid)
+	})
+
+	t.Run("Retired key", func(t *testing.T) {
+		keys.Set(newKey)
+
+		_, err := tokenService.ValidateAccessToken(ctx, oldSS)
+		assert.Equal(t, http.StatusUnauthorized, domain.Status(err))
+
+		_, err = tokenService.ValidateAccessToken(ctx, newSS)
+		assert.NoError(t, err)
+	})
+
+	t.Run("Token without kid", func(t *testing.T) {
+		claims := jwtHelper.AccessTokenTokenCustomClaims{
+			Account: a,
+			StandardClaims: jwt.StandardClaims{
+				IssuedAt:  time.Now().Unix(),
+				ExpiresAt: time.Now().Unix() + accExp,
+			},
+		}
+		ss, _ := jwt.NewWithClaims(jwt.SigningMethodRS256, claims).SignedString(newPrivKey)
+
+		_, err := tokenService.ValidateAccessToken(ctx, ss)
+		assert.NoError(t, err)
+	})
+}
+
+func TestRevokedBeforeWatermark(t *testing.T) {
+	var accExp int64 = 15 * 60
+	var refreshExp int64 = 3 * 24 * 2600
+
+	priv, _ := ioutil.ReadFile("../../config/rsa_private_test.pem")
+	privKey, _ := jwt.ParseRSAPrivateKeyFromPEM(priv)
+	pub, _ := ioutil.ReadFile("../../config/rsa_public_test.pem")
+	pubKey, _ := jwt.ParseRSAPublicKeyFromPEM(pub)
+	secret := "anotsorandomtestsecret"
+
+	uid, _ := uuid.NewRandom()
+	a := &domain.Account{
+		UID:   uid,
+		Email: "whuangz@gmail.com",
+	}
+
+	otherUID, _ := uuid.NewRandom()
+	otherAccount := &domain.Account{
+		UID:   otherUID,
+		Email: "someoneelse@gmail.com",
+	}
+
+	denylist := repository.NewMemoryTokenDenylist()
+	mockTokenRepository := new(mocks.MockTokenRepo)
+	tokenService := service.NewTokenService(mockTokenRepository, denylist, jwtHelper.NewKeyring(jwtHelper.NewKey(privKey), jwtHelper.NewPublicKey(pubKey)), secret, accExp, refreshExp)
+
+	mockTokenRepository.On("DeleteUserRefreshTokens", mock.Anything, uid.String()).Return(nil)
+
+	// tokens issued a minute before the sign out
+	issuedAt := time.Now().Add(-time.Minute).Unix()
+	claims := jwtHelper.AccessTokenTokenCustomClaims{
+		Account: a,
+		StandardClaims: jwt.StandardClaims{
+			IssuedAt:  issuedAt,
+			ExpiresAt: issuedAt + accExp,
+			Id:        "an_old_tokenID",
+		},
+	}
+	oldSS, _ := jwt.NewWithClaims(jwt.SigningMethodRS256, claims).SignedString(privKey)
+
+	claims.Account = otherAccount
+	otherSS, _ := jwt.NewWithClaims(jwt.SigningMethodRS256, claims).SignedString(privKey)
+
+	ctx := context.Background()
+
+	_, err := tokenService.ValidateAccessToken(ctx, oldSS)
+	assert.NoError(t, err)
+
+	err = tokenService.Signout(ctx, uid)
+	assert.NoError(t, err)
+
+	t.Run("Tokens issued before sign out are rejected", func(t *testing.T) {
+		_, err := tokenService.ValidateAccessToken(ctx, oldSS)
+		assert.EqualError(t, err, "Unable to verify user")
+	})
+
+	t.Run("Tokens issued after sign out are accepted", func(t *testing.T) {
+		ss, _ := jwtHelper.GenerateAccessToken(a, jwtHelper.NewKey(privKey), accExp)
+
+		_, err := tokenService.ValidateAccessToken(ctx, ss)
+		assert.NoError(t, err)
+	})
+
+	t.Run("Other accounts are not affected", func(t *testing.T) {
+		_, err := tokenService.ValidateAccessToken(ctx, otherSS)
+		assert.NoError(t, err)
 	})
 }
 
@@ -220,7 +391,7 @@ func TestValidateRefreshToken(t *testing.T) {
 
 	// instantiate a common token service to be used by all tests
 	mockTokenRepository := new(mocks.MockTokenRepo)
-	tokenService := service.NewTokenService(mockTokenRepository, privKey, pubKey, secret, accExp, refreshExp)
+	tokenService := service.NewTokenService(mockTokenRepository, repository.NewMemoryTokenDenylist(), jwtHelper.NewKeyring(jwtHelper.NewKey(privKey), jwtHelper.NewPublicKey(pubKey)), secret, accExp, refreshExp)
 
 	uid, _ := uuid.NewRandom()
 	a := &domain.Account{
@@ -229,9 +400,11 @@ func TestValidateRefreshToken(t *testing.T) {
 		Password: "blarghedymcblarghface",
 	}
 
+	familyID, _ := uuid.NewRandom()
+
 	t.Run("Valid token", func(t *testing.T) {
 
-		testRefreshToken, _ := jwtHelper.GenerateRefreshToken(a.UID, secret, refreshExp)
+		testRefreshToken, _ := jwtHelper.GenerateRefreshToken(a.UID, familyID, secret, refreshExp)
 
 		validatedRefreshToken, err := tokenService.ValidateRefreshToken(testRefreshToken.SS)
 		assert.NoError(t, err)
@@ -239,10 +412,11 @@ func TestValidateRefreshToken(t *testing.T) {
 		assert.Equal(t, a.UID, validatedRefreshToken.UID)
 		assert.Equal(t, testRefreshToken.SS, validatedRefreshToken.SS)
 		assert.Equal(t, a.UID, validatedRefreshToken.UID)
+		assert.Equal(t, familyID, validatedRefreshToken.FamilyID)
 	})
 
 	t.Run("Expired token", func(t *testing.T) {
-		testRefreshToken, _ := jwtHelper.GenerateRefreshToken(a.UID, secret, -1)
+		testRefreshToken, _ := jwtHelper.GenerateRefreshToken(a.UID, familyID, secret, -1)
 
 		expectedErr := domain.NewAuthorization("Unable to verify user from refresh token")
 
@@ -250,3 +424,148 @@ func TestValidateRefreshToken(t *testing.T) {
 		assert.EqualError(t, err, expectedErr.Message)
 	})
 }
+
+func TestSignout(t *testing.T) {
+	var accExp int64 = 15 * 60
+	var refreshExp int64 = 3 * 24 * 2600
+
+	priv, _ := ioutil.ReadFile("../../config/rsa_private_test.pem")
+	privKey, _ := jwt.ParseRSAPrivateKeyFromPEM(priv)
+	pub, _ := ioutil.ReadFile("../../config/rsa_public_test.pem")
+	pubKey, _ := jwt.ParseRSAPublicKeyFromPEM(pub)
+	secret := "anotsorandomtestsecret"
+
+	mockTokenRepository := new(mocks.MockTokenRepo)
+	tokenService := service.NewTokenService(mockTokenRepository, repository.NewMemoryTokenDenylist(), jwtHelper.NewKeyring(jwtHelper.NewKey(privKey), jwtHelper.NewPublicKey(pubKey)), secret, accExp, refreshExp)
+
+	uid, _ := uuid.NewRandom()
+	uidErrorCase, _ := uuid.NewRandom()
+
+	mockTokenRepository.On("DeleteUserRefreshTokens", mock.Anything, uid.String()).Return(nil)
+	mockTokenRepository.On("DeleteUserRefreshTokens", mock.Anything, uidErrorCase.String()).Return(domain.NewInternal())
+
+	t.Run("Success", func(t *testing.T) {
+		err := tokenService.Signout(context.Background(), uid)
+		assert.NoError(t, err)
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		err := tokenService.Signout(context.Background(), uidErrorCase)
+		assert.Error(t, err)
+
+		apiErr, ok := err.(*domain.Error)
+		assert.True(t, ok)
+		assert.Equal(t, domain.Internal, apiErr.Type)
+	})
+}
+
+func TestSignoutDevice(t *testing.T) {
+	var accExp int64 = 15 * 60
+	var refreshExp int64 = 3 * 24 * 2600
+
+	priv, _ := ioutil.ReadFile("../../config/rsa_private_test.pem")
+	privKey, _ := jwt.ParseRSAPrivateKeyFromPEM(priv)
+	pub, _ := ioutil.ReadFile("../../config/rsa_public_test.pem")
+	pubKey, _ := jwt.ParseRSAPublicKeyFromPEM(pub)
+	secret := "anotsorandomtestsecret"
+
+	mockTokenRepository := new(mocks.MockTokenRepo)
+	tokenService := service.NewTokenService(mockTokenRepository, repository.NewMemoryTokenDenylist(), jwtHelper.NewKeyring(jwtHelper.NewKey(privKey), jwtHelper.NewPublicKey(pubKey)), secret, accExp, refreshExp)
+
+	uid, _ := uuid.NewRandom()
+	otherUID, _ := uuid.NewRandom()
+	familyID, _ := uuid.NewRandom()
+
+	t.Run("Revokes only the family of the presented token", func(t *testing.T) {
+		refreshToken, _ := jwtHelper.GenerateRefreshToken(uid, familyID, secret, refreshExp)
+		mockTokenRepository.On("DeleteTokenFamily", mock.Anything, uid.String(), familyID.String()).Return(nil)
+
+		err := tokenService.SignoutDevice(context.Background(), uid, refreshToken.SS)
+		assert.NoError(t, err)
+
+		mockTokenRepository.AssertCalled(t, "DeleteTokenFamily", mock.Anything, uid.String(), familyID.String())
+		mockTokenRepository.AssertNotCalled(t, "DeleteUserRefreshTokens")
+	})
+
+	t.Run("Token of another account", func(t *testing.T) {
+		refreshToken, _ := jwtHelper.GenerateRefreshToken(otherUID, familyID, secret, refreshExp)
+
+		err := tokenService.SignoutDevice(context.Background(), uid, refreshToken.SS)
+		assert.Error(t, err)
+		assert.Equal(t, http.StatusUnauthorized, domain.Status(err))
+
+		mockTokenRepository.AssertNotCalled(t, "DeleteTokenFamily", mock.Anything, otherUID.String(), familyID.String())
+	})
+}
+
+func TestRefreshTokenReuse(t *testing.T) {
+	var accExp int64 = 15 * 60
+	var refreshExp int64 = 3 * 24 * 2600
+
+	priv, _ := ioutil.ReadFile("../../config/rsa_private_test.pem")
+	privKey, _ := jwt.ParseRSAPrivateKeyFromPEM(priv)
+	pub, _ := ioutil.ReadFile("../../config/rsa_public_test.pem")
+	pubKey, _ := jwt.ParseRSAPublicKeyFromPEM(pub)
+	secret := "anotsorandomtestsecret"
+
+	uid, _ := uuid.NewRandom()
+	a := &domain.Account{
+		UID:   uid,
+		Email: "whuangz@gmail.com",
+	}
+	familyID, _ := uuid.NewRandom()
+
+	t.Run("Replaying a rotated token revokes the family", func(t *testing.T) {
+		mockTokenRepository := new(mocks.MockTokenRepo)
+		tokenService := service.NewTokenService(mockTokenRepository, repository.NewMemoryTokenDenylist(), jwtHelper.NewKeyring(jwtHelper.NewKey(privKey), jwtHelper.NewPublicKey(pubKey)), secret, accExp, refreshExp)
+
+		firstTokenID := "first_tokenID"
+		secondTokenID := "second_tokenID"
+
+		mockTokenRepository.On("SetRefreshToken", mock.Anything, uid.String(), mock.AnythingOfType("string"), familyID.String(), mock.AnythingOfType("time.Duration")).Return(nil)
+		mockTokenRepository.On("RotateRefreshToken", mock.Anything, uid.String(), firstTokenID).Return(familyID.String(), nil).Once()
+		mockTokenRepository.On("RotateRefreshToken", mock.Anything, uid.String(), secondTokenID).Return(familyID.String(), nil).Once()
+		// an attacker replays the first token after the legitimate client rotated it
+		mockTokenRepository.On("RotateRefreshToken", mock.Anything, uid.String(), firstTokenID).Return(familyID.String(), domain.NewTokenReused()).Once()
+		mockTokenRepository.On("DeleteTokenFamily", mock.Anything, uid.String(), familyID.String()).Return(nil)
+
+		ctx := context.Background()
+
+		_, err := tokenService.NewPairFromUser(ctx, a, firstTokenID)
+		assert.NoError(t, err)
+
+		_, err = tokenService.NewPairFromUser(ctx, a, secondTokenID)
+		assert.NoError(t, err)
+
+		tokenPair, err := tokenService.NewPairFromUser(ctx, a, firstTokenID)
+		assert.Nil(t, tokenPair)
+		assert.Error(t, err)
+
+		apiErr, ok := err.(*domain.Error)
+		assert.True(t, ok)
+		assert.Equal(t, domain.TokenReused, apiErr.Type)
+		assert.Equal(t, http.StatusUnauthorized, apiErr.Status())
+
+		mockTokenRepository.AssertCalled(t, "DeleteTokenFamily", mock.Anything, uid.String(), familyID.String())
+		// no new token is issued for the replayed one
+		mockTokenRepository.AssertNumberOfCalls(t, "SetRefreshToken", 2)
+		mockTokenRepository.AssertExpectations(t)
+	})
+
+	t.Run("Unknown token does not revoke the family", func(t *testing.T) {
+		mockTokenRepository := new(mocks.MockTokenRepo)
+		tokenService := service.NewTokenService(mockTokenRepository, repository.NewMemoryTokenDenylist(), jwtHelper.NewKeyring(jwtHelper.NewKey(privKey), jwtHelper.NewPublicKey(pubKey)), secret, accExp, refreshExp)
+
+		unknownTokenID := "unknown_tokenID"
+		mockError := domain.NewAuthorization("Invalid refresh token")
+
+		mockTokenRepository.On("RotateRefreshToken", mock.Anything, uid.String(), unknownTokenID).Return("", mockError)
+
+		tokenPair, err := tokenService.NewPairFromUser(context.Background(), a, unknownTokenID)
+		assert.Nil(t, tokenPair)
+		assert.EqualError(t, err, mockError.Error())
+
+		mockTokenRepository.AssertNotCalled(t, "DeleteTokenFamily", mock.Anything, mock.Anything, mock.Anything)
+		mockTokenRepository.AssertNotCalled(t, "SetRefreshToken", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
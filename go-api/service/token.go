@@ -2,50 +2,75 @@ package service
 
 import (
 	"context"
-	"crypto/rsa"
+	"errors"
 	"log"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/whuangz/go-example/go-api/domain"
 	"github.com/whuangz/go-example/go-api/helpers/jwt"
 )
 
 type tokenService struct {
 	repo            domain.TokenRepository
-	privKey         *rsa.PrivateKey
-	pubKey          *rsa.PublicKey
+	denylist        domain.TokenDenylist
+	keys            *jwt.Keyring
 	refreshSecret   string
 	accessTokenExp  int64
 	refreshTokenExp int64
 }
 
-func NewTokenService(repo domain.TokenRepository, private *rsa.PrivateKey, public *rsa.PublicKey, refresh string, accTokenExp int64, refreshTokenExp int64) domain.TokenService {
-	return &tokenService{repo, private, public, refresh, accTokenExp, refreshTokenExp}
+func NewTokenService(repo domain.TokenRepository, denylist domain.TokenDenylist, keys *jwt.Keyring, refresh string, accTokenExp int64, refreshTokenExp int64) domain.TokenService {
+	return &tokenService{repo, denylist, keys, refresh, accTokenExp, refreshTokenExp}
 }
 
+// NewPairFromUser issues an access and refresh token pair. When the id of
+// the previous refresh token is given, it is rotated out and the new refresh
+// token joins its family. Presenting an already rotated token revokes the
+// whole family, since it means the token has leaked
 func (s *tokenService) NewPairFromUser(ctx context.Context, a *domain.Account, prevAccesstoken string) (*domain.TokenPair, error) {
 
+	familyID, err := uuid.NewRandom()
+	if err != nil {
+		log.Printf("Error generating token family for uid: %v. Error: %v\n", a.UID, err.Error())
+		return nil, domain.NewInternal()
+	}
+
 	if prevAccesstoken != "" {
-		if err := s.repo.DeleteRefreshToken(ctx, a.UID.String(), prevAccesstoken); err != nil {
-			log.Printf("Could not delete previous refreshToken for uid: %v, tokenID: %v\n", a.UID.String(), prevAccesstoken)
+		family, err := s.repo.RotateRefreshToken(ctx, a.UID.String(), prevAccesstoken)
+		if err != nil {
+			if isTokenReused(err) {
+				log.Printf("Revoking token family: %v for uid: %v after refresh token reuse\n", family, a.UID.String())
+				if err := s.repo.DeleteTokenFamily(ctx, a.UID.String(), family); err != nil {
+					return nil, err
+				}
+			} else {
+				log.Printf("Could not rotate previous refreshToken for uid: %v, tokenID: %v\n", a.UID.String(), prevAccesstoken)
+			}
 
 			return nil, err
 		}
+
+		if familyID, err = uuid.Parse(family); err != nil {
+			log.Printf("Token family could not be parsed as UUID: %s\n%v\n", family, err)
+			return nil, domain.NewInternal()
+		}
 	}
 
-	accToken, err := jwt.GenerateAccessToken(a, s.privKey, s.accessTokenExp)
+	accToken, err := jwt.GenerateAccessToken(a, s.keys.SigningKey(), s.accessTokenExp)
 	if err != nil {
 		log.Printf("Error generating idToken for uid: %v. Error: %v\n", a.UID, err.Error())
 		return nil, domain.NewInternal()
 	}
 
-	refreshToken, err := jwt.GenerateRefreshToken(a.UID, s.refreshSecret, s.refreshTokenExp)
+	refreshToken, err := jwt.GenerateRefreshToken(a.UID, familyID, s.refreshSecret, s.refreshTokenExp)
 
 	if err != nil {
 		log.Printf("Error generating refreshToken for uid: %v. Error: %v\n", a.UID, err.Error())
 		return nil, domain.NewInternal()
 	}
 
-	if err := s.repo.SetRefreshToken(ctx, a.UID.String(), refreshToken.ID, refreshToken.ExpiresIn); err != nil {
+	if err := s.repo.SetRefreshToken(ctx, a.UID.String(), refreshToken.ID, refreshToken.FamilyID, refreshToken.ExpiresIn); err != nil {
 		log.Printf("Error storing tokenID for uid: %v. Error: %v\n", a.UID, err.Error())
 		return nil, domain.NewInternal()
 	}
@@ -54,12 +79,98 @@ func (s *tokenService) NewPairFromUser(ctx context.Context, a *domain.Account, p
 
 }
 
-func (s *tokenService) ValidateAccessToken(token string) (*domain.Account, error) {
-	claims, err := jwt.ValidateAccessToken(token, s.pubKey)
+// ValidateAccessToken checks the signature and expiry of the token,
+// then makes sure it hasn't been revoked in the meantime
+func (s *tokenService) ValidateAccessToken(ctx context.Context, token string) (*domain.Account, error) {
+	claims, err := jwt.ValidateAccessToken(token, s.keys)
 
+	if err != nil || claims.Account == nil {
+		return nil, domain.NewAuthorization("Unable to verify user")
+	}
+
+	if claims.Id != "" {
+		denied, err := s.denylist.IsAccessTokenDenied(ctx, claims.Id)
+		if err != nil {
+			return nil, err
+		}
+
+		if denied {
+			log.Printf("Denied access token presented for uid: %v, tokenID: %v\n", claims.Account.UID, claims.Id)
+			return nil, domain.NewAuthorization("Unable to verify user")
+		}
+	}
+
+	revokedBefore, err := s.denylist.GetRevokedBefore(ctx, claims.Account.UID.String())
 	if err != nil {
+		return nil, err
+	}
+
+	// iat only has second precision, tokens issued within the
+	// same second as the watermark are still accepted
+	if claims.IssuedAt < revokedBefore.Unix() {
+		log.Printf("Access token issued before revocation presented for uid: %v\n", claims.Account.UID)
 		return nil, domain.NewAuthorization("Unable to verify user")
 	}
 
 	return claims.Account, nil
 }
+
+// RevokeAccessToken denies a single access token
+// for the time it has left before expiring
+func (s *tokenService) RevokeAccessToken(ctx context.Context, token string) error {
+	claims, err := jwt.ValidateAccessToken(token, s.keys)
+
+	if err != nil || claims.Account == nil {
+		return domain.NewAuthorization("Unable to verify user")
+	}
+
+	if claims.Id == "" {
+		log.Printf("Access token without id cannot be revoked for uid: %v\n", claims.Account.UID)
+		return domain.NewBadRequest("access token cannot be revoked")
+	}
+
+	expiresIn := time.Until(time.Unix(claims.ExpiresAt, 0))
+	return s.denylist.DenyAccessToken(ctx, claims.Id, expiresIn)
+}
+
+// Signout revokes every refresh token of the account and every access
+// token issued so far, signing it out on all devices
+func (s *tokenService) Signout(ctx context.Context, uid uuid.UUID) error {
+	if err := s.repo.DeleteUserRefreshTokens(ctx, uid.String()); err != nil {
+		return err
+	}
+
+	accessTokenExp := time.Duration(s.accessTokenExp) * time.Second
+	return s.denylist.SetRevokedBefore(ctx, uid.String(), time.Now(), accessTokenExp)
+}
+
+// SignoutDevice revokes only the token family of the provided refresh
+// token after making sure it was issued to the account
+func (s *tokenService) SignoutDevice(ctx context.Context, uid uuid.UUID, refreshToken string) error {
+	token, err := s.ValidateRefreshToken(refreshToken)
+	if err != nil {
+		return err
+	}
+
+	if token.UID != uid {
+		log.Printf("Refresh token of uid: %v presented for signout of uid: %v\n", token.UID, uid)
+		return domain.NewAuthorization("Unable to verify user from refresh token")
+	}
+
+	return s.repo.DeleteTokenFamily(ctx, uid.String(), token.FamilyID.String())
+}
+
+// JWKS returns the public keys access tokens can be verified with
+func (s *tokenService) JWKS() *domain.JWKS {
+	return s.keys.JWKS()
+}
+
+func isTokenReused(err error) bool {
+	var e *domain.Error
+	return errors.As(err, &e) && e.Type == domain.TokenReused
+}
+
+func isNotFound(err error) bool {
+	var e *domain.Error
+	return errors.As(err, &e) && e.Type == domain.NotFound
+}